@@ -15,34 +15,30 @@ package main
 
 import (
 	"fmt"
+	"os"
 	"strconv"
 	"time"
 
 	"github.com/pingcap/go-ycsb/pkg/client"
 	"github.com/pingcap/go-ycsb/pkg/measurement"
 	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/util"
 	"github.com/spf13/cobra"
 )
 
 func runClientCommandFunc(cmd *cobra.Command, args []string, doTransactions bool) {
 	dbName := args[0]
 
-	initialGlobal(dbName, func() {
-		doTransFlag := "true"
-		if !doTransactions {
-			doTransFlag = "false"
-		}
-		globalProps.Set(prop.DoTransactions, doTransFlag)
-
-		if cmd.Flags().Changed("threads") {
-			// We set the threadArg via command line.
-			globalProps.Set(prop.ThreadCount, strconv.Itoa(threadsArg))
-		}
+	initialGlobalProps(clientProperties(cmd, doTransactions))
+	if loadCompleted {
+		fmt.Printf("The load of checkpoint %s is already completed\n", globalProps.GetString(prop.CheckpointFile, ""))
+		return
+	}
+	initialGlobalDB(dbName)
 
-		if cmd.Flags().Changed("target") {
-			globalProps.Set(prop.Target, strconv.Itoa(targetArg))
-		}
-	})
+	checkOutputStyle()
+	checkPush()
+	checkReport()
 
 	fmt.Println("***************** properties *****************")
 	for key, value := range globalProps.Map() {
@@ -54,8 +50,87 @@ func runClientCommandFunc(cmd *cobra.Command, args []string, doTransactions bool
 	start := time.Now()
 	c.Run(globalContext)
 
-	fmt.Printf("Run finished, takes %s\n", time.Now().Sub(start))
-	measurement.Output()
+	end := time.Now()
+	fmt.Printf("Run finished, takes %s\n", end.Sub(start))
+	fmt.Printf("Random seed %d\n", globalProps.GetInt64(prop.RandomSeed, 0))
+	if dryRunSink != nil {
+		// The results of the dry run are not exported, pushed or reported.
+		measurement.Output()
+		dryRunSink.Output(os.Stdout, globalProps.GetInt64(prop.RecordCount, 0))
+		return
+	}
+	if doTransactions && globalProps.GetInt64(prop.WarmUpTime, 0) > 0 {
+		// The operations in the warm-up are not measured.
+		if warmUpEnd := measurement.WarmUpFinishedAt(); warmUpEnd.After(start) {
+			fmt.Printf("Measured window %s, excluding the warm-up of %s\n", end.Sub(warmUpEnd), warmUpEnd.Sub(start))
+		} else {
+			fmt.Println("Run finished in the warm-up, nothing is measured")
+		}
+	}
+
+	command := "load"
+	if doTransactions {
+		command = "run"
+	}
+	outputResult(command, dbName, start, end, c.ResourceUsage())
+	pushResult(command, dbName, start, end, c.ResourceUsage())
+	reportResult(command, dbName, start, end, c.ResourceUsage())
+}
+
+// clientProperties returns the callback to set the properties of the load or the run command.
+func clientProperties(cmd *cobra.Command, doTransactions bool) func() {
+	return func() {
+		doTransFlag := "true"
+		if !doTransactions {
+			doTransFlag = "false"
+		}
+		globalProps.Set(prop.DoTransactions, doTransFlag)
+
+		setClientFlagProps(cmd)
+		if dryRunArg {
+			dryRunProperties(globalProps)
+		}
+		if err := client.PartitionProperties(globalProps); err != nil {
+			util.Fatal(err)
+		}
+		resume, err := client.ResumeProperties(globalProps)
+		if err != nil {
+			util.Fatal(err)
+		}
+		loadCompleted = !resume
+	}
+}
+
+// setClientFlagProps overrides the global properties by the flags set in the command line.
+func setClientFlagProps(cmd *cobra.Command) {
+	if cmd.Flags().Changed("threads") {
+		// We set the threadArg via command line.
+		globalProps.Set(prop.ThreadCount, strconv.Itoa(threadsArg))
+	}
+
+	if cmd.Flags().Changed("target") {
+		globalProps.Set(prop.Target, targetArg)
+	}
+
+	if cmd.Flags().Changed("pprof") {
+		globalProps.Set(prop.DebugPprof, pprofArg)
+	}
+
+	if cmd.Flags().Changed("tui") {
+		globalProps.Set(prop.TUI, strconv.FormatBool(tuiArg))
+	}
+
+	if cmd.Flags().Changed("log-level") {
+		globalProps.Set(prop.LogLevel, logLevelArg)
+	}
+
+	if cmd.Flags().Changed("log-file") {
+		globalProps.Set(prop.LogFile, logFileArg)
+	}
+
+	if cmd.Flags().Changed("resume") {
+		globalProps.Set(prop.CheckpointResume, strconv.FormatBool(resumeArg))
+	}
 }
 
 func runLoadCommandFunc(cmd *cobra.Command, args []string) {
@@ -67,16 +142,29 @@ func runTransCommandFunc(cmd *cobra.Command, args []string) {
 }
 
 var (
-	threadsArg int
-	targetArg  int
+	threadsArg  int
+	targetArg   string
+	pprofArg    string
+	tuiArg      bool
+	logLevelArg string
+	logFileArg  string
+	resumeArg   bool
+
+	// loadCompleted is set if the load resumed from the checkpoint is already completed.
+	loadCompleted bool
 )
 
 func initClientCommand(m *cobra.Command) {
-	m.Flags().StringSliceVarP(&propertyFiles, "property_file", "P", nil, "Spefify a property file")
+	m.Flags().StringSliceVarP(&propertyFiles, "property_file", "P", nil, "Spefify a property file, or a YAML or TOML config file")
 	m.Flags().StringArrayVarP(&propertyValues, "prop", "p", nil, "Specify a property value with name=value")
 	m.Flags().StringVar(&tableName, "table", "", "Use the table name instead of the default \""+prop.TableNameDefault+"\"")
 	m.Flags().IntVar(&threadsArg, "threads", 1, "Execute using n threads - can also be specified as the \"threadcount\" property")
-	m.Flags().IntVar(&targetArg, "target", 0, "Attempt to do n operations per second (default: unlimited) - can also be specified as the \"target\" property")
+	m.Flags().StringVar(&targetArg, "target", "", "Attempt to do n operations per second (default: unlimited), or the steps like 1000:60s,5000:60s - can also be specified as the \"target\" property")
+	m.Flags().StringVar(&pprofArg, "pprof", prop.DebugPprofDefault, "Serve net/http/pprof and the metrics on the address - can also be specified as the \"debug.pprof\" property")
+	m.Flags().BoolVar(&tuiArg, "tui", prop.TUIDefault, "Show the live dashboard in the terminal - can also be specified as the \"tui\" property")
+	m.Flags().StringVar(&logLevelArg, "log-level", prop.LogLevelDefault, "Log the messages of the level or above, debug, info, warn or error - can also be specified as the \"log.level\" property")
+	m.Flags().StringVar(&logFileArg, "log-file", "", "Write the logs in JSON to the file instead of stderr - can also be specified as the \"log.file\" property")
+	m.Flags().BoolVar(&strictArg, "strict", false, "Exit if there are unknown properties, invalid values or conflicting properties instead of warning")
 }
 
 func newLoadCommand() *cobra.Command {
@@ -88,6 +176,8 @@ func newLoadCommand() *cobra.Command {
 	}
 
 	initClientCommand(m)
+	initDryRunCommand(m)
+	m.Flags().BoolVar(&resumeArg, "resume", prop.CheckpointResumeDefault, "Continue the load from the checkpoint of \"checkpoint.file\" - can also be specified as the \"checkpoint.resume\" property")
 	return m
 }
 
@@ -100,5 +190,6 @@ func newRunCommand() *cobra.Command {
 	}
 
 	initClientCommand(m)
+	initDryRunCommand(m)
 	return m
 }
@@ -0,0 +1,332 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/client"
+	"github.com/pingcap/go-ycsb/pkg/measurement"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/util"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+	"github.com/spf13/cobra"
+)
+
+var (
+	compareLoadArg       bool
+	compareInterleaveArg int
+)
+
+// compareTarget is a database compared by the compare command, it keeps its own workload
+// and measurement across the rounds.
+type compareTarget struct {
+	dbName   string
+	p        *properties.Properties
+	db       ycsb.DB
+	workload ycsb.Workload
+	measure  measurement.State
+	// elapsed is the total time of the rounds, the throughput is calculated by it instead of the
+	// wall time, since the rounds of the other databases are interleaved.
+	elapsed time.Duration
+}
+
+func copyProperties(p *properties.Properties) *properties.Properties {
+	c := properties.NewProperties()
+	c.Merge(p)
+	return c
+}
+
+func createWorkloadAndDB(dbName string, p *properties.Properties) (ycsb.Workload, ycsb.DB) {
+	workloadName := p.GetString(prop.Workload, "core")
+	workload, err := ycsb.GetWorkloadCreator(workloadName).Create(p)
+	if err != nil {
+		util.Fatalf("create workload %s failed %v", workloadName, err)
+	}
+
+	dbCreator := ycsb.GetDBCreator(dbName)
+	if dbCreator == nil {
+		util.Fatalf("%s is not registered", dbName)
+	}
+	db, err := dbCreator.Create(p)
+	if err != nil {
+		util.Fatalf("create db %s failed %v", dbName, err)
+	}
+
+	return workload, client.DbWrapper{DB: db}
+}
+
+func (t *compareTarget) close() {
+	t.db.Close()
+	t.workload.Close()
+}
+
+// load runs the load phase of the database, the measurement is printed but not compared.
+func (t *compareTarget) load() {
+	p := copyProperties(t.p)
+	p.Set(prop.DoTransactions, "false")
+
+	measurement.InitMeasure(p)
+	workload, db := createWorkloadAndDB(t.dbName, p)
+	defer func() {
+		db.Close()
+		workload.Close()
+	}()
+
+	start := time.Now()
+	client.NewClient(p, workload, db).Run(globalContext)
+	fmt.Printf("Load %s finished, takes %s\n", t.dbName, time.Now().Sub(start))
+	measurement.Output()
+}
+
+// runRound runs opCount operations of the run phase, the workload and the DB are created in the first round.
+func (t *compareTarget) runRound(opCount int64) {
+	if t.db == nil {
+		measurement.InitMeasure(t.p)
+		t.measure = measurement.Save()
+		t.workload, t.db = createWorkloadAndDB(t.dbName, t.p)
+	}
+
+	measurement.Restore(t.measure)
+	// The warm-up is global, so every database warms up before its first round.
+	measurement.EnableWarmUp(t.elapsed == 0 && t.p.GetInt64(prop.WarmUpTime, 0) > 0)
+
+	p := copyProperties(t.p)
+	p.Set(prop.OperationCount, strconv.FormatInt(opCount, 10))
+
+	start := time.Now()
+	client.NewClient(p, t.workload, t.db).Run(globalContext)
+	t.elapsed += time.Now().Sub(start)
+}
+
+func runCompareCommandFunc(cmd *cobra.Command, args []string) {
+	var dbNames []string
+	for _, arg := range args {
+		for _, name := range strings.Split(arg, ",") {
+			if name = strings.TrimSpace(name); len(name) > 0 {
+				dbNames = append(dbNames, name)
+			}
+		}
+	}
+
+	initialGlobalProps(func() {
+		globalProps.Set(prop.DoTransactions, "true")
+		setClientFlagProps(cmd)
+	})
+
+	rounds := int64(compareInterleaveArg)
+	if rounds < 1 {
+		rounds = 1
+	}
+	opCount := globalProps.GetInt64(prop.OperationCount, 0)
+	if opCount < rounds {
+		util.Fatalf("%s %d must be not less than the interleaved rounds %d", prop.OperationCount, opCount, rounds)
+	}
+
+	targets := make([]*compareTarget, 0, len(dbNames))
+	for _, dbName := range dbNames {
+		targets = append(targets, &compareTarget{
+			dbName: dbName,
+			p:      copyProperties(globalProps),
+		})
+	}
+
+	if compareLoadArg {
+		for _, t := range targets {
+			if globalContext.Err() != nil {
+				return
+			}
+			t.load()
+		}
+	}
+
+	for i := int64(0); i < rounds; i++ {
+		roundOpCount := opCount / rounds
+		if i == rounds-1 {
+			roundOpCount += opCount % rounds
+		}
+
+		for _, t := range targets {
+			if globalContext.Err() != nil {
+				break
+			}
+			fmt.Printf("Run %s round %d/%d\n", t.dbName, i+1, rounds)
+			t.runRound(roundOpCount)
+			if rounds == 1 {
+				t.close()
+			}
+		}
+	}
+
+	if rounds > 1 {
+		for _, t := range targets {
+			if t.db != nil {
+				t.close()
+			}
+		}
+	}
+
+	outputComparison(targets, rounds)
+}
+
+// delta returns the relative difference of v to the base in percent.
+func delta(v float64, base float64) string {
+	if base == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%+.1f%%", (v-base)/base*100)
+}
+
+func toFloat64(v interface{}) float64 {
+	switch n := v.(type) {
+	case int:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case float64:
+		return n
+	default:
+		return 0
+	}
+}
+
+// outputComparison prints the throughput and the latencies of every operation of the databases,
+// the deltas are relative to the first database.
+func outputComparison(targets []*compareTarget, rounds int64) {
+	type result struct {
+		dbName string
+		ops    map[string]ycsb.MeasurementInfo
+		count  float64
+		secs   float64
+	}
+
+	results := make([]result, 0, len(targets))
+	opNames := make(map[string]struct{})
+	for _, t := range targets {
+		if t.db == nil {
+			continue
+		}
+
+		measurement.Restore(t.measure)
+		r := result{
+			dbName: t.dbName,
+			ops:    measurement.Info(),
+			secs:   t.elapsed.Seconds(),
+		}
+		for op, info := range r.ops {
+			opNames[op] = struct{}{}
+			if measurement.IsPrimaryOperation(op) {
+				r.count += toFloat64(info.Get(measurement.COUNT))
+			}
+		}
+		results = append(results, r)
+	}
+
+	if len(results) == 0 {
+		return
+	}
+
+	mode := "sequential"
+	if rounds > 1 {
+		mode = fmt.Sprintf("interleaved in %d rounds", rounds)
+	}
+
+	fmt.Println("***************** comparison *****************")
+	fmt.Printf("Run %s, the deltas are relative to %s\n\n", mode, results[0].dbName)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DB\tTakes(s)\tCount\tOPS\tDelta")
+	base := results[0].count / results[0].secs
+	for _, r := range results {
+		ops := r.count / r.secs
+		fmt.Fprintf(w, "%s\t%.1f\t%.0f\t%.1f\t%s\n", r.dbName, r.secs, r.count, ops, delta(ops, base))
+	}
+	w.Flush()
+
+	ops := make([]string, 0, len(opNames))
+	for op := range opNames {
+		ops = append(ops, op)
+	}
+	sort.Strings(ops)
+
+	// The delta of the tail is the one of the 99th percentile, or the highest percentile if the 99th
+	// isn't in measurement.percentiles.
+	header := "DB\tCount\tOPS\tAvg(us)"
+	var tail measurement.Percentile
+	for _, p := range measurement.Percentiles {
+		header += "\t" + p.Name() + "(us)"
+		if tail.Metric == "" || tail.Percentile != 99 {
+			tail = p
+		}
+	}
+	header += "\tDelta OPS\tDelta Avg"
+	if tail.Metric != "" {
+		header += "\tDelta " + tail.Name()
+	}
+
+	for _, op := range ops {
+		fmt.Printf("\n%s\n", op)
+		fmt.Fprintln(w, header)
+
+		var baseInfo ycsb.MeasurementInfo
+		var baseSecs float64
+		for _, r := range results {
+			info, ok := r.ops[op]
+			if !ok {
+				fmt.Fprintln(w, r.dbName+strings.Repeat("\t-", strings.Count(header, "\t")))
+				continue
+			}
+			if baseInfo == nil {
+				baseInfo = info
+				baseSecs = r.secs
+			}
+
+			count := toFloat64(info.Get(measurement.COUNT))
+			avg := toFloat64(info.Get(measurement.AVG))
+			line := fmt.Sprintf("%s\t%.0f\t%.1f\t%.0f", r.dbName, count, count/r.secs, avg)
+			for _, p := range measurement.Percentiles {
+				line += fmt.Sprintf("\t%.0f", toFloat64(info.Get(p.Metric)))
+			}
+			line += fmt.Sprintf("\t%s\t%s", delta(count/r.secs, toFloat64(baseInfo.Get(measurement.COUNT))/baseSecs),
+				delta(avg, toFloat64(baseInfo.Get(measurement.AVG))))
+			if tail.Metric != "" {
+				line += "\t" + delta(toFloat64(info.Get(tail.Metric)), toFloat64(baseInfo.Get(tail.Metric)))
+			}
+			fmt.Fprintln(w, line)
+		}
+		w.Flush()
+	}
+	fmt.Println("**********************************************")
+}
+
+func newCompareCommand() *cobra.Command {
+	m := &cobra.Command{
+		Use:   "compare db[,db...]",
+		Short: "Run the same workload against multiple databases and compare the results",
+		Args:  cobra.MinimumNArgs(1),
+		Run:   runCompareCommandFunc,
+	}
+
+	initClientCommand(m)
+	m.Flags().BoolVar(&compareLoadArg, "load", false, "Load every database before running the workload")
+	m.Flags().IntVar(&compareInterleaveArg, "interleave", 1, "Split the operations into n rounds and run the databases in turn in every round")
+	return m
+}
@@ -0,0 +1,220 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/magiconair/properties"
+	"gopkg.in/yaml.v2"
+)
+
+// configSections are the top level sections of a config file only to group the properties, the
+// properties in them are not prefixed with the section name.
+var configSections = map[string]bool{
+	"workload":    true,
+	"measurement": true,
+	"db":          true,
+}
+
+// envPattern matches ${VAR} and ${VAR:-default} in the values of a config file.
+var envPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+func isConfigFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml", ".toml":
+		return true
+	}
+	return false
+}
+
+// loadPropertyFiles loads the property files and the YAML or TOML config files in order, the
+// properties in the latter files override the ones in the former.
+func loadPropertyFiles(paths []string) (*properties.Properties, error) {
+	all := properties.NewProperties()
+	for _, path := range paths {
+		var (
+			p   *properties.Properties
+			err error
+		)
+		if isConfigFile(path) {
+			p, err = loadConfigFile(path)
+		} else {
+			p, err = properties.LoadFiles([]string{path}, properties.UTF8, false)
+		}
+		if err != nil {
+			return nil, err
+		}
+		all.Merge(p)
+	}
+	return all, nil
+}
+
+// loadConfigFile loads a YAML or TOML config file as the properties. The nested tables are
+// flattened with the keys joined by ".", except the workload, measurement and db sections, like
+//
+//	workload:
+//	  recordcount: 1000
+//	db:
+//	  mysql:
+//	    host: ${MYSQL_HOST:-127.0.0.1}
+//
+// is recordcount=1000 and mysql.host=127.0.0.1 if MYSQL_HOST is not set. The lists are joined
+// by ",".
+func loadConfigFile(path string) (*properties.Properties, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var root map[string]interface{}
+	if strings.ToLower(filepath.Ext(path)) == ".toml" {
+		err = toml.Unmarshal(data, &root)
+	} else {
+		err = yaml.Unmarshal(data, &root)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse config file %s failed %v", path, err)
+	}
+
+	p := properties.NewProperties()
+	keys := make([]string, 0, len(root))
+	for key := range root {
+		keys = append(keys, key)
+	}
+	// Set the properties in the sections first, so the top level ones win.
+	sort.Slice(keys, func(i, j int) bool {
+		if configSections[keys[i]] != configSections[keys[j]] {
+			return configSections[keys[i]]
+		}
+		return keys[i] < keys[j]
+	})
+	for _, key := range keys {
+		prefix := key
+		if _, ok := toConfigTable(root[key]); ok && configSections[key] {
+			prefix = ""
+		}
+		if err = flattenConfig(p, prefix, root[key]); err != nil {
+			return nil, fmt.Errorf("config file %s: %v", path, err)
+		}
+	}
+	return p, nil
+}
+
+func toConfigTable(v interface{}) (map[string]interface{}, bool) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		return t, true
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, v := range t {
+			m[fmt.Sprint(k)] = v
+		}
+		return m, true
+	}
+	return nil, false
+}
+
+func flattenConfig(p *properties.Properties, prefix string, v interface{}) error {
+	if table, ok := toConfigTable(v); ok {
+		keys := make([]string, 0, len(table))
+		for key := range table {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			name := key
+			if prefix != "" {
+				name = prefix + "." + key
+			}
+			if err := flattenConfig(p, name, table[key]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if prefix == "" {
+		return fmt.Errorf("the value must be in a table")
+	}
+
+	var value string
+	switch t := v.(type) {
+	case []interface{}:
+		items := make([]string, 0, len(t))
+		for _, item := range t {
+			if _, ok := toConfigTable(item); ok {
+				return fmt.Errorf("%s: the items of a list must not be tables", prefix)
+			}
+			items = append(items, configValue(item))
+		}
+		value = strings.Join(items, ",")
+	default:
+		value = configValue(v)
+	}
+
+	value, err := expandEnv(value)
+	if err != nil {
+		return fmt.Errorf("%s: %v", prefix, err)
+	}
+	if _, _, err = p.Set(prefix, value); err != nil {
+		return fmt.Errorf("%s: %v", prefix, err)
+	}
+	return nil
+}
+
+func configValue(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case float64:
+		// Format 1e6 as 1000000, so it can be read as an integer.
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	default:
+		return fmt.Sprint(t)
+	}
+}
+
+// expandEnv replaces ${VAR} with the environment variable, and ${VAR:-default} with the default
+// if the variable is not set or empty. It's an error if a variable without the default is not set.
+func expandEnv(s string) (string, error) {
+	var err error
+	s = envPattern.ReplaceAllStringFunc(s, func(m string) string {
+		sub := envPattern.FindStringSubmatch(m)
+		if v := os.Getenv(sub[1]); v != "" {
+			return v
+		}
+		if sub[2] != "" {
+			return sub[3]
+		}
+		if v, ok := os.LookupEnv(sub[1]); ok {
+			return v
+		}
+		if err == nil {
+			err = fmt.Errorf("environment variable %s is not set", sub[1])
+		}
+		return m
+	})
+	return s, err
+}
@@ -0,0 +1,245 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/spf13/cobra"
+)
+
+const (
+	dryRunTopKeys    = 10
+	dryRunHistBucket = 10
+	dryRunBarWidth   = 40
+)
+
+var dryRunArg bool
+
+// initDryRunCommand adds the dry-run flag to the load and the run commands, k8s run has its own
+// dry-run to print the manifests.
+func initDryRunCommand(m *cobra.Command) {
+	m.Flags().BoolVar(&dryRunArg, "dry-run", false, "Run the workload against an internal sink instead of the database, and print the operation mix, the key distribution and the estimated data size")
+}
+
+// dryRunProperties builds the keys of the dry run in the sequential order, so the key indices can be
+// parsed back from the keys. The key choosers only pick the indices, the key distribution is the same
+// for all the key types. The dry run doesn't save or resume the checkpoint of the load.
+func dryRunProperties(p *properties.Properties) {
+	p.Set(prop.KeyType, "sequential")
+	p.Delete(prop.KeyComposition)
+	p.Delete(prop.CheckpointFile)
+	p.Delete(prop.CheckpointResume)
+}
+
+// dryRunDB is the internal sink of the dry run, it discards the operations and records the operation
+// mix, the accesses of every key and the size of the written data.
+type dryRunDB struct {
+	keyPrefix string
+
+	mu       sync.Mutex
+	ops      map[string]int64
+	accesses map[string]int64
+	indices  map[int64]int64
+	maxIndex int64
+	// The rows and bytes of the inserts and the updates, the bytes include the keys.
+	insertRows  int64
+	insertBytes int64
+	updateRows  int64
+	updateBytes int64
+	scanRecords int64
+}
+
+func newDryRunDB(p *properties.Properties) *dryRunDB {
+	return &dryRunDB{
+		keyPrefix: p.GetString(prop.KeyPrefix, prop.KeyPrefixDefault),
+		ops:       make(map[string]int64),
+		accesses:  make(map[string]int64),
+		indices:   make(map[int64]int64),
+		maxIndex:  -1,
+	}
+}
+
+func (db *dryRunDB) record(op string, key string) {
+	db.ops[op]++
+	db.accesses[key]++
+	if index, err := strconv.ParseInt(strings.TrimPrefix(key, db.keyPrefix), 10, 64); err == nil {
+		db.indices[index]++
+		if index > db.maxIndex {
+			db.maxIndex = index
+		}
+	}
+}
+
+func rowBytes(key string, values map[string][]byte) int64 {
+	n := int64(len(key))
+	for field, value := range values {
+		n += int64(len(field) + len(value))
+	}
+	return n
+}
+
+func (db *dryRunDB) Close() error {
+	return nil
+}
+
+func (db *dryRunDB) InitThread(ctx context.Context, _ int, _ int) context.Context {
+	return ctx
+}
+
+func (db *dryRunDB) CleanupThread(_ context.Context) {
+}
+
+func (db *dryRunDB) Read(ctx context.Context, table string, key string, fields []string) (map[string][]byte, error) {
+	db.mu.Lock()
+	db.record("READ", key)
+	db.mu.Unlock()
+	return nil, nil
+}
+
+func (db *dryRunDB) Scan(ctx context.Context, table string, startKey string, count int, fields []string) ([]map[string][]byte, error) {
+	db.mu.Lock()
+	db.record("SCAN", startKey)
+	db.scanRecords += int64(count)
+	db.mu.Unlock()
+	return nil, nil
+}
+
+func (db *dryRunDB) Update(ctx context.Context, table string, key string, values map[string][]byte) error {
+	db.mu.Lock()
+	db.record("UPDATE", key)
+	db.updateRows++
+	db.updateBytes += rowBytes(key, values)
+	db.mu.Unlock()
+	return nil
+}
+
+func (db *dryRunDB) Insert(ctx context.Context, table string, key string, values map[string][]byte) error {
+	db.mu.Lock()
+	db.record("INSERT", key)
+	db.insertRows++
+	db.insertBytes += rowBytes(key, values)
+	db.mu.Unlock()
+	return nil
+}
+
+func (db *dryRunDB) Delete(ctx context.Context, table string, key string) error {
+	db.mu.Lock()
+	db.record("DELETE", key)
+	db.mu.Unlock()
+	return nil
+}
+
+// Output prints the operation mix, the hottest keys, the histogram of the key indices and the
+// estimated data size.
+func (db *dryRunDB) Output(w io.Writer, recordCount int64) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	var total int64
+	ops := make([]string, 0, len(db.ops))
+	for op, n := range db.ops {
+		ops = append(ops, op)
+		total += n
+	}
+	sort.Strings(ops)
+
+	fmt.Fprintln(w, "***************** dry run *****************")
+	fmt.Fprintf(w, "Operations: %d\n", total)
+	if total == 0 {
+		return
+	}
+	for _, op := range ops {
+		fmt.Fprintf(w, "  %-8s %12d %6.2f%%\n", op, db.ops[op], 100*float64(db.ops[op])/float64(total))
+	}
+	if n := db.ops["SCAN"]; n > 0 {
+		fmt.Fprintf(w, "  Average scan length %.1f\n", float64(db.scanRecords)/float64(n))
+	}
+
+	keys := make([]string, 0, len(db.accesses))
+	for key := range db.accesses {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if db.accesses[keys[i]] != db.accesses[keys[j]] {
+			return db.accesses[keys[i]] > db.accesses[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	fmt.Fprintf(w, "Distinct keys: %d\n", len(keys))
+	fmt.Fprintf(w, "Top %d hottest keys:\n", minInt(dryRunTopKeys, len(keys)))
+	for _, key := range keys[:minInt(dryRunTopKeys, len(keys))] {
+		n := db.accesses[key]
+		fmt.Fprintf(w, "  %-24s %12d %6.2f%%\n", key, n, 100*float64(n)/float64(total))
+	}
+
+	if db.maxIndex >= 0 {
+		db.outputHistogram(w, total)
+	}
+
+	fmt.Fprintln(w, "Estimated data size:")
+	if db.insertRows > 0 {
+		avg := db.insertBytes / db.insertRows
+		fmt.Fprintf(w, "  Inserted %d rows, %s, %s per row\n", db.insertRows, formatBytes(db.insertBytes), formatBytes(avg))
+		fmt.Fprintf(w, "  %d records of the table take about %s\n", recordCount, formatBytes(avg*recordCount))
+	}
+	if db.updateRows > 0 {
+		fmt.Fprintf(w, "  Updated %d rows, %s\n", db.updateRows, formatBytes(db.updateBytes))
+	}
+}
+
+func (db *dryRunDB) outputHistogram(w io.Writer, total int64) {
+	width := (db.maxIndex + dryRunHistBucket) / dryRunHistBucket
+	buckets := make([]int64, dryRunHistBucket)
+	var maxCount int64
+	for index, n := range db.indices {
+		i := index / width
+		buckets[i] += n
+		if buckets[i] > maxCount {
+			maxCount = buckets[i]
+		}
+	}
+
+	fmt.Fprintln(w, "Histogram of key indices:")
+	for i, n := range buckets {
+		lo := int64(i) * width
+		if lo > db.maxIndex {
+			break
+		}
+		bar := strings.Repeat("#", int(n*dryRunBarWidth/maxCount))
+		fmt.Fprintf(w, "  [%10d, %10d) %12d %6.2f%% %s\n", lo, lo+width, n, 100*float64(n)/float64(total), bar)
+	}
+}
+
+func formatBytes(n int64) string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB"}
+	v := float64(n)
+	i := 0
+	for v >= 1024 && i < len(units)-1 {
+		v /= 1024
+		i++
+	}
+	if i == 0 {
+		return fmt.Sprintf("%d B", n)
+	}
+	return fmt.Sprintf("%.1f %s", v, units[i])
+}
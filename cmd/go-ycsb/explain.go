@@ -0,0 +1,102 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/util"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+	"github.com/spf13/cobra"
+)
+
+var explainLoadArg bool
+
+// runExplainCommandFunc prints the effective properties of the load or the run command in the format
+// of a property file, with the problems of the properties as the comments, followed by the defaults of
+// the properties which are not set.
+func runExplainCommandFunc(cmd *cobra.Command, args []string) {
+	dbName := args[0]
+	if ycsb.GetDBCreator(dbName) == nil {
+		util.Fatalf("%s is not registered", dbName)
+	}
+
+	loadGlobalProps(clientProperties(cmd, !explainLoadArg))
+	if _, ok := globalProps.Get(prop.DB); !ok {
+		globalProps.Set(prop.DB, dbName)
+	}
+	if len(tableName) > 0 {
+		globalProps.Set(prop.TableName, tableName)
+	}
+
+	problems := checkProperties(globalProps)
+	reasons := make(map[string][]string, len(problems))
+	for _, problem := range problems {
+		reasons[problem.name] = append(reasons[problem.name], problem.reason)
+	}
+
+	command := "run"
+	if explainLoadArg {
+		command = "load"
+	}
+	fmt.Printf("# The effective properties of go-ycsb %s %s\n", command, dbName)
+	keys := globalProps.Keys()
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, reason := range reasons[key] {
+			fmt.Printf("# WARNING: %s\n", reason)
+		}
+		fmt.Printf("%s=%s\n", key, globalProps.GetString(key, ""))
+	}
+	// The conflicts may be reported on the properties which are not set, like the defaults.
+	for _, problem := range problems {
+		if _, ok := globalProps.Get(problem.name); !ok {
+			fmt.Printf("# WARNING: %s\n", problem)
+		}
+	}
+
+	fmt.Println()
+	fmt.Println("# The defaults of the properties which are not set")
+	for _, name := range prop.Names() {
+		if _, ok := globalProps.Get(name); ok {
+			continue
+		}
+		if value, ok := prop.Default(name); ok {
+			fmt.Printf("#%s=%s\n", name, value)
+		}
+	}
+
+	if len(problems) > 0 {
+		fmt.Printf("\n# %d problems found\n", len(problems))
+		if strictArg {
+			os.Exit(1)
+		}
+	}
+}
+
+func newExplainCommand() *cobra.Command {
+	m := &cobra.Command{
+		Use:   "explain db",
+		Short: "Print the effective properties of a benchmark and check them",
+		Args:  cobra.MinimumNArgs(1),
+		Run:   runExplainCommandFunc,
+	}
+
+	initClientCommand(m)
+	m.Flags().BoolVar(&explainLoadArg, "load", false, "Explain the load phase instead of the run phase")
+	return m
+}
@@ -0,0 +1,506 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+// k8sResultMarker is printed by every pod before its JSON result, so the result can be told
+// from the other logs.
+const k8sResultMarker = "===== go-ycsb result ====="
+
+var (
+	k8sClientsArg   int
+	k8sImageArg     string
+	k8sBinaryArg    string
+	k8sNameArg      string
+	k8sNamespaceArg string
+	k8sTemplateArg  string
+	k8sKubectlArg   string
+	k8sTimeoutArg   time.Duration
+	k8sLoadArg      bool
+	k8sDryRunArg    bool
+	k8sKeepArg      bool
+	k8sOutputArg    string
+)
+
+// k8sDefaultTemplate is the ConfigMap of the property files and the indexed Job of the clients,
+// the completion index of a pod is its clientid.
+const k8sDefaultTemplate = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{json .Name}}
+  labels:
+    app: go-ycsb
+data:
+{{- range $name, $content := .Files}}
+  {{json $name}}: {{json $content}}
+{{- end}}
+---
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: {{json .Name}}
+  labels:
+    app: go-ycsb
+spec:
+  completions: {{.Clients}}
+  parallelism: {{.Clients}}
+  completionMode: Indexed
+  backoffLimit: 0
+  template:
+    metadata:
+      labels:
+        app: go-ycsb
+    spec:
+      restartPolicy: Never
+      containers:
+      - name: go-ycsb
+        image: {{json .Image}}
+        command: {{json .Command}}
+        volumeMounts:
+        - name: config
+          mountPath: /config
+      volumes:
+      - name: config
+        configMap:
+          name: {{json .Name}}
+`
+
+// k8sJob is the data of the template.
+type k8sJob struct {
+	Name    string
+	Image   string
+	Clients int
+	// Files are the property files in the ConfigMap by the name, mounted in /config.
+	Files map[string]string
+	// Command is the command of the container, which runs the client and prints its result.
+	Command []string
+}
+
+// kubectl runs the kubectl commands in the namespace.
+type kubectl struct {
+	path      string
+	namespace string
+}
+
+func (k kubectl) run(stdin string, args ...string) (string, error) {
+	if k.namespace != "" {
+		args = append([]string{"--namespace", k.namespace}, args...)
+	}
+	cmd := exec.CommandContext(globalContext, k.path, args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("kubectl %s failed %v: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// shellQuote quotes s for sh.
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'"'"'`, -1) + "'"
+}
+
+func newK8sJob(cmd *cobra.Command, dbName string) *k8sJob {
+	job := &k8sJob{
+		Name:    k8sNameArg,
+		Image:   k8sImageArg,
+		Clients: k8sClientsArg,
+		Files:   make(map[string]string),
+	}
+	if job.Name == "" {
+		job.Name = "go-ycsb-" + time.Now().Format("20060102-150405")
+	}
+
+	command := "run"
+	if k8sLoadArg {
+		command = "load"
+	}
+	args := []string{shellQuote(k8sBinaryArg), command, shellQuote(dbName)}
+	for _, path := range propertyFiles {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			util.Fatalf("read property file %s failed %v", path, err)
+		}
+		name := filepath.Base(path)
+		if _, ok := job.Files[name]; ok {
+			util.Fatalf("property files must have different names, %s is duplicated", name)
+		}
+		job.Files[name] = string(data)
+		args = append(args, "-P", shellQuote("/config/"+name))
+	}
+	for _, value := range propertyValues {
+		args = append(args, "-p", shellQuote(value))
+	}
+	if cmd.Flags().Changed("threads") {
+		args = append(args, "--threads", strconv.Itoa(threadsArg))
+	}
+	if cmd.Flags().Changed("target") {
+		args = append(args, "--target", shellQuote(targetArg))
+	}
+	if cmd.Flags().Changed("log-level") {
+		args = append(args, "--log-level", shellQuote(logLevelArg))
+	}
+	if strictArg {
+		args = append(args, "--strict")
+	}
+	if tableName != "" {
+		args = append(args, "--table", shellQuote(tableName))
+	}
+	args = append(args,
+		"-p", fmt.Sprintf("%s=%d", prop.ClientCount, job.Clients),
+		"-p", fmt.Sprintf(`"%s=$JOB_COMPLETION_INDEX"`, prop.ClientID),
+		"-p", prop.OutputStyle+"=json",
+		"-p", prop.ExportFile+"=/tmp/result.json")
+	script := fmt.Sprintf("%s && echo %s && cat /tmp/result.json", strings.Join(args, " "), shellQuote(k8sResultMarker))
+	job.Command = []string{"/bin/sh", "-c", script}
+	return job
+}
+
+func (job *k8sJob) render() string {
+	text := k8sDefaultTemplate
+	if k8sTemplateArg != "" {
+		data, err := ioutil.ReadFile(k8sTemplateArg)
+		if err != nil {
+			util.Fatalf("read template %s failed %v", k8sTemplateArg, err)
+		}
+		text = string(data)
+	}
+
+	t, err := template.New("k8s").Funcs(template.FuncMap{
+		// The JSON strings are valid YAML strings.
+		"json": func(v interface{}) (string, error) {
+			var buf bytes.Buffer
+			enc := json.NewEncoder(&buf)
+			enc.SetEscapeHTML(false)
+			err := enc.Encode(v)
+			return strings.TrimSpace(buf.String()), err
+		},
+	}).Parse(text)
+	if err != nil {
+		util.Fatalf("parse template failed %v", err)
+	}
+	var buf bytes.Buffer
+	if err = t.Execute(&buf, job); err != nil {
+		util.Fatalf("render template failed %v", err)
+	}
+	return buf.String()
+}
+
+// wait waits for all the pods of the job to complete, it returns false if any pod fails.
+func (job *k8sJob) wait(k kubectl) bool {
+	deadline := time.Now().Add(k8sTimeoutArg)
+	t := time.NewTicker(5 * time.Second)
+	defer t.Stop()
+
+	last := ""
+	for {
+		out, err := k.run("", "get", "job", job.Name, "-o", "jsonpath={.status.succeeded}/{.status.failed}")
+		if err != nil {
+			fmt.Println(err)
+		} else {
+			parts := strings.SplitN(strings.TrimSpace(out), "/", 2)
+			succeeded, _ := strconv.Atoi(parts[0])
+			failed := 0
+			if len(parts) == 2 {
+				failed, _ = strconv.Atoi(parts[1])
+			}
+			if status := fmt.Sprintf("%d/%d completed, %d failed", succeeded, job.Clients, failed); status != last {
+				fmt.Printf("Job %s: %s\n", job.Name, status)
+				last = status
+			}
+			if failed > 0 {
+				return false
+			}
+			if succeeded >= job.Clients {
+				return true
+			}
+		}
+
+		if time.Now().After(deadline) {
+			fmt.Printf("Job %s doesn't complete in %s\n", job.Name, k8sTimeoutArg)
+			return false
+		}
+		select {
+		case <-t.C:
+		case <-globalContext.Done():
+			return false
+		}
+	}
+}
+
+// collect returns the results of the pods of the job, the tails of the logs of the pods
+// without a result are printed.
+func (job *k8sJob) collect(k kubectl) []*runResult {
+	out, err := k.run("", "get", "pods", "-l", "job-name="+job.Name, "-o", `jsonpath={range .items[*]}{.metadata.name}{"\n"}{end}`)
+	if err != nil {
+		fmt.Println(err)
+		return nil
+	}
+
+	var results []*runResult
+	for _, pod := range strings.Fields(out) {
+		logs, err := k.run("", "logs", pod)
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		i := strings.LastIndex(logs, k8sResultMarker)
+		if i < 0 {
+			lines := strings.Split(strings.TrimSpace(logs), "\n")
+			if len(lines) > 10 {
+				lines = lines[len(lines)-10:]
+			}
+			fmt.Printf("Pod %s has no result:\n%s\n", pod, strings.Join(lines, "\n"))
+			continue
+		}
+		r := new(runResult)
+		if err = json.Unmarshal([]byte(logs[i+len(k8sResultMarker):]), r); err != nil {
+			fmt.Printf("Parse the result of pod %s failed %v\n", pod, err)
+			continue
+		}
+		results = append(results, r)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		a, _ := strconv.Atoi(results[i].Properties[prop.ClientID])
+		b, _ := strconv.Atoi(results[j].Properties[prop.ClientID])
+		return a < b
+	})
+	return results
+}
+
+func runK8sRunCommandFunc(cmd *cobra.Command, args []string) {
+	if k8sClientsArg < 1 {
+		util.Fatalf("--clients must be positive")
+	}
+
+	job := newK8sJob(cmd, args[0])
+	manifest := job.render()
+	if k8sDryRunArg {
+		fmt.Print(manifest)
+		return
+	}
+
+	k := kubectl{path: k8sKubectlArg, namespace: k8sNamespaceArg}
+	if _, err := k.run(manifest, "apply", "-f", "-"); err != nil {
+		util.Fatal(err)
+	}
+	fmt.Printf("Created job %s of %d clients\n", job.Name, job.Clients)
+
+	ok := job.wait(k)
+	results := job.collect(k)
+	if !k8sKeepArg {
+		if _, err := k.run("", "delete", "job,configmap", job.Name, "--ignore-not-found"); err != nil {
+			fmt.Println(err)
+		}
+	}
+	if len(results) == 0 {
+		util.Fatalf("no result is collected from job %s", job.Name)
+	}
+
+	merged := mergeRunResults(results)
+	outputMergedResult(results, merged)
+	if k8sOutputArg != "" {
+		data, err := json.MarshalIndent(merged, "", "  ")
+		if err != nil {
+			util.Fatal(err)
+		}
+		if err = ioutil.WriteFile(k8sOutputArg, append(data, '\n'), 0644); err != nil {
+			util.Fatalf("write the merged result failed %v", err)
+		}
+	}
+	if !ok || len(results) < job.Clients {
+		fmt.Printf("Only %d of %d clients succeeded\n", len(results), job.Clients)
+		os.Exit(1)
+	}
+}
+
+// mergeLatency merges the latencies of two clients, the average is weighted by the counts, and
+// the percentiles are the max of the clients, which are the upper bounds of the merged ones.
+func mergeLatency(a latencyResult, b latencyResult) latencyResult {
+	if a.Count == 0 {
+		return b
+	}
+	if b.Count == 0 {
+		return a
+	}
+
+	m := latencyResult{
+		Count:       a.Count + b.Count,
+		Throughput:  a.Throughput + b.Throughput,
+		Avg:         (a.Avg*a.Count + b.Avg*b.Count) / (a.Count + b.Count),
+		Min:         a.Min,
+		Max:         a.Max,
+		Percentiles: make(map[string]int64, len(a.Percentiles)),
+	}
+	if b.Min < m.Min {
+		m.Min = b.Min
+	}
+	if b.Max > m.Max {
+		m.Max = b.Max
+	}
+	for _, percentiles := range []map[string]int64{a.Percentiles, b.Percentiles} {
+		for name, v := range percentiles {
+			if v > m.Percentiles[name] {
+				m.Percentiles[name] = v
+			}
+		}
+	}
+	return m
+}
+
+// mergeRunResults merges the results of the clients which run at the same time, the counts and
+// the throughputs are summed, and the latencies are merged by mergeLatency.
+func mergeRunResults(results []*runResult) *runResult {
+	first := results[0]
+	m := &runResult{
+		Command:    first.Command,
+		DB:         first.DB,
+		Workload:   first.Workload,
+		Start:      first.Start,
+		End:        first.End,
+		Properties: make(map[string]string, len(first.Properties)),
+	}
+	for key, value := range first.Properties {
+		if key != prop.ClientID {
+			m.Properties[key] = value
+		}
+	}
+
+	ops := make(map[string]*operationResult)
+	for _, r := range results {
+		if r.Start.Before(m.Start) {
+			m.Start = r.Start
+		}
+		if r.End.After(m.End) {
+			m.End = r.End
+		}
+		if r.MeasuredSeconds > m.MeasuredSeconds {
+			m.MeasuredSeconds = r.MeasuredSeconds
+		}
+		m.Threads += r.Threads
+		m.Operations += r.Operations
+		m.Errors += r.Errors
+		m.Throughput += r.Throughput
+
+		for _, res := range r.Results {
+			o := ops[res.Operation]
+			if o == nil {
+				o = &operationResult{Operation: res.Operation}
+				ops[res.Operation] = o
+			}
+			o.latencyResult = mergeLatency(o.latencyResult, res.latencyResult)
+			o.Errors += res.Errors
+			for class, count := range res.ErrorClasses {
+				if o.ErrorClasses == nil {
+					o.ErrorClasses = make(map[string]int64)
+				}
+				o.ErrorClasses[class] += count
+			}
+			if res.Failures != nil {
+				var failures latencyResult
+				if o.Failures != nil {
+					failures = *o.Failures
+				}
+				failures = mergeLatency(failures, *res.Failures)
+				o.Failures = &failures
+			}
+		}
+	}
+	m.RuntimeSeconds = m.End.Sub(m.Start).Seconds()
+
+	for _, o := range ops {
+		m.Results = append(m.Results, *o)
+	}
+	sort.Slice(m.Results, func(i, j int) bool {
+		return m.Results[i].Operation < m.Results[j].Operation
+	})
+	return m
+}
+
+// outputMergedResult prints the result of every client and the merged result.
+func outputMergedResult(results []*runResult, merged *runResult) {
+	fmt.Println("***************** clients *****************")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Client\tTakes(s)\tOperations\tErrors\tOPS")
+	for _, r := range results {
+		fmt.Fprintf(w, "%s\t%.1f\t%d\t%d\t%.1f\n", r.Properties[prop.ClientID], r.MeasuredSeconds, r.Operations, r.Errors, r.Throughput)
+	}
+	fmt.Fprintf(w, "TOTAL\t%.1f\t%d\t%d\t%.1f\n", merged.MeasuredSeconds, merged.Operations, merged.Errors, merged.Throughput)
+	w.Flush()
+
+	fmt.Println("\nThe percentiles are the max of the clients")
+	for _, res := range merged.Results {
+		line := fmt.Sprintf("%-6s - Count: %d, OPS: %.1f, Avg(us): %d, Min(us): %d, Max(us): %d", res.Operation,
+			res.Count, res.Throughput, res.Avg, res.Min, res.Max)
+		for _, name := range sortedPercentiles(res.Percentiles) {
+			line += fmt.Sprintf(", %s(us): %d", name, res.Percentiles[name])
+		}
+		if res.Errors > 0 {
+			line += fmt.Sprintf(", Errors: %d", res.Errors)
+		}
+		fmt.Println(line)
+	}
+	fmt.Println("*******************************************")
+}
+
+func newK8sCommand() *cobra.Command {
+	m := &cobra.Command{
+		Use:   "k8s",
+		Short: "Run the benchmark by the clients in a Kubernetes Job",
+	}
+
+	run := &cobra.Command{
+		Use:   "run db",
+		Short: "Run the clients in an indexed Job, wait for them and merge their results",
+		Args:  cobra.ExactArgs(1),
+		Run:   runK8sRunCommandFunc,
+	}
+	initClientCommand(run)
+	run.Flags().IntVar(&k8sClientsArg, "clients", 1, "The number of the clients, every client runs in a pod with its clientid")
+	run.Flags().StringVar(&k8sImageArg, "image", "pingcap/go-ycsb:latest", "The image of go-ycsb")
+	run.Flags().StringVar(&k8sBinaryArg, "binary", "/go-ycsb", "The path of go-ycsb in the image")
+	run.Flags().StringVar(&k8sNameArg, "name", "", "The name of the Job and the ConfigMap (default go-ycsb-<time>)")
+	run.Flags().StringVarP(&k8sNamespaceArg, "namespace", "n", "", "The namespace of the Job, the namespace of the current context by default")
+	run.Flags().StringVar(&k8sTemplateArg, "template", "", "The template of the manifests instead of the default ConfigMap and Job")
+	run.Flags().StringVar(&k8sKubectlArg, "kubectl", "kubectl", "The path of kubectl")
+	run.Flags().DurationVar(&k8sTimeoutArg, "timeout", time.Hour, "Max time to wait for the clients")
+	run.Flags().BoolVar(&k8sLoadArg, "load", false, "Run the load phase instead of the run phase")
+	run.Flags().BoolVar(&k8sDryRunArg, "dry-run", false, "Print the manifests without creating them")
+	run.Flags().BoolVar(&k8sKeepArg, "keep", false, "Keep the Job and the ConfigMap after the run")
+	run.Flags().StringVar(&k8sOutputArg, "output", "", "The file to write the merged result to as JSON")
+
+	m.AddCommand(run)
+	return m
+}
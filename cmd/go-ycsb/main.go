@@ -19,6 +19,7 @@ import (
 	_ "net/http/pprof"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
@@ -35,7 +36,9 @@ import (
 	"github.com/pingcap/go-ycsb/pkg/util"
 	_ "github.com/pingcap/go-ycsb/pkg/workload"
 	"github.com/pingcap/go-ycsb/pkg/ycsb"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/spf13/cobra"
+	"go.uber.org/zap"
 
 	// Register basic database
 	_ "github.com/pingcap/go-ycsb/db/basic"
@@ -67,6 +70,45 @@ import (
 	_ "github.com/pingcap/go-ycsb/db/boltdb"
 	// Register minio
 	_ "github.com/pingcap/go-ycsb/db/minio"
+	// Register clickhouse database
+	_ "github.com/pingcap/go-ycsb/db/clickhouse"
+	// Register memcache database
+	_ "github.com/pingcap/go-ycsb/db/memcache"
+	// Register etcd database
+	_ "github.com/pingcap/go-ycsb/db/etcd"
+	// Register DynamoDB database
+	_ "github.com/pingcap/go-ycsb/db/dynamodb"
+	// Register Bigtable database
+	_ "github.com/pingcap/go-ycsb/db/bigtable"
+	// Register Couchbase database
+	_ "github.com/pingcap/go-ycsb/db/couchbase"
+	// Register Elasticsearch database
+	_ "github.com/pingcap/go-ycsb/db/elastic"
+	// Register Pebble database
+	_ "github.com/pingcap/go-ycsb/db/pebble"
+	// Register SQL Server database
+	_ "github.com/pingcap/go-ycsb/db/mssql"
+	// Register Kafka
+	_ "github.com/pingcap/go-ycsb/db/kafka"
+	// Register S3
+	_ "github.com/pingcap/go-ycsb/db/s3"
+	// Register HTTP
+	_ "github.com/pingcap/go-ycsb/db/http"
+	// Register gRPC
+	_ "github.com/pingcap/go-ycsb/db/grpc"
+	// Register noop and sleep
+	_ "github.com/pingcap/go-ycsb/db/noop"
+	_ "github.com/pingcap/go-ycsb/db/sleep"
+	// Register memory
+	_ "github.com/pingcap/go-ycsb/db/memory"
+	// Register Neo4j
+	_ "github.com/pingcap/go-ycsb/db/neo4j"
+	// Register Azure Cosmos DB
+	_ "github.com/pingcap/go-ycsb/db/cosmos"
+	// Register InfluxDB
+	_ "github.com/pingcap/go-ycsb/db/influx"
+	// Register the external driver
+	_ "github.com/pingcap/go-ycsb/db/external"
 )
 
 var (
@@ -79,14 +121,19 @@ var (
 	globalCancel  context.CancelFunc
 
 	globalDB       ycsb.DB
+	dryRunSink     *dryRunDB
 	globalWorkload ycsb.Workload
 	globalProps    *properties.Properties
 )
 
-func initialGlobal(dbName string, onProperties func()) {
+// loadGlobalProps loads the global properties from the property files and the command line.
+func loadGlobalProps(onProperties func()) {
 	globalProps = properties.NewProperties()
 	if len(propertyFiles) > 0 {
-		globalProps = properties.MustLoadFiles(propertyFiles, properties.UTF8, false)
+		var err error
+		if globalProps, err = loadPropertyFiles(propertyFiles); err != nil {
+			util.Fatalf("load property files failed %v", err)
+		}
 	}
 
 	for _, prop := range propertyValues {
@@ -94,14 +141,65 @@ func initialGlobal(dbName string, onProperties func()) {
 		globalProps.Set(seps[0], seps[1])
 	}
 
+	// warmup_time is an alias of warmuptime.
+	if v, ok := globalProps.Get(prop.WarmUpTimeAlias); ok {
+		if _, ok := globalProps.Get(prop.WarmUpTime); !ok {
+			globalProps.Set(prop.WarmUpTime, v)
+		}
+	}
+
 	if onProperties != nil {
 		onProperties()
 	}
+}
+
+// initialGlobalProps loads the global properties, checks them and starts the debug server of pprof and the
+// Prometheus metrics.
+func initialGlobalProps(onProperties func()) {
+	loadGlobalProps(onProperties)
+
+	logLevel := globalProps.GetString(prop.LogLevel, prop.LogLevelDefault)
+	logFile := globalProps.GetString(prop.LogFile, "")
+	if err := util.InitLogger(logLevel, logFile, globalProps.GetString(prop.LogFormat, "")); err != nil {
+		util.Fatalf("init logger failed %v", err)
+	}
+
+	if problems := checkProperties(globalProps); len(problems) > 0 {
+		if strictArg {
+			lines := make([]string, 0, len(problems))
+			for _, problem := range problems {
+				lines = append(lines, problem.String())
+			}
+			util.Fatalf("invalid properties\n%s", strings.Join(lines, "\n"))
+		}
+		for _, problem := range problems {
+			util.Logger().Warn("invalid property", zap.String("name", problem.name), zap.String("reason", problem.reason))
+		}
+	}
+
+	// The seed is recorded in the properties to repeat the run, and the databases
+	// compared get the same operations.
+	if _, ok := globalProps.Get(prop.RandomSeed); !ok {
+		globalProps.Set(prop.RandomSeed, strconv.FormatInt(time.Now().UnixNano(), 10))
+	}
 
 	addr := globalProps.GetString(prop.DebugPprof, prop.DebugPprofDefault)
+	http.Handle("/metrics", promhttp.Handler())
 	go func() {
 		http.ListenAndServe(addr, nil)
 	}()
+}
+
+func initialGlobal(dbName string, onProperties func()) {
+	initialGlobalProps(onProperties)
+	initialGlobalDB(dbName)
+}
+
+// initialGlobalDB creates the workload and the DB by the global properties.
+func initialGlobalDB(dbName string) {
+	if _, ok := globalProps.Get(prop.DB); !ok {
+		globalProps.Set(prop.DB, dbName)
+	}
 
 	measurement.InitMeasure(globalProps)
 
@@ -117,6 +215,12 @@ func initialGlobal(dbName string, onProperties func()) {
 		util.Fatalf("create workload %s failed %v", workloadName, err)
 	}
 
+	if dryRunArg {
+		dryRunSink = newDryRunDB(globalProps)
+		globalDB = client.DbWrapper{DB: dryRunSink}
+		return
+	}
+
 	dbCreator := ycsb.GetDBCreator(dbName)
 	if dbCreator == nil {
 		util.Fatalf("%s is not registered", dbName)
@@ -124,7 +228,12 @@ func initialGlobal(dbName string, onProperties func()) {
 	if globalDB, err = dbCreator.Create(globalProps); err != nil {
 		util.Fatalf("create db %s failed %v", dbName, err)
 	}
-	globalDB = client.DbWrapper{globalDB}
+	// The checkpoint would cover the inserts buffered by the DB, which are lost if the client crashes.
+	if buffered, ok := globalDB.(ycsb.BufferedDB); ok && buffered.BufferedInserts() &&
+		!globalProps.GetBool(prop.DoTransactions, true) && globalProps.GetString(prop.CheckpointFile, "") != "" {
+		util.Fatalf("%s buffers the inserts, disable its batching to use %s", dbName, prop.CheckpointFile)
+	}
+	globalDB = client.DbWrapper{DB: globalDB}
 }
 
 func main() {
@@ -165,6 +274,11 @@ func main() {
 		newShellCommand(),
 		newLoadCommand(),
 		newRunCommand(),
+		newCompareCommand(),
+		newVerifyCommand(),
+		newReportCommand(),
+		newK8sCommand(),
+		newExplainCommand(),
 	)
 
 	cobra.EnablePrefixMatching = true
@@ -182,5 +296,6 @@ func main() {
 		globalWorkload.Close()
 	}
 
+	util.SyncLogger()
 	closeDone <- struct{}{}
 }
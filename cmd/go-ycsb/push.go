@@ -0,0 +1,331 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/pingcap/go-ycsb/pkg/client"
+	"github.com/pingcap/go-ycsb/pkg/measurement"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/util"
+)
+
+// remoteWriteBatchSize is the max number of the series pushed in a remote write request.
+const remoteWriteBatchSize = 500
+
+var labelNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+type pushLabel struct {
+	name  string
+	value string
+}
+
+// pushSample is a gauge sample of the results, the latencies are in microseconds.
+type pushSample struct {
+	name      string
+	labels    []pushLabel
+	value     float64
+	timestamp time.Time
+}
+
+// checkPush checks the labels of the pushed results before the run.
+func checkPush() {
+	if _, err := parsePushLabels(); err != nil {
+		util.Fatal(err)
+	}
+}
+
+// parsePushLabels parses push.labels like "commit=abc123,env=staging".
+func parsePushLabels() ([]pushLabel, error) {
+	var labels []pushLabel
+	for _, pair := range strings.Split(globalProps.GetString(prop.PushLabels, ""), ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		seps := strings.SplitN(pair, "=", 2)
+		name := strings.TrimSpace(seps[0])
+		if len(seps) != 2 || !labelNamePattern.MatchString(name) || strings.HasPrefix(name, "__") {
+			return nil, fmt.Errorf("invalid label %q in %s, must be name=value", pair, prop.PushLabels)
+		}
+		switch name {
+		case "job", "workload", "db", "command", "run_id", "operation", "stat":
+			return nil, fmt.Errorf("label %s in %s is reserved", name, prop.PushLabels)
+		}
+		labels = append(labels, pushLabel{name: name, value: strings.TrimSpace(seps[1])})
+	}
+	return labels, nil
+}
+
+// pushResult pushes the results of the run to push.gateway and push.remote_write if they are set,
+// the failures of the push are printed, since the results have been output.
+func pushResult(command string, dbName string, start time.Time, end time.Time, usage *client.ResourceUsage) {
+	gateway := globalProps.GetString(prop.PushGateway, "")
+	remoteWrite := globalProps.GetString(prop.PushRemoteWrite, "")
+	if gateway == "" && remoteWrite == "" {
+		return
+	}
+
+	labels, _ := parsePushLabels()
+	r := newRunResult(command, dbName, start, end, usage)
+	labels = append([]pushLabel{
+		{name: "workload", value: r.Workload},
+		{name: "db", value: r.DB},
+		{name: "command", value: r.Command},
+		{name: "run_id", value: globalProps.GetString(prop.PushRunID, start.Format("20060102-150405"))},
+	}, labels...)
+	job := globalProps.GetString(prop.PushJob, prop.PushJobDefault)
+	samples := r.pushSamples()
+
+	if gateway != "" {
+		if err := pushGateway(gateway, job, labels, samples); err != nil {
+			fmt.Printf("Push the results to %s failed %v\n", gateway, err)
+		} else {
+			fmt.Printf("Pushed %d samples to %s\n", len(samples), gateway)
+		}
+	}
+
+	if remoteWrite != "" {
+		samples = append(samples, intervalSamples(measurement.TimeSeries())...)
+		labels = append(labels, pushLabel{name: "job", value: job})
+		if err := pushRemoteWrite(remoteWrite, labels, samples); err != nil {
+			fmt.Printf("Push the results to %s failed %v\n", remoteWrite, err)
+		} else {
+			fmt.Printf("Pushed %d samples to %s\n", len(samples), remoteWrite)
+		}
+	}
+}
+
+// pushSamples returns the samples of the summary of the run at the end of the run.
+func (r *runResult) pushSamples() []pushSample {
+	var samples []pushSample
+	add := func(name string, value float64, labels ...pushLabel) {
+		samples = append(samples, pushSample{name: name, labels: labels, value: value, timestamp: r.End})
+	}
+	latencies := func(name string, op pushLabel, l latencyResult) {
+		add(name, float64(l.Avg), op, pushLabel{"stat", "avg"})
+		add(name, float64(l.Min), op, pushLabel{"stat", "min"})
+		add(name, float64(l.Max), op, pushLabel{"stat", "max"})
+		for _, p := range measurement.Percentiles {
+			if value, ok := l.Percentiles[p.Name()]; ok {
+				add(name, float64(value), op, pushLabel{"stat", p.Name()})
+			}
+		}
+	}
+
+	add("ycsb_run_runtime_seconds", r.RuntimeSeconds)
+	add("ycsb_run_measured_seconds", r.MeasuredSeconds)
+	add("ycsb_run_threads", float64(r.Threads))
+	add("ycsb_run_throughput", r.Throughput)
+	for _, res := range r.Results {
+		op := pushLabel{"operation", res.Operation}
+		add("ycsb_run_operations", float64(res.Count), op)
+		add("ycsb_run_operation_throughput", res.Throughput, op)
+		add("ycsb_run_errors", float64(res.Errors), op)
+		latencies("ycsb_run_latency_microseconds", op, res.latencyResult)
+		if res.Failures != nil {
+			latencies("ycsb_run_error_latency_microseconds", op, *res.Failures)
+		}
+	}
+	if u := r.Client; u != nil {
+		add("ycsb_run_client_cpu_cores", u.AvgCPU, pushLabel{"stat", "avg"})
+		add("ycsb_run_client_cpu_cores", u.MaxCPU, pushLabel{"stat", "max"})
+		saturated := float64(0)
+		if u.Saturated {
+			saturated = 1
+		}
+		add("ycsb_run_client_saturated", saturated)
+	}
+	return samples
+}
+
+// intervalSamples returns the samples of the intervals at their end.
+func intervalSamples(points []measurement.TimeSeriesPoint) []pushSample {
+	var samples []pushSample
+	for _, point := range points {
+		add := func(name string, value float64, labels ...pushLabel) {
+			labels = append([]pushLabel{{"operation", point.Operation}}, labels...)
+			samples = append(samples, pushSample{name: name, labels: labels, value: value, timestamp: point.Time})
+		}
+		add("ycsb_interval_operations", float64(point.Count))
+		add("ycsb_interval_throughput", point.Throughput)
+		add("ycsb_interval_latency_microseconds", float64(point.Mean), pushLabel{"stat", "avg"})
+		add("ycsb_interval_latency_microseconds", float64(point.Min), pushLabel{"stat", "min"})
+		add("ycsb_interval_latency_microseconds", float64(point.Max), pushLabel{"stat", "max"})
+		for _, p := range measurement.Percentiles {
+			add("ycsb_interval_latency_microseconds", float64(point.Percentiles[p.Name()]), pushLabel{"stat", p.Name()})
+		}
+	}
+	return samples
+}
+
+// pushGateway replaces the group of the run in the Pushgateway by the samples in the text
+// format, the run labels are the grouping key, and the samples have no timestamp.
+func pushGateway(gateway string, job string, labels []pushLabel, samples []pushSample) error {
+	u := strings.TrimSuffix(gateway, "/") + "/metrics/" + pushPathSegment("job", job)
+	for _, l := range labels {
+		u += "/" + pushPathSegment(l.name, l.value)
+	}
+
+	sorted := append([]pushSample(nil), samples...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].name < sorted[j].name
+	})
+	var buf bytes.Buffer
+	for i, s := range sorted {
+		if i == 0 || sorted[i-1].name != s.name {
+			fmt.Fprintf(&buf, "# TYPE %s gauge\n", s.name)
+		}
+		buf.WriteString(s.name)
+		if len(s.labels) > 0 {
+			buf.WriteByte('{')
+			for j, l := range s.labels {
+				if j > 0 {
+					buf.WriteByte(',')
+				}
+				fmt.Fprintf(&buf, "%s=%q", l.name, l.value)
+			}
+			buf.WriteByte('}')
+		}
+		fmt.Fprintf(&buf, " %s\n", strconv.FormatFloat(s.value, 'g', -1, 64))
+	}
+
+	req, err := http.NewRequest(http.MethodPut, u, &buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+	return doPush(&http.Client{Timeout: 30 * time.Second}, req)
+}
+
+// pushPathSegment returns the label pair in the URL path of the Pushgateway, the values
+// which can't be in a path segment are encoded in base64.
+func pushPathSegment(name string, value string) string {
+	if value == "" || strings.Contains(value, "/") {
+		return name + "@base64/" + base64.URLEncoding.EncodeToString([]byte(value))
+	}
+	return name + "/" + url.PathEscape(value)
+}
+
+// pushRemoteWrite pushes the samples with their timestamps by the Prometheus remote write
+// protocol, the samples with the same labels are pushed as a series.
+func pushRemoteWrite(endpoint string, labels []pushLabel, samples []pushSample) error {
+	type series struct {
+		labels  []pushLabel
+		samples []pushSample
+	}
+	var all []*series
+	index := make(map[string]*series)
+	for _, s := range samples {
+		ls := append([]pushLabel{{"__name__", s.name}}, labels...)
+		ls = append(ls, s.labels...)
+		sort.Slice(ls, func(i, j int) bool {
+			return ls[i].name < ls[j].name
+		})
+		var key strings.Builder
+		for _, l := range ls {
+			key.WriteString(l.name + "\xff" + l.value + "\xff")
+		}
+		if index[key.String()] == nil {
+			index[key.String()] = &series{labels: ls}
+			all = append(all, index[key.String()])
+		}
+		index[key.String()].samples = append(index[key.String()].samples, s)
+	}
+
+	c := &http.Client{Timeout: 30 * time.Second}
+	for len(all) > 0 {
+		n := len(all)
+		if n > remoteWriteBatchSize {
+			n = remoteWriteBatchSize
+		}
+
+		// WriteRequest { repeated TimeSeries timeseries = 1; }
+		// TimeSeries { repeated Label labels = 1; repeated Sample samples = 2; }
+		// Label { string name = 1; string value = 2; }
+		// Sample { double value = 1; int64 timestamp = 2; }
+		var req []byte
+		for _, s := range all[:n] {
+			var ts []byte
+			for _, l := range s.labels {
+				var label []byte
+				label = appendProtoBytes(label, 1, []byte(l.name))
+				label = appendProtoBytes(label, 2, []byte(l.value))
+				ts = appendProtoBytes(ts, 1, label)
+			}
+			for _, sample := range s.samples {
+				var b [8]byte
+				binary.LittleEndian.PutUint64(b[:], math.Float64bits(sample.value))
+				v := appendProtoVarint(nil, 1<<3|1)
+				v = append(v, b[:]...)
+				v = appendProtoVarint(v, 2<<3)
+				v = appendProtoVarint(v, uint64(sample.timestamp.UnixNano()/int64(time.Millisecond)))
+				ts = appendProtoBytes(ts, 2, v)
+			}
+			req = appendProtoBytes(req, 1, ts)
+		}
+		all = all[n:]
+
+		httpReq, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(snappy.Encode(nil, req)))
+		if err != nil {
+			return err
+		}
+		httpReq.Header.Set("Content-Encoding", "snappy")
+		httpReq.Header.Set("Content-Type", "application/x-protobuf")
+		httpReq.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+		if err = doPush(c, httpReq); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func appendProtoVarint(b []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	return append(b, buf[:binary.PutUvarint(buf[:], v)]...)
+}
+
+// appendProtoBytes appends a length-delimited protobuf field.
+func appendProtoBytes(b []byte, field uint64, data []byte) []byte {
+	b = appendProtoVarint(b, field<<3|2)
+	b = appendProtoVarint(b, uint64(len(data)))
+	return append(b, data...)
+}
+
+func doPush(c *http.Client, req *http.Request) error {
+	resp, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
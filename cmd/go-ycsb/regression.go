@@ -0,0 +1,215 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pingcap/go-ycsb/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+var (
+	regressionThresholdArg float64
+	regressionMetricsArg   string
+)
+
+// resultMetric is a metric of an operation compared between two results, the throughput is
+// better if it's higher, and the latencies are better if they are lower.
+type resultMetric struct {
+	operation string
+	name      string
+	base      float64
+	value     float64
+}
+
+func (m resultMetric) higherIsBetter() bool {
+	return m.name == "throughput"
+}
+
+// change returns the relative change of the metric in percent, it's not ok if the base is 0.
+func (m resultMetric) change() (float64, bool) {
+	if m.base == 0 {
+		return 0, false
+	}
+	return (m.value - m.base) / m.base * 100, true
+}
+
+// worse returns whether the metric gets worse than the base by more than the threshold in percent.
+func (m resultMetric) worse(threshold float64) bool {
+	c, ok := m.change()
+	if !ok {
+		return false
+	}
+	if m.higherIsBetter() {
+		return -c > threshold
+	}
+	return c > threshold
+}
+
+func (m resultMetric) better(threshold float64) bool {
+	c, ok := m.change()
+	if !ok {
+		return false
+	}
+	if m.higherIsBetter() {
+		return c > threshold
+	}
+	return -c > threshold
+}
+
+func loadRunResult(path string) *runResult {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		util.Fatalf("read result %s failed %v", path, err)
+	}
+	r := new(runResult)
+	if err = json.Unmarshal(data, r); err != nil {
+		util.Fatalf("parse result %s failed %v, only the results of outputstyle json can be compared", path, err)
+	}
+	return r
+}
+
+// compareResults returns the metrics of the operations in both results, the overall throughput
+// is compared as the operation TOTAL.
+func compareResults(base *runResult, r *runResult) []resultMetric {
+	metrics := []resultMetric{{operation: "TOTAL", name: "throughput", base: base.Throughput, value: r.Throughput}}
+
+	results := make(map[string]operationResult, len(r.Results))
+	for _, res := range r.Results {
+		results[res.Operation] = res
+	}
+	for _, b := range base.Results {
+		res, ok := results[b.Operation]
+		if !ok {
+			continue
+		}
+		add := func(name string, base float64, value float64) {
+			metrics = append(metrics, resultMetric{operation: b.Operation, name: name, base: base, value: value})
+		}
+		add("throughput", b.Throughput, res.Throughput)
+		add("avg", float64(b.Avg), float64(res.Avg))
+		add("min", float64(b.Min), float64(res.Min))
+		add("max", float64(b.Max), float64(res.Max))
+		for _, p := range sortedPercentiles(b.Percentiles) {
+			if value, ok := res.Percentiles[p]; ok {
+				add(p, float64(b.Percentiles[p]), float64(value))
+			}
+		}
+	}
+	return metrics
+}
+
+// sortedPercentiles returns the names of the percentiles like p99 in the ascending order.
+func sortedPercentiles(percentiles map[string]int64) []string {
+	names := make([]string, 0, len(percentiles))
+	for name := range percentiles {
+		names = append(names, name)
+	}
+	value := func(name string) float64 {
+		v, _ := strconv.ParseFloat(strings.TrimPrefix(name, "p"), 64)
+		return v
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return value(names[i]) < value(names[j])
+	})
+	return names
+}
+
+func runReportCompareCommandFunc(cmd *cobra.Command, args []string) {
+	gated := make(map[string]bool)
+	for _, name := range strings.Split(regressionMetricsArg, ",") {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			gated[name] = true
+		}
+	}
+	if len(gated) == 0 {
+		util.Fatalf("--metrics must not be empty")
+	}
+
+	base := loadRunResult(args[0])
+	r := loadRunResult(args[1])
+	metrics := compareResults(base, r)
+	for name := range gated {
+		found := false
+		for _, m := range metrics {
+			found = found || m.name == name
+		}
+		if !found {
+			util.Fatalf("metric %s in --metrics is not in the results", name)
+		}
+	}
+
+	fmt.Printf("Compare %s (%s %s, %s) to the base %s (%s %s, %s)\n", args[1], r.Command, r.DB, r.Workload,
+		args[0], base.Command, base.DB, base.Workload)
+	fmt.Printf("The metrics %s regress if they get worse by more than %.1f%%\n\n", regressionMetricsArg, regressionThresholdArg)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Operation\tMetric\tBase\tNew\tDelta\tStatus")
+	var regressions []string
+	for _, m := range metrics {
+		change := "-"
+		if c, ok := m.change(); ok {
+			change = fmt.Sprintf("%+.1f%%", c)
+		}
+		status := ""
+		switch {
+		case m.worse(regressionThresholdArg) && gated[m.name]:
+			status = "REGRESSION"
+			regressions = append(regressions, m.operation+" "+m.name)
+		case m.worse(regressionThresholdArg):
+			status = "worse"
+		case m.better(regressionThresholdArg):
+			status = "better"
+		}
+		unit := "us"
+		if m.higherIsBetter() {
+			unit = "ops/s"
+		}
+		fmt.Fprintf(w, "%s\t%s(%s)\t%.1f\t%.1f\t%s\t%s\n", m.operation, m.name, unit, m.base, m.value, change, status)
+	}
+	w.Flush()
+
+	if len(regressions) > 0 {
+		fmt.Printf("\n%d regressions: %s\n", len(regressions), strings.Join(regressions, ", "))
+		os.Exit(1)
+	}
+	fmt.Println("\nNo regression")
+}
+
+func newReportCommand() *cobra.Command {
+	m := &cobra.Command{
+		Use:   "report",
+		Short: "Work with the results of the runs",
+	}
+
+	compare := &cobra.Command{
+		Use:   "compare base.json new.json",
+		Short: "Compare the result to the base and exit with 1 on regression",
+		Args:  cobra.ExactArgs(2),
+		Run:   runReportCompareCommandFunc,
+	}
+	compare.Flags().Float64Var(&regressionThresholdArg, "threshold", 5, "The regression threshold in percent")
+	compare.Flags().StringVar(&regressionMetricsArg, "metrics", "throughput,avg,p99", "The comma separated metrics checked for the regressions, throughput, avg, min, max or the percentiles like p99")
+
+	m.AddCommand(compare)
+	return m
+}
@@ -0,0 +1,52 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+)
+
+func TestResultMetric(t *testing.T) {
+	tests := []struct {
+		metric resultMetric
+		change float64
+		ok     bool
+		worse  bool
+		better bool
+	}{
+		{resultMetric{name: "throughput", base: 100, value: 80}, -20, true, true, false},
+		{resultMetric{name: "throughput", base: 100, value: 120}, 20, true, false, true},
+		{resultMetric{name: "throughput", base: 100, value: 95}, -5, true, false, false},
+		{resultMetric{name: "p99", base: 100, value: 120}, 20, true, true, false},
+		{resultMetric{name: "p99", base: 100, value: 80}, -20, true, false, true},
+		{resultMetric{name: "avg", base: 100, value: 110}, 10, true, false, false},
+		// The change from the base 0 is unknown, which is neither worse nor better.
+		{resultMetric{name: "throughput", base: 0, value: 100}, 0, false, false, false},
+		{resultMetric{name: "p99", base: 0, value: 100}, 0, false, false, false},
+	}
+
+	const threshold = 10
+	for _, test := range tests {
+		m := test.metric
+		if change, ok := m.change(); change != test.change || ok != test.ok {
+			t.Errorf("want change %v, %v of %+v, but got %v, %v", test.change, test.ok, m, change, ok)
+		}
+		if worse := m.worse(threshold); worse != test.worse {
+			t.Errorf("want worse %v of %+v, but got %v", test.worse, m, worse)
+		}
+		if better := m.better(threshold); better != test.better {
+			t.Errorf("want better %v of %+v, but got %v", test.better, m, better)
+		}
+	}
+}
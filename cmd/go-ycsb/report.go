@@ -0,0 +1,254 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	// Register the drivers of the results database
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	"github.com/pingcap/go-ycsb/pkg/client"
+	"github.com/pingcap/go-ycsb/pkg/measurement"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/util"
+)
+
+// reportDialect is the SQL dialect of the results database.
+type reportDialect struct {
+	driver    string
+	timestamp string
+	double    string
+	// placeholder returns the placeholder of the ith argument from 1.
+	placeholder func(i int) string
+}
+
+var (
+	mysqlReportDialect = reportDialect{
+		driver:      "mysql",
+		timestamp:   "DATETIME(6)",
+		double:      "DOUBLE",
+		placeholder: func(int) string { return "?" },
+	}
+	pgReportDialect = reportDialect{
+		driver:      "postgres",
+		timestamp:   "TIMESTAMP",
+		double:      "DOUBLE PRECISION",
+		placeholder: func(i int) string { return fmt.Sprintf("$%d", i) },
+	}
+)
+
+// globalReport is the results database opened before the run, so a wrong report.db fails the
+// run before it starts.
+var globalReport *reportStore
+
+// reportStore is the results database.
+type reportStore struct {
+	db      *sql.DB
+	dialect reportDialect
+}
+
+// checkReport opens report.db and creates the tables of the results if they don't exist.
+func checkReport() {
+	dsn := globalProps.GetString(prop.ReportDB, "")
+	if dsn == "" {
+		return
+	}
+
+	var dialect reportDialect
+	switch {
+	case strings.HasPrefix(dsn, "mysql://"):
+		dialect = mysqlReportDialect
+		dsn = strings.TrimPrefix(dsn, "mysql://")
+	case strings.HasPrefix(dsn, "postgres://"), strings.HasPrefix(dsn, "postgresql://"):
+		dialect = pgReportDialect
+	default:
+		util.Fatalf("unsupported %s, must start with mysql:// or postgres://", prop.ReportDB)
+	}
+
+	db, err := sql.Open(dialect.driver, dsn)
+	if err != nil {
+		util.Fatalf("open %s failed %v", prop.ReportDB, err)
+	}
+	for _, stmt := range dialect.schema() {
+		if _, err = db.Exec(stmt); err != nil {
+			util.Fatalf("create the tables of %s failed %v", prop.ReportDB, err)
+		}
+	}
+	globalReport = &reportStore{db: db, dialect: dialect}
+}
+
+// schema returns the statements to create the tables of the results, ycsb_runs has the
+// summary of every run, ycsb_results has the results of every operation in every run, and
+// ycsb_intervals has the results of every operation in every measurement.interval, the
+// percentiles are the JSON objects like {"p99": 1200} in microseconds.
+func (d reportDialect) schema() []string {
+	return []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS ycsb_runs (
+	run_id VARCHAR(64) NOT NULL,
+	command VARCHAR(16) NOT NULL,
+	workload VARCHAR(64) NOT NULL,
+	db VARCHAR(64) NOT NULL,
+	revision VARCHAR(64) NOT NULL,
+	start_time %[1]s NOT NULL,
+	end_time %[1]s NOT NULL,
+	runtime_seconds %[2]s NOT NULL,
+	measured_seconds %[2]s NOT NULL,
+	threads BIGINT NOT NULL,
+	operations BIGINT NOT NULL,
+	errors BIGINT NOT NULL,
+	throughput %[2]s NOT NULL,
+	client_avg_cpu %[2]s,
+	client_saturated BOOLEAN,
+	properties TEXT NOT NULL,
+	PRIMARY KEY (run_id, command)
+)`, d.timestamp, d.double),
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS ycsb_results (
+	run_id VARCHAR(64) NOT NULL,
+	command VARCHAR(16) NOT NULL,
+	operation VARCHAR(64) NOT NULL,
+	count BIGINT NOT NULL,
+	throughput %[1]s NOT NULL,
+	avg_us BIGINT NOT NULL,
+	min_us BIGINT NOT NULL,
+	max_us BIGINT NOT NULL,
+	percentiles_us TEXT NOT NULL,
+	errors BIGINT NOT NULL,
+	error_classes TEXT,
+	PRIMARY KEY (run_id, command, operation)
+)`, d.double),
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS ycsb_intervals (
+	run_id VARCHAR(64) NOT NULL,
+	command VARCHAR(16) NOT NULL,
+	operation VARCHAR(64) NOT NULL,
+	time %[1]s NOT NULL,
+	elapsed_seconds %[2]s NOT NULL,
+	count BIGINT NOT NULL,
+	throughput %[2]s NOT NULL,
+	mean_us BIGINT NOT NULL,
+	min_us BIGINT NOT NULL,
+	max_us BIGINT NOT NULL,
+	percentiles_us TEXT NOT NULL,
+	PRIMARY KEY (run_id, command, operation, time)
+)`, d.timestamp, d.double),
+	}
+}
+
+// insert returns the INSERT statement of the columns in the dialect.
+func (d reportDialect) insert(table string, columns ...string) string {
+	placeholders := make([]string, len(columns))
+	for i := range columns {
+		placeholders[i] = d.placeholder(i + 1)
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table, strings.Join(columns, ", "), strings.Join(placeholders, ", "))
+}
+
+// reportResult inserts the results of the run into report.db in a transaction if it is set,
+// the failure of the insert is printed, since the results have been output.
+func reportResult(command string, dbName string, start time.Time, end time.Time, usage *client.ResourceUsage) {
+	if globalReport == nil {
+		return
+	}
+	defer globalReport.db.Close()
+
+	runID := globalProps.GetString(prop.ReportRunID, "")
+	if runID == "" {
+		runID = defaultRunID(start)
+	}
+	r := newRunResult(command, dbName, start, end, usage)
+	if err := globalReport.insert(r, runID); err != nil {
+		fmt.Printf("Insert the results into %s failed %v\n", prop.ReportDB, err)
+		return
+	}
+	fmt.Printf("Inserted the results of run %s into %s\n", runID, prop.ReportDB)
+}
+
+// defaultRunID returns the start time of the run with the client id and a random suffix, so the
+// clients started in the same second don't insert the same run.
+func defaultRunID(start time.Time) string {
+	suffix := make([]byte, 3)
+	rand.Read(suffix)
+	return fmt.Sprintf("%s-%d-%s", start.Format("20060102-150405"), globalProps.GetInt64(prop.ClientID, prop.ClientIDDefault),
+		hex.EncodeToString(suffix))
+}
+
+func (s *reportStore) insert(r *runResult, runID string) error {
+	d := s.dialect
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	properties, err := json.Marshal(r.Properties)
+	if err != nil {
+		return err
+	}
+	var avgCPU sql.NullFloat64
+	var saturated sql.NullBool
+	if r.Client != nil {
+		avgCPU = sql.NullFloat64{Float64: r.Client.AvgCPU, Valid: true}
+		saturated = sql.NullBool{Bool: r.Client.Saturated, Valid: true}
+	}
+	if _, err = tx.Exec(d.insert("ycsb_runs", "run_id", "command", "workload", "db", "revision", "start_time",
+		"end_time", "runtime_seconds", "measured_seconds", "threads", "operations", "errors", "throughput",
+		"client_avg_cpu", "client_saturated", "properties"),
+		runID, r.Command, r.Workload, r.DB, globalProps.GetString(prop.ReportRevision, ""), r.Start.UTC(),
+		r.End.UTC(), r.RuntimeSeconds, r.MeasuredSeconds, r.Threads, r.Operations, r.Errors, r.Throughput,
+		avgCPU, saturated, string(properties)); err != nil {
+		return err
+	}
+
+	insertResult := d.insert("ycsb_results", "run_id", "command", "operation", "count", "throughput", "avg_us",
+		"min_us", "max_us", "percentiles_us", "errors", "error_classes")
+	for _, res := range r.Results {
+		percentiles, err := json.Marshal(res.Percentiles)
+		if err != nil {
+			return err
+		}
+		var errorClasses sql.NullString
+		if len(res.ErrorClasses) > 0 {
+			b, err := json.Marshal(res.ErrorClasses)
+			if err != nil {
+				return err
+			}
+			errorClasses = sql.NullString{String: string(b), Valid: true}
+		}
+		if _, err = tx.Exec(insertResult, runID, r.Command, res.Operation, res.Count, res.Throughput, res.Avg,
+			res.Min, res.Max, string(percentiles), res.Errors, errorClasses); err != nil {
+			return err
+		}
+	}
+
+	insertInterval := d.insert("ycsb_intervals", "run_id", "command", "operation", "time", "elapsed_seconds",
+		"count", "throughput", "mean_us", "min_us", "max_us", "percentiles_us")
+	for _, point := range measurement.TimeSeries() {
+		percentiles, err := json.Marshal(point.Percentiles)
+		if err != nil {
+			return err
+		}
+		if _, err = tx.Exec(insertInterval, runID, r.Command, point.Operation, point.Time.UTC(), point.Elapsed,
+			point.Count, point.Throughput, point.Mean, point.Min, point.Max, string(percentiles)); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
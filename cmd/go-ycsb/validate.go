@@ -0,0 +1,152 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+)
+
+var strictArg bool
+
+// propertyProblem is an unknown property, an invalid value or a conflict between the properties.
+type propertyProblem struct {
+	name   string
+	reason string
+}
+
+func (p propertyProblem) String() string {
+	return fmt.Sprintf("%s: %s", p.name, p.reason)
+}
+
+// propertyConflicts check the combinations of the properties which don't work together.
+var propertyConflicts = []func(p *properties.Properties) *propertyProblem{
+	func(p *properties.Properties) *propertyProblem {
+		if p.GetBool(prop.DoTransactions, false) && p.GetBool(prop.DropData, prop.DropDataDefault) {
+			return &propertyProblem{prop.DropData, "the loaded data is dropped before the run"}
+		}
+		return nil
+	},
+	func(p *properties.Properties) *propertyProblem {
+		min := p.GetInt64(prop.MinFieldLength, prop.MinFieldLengthDefault)
+		max := p.GetInt64(prop.FieldLength, prop.FieldLengthDefault)
+		if min > max {
+			return &propertyProblem{prop.MinFieldLength, fmt.Sprintf("%d is larger than %s %d", min, prop.FieldLength, max)}
+		}
+		return nil
+	},
+	func(p *properties.Properties) *propertyProblem {
+		if _, ok := p.Get(prop.InsertCount); !ok {
+			return nil
+		}
+		start := p.GetInt64(prop.InsertStart, prop.InsertStartDefault)
+		count := p.GetInt64(prop.InsertCount, 0)
+		records := p.GetInt64(prop.RecordCount, prop.RecordCountDefault)
+		if start+count > records {
+			return &propertyProblem{prop.InsertCount, fmt.Sprintf("%s + %s %d is larger than %s %d",
+				prop.InsertStart, prop.InsertCount, start+count, prop.RecordCount, records)}
+		}
+		return nil
+	},
+	func(p *properties.Properties) *propertyProblem {
+		warmUp := p.GetInt64(prop.WarmUpTime, 0)
+		max := p.GetInt64(prop.MaxExecutiontime, 0)
+		if warmUp > 0 && max > 0 && warmUp >= max {
+			return &propertyProblem{prop.WarmUpTime, fmt.Sprintf("the run ends in the warm-up of %ds, %s is %ds",
+				warmUp, prop.MaxExecutiontime, max)}
+		}
+		return nil
+	},
+	func(p *properties.Properties) *propertyProblem {
+		min := p.GetInt64(prop.TransactionMinKeys, prop.TransactionMinKeysDefault)
+		max := p.GetInt64(prop.TransactionMaxKeys, prop.TransactionMaxKeysDefault)
+		if min > max {
+			return &propertyProblem{prop.TransactionMinKeys, fmt.Sprintf("%d is larger than %s %d", min, prop.TransactionMaxKeys, max)}
+		}
+		return nil
+	},
+}
+
+// checkProperties returns the unknown properties, the invalid values and the conflicts of the properties.
+// The properties are known if they are registered by go-ycsb or the drivers.
+func checkProperties(p *properties.Properties) []propertyProblem {
+	var problems []propertyProblem
+	keys := p.Keys()
+	sort.Strings(keys)
+	for _, key := range keys {
+		kind, ok := prop.Lookup(key)
+		if !ok {
+			reason := "unknown property"
+			if name := closestProperty(key); name != "" {
+				reason = fmt.Sprintf("unknown property, did you mean %s?", name)
+			}
+			problems = append(problems, propertyProblem{key, reason})
+			continue
+		}
+		if err := kind.Check(p.GetString(key, "")); err != nil {
+			problems = append(problems, propertyProblem{key, err.Error()})
+		}
+	}
+
+	for _, conflict := range propertyConflicts {
+		if problem := conflict(p); problem != nil {
+			problems = append(problems, *problem)
+		}
+	}
+	return problems
+}
+
+// closestProperty returns the registered property which is at most 2 edits away from the name, it's
+// empty if there is no such property.
+func closestProperty(name string) string {
+	closest, distance := "", 3
+	for _, known := range prop.Names() {
+		if d := editDistance(strings.ToLower(name), known); d < distance {
+			closest, distance = known, d
+		}
+	}
+	return closest
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(minInt(prev[j]+1, cur[j-1]+1), prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
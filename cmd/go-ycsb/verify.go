@@ -0,0 +1,63 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pingcap/go-ycsb/pkg/measurement"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/util"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+	"github.com/spf13/cobra"
+)
+
+func runVerifyCommandFunc(cmd *cobra.Command, args []string) {
+	dbName := args[0]
+
+	initialGlobal(dbName, func() {
+		// The records are audited as loaded.
+		globalProps.Set(prop.DoTransactions, "false")
+		setClientFlagProps(cmd)
+	})
+
+	auditWorkload, ok := globalWorkload.(ycsb.AuditWorkload)
+	if !ok {
+		util.Fatalf("the %T doesn't implement the AuditWorkload interface", globalWorkload)
+	}
+
+	start := time.Now()
+	err := auditWorkload.Audit(globalContext, globalDB, globalProps.GetInt(prop.ThreadCount, 1))
+	fmt.Printf("Verify finished, takes %s\n", time.Now().Sub(start))
+	measurement.Output()
+
+	if err != nil {
+		fmt.Printf("Verify failed, %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func newVerifyCommand() *cobra.Command {
+	m := &cobra.Command{
+		Use:   "verify db",
+		Short: "Verify the records in the database after the run",
+		Args:  cobra.MinimumNArgs(1),
+		Run:   runVerifyCommandFunc,
+	}
+
+	initClientCommand(m)
+	return m
+}
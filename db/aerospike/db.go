@@ -3,9 +3,11 @@ package aerospike
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	as "github.com/aerospike/aerospike-client-go"
 	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
 	"github.com/pingcap/go-ycsb/pkg/ycsb"
 )
 
@@ -13,11 +15,48 @@ const (
 	asNs   = "aerospike.ns"
 	asHost = "aerospike.host"
 	asPort = "aerospike.port"
+	// The set of the records, use the table name if not set
+	asSet = "aerospike.set"
+	// "all" or "master"
+	asCommitLevel = "aerospike.commit_level"
+	// The TTL of the records in seconds, 0 for the namespace default, -1 for never expire
+	asTTL = "aerospike.ttl"
 )
 
 type aerospikedb struct {
 	client *as.Client
 	ns     string
+	set    string
+
+	commitLevel as.CommitLevel
+	ttl         uint32
+}
+
+// newKey returns the key of the record, the record is in the configured set or the table set.
+func (adb *aerospikedb) newKey(table string, key string) (*as.Key, error) {
+	if len(adb.set) > 0 {
+		table = adb.set
+	}
+	return as.NewKey(adb.ns, table, key)
+}
+
+// newWritePolicy returns the write policy with the configured commit level and TTL.
+func (adb *aerospikedb) newWritePolicy(generation uint32) *as.WritePolicy {
+	policy := as.NewWritePolicy(generation, adb.ttl)
+	policy.CommitLevel = adb.commitLevel
+	return policy
+}
+
+func decodeBins(bins as.BinMap) (map[string][]byte, error) {
+	res := make(map[string][]byte, len(bins))
+	var ok bool
+	for k, v := range bins {
+		res[k], ok = v.([]byte)
+		if !ok {
+			return nil, errors.New("couldn't convert to byte array")
+		}
+	}
+	return res, nil
 }
 
 // Close closes the database layer.
@@ -42,23 +81,18 @@ func (adb *aerospikedb) CleanupThread(ctx context.Context) {
 // key: The record key of the record to read.
 // fileds: The list of fields to read, nil|empty for reading all.
 func (adb *aerospikedb) Read(ctx context.Context, table string, key string, fields []string) (map[string][]byte, error) {
-	asKey, err := as.NewKey(adb.ns, table, key)
-	record, err := adb.client.Get(nil, asKey)
+	asKey, err := adb.newKey(table, key)
+	if err != nil {
+		return nil, err
+	}
+	record, err := adb.client.Get(nil, asKey, fields...)
 	if err != nil {
 		return nil, err
 	}
 	if record == nil {
 		return map[string][]byte{}, nil
 	}
-	res := make(map[string][]byte, len(record.Bins))
-	var ok bool
-	for k, v := range record.Bins {
-		res[k], ok = v.([]byte)
-		if !ok {
-			return nil, errors.New("couldn't convert to byte array")
-		}
-	}
-	return res, nil
+	return decodeBins(record.Bins)
 }
 
 // Scan scans records from the database.
@@ -69,31 +103,24 @@ func (adb *aerospikedb) Read(ctx context.Context, table string, key string, fiel
 func (adb *aerospikedb) Scan(ctx context.Context, table string, startKey string, count int, fields []string) ([]map[string][]byte, error) {
 	policy := as.NewScanPolicy()
 	policy.ConcurrentNodes = true
-	recordset, err := adb.client.ScanAll(policy, adb.ns, table)
+	set := table
+	if len(adb.set) > 0 {
+		set = adb.set
+	}
+	recordset, err := adb.client.ScanAll(policy, adb.ns, set, fields...)
 	if err != nil {
 		return nil, err
 	}
-	filter := make(map[string]bool, len(fields))
-	for _, field := range fields {
-		filter[field] = true
-	}
+	defer recordset.Close()
 	scanRes := make([]map[string][]byte, 0)
-	var ok bool
 	nRead := 0
 	for res := range recordset.Results() {
 		if res.Err != nil {
-			recordset.Close()
 			return nil, res.Err
 		}
-		vals := make(map[string][]byte, len(res.Record.Bins))
-		for k, v := range res.Record.Bins {
-			if !filter[k] {
-				continue
-			}
-			vals[k], ok = v.([]byte)
-			if !ok {
-				return nil, errors.New("couldn't convert to byte array")
-			}
+		vals, err := decodeBins(res.Record.Bins)
+		if err != nil {
+			return nil, err
 		}
 		scanRes = append(scanRes, vals)
 		nRead++
@@ -110,7 +137,7 @@ func (adb *aerospikedb) Scan(ctx context.Context, table string, startKey string,
 // key: The record key of the record to update.
 // values: A map of field/value pairs to update in the record.
 func (adb *aerospikedb) Update(ctx context.Context, table string, key string, values map[string][]byte) error {
-	asKey, err := as.NewKey(adb.ns, table, key)
+	asKey, err := adb.newKey(table, key)
 	if err != nil {
 		return err
 	}
@@ -119,10 +146,10 @@ func (adb *aerospikedb) Update(ctx context.Context, table string, key string, va
 		return err
 	}
 	bins := as.BinMap{}
-	var policy *as.WritePolicy
+	policy := adb.newWritePolicy(0)
 	if record != nil {
 		bins = record.Bins
-		policy := as.NewWritePolicy(record.Generation, 0)
+		policy = adb.newWritePolicy(record.Generation)
 		policy.GenerationPolicy = as.EXPECT_GEN_EQUAL
 	}
 	for k, v := range values {
@@ -137,7 +164,7 @@ func (adb *aerospikedb) Update(ctx context.Context, table string, key string, va
 // key: The record key of the record to insert.
 // values: A map of field/value pairs to insert in the record.
 func (adb *aerospikedb) Insert(ctx context.Context, table string, key string, values map[string][]byte) error {
-	asKey, err := as.NewKey(adb.ns, table, key)
+	asKey, err := adb.newKey(table, key)
 	if err != nil {
 		return err
 	}
@@ -147,26 +174,113 @@ func (adb *aerospikedb) Insert(ctx context.Context, table string, key string, va
 		bins[i] = as.NewBin(k, v)
 		i++
 	}
-	return adb.client.PutBins(nil, asKey, bins...)
+	return adb.client.PutBins(adb.newWritePolicy(0), asKey, bins...)
 }
 
 // Delete deletes a record from the database.
 // table: The name of the table.
 // key: The record key of the record to delete.
 func (adb *aerospikedb) Delete(ctx context.Context, table string, key string) error {
-	asKey, err := as.NewKey(adb.ns, table, key)
+	asKey, err := adb.newKey(table, key)
 	if err != nil {
 		return err
 	}
-	_, err = adb.client.Delete(nil, asKey)
+	_, err = adb.client.Delete(adb.newWritePolicy(0), asKey)
 	return err
 }
 
+// BatchInsert inserts batch records in the database.
+// table: The name of the table.
+// keys: The keys of batch records.
+// values: The values of batch records.
+func (adb *aerospikedb) BatchInsert(ctx context.Context, table string, keys []string, values []map[string][]byte) error {
+	for i, key := range keys {
+		if err := adb.Insert(ctx, table, key, values[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BatchRead reads records from the database in one batch request.
+// table: The name of the table.
+// keys: The keys of records to read.
+// fields: The list of fields to read, nil|empty for reading all.
+func (adb *aerospikedb) BatchRead(ctx context.Context, table string, keys []string, fields []string) ([]map[string][]byte, error) {
+	asKeys := make([]*as.Key, len(keys))
+	for i, key := range keys {
+		asKey, err := adb.newKey(table, key)
+		if err != nil {
+			return nil, err
+		}
+		asKeys[i] = asKey
+	}
+	records, err := adb.client.BatchGet(nil, asKeys, fields...)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]map[string][]byte, len(records))
+	for i, record := range records {
+		if record == nil {
+			continue
+		}
+		if res[i], err = decodeBins(record.Bins); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// BatchUpdate updates records in the database.
+// table: The name of table.
+// keys: The keys of records to update.
+// values: The values of records to update.
+func (adb *aerospikedb) BatchUpdate(ctx context.Context, table string, keys []string, values []map[string][]byte) error {
+	for i, key := range keys {
+		if err := adb.Update(ctx, table, key, values[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BatchDelete deletes records from the database.
+// table: The name of the table.
+// keys: The keys of the records to delete.
+func (adb *aerospikedb) BatchDelete(ctx context.Context, table string, keys []string) error {
+	for _, key := range keys {
+		if err := adb.Delete(ctx, table, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 type aerospikeCreator struct{}
 
 func (a aerospikeCreator) Create(p *properties.Properties) (ycsb.DB, error) {
 	adb := &aerospikedb{}
 	adb.ns = p.GetString(asNs, "test")
+	adb.set = p.GetString(asSet, "")
+
+	switch level := p.GetString(asCommitLevel, "all"); level {
+	case "all":
+		adb.commitLevel = as.COMMIT_ALL
+	case "master":
+		adb.commitLevel = as.COMMIT_MASTER
+	default:
+		return nil, fmt.Errorf("unsupported commit level %s", level)
+	}
+
+	switch ttl := p.GetInt64(asTTL, 0); {
+	case ttl == -1:
+		adb.ttl = as.TTLDontExpire
+	case ttl >= 0:
+		adb.ttl = uint32(ttl)
+	default:
+		return nil, fmt.Errorf("invalid ttl %d", ttl)
+	}
+
 	var err error
 	adb.client, err = as.NewClient(p.GetString(asHost, "localhost"), p.GetInt(asPort, 3000))
 	return adb, err
@@ -174,4 +288,7 @@ func (a aerospikeCreator) Create(p *properties.Properties) (ycsb.DB, error) {
 
 func init() {
 	ycsb.RegisterDBCreator("aerospike", aerospikeCreator{})
+
+	prop.Register(prop.String, asNs, asHost, asSet, asCommitLevel)
+	prop.Register(prop.Int, asPort, asTTL)
 }
@@ -17,13 +17,16 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"sync"
+	"time"
 
-	"github.com/dgraph-io/badger"
-	"github.com/dgraph-io/badger/options"
+	"github.com/dgraph-io/badger/v2"
+	"github.com/dgraph-io/badger/v2/options"
 	"github.com/magiconair/properties"
 	"github.com/pingcap/go-ycsb/pkg/prop"
 	"github.com/pingcap/go-ycsb/pkg/util"
 	"github.com/pingcap/go-ycsb/pkg/ycsb"
+	"go.uber.org/zap"
 )
 
 //  properties
@@ -46,6 +49,11 @@ const (
 	badgerDoNotCompact            = "badger.do_not_compact"
 	badgerTableLoadingMode        = "badger.table_loading_mode"
 	badgerValueLogLoadingMode     = "badger.value_log_loading_mode"
+	// Keep all the data in memory, badger.dir and badger.valuedir are ignored.
+	badgerInMemory = "badger.in_memory"
+	// Run the value log GC periodically, 0 disables it.
+	badgerGCInterval     = "badger.gc_interval"
+	badgerGCDiscardRatio = "badger.gc_discard_ratio"
 	// TODO: add more configurations
 )
 
@@ -59,6 +67,9 @@ type badgerDB struct {
 
 	r       *util.RowCodec
 	bufPool *util.BufPool
+
+	closeCh chan struct{}
+	wg      sync.WaitGroup
 }
 
 type contextKey string
@@ -71,7 +82,7 @@ type badgerState struct {
 func (c badgerCreator) Create(p *properties.Properties) (ycsb.DB, error) {
 	opts := getOptions(p)
 
-	if p.GetBool(prop.DropData, prop.DropDataDefault) {
+	if !opts.InMemory && p.GetBool(prop.DropData, prop.DropDataDefault) {
 		os.RemoveAll(opts.Dir)
 		os.RemoveAll(opts.ValueDir)
 	}
@@ -81,18 +92,58 @@ func (c badgerCreator) Create(p *properties.Properties) (ycsb.DB, error) {
 		return nil, err
 	}
 
-	return &badgerDB{
+	d := &badgerDB{
 		p:       p,
 		db:      db,
 		r:       util.NewRowCodec(p),
 		bufPool: util.NewBufPool(),
-	}, nil
+		closeCh: make(chan struct{}),
+	}
+
+	// The value log is not used in the in-memory mode.
+	if interval := p.GetParsedDuration(badgerGCInterval, 0); interval > 0 && !opts.InMemory {
+		d.wg.Add(1)
+		go d.runValueLogGC(interval, p.GetFloat64(badgerGCDiscardRatio, 0.5))
+	}
+
+	return d, nil
+}
+
+func (db *badgerDB) runValueLogGC(interval time.Duration, discardRatio float64) {
+	defer db.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-db.closeCh:
+			return
+		case <-ticker.C:
+		}
+
+		// One call rewrites at most one log file, so keep running until nothing is rewritten.
+		for {
+			err := db.db.RunValueLogGC(discardRatio)
+			if err == nil {
+				continue
+			}
+			if err != badger.ErrNoRewrite {
+				util.Logger().Error("run value log GC failed", zap.Error(err))
+			}
+			break
+		}
+	}
 }
 
 func getOptions(p *properties.Properties) badger.Options {
-	opts := badger.DefaultOptions
-	opts.Dir = p.GetString(badgerDir, "/tmp/badger")
+	opts := badger.DefaultOptions(p.GetString(badgerDir, "/tmp/badger"))
 	opts.ValueDir = p.GetString(badgerValueDir, opts.Dir)
+	if p.GetBool(badgerInMemory, false) {
+		opts.Dir = ""
+		opts.ValueDir = ""
+		opts.InMemory = true
+	}
 
 	opts.SyncWrites = p.GetBool(badgerSyncWrites, false)
 	opts.NumVersionsToKeep = p.GetInt(badgerNumVersionsToKeep, 1)
@@ -107,7 +158,10 @@ func getOptions(p *properties.Properties) badger.Options {
 	opts.ValueLogFileSize = p.GetInt64(badgerValueLogFileSize, 1<<30)
 	opts.ValueLogMaxEntries = uint32(p.GetUint64(badgerValueLogMaxEntries, 1000000))
 	opts.NumCompactors = p.GetInt(badgerNumCompactors, 3)
-	opts.DoNotCompact = p.GetBool(badgerDoNotCompact, false)
+	// Badger stops compactions if there are no compactors.
+	if p.GetBool(badgerDoNotCompact, false) {
+		opts.NumCompactors = 0
+	}
 	if b := p.GetString(badgerTableLoadingMode, "LoadToRAM"); len(b) > 0 {
 		if b == "FileIO" {
 			opts.TableLoadingMode = options.FileIO
@@ -131,6 +185,9 @@ func getOptions(p *properties.Properties) badger.Options {
 }
 
 func (db *badgerDB) Close() error {
+	close(db.closeCh)
+	db.wg.Wait()
+
 	return db.db.Close()
 }
 
@@ -153,7 +210,7 @@ func (db *badgerDB) Read(ctx context.Context, table string, key string, fields [
 		if err != nil {
 			return err
 		}
-		row, err := item.Value()
+		row, err := item.ValueCopy(nil)
 		if err != nil {
 			return err
 		}
@@ -166,14 +223,15 @@ func (db *badgerDB) Read(ctx context.Context, table string, key string, fields [
 }
 
 func (db *badgerDB) Scan(ctx context.Context, table string, startKey string, count int, fields []string) ([]map[string][]byte, error) {
-	res := make([]map[string][]byte, count)
+	res := make([]map[string][]byte, 0, count)
 	err := db.db.View(func(txn *badger.Txn) error {
 		rowStartKey := db.getRowKey(table, startKey)
-		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = db.getRowKey(table, "")
+		it := txn.NewIterator(opts)
 		defer it.Close()
 
-		i := 0
-		for it.Seek(rowStartKey); it.Valid() && i < count; it.Next() {
+		for it.Seek(rowStartKey); it.ValidForPrefix(opts.Prefix) && len(res) < count; it.Next() {
 			item := it.Item()
 			value, err := item.ValueCopy(nil)
 			if err != nil {
@@ -185,8 +243,7 @@ func (db *badgerDB) Scan(ctx context.Context, table string, startKey string, cou
 				return err
 			}
 
-			res[i] = m
-			i++
+			res = append(res, m)
 		}
 
 		return nil
@@ -203,7 +260,7 @@ func (db *badgerDB) Update(ctx context.Context, table string, key string, values
 			return err
 		}
 
-		value, err := item.Value()
+		value, err := item.ValueCopy(nil)
 		if err != nil {
 			return err
 		}
@@ -256,4 +313,15 @@ func (db *badgerDB) Delete(ctx context.Context, table string, key string) error
 
 func init() {
 	ycsb.RegisterDBCreator("badger", badgerCreator{})
+
+	prop.Register(prop.String,
+		badgerDir, badgerValueDir, badgerTableLoadingMode, badgerValueLogLoadingMode)
+	prop.Register(prop.Int,
+		badgerNumVersionsToKeep, badgerMaxTableSize, badgerLevelSizeMultiplier, badgerMaxLevels,
+		badgerValueThreshold, badgerNumMemtables, badgerNumLevelZeroTables,
+		badgerNumLevelZeroTablesStall, badgerLevelOneSize, badgerValueLogFileSize,
+		badgerValueLogMaxEntries, badgerNumCompactors)
+	prop.Register(prop.Float, badgerGCDiscardRatio)
+	prop.Register(prop.Bool, badgerSyncWrites, badgerDoNotCompact, badgerInMemory)
+	prop.Register(prop.Duration, badgerGCInterval)
 }
@@ -39,7 +39,9 @@ import (
 
 	"github.com/magiconair/properties"
 	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/util"
 	"github.com/pingcap/go-ycsb/pkg/ycsb"
+	"go.uber.org/zap"
 )
 
 const (
@@ -124,7 +126,7 @@ func (db *basicDB) Read(ctx context.Context, table string, key string, fields []
 		buf.WriteString("<all fields> ")
 	}
 	buf.WriteByte(']')
-	fmt.Println(buf.String())
+	util.Logger().Info("operation", zap.String("operation", buf.String()))
 	buf.Reset()
 	return nil, nil
 }
@@ -155,7 +157,7 @@ func (db *basicDB) Scan(ctx context.Context, table string, startKey string, coun
 		buf.WriteString("<all fields> ")
 	}
 	buf.WriteByte(']')
-	fmt.Println(buf.String())
+	util.Logger().Info("operation", zap.String("operation", buf.String()))
 	buf.Reset()
 	return nil, nil
 }
@@ -181,7 +183,7 @@ func (db *basicDB) Update(ctx context.Context, table string, key string, values
 	}
 
 	buf.WriteByte(']')
-	fmt.Println(buf.String())
+	util.Logger().Info("operation", zap.String("operation", buf.String()))
 	buf.Reset()
 	return nil
 }
@@ -229,7 +231,7 @@ func insertRecord(buf *bytes.Buffer, table string, key string, values map[string
 		buf.WriteByte(' ')
 	}
 	buf.WriteByte(']')
-	fmt.Println(buf.String())
+	util.Logger().Info("operation", zap.String("operation", buf.String()))
 	buf.Reset()
 }
 
@@ -245,7 +247,7 @@ func (db *basicDB) Delete(ctx context.Context, table string, key string) error {
 	s := fmt.Sprintf("DELETE %s %s", table, key)
 	buf.WriteString(s)
 
-	fmt.Println(buf.String())
+	util.Logger().Info("operation", zap.String("operation", buf.String()))
 	buf.Reset()
 	return nil
 }
@@ -268,4 +270,7 @@ func (basicDBCreator) Create(p *properties.Properties) (ycsb.DB, error) {
 
 func init() {
 	ycsb.RegisterDBCreator("basic", basicDBCreator{})
+
+	prop.Register(prop.Int, simulateDelay)
+	prop.Register(prop.Bool, randomizeDelay)
 }
@@ -0,0 +1,348 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bigtable
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"cloud.google.com/go/bigtable"
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/util"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+	"go.uber.org/zap"
+	"google.golang.org/api/option"
+)
+
+// bigtable properties
+const (
+	bigtableProject      = "bigtable.project"
+	bigtableInstance     = "bigtable.instance"
+	bigtableCredentials  = "bigtable.credentials"
+	bigtableAppProfile   = "bigtable.app_profile"
+	bigtableColumnFamily = "bigtable.column_family"
+	// Inserts in the load phase are buffered per thread and applied in one bulk mutation.
+	bigtableBatchSize = "bigtable.batchsize"
+)
+
+type bigtableCreator struct {
+}
+
+type bigtableDB struct {
+	client    *bigtable.Client
+	family    string
+	batchSize int
+
+	tables map[string]*bigtable.Table
+}
+
+type contextKey string
+
+const stateKey = contextKey("bigtableDB")
+
+type bigtableState struct {
+	pendingTable string
+	pendingKeys  []string
+	pendingMuts  []*bigtable.Mutation
+}
+
+func (c bigtableCreator) Create(p *properties.Properties) (ycsb.DB, error) {
+	project := p.GetString(bigtableProject, "")
+	instance := p.GetString(bigtableInstance, "")
+	if len(project) == 0 || len(instance) == 0 {
+		return nil, fmt.Errorf("must provide %s and %s", bigtableProject, bigtableInstance)
+	}
+
+	var opts []option.ClientOption
+	if credentials, ok := p.Get(bigtableCredentials); ok {
+		opts = append(opts, option.WithCredentialsFile(credentials))
+	}
+
+	ctx := context.Background()
+	d := &bigtableDB{
+		family:    p.GetString(bigtableColumnFamily, "cf"),
+		batchSize: 1,
+		tables:    make(map[string]*bigtable.Table),
+	}
+
+	tableNames := util.TableNames(p)
+	if !p.GetBool(prop.DoTransactions, true) {
+		if err := d.createTables(ctx, p, project, instance, tableNames, opts); err != nil {
+			return nil, err
+		}
+		d.batchSize = p.GetInt(bigtableBatchSize, 100)
+	}
+
+	client, err := bigtable.NewClientWithConfig(ctx, project, instance, bigtable.ClientConfig{
+		AppProfile: p.GetString(bigtableAppProfile, ""),
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	d.client = client
+
+	for _, tableName := range tableNames {
+		d.tables[tableName] = client.Open(tableName)
+	}
+
+	return d, nil
+}
+
+func (db *bigtableDB) createTables(ctx context.Context, p *properties.Properties, project string, instance string, tableNames []string, opts []option.ClientOption) error {
+	adminClient, err := bigtable.NewAdminClient(ctx, project, instance, opts...)
+	if err != nil {
+		return err
+	}
+	defer adminClient.Close()
+
+	existed, err := adminClient.Tables(ctx)
+	if err != nil {
+		return err
+	}
+	existedTables := make(map[string]struct{}, len(existed))
+	for _, tableName := range existed {
+		existedTables[tableName] = struct{}{}
+	}
+
+	for _, tableName := range tableNames {
+		if _, ok := existedTables[tableName]; ok {
+			if !p.GetBool(prop.DropData, prop.DropDataDefault) {
+				continue
+			}
+			if err = adminClient.DeleteTable(ctx, tableName); err != nil {
+				return err
+			}
+		}
+
+		if err = adminClient.CreateTable(ctx, tableName); err != nil {
+			return err
+		}
+		if err = adminClient.CreateColumnFamily(ctx, tableName, db.family); err != nil {
+			return err
+		}
+		// Only the latest value is read, so the old versions are useless.
+		if err = adminClient.SetGCPolicy(ctx, tableName, db.family, bigtable.MaxVersionsPolicy(1)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// BufferedInserts implements the BufferedDB BufferedInserts interface.
+func (db *bigtableDB) BufferedInserts() bool {
+	return db.batchSize > 1
+}
+
+func (db *bigtableDB) Close() error {
+	if db.client == nil {
+		return nil
+	}
+
+	return db.client.Close()
+}
+
+func (db *bigtableDB) InitThread(ctx context.Context, _ int, _ int) context.Context {
+	return context.WithValue(ctx, stateKey, new(bigtableState))
+}
+
+func (db *bigtableDB) CleanupThread(ctx context.Context) {
+	state := ctx.Value(stateKey).(*bigtableState)
+
+	if err := db.flushPending(ctx, state); err != nil {
+		util.Logger().Error("flush pending rows failed", zap.Error(err))
+	}
+}
+
+func (db *bigtableDB) table(table string) *bigtable.Table {
+	if t, ok := db.tables[table]; ok {
+		return t
+	}
+	return db.client.Open(table)
+}
+
+// readFilter returns the filter to read the latest value of the fields, or all the fields if fields is empty.
+func (db *bigtableDB) readFilter(fields []string) bigtable.ReadOption {
+	filters := []bigtable.Filter{
+		bigtable.FamilyFilter(regexp.QuoteMeta(db.family)),
+		bigtable.LatestNFilter(1),
+	}
+	if len(fields) > 0 {
+		quoted := make([]string, 0, len(fields))
+		for _, field := range fields {
+			quoted = append(quoted, regexp.QuoteMeta(field))
+		}
+		filters = append(filters, bigtable.ColumnFilter(fmt.Sprintf("^(%s)$", strings.Join(quoted, "|"))))
+	}
+	return bigtable.RowFilter(bigtable.ChainFilters(filters...))
+}
+
+func (db *bigtableDB) decodeRow(row bigtable.Row) map[string][]byte {
+	items := row[db.family]
+	res := make(map[string][]byte, len(items))
+	prefix := db.family + ":"
+	for _, item := range items {
+		res[strings.TrimPrefix(item.Column, prefix)] = item.Value
+	}
+	return res
+}
+
+func (db *bigtableDB) newMutation(values map[string][]byte) *bigtable.Mutation {
+	m := bigtable.NewMutation()
+	ts := bigtable.Now()
+	for field, value := range values {
+		m.Set(db.family, field, ts, value)
+	}
+	return m
+}
+
+func (db *bigtableDB) Read(ctx context.Context, table string, key string, fields []string) (map[string][]byte, error) {
+	row, err := db.table(table).ReadRow(ctx, key, db.readFilter(fields))
+	if err != nil {
+		return nil, err
+	} else if len(row) == 0 {
+		return nil, nil
+	}
+
+	return db.decodeRow(row), nil
+}
+
+func (db *bigtableDB) Scan(ctx context.Context, table string, startKey string, count int, fields []string) ([]map[string][]byte, error) {
+	res := make([]map[string][]byte, 0, count)
+	err := db.table(table).ReadRows(ctx, bigtable.InfiniteRange(startKey), func(row bigtable.Row) bool {
+		res = append(res, db.decodeRow(row))
+		return true
+	}, bigtable.LimitRows(int64(count)), db.readFilter(fields))
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (db *bigtableDB) Update(ctx context.Context, table string, key string, values map[string][]byte) error {
+	return db.table(table).Apply(ctx, key, db.newMutation(values))
+}
+
+func (db *bigtableDB) Insert(ctx context.Context, table string, key string, values map[string][]byte) error {
+	if db.batchSize <= 1 {
+		return db.table(table).Apply(ctx, key, db.newMutation(values))
+	}
+
+	state := ctx.Value(stateKey).(*bigtableState)
+
+	if state.pendingTable != table {
+		if err := db.flushPending(ctx, state); err != nil {
+			return err
+		}
+		state.pendingTable = table
+	}
+
+	// The values may be reused by the workload after Insert returns, so we must copy them.
+	row := make(map[string][]byte, len(values))
+	for field, value := range values {
+		row[field] = append([]byte(nil), value...)
+	}
+
+	state.pendingKeys = append(state.pendingKeys, key)
+	state.pendingMuts = append(state.pendingMuts, db.newMutation(row))
+
+	if len(state.pendingKeys) < db.batchSize {
+		return nil
+	}
+
+	return db.flushPending(ctx, state)
+}
+
+func (db *bigtableDB) flushPending(ctx context.Context, state *bigtableState) error {
+	if len(state.pendingKeys) == 0 {
+		return nil
+	}
+
+	err := db.applyBulk(ctx, state.pendingTable, state.pendingKeys, state.pendingMuts)
+	state.pendingKeys = state.pendingKeys[:0]
+	state.pendingMuts = state.pendingMuts[:0]
+	return err
+}
+
+func (db *bigtableDB) applyBulk(ctx context.Context, table string, keys []string, muts []*bigtable.Mutation) error {
+	errs, err := db.table(table).ApplyBulk(ctx, keys, muts)
+	if err != nil {
+		return err
+	}
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (db *bigtableDB) Delete(ctx context.Context, table string, key string) error {
+	m := bigtable.NewMutation()
+	m.DeleteRow()
+	return db.table(table).Apply(ctx, key, m)
+}
+
+func (db *bigtableDB) BatchInsert(ctx context.Context, table string, keys []string, values []map[string][]byte) error {
+	muts := make([]*bigtable.Mutation, 0, len(keys))
+	for _, v := range values {
+		muts = append(muts, db.newMutation(v))
+	}
+	return db.applyBulk(ctx, table, keys, muts)
+}
+
+func (db *bigtableDB) BatchRead(ctx context.Context, table string, keys []string, fields []string) ([]map[string][]byte, error) {
+	rows := make(map[string]map[string][]byte, len(keys))
+	err := db.table(table).ReadRows(ctx, bigtable.RowList(keys), func(row bigtable.Row) bool {
+		rows[row.Key()] = db.decodeRow(row)
+		return true
+	}, db.readFilter(fields))
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]map[string][]byte, len(keys))
+	for i, key := range keys {
+		res[i] = rows[key]
+	}
+	return res, nil
+}
+
+func (db *bigtableDB) BatchUpdate(ctx context.Context, table string, keys []string, values []map[string][]byte) error {
+	return db.BatchInsert(ctx, table, keys, values)
+}
+
+func (db *bigtableDB) BatchDelete(ctx context.Context, table string, keys []string) error {
+	muts := make([]*bigtable.Mutation, 0, len(keys))
+	for range keys {
+		m := bigtable.NewMutation()
+		m.DeleteRow()
+		muts = append(muts, m)
+	}
+	return db.applyBulk(ctx, table, keys, muts)
+}
+
+func init() {
+	ycsb.RegisterDBCreator("bigtable", bigtableCreator{})
+
+	prop.Register(prop.String,
+		bigtableProject, bigtableInstance, bigtableCredentials, bigtableAppProfile,
+		bigtableColumnFamily)
+	prop.Register(prop.Int, bigtableBatchSize)
+}
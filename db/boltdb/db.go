@@ -161,6 +161,36 @@ func (db *boltDB) Scan(ctx context.Context, table string, startKey string, count
 	return res, err
 }
 
+// ReverseScan scans the records before the start key in the descending order.
+func (db *boltDB) ReverseScan(ctx context.Context, table string, startKey string, count int, fields []string) ([]map[string][]byte, error) {
+	res := make([]map[string][]byte, 0, count)
+	err := db.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(table))
+		if bucket == nil {
+			return fmt.Errorf("table not found: %s", table)
+		}
+
+		cursor := bucket.Cursor()
+		key, value := cursor.Seek([]byte(startKey))
+		if key == nil {
+			key, value = cursor.Last()
+		} else if string(key) != startKey {
+			key, value = cursor.Prev()
+		}
+		for ; key != nil && len(res) < count; key, value = cursor.Prev() {
+			m, err := db.r.Decode(value, fields)
+			if err != nil {
+				return err
+			}
+
+			res = append(res, m)
+		}
+
+		return nil
+	})
+	return res, err
+}
+
 func (db *boltDB) Update(ctx context.Context, table string, key string, values map[string][]byte) error {
 	err := db.db.Update(func(tx *bolt.Tx) error {
 		bucket := tx.Bucket([]byte(table))
@@ -237,4 +267,8 @@ func (db *boltDB) Delete(ctx context.Context, table string, key string) error {
 
 func init() {
 	ycsb.RegisterDBCreator("boltdb", boltCreator{})
+
+	prop.Register(prop.String, boltPath)
+	prop.Register(prop.Int, boltTimeout, boltMmapFlags, boltInitialMmapSize)
+	prop.Register(prop.Bool, boltNoGrowSync, boltReadOnly)
 }
@@ -26,6 +26,7 @@ import (
 	"github.com/gocql/gocql"
 	"github.com/magiconair/properties"
 	"github.com/pingcap/go-ycsb/pkg/ycsb"
+	"go.uber.org/zap"
 )
 
 // cassandra properties
@@ -33,10 +34,16 @@ const (
 	cassandraCluster     = "cassandra.cluster"
 	cassandraKeyspace    = "cassandra.keyspace"
 	cassandraConnections = "cassandra.connections"
-
-	cassandraClusterDefault     = "127.0.0.1:9042"
-	cassandraKeyspaceDefault    = "test"
-	cassandraConnectionsDefault = 2 // refer to https://github.com/gocql/gocql/blob/master/cluster.go#L52
+	// Consistency level like "ONE", "QUORUM" or "ALL"
+	cassandraConsistency = "cassandra.consistency"
+	// Replication factor of the keyspace if it is created by the benchmark
+	cassandraReplicationFactor = "cassandra.replication_factor"
+
+	cassandraClusterDefault           = "127.0.0.1:9042"
+	cassandraKeyspaceDefault          = "test"
+	cassandraConnectionsDefault       = 2 // refer to https://github.com/gocql/gocql/blob/master/cluster.go#L52
+	cassandraConsistencyDefault       = "QUORUM"
+	cassandraReplicationFactorDefault = 1
 )
 
 type cassandraCreator struct {
@@ -66,14 +73,25 @@ func (c cassandraCreator) Create(p *properties.Properties) (ycsb.DB, error) {
 
 	hosts := strings.Split(p.GetString(cassandraCluster, cassandraClusterDefault), ",")
 
-	cluster := gocql.NewCluster(hosts...)
-	cluster.Keyspace = p.GetString(cassandraKeyspace, cassandraKeyspaceDefault)
-	d.keySpace = cluster.Keyspace
+	consistency, err := gocql.ParseConsistencyWrapper(p.GetString(cassandraConsistency, cassandraConsistencyDefault))
+	if err != nil {
+		return nil, err
+	}
 
+	cluster := gocql.NewCluster(hosts...)
 	cluster.NumConns = p.GetInt(cassandraConnections, cassandraConnectionsDefault)
 	cluster.Timeout = 30 * time.Second
-	cluster.Consistency = gocql.Quorum
+	cluster.Consistency = consistency
+	// Route the queries to the replicas of the key directly.
+	cluster.PoolConfig.HostSelectionPolicy = gocql.TokenAwareHostPolicy(gocql.RoundRobinHostPolicy())
+
+	d.keySpace = p.GetString(cassandraKeyspace, cassandraKeyspaceDefault)
+	if err := createKeyspace(cluster, d.keySpace, p.GetInt(cassandraReplicationFactor, cassandraReplicationFactorDefault)); err != nil {
+		return nil, err
+	}
 
+	// The queries with arguments are prepared once and reused by the session.
+	cluster.Keyspace = d.keySpace
 	session, err := cluster.CreateSession()
 	if err != nil {
 		return nil, err
@@ -84,41 +102,47 @@ func (c cassandraCreator) Create(p *properties.Properties) (ycsb.DB, error) {
 
 	d.bufPool = util.NewBufPool()
 
-	if err := d.createTable(); err != nil {
-		return nil, err
+	for _, tableName := range util.TableNames(p) {
+		if err := d.createTable(tableName); err != nil {
+			return nil, err
+		}
 	}
 
 	return d, nil
 }
 
-func (db *cassandraDB) createTable() error {
-	tableName := db.p.GetString(prop.TableName, prop.TableNameDefault)
+func createKeyspace(cluster *gocql.ClusterConfig, keySpace string, replicationFactor int) error {
+	session, err := cluster.CreateSession()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	return session.Query(fmt.Sprintf("CREATE KEYSPACE IF NOT EXISTS %s WITH replication = {'class': 'SimpleStrategy', 'replication_factor': %d}",
+		keySpace, replicationFactor)).Exec()
+}
 
+func (db *cassandraDB) createTable(tableName string) error {
 	if db.p.GetBool(prop.DropData, prop.DropDataDefault) {
 		if err := db.session.Query(fmt.Sprintf("DROP TABLE IF EXISTS %s.%s", db.keySpace, tableName)).Exec(); err != nil {
 			return err
 		}
 	}
 
-	fieldCount := db.p.GetInt64(prop.FieldCount, prop.FieldCountDefault)
-
-	db.fieldNames = make([]string, fieldCount)
-	for i := int64(0); i < fieldCount; i++ {
-		db.fieldNames[i] = fmt.Sprintf("field%d", i)
-	}
+	db.fieldNames = util.FieldNames(db.p)
 
 	buf := new(bytes.Buffer)
 	s := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s.%s (YCSB_KEY VARCHAR PRIMARY KEY", db.keySpace, tableName)
 	buf.WriteString(s)
 
-	for i := int64(0); i < fieldCount; i++ {
-		buf.WriteString(fmt.Sprintf(", FIELD%d VARCHAR", i))
+	for _, field := range db.fieldNames {
+		buf.WriteString(fmt.Sprintf(", %s VARCHAR", strings.ToUpper(field)))
 	}
 
 	buf.WriteString(");")
 
 	if db.verbose {
-		fmt.Println(buf.String())
+		util.Logger().Info("query", zap.String("query", buf.String()))
 	}
 
 	err := db.session.Query(buf.String()).Exec()
@@ -151,7 +175,7 @@ func (db *cassandraDB) Read(ctx context.Context, table string, key string, field
 	query = fmt.Sprintf(`SELECT %s FROM %s.%s WHERE YCSB_KEY = ?`, strings.Join(fields, ","), db.keySpace, table)
 
 	if db.verbose {
-		fmt.Printf("%s\n", query)
+		util.Logger().Info("query", zap.String("query", query))
 	}
 
 	m := make(map[string][]byte, len(fields))
@@ -175,13 +199,44 @@ func (db *cassandraDB) Read(ctx context.Context, table string, key string, field
 	return m, nil
 }
 
+// Scan scans the records in the token order from the token of the start key.
 func (db *cassandraDB) Scan(ctx context.Context, table string, startKey string, count int, fields []string) ([]map[string][]byte, error) {
-	return nil, fmt.Errorf("scan is not supported")
+	if len(fields) == 0 {
+		fields = db.fieldNames
+	}
+
+	query := fmt.Sprintf(`SELECT %s FROM %s.%s WHERE token(YCSB_KEY) >= token(?) LIMIT ?`, strings.Join(fields, ","), db.keySpace, table)
+
+	if db.verbose {
+		util.Logger().Info("query", zap.String("query", query))
+	}
+
+	iter := db.session.Query(query, startKey, count).WithContext(ctx).Iter()
+
+	res := make([]map[string][]byte, 0, count)
+	for {
+		dest := make([]interface{}, len(fields))
+		for i := 0; i < len(fields); i++ {
+			v := new([]byte)
+			dest[i] = v
+		}
+		if !iter.Scan(dest...) {
+			break
+		}
+
+		m := make(map[string][]byte, len(fields))
+		for i, v := range dest {
+			m[fields[i]] = *v.(*[]byte)
+		}
+		res = append(res, m)
+	}
+
+	return res, iter.Close()
 }
 
 func (db *cassandraDB) execQuery(ctx context.Context, query string, args ...interface{}) error {
 	if db.verbose {
-		fmt.Printf("%s %v\n", query, args)
+		util.Logger().Info("query", zap.String("query", query), zap.Reflect("args", args))
 	}
 
 	err := db.session.Query(query, args...).WithContext(ctx).Exec()
@@ -253,4 +308,7 @@ func (db *cassandraDB) Delete(ctx context.Context, table string, key string) err
 func init() {
 	ycsb.RegisterDBCreator("cassandra", cassandraCreator{})
 	ycsb.RegisterDBCreator("scylla", cassandraCreator{})
+
+	prop.Register(prop.String, cassandraCluster, cassandraKeyspace, cassandraConsistency)
+	prop.Register(prop.Int, cassandraConnections, cassandraReplicationFactor)
 }
@@ -0,0 +1,451 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clickhouse
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	// clickhouse package
+	_ "github.com/ClickHouse/clickhouse-go"
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/util"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+	"go.uber.org/zap"
+)
+
+// clickhouse properties
+const (
+	clickhouseHost     = "clickhouse.host"
+	clickhousePort     = "clickhouse.port"
+	clickhouseUser     = "clickhouse.user"
+	clickhousePassword = "clickhouse.password"
+	clickhouseDBName   = "clickhouse.db"
+	clickhouseDebug    = "clickhouse.debug"
+	// The ORDER BY key of the MergeTree table.
+	clickhouseOrderBy = "clickhouse.order_by"
+	// Inserts are buffered per thread and written in one batch when the buffer is full.
+	clickhouseBatchSize = "clickhouse.batchsize"
+)
+
+type clickhouseCreator struct {
+}
+
+type clickhouseDB struct {
+	p         *properties.Properties
+	db        *sql.DB
+	verbose   bool
+	batchSize int
+
+	bufPool *util.BufPool
+}
+
+type contextKey string
+
+const stateKey = contextKey("clickhouseDB")
+
+type clickhouseState struct {
+	// pending rows buffered by Insert, they will be flushed when the buffer is full
+	// or the thread is cleaned up.
+	pendingTable  string
+	pendingKeys   []string
+	pendingValues []map[string][]byte
+}
+
+func (c clickhouseCreator) Create(p *properties.Properties) (ycsb.DB, error) {
+	d := new(clickhouseDB)
+	d.p = p
+
+	host := p.GetString(clickhouseHost, "127.0.0.1")
+	port := p.GetInt(clickhousePort, 9000)
+	user := p.GetString(clickhouseUser, "default")
+	password := p.GetString(clickhousePassword, "")
+	dbName := p.GetString(clickhouseDBName, "test")
+
+	v := url.Values{}
+	v.Set("username", user)
+	v.Set("password", password)
+	v.Set("debug", fmt.Sprintf("%v", p.GetBool(clickhouseDebug, false)))
+	dsn := fmt.Sprintf("tcp://%s:%d?%s", host, port, v.Encode())
+
+	// The database must exist before connecting to it.
+	if err := createDatabase(dsn, dbName); err != nil {
+		return nil, err
+	}
+
+	v.Set("database", dbName)
+	dsn = fmt.Sprintf("tcp://%s:%d?%s", host, port, v.Encode())
+	db, err := sql.Open("clickhouse", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	threadCount := int(p.GetInt64(prop.ThreadCount, prop.ThreadCountDefault))
+	db.SetMaxIdleConns(threadCount + 1)
+	db.SetMaxOpenConns(threadCount * 2)
+
+	d.verbose = p.GetBool(prop.Verbose, prop.VerboseDefault)
+	d.batchSize = p.GetInt(clickhouseBatchSize, 1000)
+	d.db = db
+
+	d.bufPool = util.NewBufPool()
+
+	for _, tableName := range util.TableNames(p) {
+		if err := d.createTable(tableName); err != nil {
+			return nil, err
+		}
+	}
+
+	return d, nil
+}
+
+func createDatabase(dsn string, dbName string) error {
+	db, err := sql.Open("clickhouse", dsn)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	_, err = db.Exec(fmt.Sprintf("CREATE DATABASE IF NOT EXISTS %s", dbName))
+	return err
+}
+
+func (db *clickhouseDB) createTable(tableName string) error {
+	if db.p.GetBool(prop.DropData, prop.DropDataDefault) && !db.p.GetBool(prop.DoTransactions, true) {
+		if _, err := db.db.Exec(fmt.Sprintf("DROP TABLE IF EXISTS %s", tableName)); err != nil {
+			return err
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	s := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (YCSB_KEY String", tableName)
+	buf.WriteString(s)
+
+	for _, field := range util.FieldNames(db.p) {
+		buf.WriteString(fmt.Sprintf(", %s String", strings.ToUpper(field)))
+	}
+
+	buf.WriteString(fmt.Sprintf(") ENGINE = MergeTree() ORDER BY (%s)", db.p.GetString(clickhouseOrderBy, "YCSB_KEY")))
+
+	if db.verbose {
+		util.Logger().Info("query", zap.String("query", buf.String()))
+	}
+
+	_, err := db.db.Exec(buf.String())
+	return err
+}
+
+// BufferedInserts implements the BufferedDB BufferedInserts interface.
+func (db *clickhouseDB) BufferedInserts() bool {
+	return db.batchSize > 1
+}
+
+func (db *clickhouseDB) Close() error {
+	if db.db == nil {
+		return nil
+	}
+
+	return db.db.Close()
+}
+
+func (db *clickhouseDB) InitThread(ctx context.Context, _ int, _ int) context.Context {
+	return context.WithValue(ctx, stateKey, new(clickhouseState))
+}
+
+func (db *clickhouseDB) CleanupThread(ctx context.Context) {
+	state := ctx.Value(stateKey).(*clickhouseState)
+
+	if err := db.flushPending(ctx, state); err != nil {
+		util.Logger().Error("flush pending rows failed", zap.Error(err))
+	}
+}
+
+func (db *clickhouseDB) queryRows(ctx context.Context, query string, count int, args ...interface{}) ([]map[string][]byte, error) {
+	if db.verbose {
+		util.Logger().Info("query", zap.String("query", query), zap.Reflect("args", args))
+	}
+
+	rows, err := db.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	vs := make([]map[string][]byte, 0, count)
+	for rows.Next() {
+		m := make(map[string][]byte, len(cols))
+		dest := make([]interface{}, len(cols))
+		for i := 0; i < len(cols); i++ {
+			v := new([]byte)
+			dest[i] = v
+		}
+		if err = rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+
+		for i, v := range dest {
+			m[cols[i]] = *v.(*[]byte)
+		}
+
+		vs = append(vs, m)
+	}
+
+	return vs, rows.Err()
+}
+
+func (db *clickhouseDB) Read(ctx context.Context, table string, key string, fields []string) (map[string][]byte, error) {
+	var query string
+	if len(fields) == 0 {
+		query = fmt.Sprintf(`SELECT * FROM %s WHERE YCSB_KEY = ? LIMIT 1`, table)
+	} else {
+		query = fmt.Sprintf(`SELECT %s FROM %s WHERE YCSB_KEY = ? LIMIT 1`, strings.Join(fields, ","), table)
+	}
+
+	rows, err := db.queryRows(ctx, query, 1, key)
+
+	if err != nil {
+		return nil, err
+	} else if len(rows) == 0 {
+		return nil, nil
+	}
+
+	return rows[0], nil
+}
+
+func (db *clickhouseDB) Scan(ctx context.Context, table string, startKey string, count int, fields []string) ([]map[string][]byte, error) {
+	var query string
+	if len(fields) == 0 {
+		query = fmt.Sprintf(`SELECT * FROM %s WHERE YCSB_KEY >= ? ORDER BY YCSB_KEY LIMIT ?`, table)
+	} else {
+		query = fmt.Sprintf(`SELECT %s FROM %s WHERE YCSB_KEY >= ? ORDER BY YCSB_KEY LIMIT ?`, strings.Join(fields, ","), table)
+	}
+
+	return db.queryRows(ctx, query, count, startKey, count)
+}
+
+func (db *clickhouseDB) execQuery(ctx context.Context, query string, args ...interface{}) error {
+	if db.verbose {
+		util.Logger().Info("query", zap.String("query", query), zap.Reflect("args", args))
+	}
+
+	_, err := db.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+// Update updates the row by a mutation, it is asynchronous in ClickHouse.
+func (db *clickhouseDB) Update(ctx context.Context, table string, key string, values map[string][]byte) error {
+	buf := db.bufPool.Get()
+	defer db.bufPool.Put(buf)
+
+	buf.WriteString("ALTER TABLE ")
+	buf.WriteString(table)
+	buf.WriteString(" UPDATE ")
+	firstField := true
+	pairs := util.NewFieldPairs(values)
+	args := make([]interface{}, 0, len(values)+1)
+	for _, p := range pairs {
+		if firstField {
+			firstField = false
+		} else {
+			buf.WriteString(", ")
+		}
+
+		buf.WriteString(p.Field)
+		buf.WriteString(` = ?`)
+		args = append(args, string(p.Value))
+	}
+	buf.WriteString(" WHERE YCSB_KEY = ?")
+
+	args = append(args, key)
+
+	return db.execQuery(ctx, buf.String(), args...)
+}
+
+func (db *clickhouseDB) Insert(ctx context.Context, table string, key string, values map[string][]byte) error {
+	state := ctx.Value(stateKey).(*clickhouseState)
+
+	if state.pendingTable != table {
+		if err := db.flushPending(ctx, state); err != nil {
+			return err
+		}
+		state.pendingTable = table
+	}
+
+	// The values may be reused by the workload after Insert returns, so we must copy them.
+	row := make(map[string][]byte, len(values))
+	for field, value := range values {
+		row[field] = append([]byte(nil), value...)
+	}
+
+	state.pendingKeys = append(state.pendingKeys, key)
+	state.pendingValues = append(state.pendingValues, row)
+
+	if len(state.pendingKeys) < db.batchSize {
+		return nil
+	}
+
+	return db.flushPending(ctx, state)
+}
+
+func (db *clickhouseDB) flushPending(ctx context.Context, state *clickhouseState) error {
+	if len(state.pendingKeys) == 0 {
+		return nil
+	}
+
+	err := db.BatchInsert(ctx, state.pendingTable, state.pendingKeys, state.pendingValues)
+	state.pendingKeys = state.pendingKeys[:0]
+	state.pendingValues = state.pendingValues[:0]
+	return err
+}
+
+// batchFields returns the sorted union of the fields in the batch values.
+func batchFields(values []map[string][]byte) []string {
+	set := make(map[string]struct{})
+	for _, value := range values {
+		for field := range value {
+			set[field] = struct{}{}
+		}
+	}
+
+	fields := make([]string, 0, len(set))
+	for field := range set {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// BatchInsert writes the rows in one block, the driver requires a transaction for it.
+func (db *clickhouseDB) BatchInsert(ctx context.Context, table string, keys []string, values []map[string][]byte) error {
+	fields := batchFields(values)
+	cols := append([]string{"YCSB_KEY"}, fields...)
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(cols)), ", ")
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table, strings.Join(cols, ", "), placeholders)
+
+	if db.verbose {
+		util.Logger().Info("query", zap.String("query", query), zap.Int("rows", len(keys)))
+	}
+
+	tx, err := db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+
+	args := make([]interface{}, 1+len(fields))
+	for i, key := range keys {
+		args[0] = key
+		for j, field := range fields {
+			// The missing field will be inserted as the empty string.
+			args[j+1] = values[i][field]
+			if args[j+1] == nil {
+				args[j+1] = []byte{}
+			}
+		}
+
+		if _, err = stmt.ExecContext(ctx, args...); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (db *clickhouseDB) BatchRead(ctx context.Context, table string, keys []string, fields []string) ([]map[string][]byte, error) {
+	var query string
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(keys)), ",")
+	if len(fields) == 0 {
+		query = fmt.Sprintf(`SELECT * FROM %s WHERE YCSB_KEY IN (%s)`, table, placeholders)
+	} else {
+		query = fmt.Sprintf(`SELECT YCSB_KEY, %s FROM %s WHERE YCSB_KEY IN (%s)`, strings.Join(fields, ","), table, placeholders)
+	}
+
+	args := make([]interface{}, 0, len(keys))
+	for _, key := range keys {
+		args = append(args, key)
+	}
+
+	rows, err := db.queryRows(ctx, query, len(keys), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	rowsByKey := make(map[string]map[string][]byte, len(rows))
+	for _, row := range rows {
+		key := string(row["YCSB_KEY"])
+		delete(row, "YCSB_KEY")
+		rowsByKey[key] = row
+	}
+
+	// Keep the same order as the keys, nil for the missing records.
+	res := make([]map[string][]byte, len(keys))
+	for i, key := range keys {
+		res[i] = rowsByKey[key]
+	}
+	return res, nil
+}
+
+func (db *clickhouseDB) BatchUpdate(ctx context.Context, table string, keys []string, values []map[string][]byte) error {
+	for i, key := range keys {
+		if err := db.Update(ctx, table, key, values[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete deletes the row by a mutation, it is asynchronous in ClickHouse.
+func (db *clickhouseDB) Delete(ctx context.Context, table string, key string) error {
+	query := fmt.Sprintf(`ALTER TABLE %s DELETE WHERE YCSB_KEY = ?`, table)
+
+	return db.execQuery(ctx, query, key)
+}
+
+func (db *clickhouseDB) BatchDelete(ctx context.Context, table string, keys []string) error {
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(keys)), ",")
+	query := fmt.Sprintf(`ALTER TABLE %s DELETE WHERE YCSB_KEY IN (%s)`, table, placeholders)
+
+	args := make([]interface{}, 0, len(keys))
+	for _, key := range keys {
+		args = append(args, key)
+	}
+	return db.execQuery(ctx, query, args...)
+}
+
+func init() {
+	ycsb.RegisterDBCreator("clickhouse", clickhouseCreator{})
+
+	prop.Register(prop.String,
+		clickhouseHost, clickhouseUser, clickhousePassword, clickhouseDBName, clickhouseOrderBy)
+	prop.Register(prop.Int, clickhousePort, clickhouseBatchSize)
+	prop.Register(prop.Bool, clickhouseDebug)
+}
@@ -0,0 +1,542 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cosmos
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/measurement"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/util"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+	"go.uber.org/zap"
+)
+
+// cosmos properties
+const (
+	cosmosEndpoint = "cosmos.endpoint"
+	// The primary or secondary key of the account.
+	cosmosKey      = "cosmos.key"
+	cosmosDatabase = "cosmos.database"
+	// The RU/s provisioned for every container when it is created.
+	cosmosThroughput = "cosmos.throughput"
+	// "session", "strong" or "" to use the default consistency of the account.
+	cosmosConsistency = "cosmos.consistency"
+	// "key", "single" or "bucket"
+	cosmosPartitionKey     = "cosmos.partition_key"
+	cosmosPartitionBuckets = "cosmos.partition_buckets"
+	cosmosUpsert           = "cosmos.upsert"
+	// The max retries of the throttled requests.
+	cosmosMaxRetries         = "cosmos.max_retries"
+	cosmosTimeout            = "cosmos.timeout"
+	cosmosInsecureSkipVerify = "cosmos.insecure_skip_verify"
+)
+
+const (
+	apiVersion = "2018-12-31"
+	// The partition key path of the containers, the value depends on the partition key strategy.
+	partitionKeyPath = "pk"
+)
+
+type contextKey string
+
+const stateKey = contextKey("cosmosDB")
+
+type cosmosCreator struct {
+}
+
+type cosmosDB struct {
+	p      *properties.Properties
+	client *http.Client
+
+	endpoint    string
+	key         []byte
+	database    string
+	consistency string
+	maxRetries  int
+	upsert      bool
+
+	partitionKey string
+	buckets      int
+
+	ru *ruCounter
+}
+
+// cosmosState keeps the session token of the thread for the session consistency.
+type cosmosState struct {
+	sessionToken string
+}
+
+func (c cosmosCreator) Create(p *properties.Properties) (ycsb.DB, error) {
+	key, err := base64.StdEncoding.DecodeString(p.GetString(cosmosKey, ""))
+	if err != nil {
+		return nil, fmt.Errorf("decode %s failed %v", cosmosKey, err)
+	}
+
+	threadCount := int(p.GetInt64(prop.ThreadCount, prop.ThreadCountDefault))
+	d := &cosmosDB{
+		p: p,
+		client: &http.Client{
+			Transport: &http.Transport{
+				Proxy:               http.ProxyFromEnvironment,
+				TLSClientConfig:     &tls.Config{InsecureSkipVerify: p.GetBool(cosmosInsecureSkipVerify, false)},
+				MaxIdleConnsPerHost: threadCount,
+			},
+			Timeout: p.GetParsedDuration(cosmosTimeout, 10*time.Second),
+		},
+		endpoint:     strings.TrimSuffix(p.GetString(cosmosEndpoint, "https://127.0.0.1:8081"), "/"),
+		key:          key,
+		database:     p.GetString(cosmosDatabase, "ycsb"),
+		maxRetries:   p.GetInt(cosmosMaxRetries, 9),
+		upsert:       p.GetBool(cosmosUpsert, false),
+		partitionKey: p.GetString(cosmosPartitionKey, "key"),
+		buckets:      p.GetInt(cosmosPartitionBuckets, 16),
+		ru:           newRUCounter(),
+	}
+
+	switch consistency := p.GetString(cosmosConsistency, "session"); consistency {
+	case "session":
+		d.consistency = "Session"
+	case "strong":
+		d.consistency = "Strong"
+	case "":
+	default:
+		return nil, fmt.Errorf("unsupported consistency %s", consistency)
+	}
+
+	switch d.partitionKey {
+	case "key", "single":
+	case "bucket":
+		if d.buckets <= 0 {
+			return nil, fmt.Errorf("%s must be positive, but got %d", cosmosPartitionBuckets, d.buckets)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported partition key %s", d.partitionKey)
+	}
+
+	if !p.GetBool(prop.DoTransactions, true) {
+		if err = d.createContainers(); err != nil {
+			return nil, err
+		}
+	}
+
+	return d, nil
+}
+
+// ruCounter sums the request units consumed by every operation class.
+type ruCounter struct {
+	sync.Mutex
+	counts map[string]int64
+	units  map[string]float64
+}
+
+func newRUCounter() *ruCounter {
+	return &ruCounter{
+		counts: make(map[string]int64),
+		units:  make(map[string]float64),
+	}
+}
+
+func (c *ruCounter) add(op string, units float64) {
+	c.Lock()
+	c.counts[op]++
+	c.units[op] += units
+	c.Unlock()
+}
+
+func (c *ruCounter) output() {
+	c.Lock()
+	defer c.Unlock()
+
+	ops := make([]string, 0, len(c.counts))
+	for op := range c.counts {
+		ops = append(ops, op)
+	}
+	sort.Strings(ops)
+
+	for _, op := range ops {
+		util.Logger().Info("RU consumption", zap.String("operation", op), zap.Int64("requests", c.counts[op]),
+			zap.Float64("total", c.units[op]), zap.Float64("avg", c.units[op]/float64(c.counts[op])))
+	}
+}
+
+func (db *cosmosDB) authorization(verb string, resourceType string, resourceLink string, date string) string {
+	text := strings.ToLower(verb) + "\n" + strings.ToLower(resourceType) + "\n" + resourceLink + "\n" + strings.ToLower(date) + "\n\n"
+	mac := hmac.New(sha256.New, db.key)
+	mac.Write([]byte(text))
+	sig := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	return url.QueryEscape("type=master&ver=1.0&sig=" + sig)
+}
+
+// do sends the request of the resource, and decodes the JSON response into res if res is not nil.
+// The consumed RU is counted to the op class, and the throttled request is retried.
+// It returns the status code, the error is nil if the status is 2xx, 404 or 409.
+func (db *cosmosDB) do(ctx context.Context, op string, method string, resourceType string, resourceLink string,
+	header http.Header, body interface{}, res interface{}) (int, error) {
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			return 0, err
+		}
+	}
+
+	state, _ := ctx.Value(stateKey).(*cosmosState)
+
+	path := resourceLink
+	if method == http.MethodPost {
+		// POST creates or queries the resources in the feed, the resource link is the parent of the feed.
+		path = strings.TrimPrefix(path+"/"+resourceType, "/")
+	}
+
+	for i := 0; ; i++ {
+		req, err := http.NewRequest(method, db.endpoint+"/"+path, bytes.NewReader(data))
+		if err != nil {
+			return 0, err
+		}
+		req = req.WithContext(ctx)
+		for name, values := range header {
+			req.Header[name] = values
+		}
+
+		date := time.Now().UTC().Format(http.TimeFormat)
+		req.Header.Set("x-ms-date", date)
+		req.Header.Set("x-ms-version", apiVersion)
+		req.Header.Set("Authorization", db.authorization(method, resourceType, resourceLink, date))
+		if len(req.Header.Get("Content-Type")) == 0 {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		if len(db.consistency) > 0 {
+			req.Header.Set("x-ms-consistency-level", db.consistency)
+		}
+		if state != nil && len(state.sessionToken) > 0 {
+			req.Header.Set("x-ms-session-token", state.sessionToken)
+		}
+
+		resp, err := db.client.Do(req)
+		if err != nil {
+			return 0, err
+		}
+
+		charge, _ := strconv.ParseFloat(resp.Header.Get("x-ms-request-charge"), 64)
+		db.ru.add(op, charge)
+		if token := resp.Header.Get("x-ms-session-token"); state != nil && len(token) > 0 {
+			state.sessionToken = token
+		}
+
+		if resp.StatusCode == http.StatusTooManyRequests && i < db.maxRetries {
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+
+			retryAfter, _ := strconv.Atoi(resp.Header.Get("x-ms-retry-after-ms"))
+			select {
+			case <-ctx.Done():
+				return 0, ctx.Err()
+			case <-time.After(time.Duration(retryAfter) * time.Millisecond):
+			}
+			continue
+		}
+
+		err = decodeResponse(resp, res)
+		resp.Body.Close()
+		return resp.StatusCode, err
+	}
+}
+
+func decodeResponse(resp *http.Response, res interface{}) error {
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusConflict:
+		io.Copy(ioutil.Discard, resp.Body)
+		return nil
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("request failed with status %d: %s", resp.StatusCode, msg)
+	case res == nil:
+		io.Copy(ioutil.Discard, resp.Body)
+		return nil
+	default:
+		return json.NewDecoder(resp.Body).Decode(res)
+	}
+}
+
+func (db *cosmosDB) databaseLink() string {
+	return "dbs/" + db.database
+}
+
+func (db *cosmosDB) containerLink(table string) string {
+	return db.databaseLink() + "/colls/" + table
+}
+
+func (db *cosmosDB) documentLink(table string, key string) string {
+	return db.containerLink(table) + "/docs/" + key
+}
+
+// createContainers creates the database and the containers of the tables if they don't exist.
+func (db *cosmosDB) createContainers() error {
+	ctx := context.Background()
+
+	_, err := db.do(ctx, "SETUP", http.MethodPost, "dbs", "", nil, map[string]string{"id": db.database}, nil)
+	if err != nil {
+		return err
+	}
+
+	for _, tableName := range util.TableNames(db.p) {
+		if db.p.GetBool(prop.DropData, prop.DropDataDefault) {
+			_, err = db.do(ctx, "SETUP", http.MethodDelete, "colls", db.containerLink(tableName), nil, nil, nil)
+			if err != nil {
+				return err
+			}
+		}
+
+		header := make(http.Header)
+		header.Set("x-ms-offer-throughput", strconv.Itoa(db.p.GetInt(cosmosThroughput, 400)))
+		body := map[string]interface{}{
+			"id": tableName,
+			"partitionKey": map[string]interface{}{
+				"paths": []string{"/" + partitionKeyPath},
+				"kind":  "Hash",
+			},
+		}
+		if _, err = db.do(ctx, "SETUP", http.MethodPost, "colls", db.databaseLink(), header, body, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (db *cosmosDB) Close() error {
+	db.ru.output()
+	return nil
+}
+
+func (db *cosmosDB) InitThread(ctx context.Context, _ int, _ int) context.Context {
+	return context.WithValue(ctx, stateKey, &cosmosState{})
+}
+
+func (db *cosmosDB) CleanupThread(_ context.Context) {
+}
+
+// partitionValue returns the partition key value of the key:
+// "key" uses the key itself, "single" puts all the documents of the table into one logical partition,
+// and "bucket" spreads the keys into a fixed number of the logical partitions by the hash.
+func (db *cosmosDB) partitionValue(table string, key string) string {
+	switch db.partitionKey {
+	case "single":
+		return table
+	case "bucket":
+		return strconv.FormatUint(uint64(util.StringHash64(key))%uint64(db.buckets), 10)
+	default:
+		return key
+	}
+}
+
+func partitionHeader(value string) http.Header {
+	data, _ := json.Marshal([]string{value})
+	header := make(http.Header)
+	header.Set("x-ms-documentdb-partitionkey", string(data))
+	return header
+}
+
+// docFields returns the fields of the document, the system properties are skipped.
+func docFields(doc map[string]interface{}, fields []string) map[string][]byte {
+	if len(fields) == 0 {
+		res := make(map[string][]byte, len(doc))
+		for field, value := range doc {
+			if field == "id" || field == partitionKeyPath || strings.HasPrefix(field, "_") {
+				continue
+			}
+			if s, ok := value.(string); ok {
+				res[field] = util.Slice(s)
+			}
+		}
+		return res
+	}
+
+	res := make(map[string][]byte, len(fields))
+	for _, field := range fields {
+		if s, ok := doc[field].(string); ok {
+			res[field] = util.Slice(s)
+		}
+	}
+	return res
+}
+
+func (db *cosmosDB) get(ctx context.Context, op string, table string, key string) (map[string]interface{}, error) {
+	var doc map[string]interface{}
+	status, err := db.do(ctx, op, http.MethodGet, "docs", db.documentLink(table, key),
+		partitionHeader(db.partitionValue(table, key)), nil, &doc)
+	if err != nil || status == http.StatusNotFound {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func (db *cosmosDB) Read(ctx context.Context, table string, key string, fields []string) (map[string][]byte, error) {
+	doc, err := db.get(ctx, "READ", table, key)
+	if err != nil || doc == nil {
+		return nil, err
+	}
+	return docFields(doc, fields), nil
+}
+
+func (db *cosmosDB) query(ctx context.Context, table string, partition string, startKey string, count int) ([]map[string]interface{}, error) {
+	header := partitionHeader(partition)
+	header.Set("Content-Type", "application/query+json")
+	header.Set("x-ms-documentdb-isquery", "True")
+	header.Set("x-ms-max-item-count", strconv.Itoa(count))
+
+	body := map[string]interface{}{
+		"query": "SELECT TOP @count * FROM c WHERE c.id >= @key ORDER BY c.id",
+		"parameters": []map[string]interface{}{
+			{"name": "@count", "value": count},
+			{"name": "@key", "value": startKey},
+		},
+	}
+
+	var res struct {
+		Documents []map[string]interface{} `json:"Documents"`
+	}
+	if _, err := db.do(ctx, "SCAN", http.MethodPost, "docs", db.containerLink(table), header, body, &res); err != nil {
+		return nil, err
+	}
+	return res.Documents, nil
+}
+
+// Scan queries the partition in the "single" strategy, or every partition in the "bucket" strategy and
+// merges the results, it is not supported in the "key" strategy since every key is a partition.
+func (db *cosmosDB) Scan(ctx context.Context, table string, startKey string, count int, fields []string) ([]map[string][]byte, error) {
+	var docs []map[string]interface{}
+	switch db.partitionKey {
+	case "single":
+		var err error
+		if docs, err = db.query(ctx, table, table, startKey, count); err != nil {
+			return nil, err
+		}
+	case "bucket":
+		results := make([][]map[string]interface{}, db.buckets)
+		errs := make([]error, db.buckets)
+
+		var wg sync.WaitGroup
+		for i := 0; i < db.buckets; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				results[i], errs[i] = db.query(ctx, table, strconv.Itoa(i), startKey, count)
+			}(i)
+		}
+		wg.Wait()
+
+		for i, err := range errs {
+			if err != nil {
+				return nil, err
+			}
+			docs = append(docs, results[i]...)
+		}
+		sort.Slice(docs, func(i, j int) bool {
+			return docs[i]["id"].(string) < docs[j]["id"].(string)
+		})
+		if len(docs) > count {
+			docs = docs[:count]
+		}
+	default:
+		measurement.Measure("COMMAND_NOT_SUPPORTED", 0)
+		return nil, fmt.Errorf("scan is not supported if %s is %s", cosmosPartitionKey, db.partitionKey)
+	}
+
+	res := make([]map[string][]byte, 0, len(docs))
+	for _, doc := range docs {
+		res = append(res, docFields(doc, fields))
+	}
+	return res, nil
+}
+
+func (db *cosmosDB) put(ctx context.Context, op string, method string, table string, key string, doc map[string]interface{}, upsert bool) error {
+	partition := db.partitionValue(table, key)
+	doc["id"] = key
+	doc[partitionKeyPath] = partition
+
+	header := partitionHeader(partition)
+	resourceLink := db.containerLink(table)
+	if method == http.MethodPut {
+		resourceLink = db.documentLink(table, key)
+	}
+	if upsert {
+		header.Set("x-ms-documentdb-is-upsert", "True")
+	}
+
+	status, err := db.do(ctx, op, method, "docs", resourceLink, header, doc, nil)
+	if err == nil && status == http.StatusConflict {
+		return fmt.Errorf("document %s already exists", key)
+	} else if err == nil && status == http.StatusNotFound {
+		return fmt.Errorf("document %s not found", key)
+	}
+	return err
+}
+
+// Update reads the document and replaces it with the updated fields.
+func (db *cosmosDB) Update(ctx context.Context, table string, key string, values map[string][]byte) error {
+	doc, err := db.get(ctx, "UPDATE", table, key)
+	if err != nil {
+		return err
+	} else if doc == nil {
+		return fmt.Errorf("document %s not found", key)
+	}
+
+	for field, value := range values {
+		doc[field] = string(value)
+	}
+	return db.put(ctx, "UPDATE", http.MethodPut, table, key, doc, false)
+}
+
+func (db *cosmosDB) Insert(ctx context.Context, table string, key string, values map[string][]byte) error {
+	doc := make(map[string]interface{}, len(values)+2)
+	for field, value := range values {
+		doc[field] = string(value)
+	}
+	return db.put(ctx, "INSERT", http.MethodPost, table, key, doc, db.upsert)
+}
+
+func (db *cosmosDB) Delete(ctx context.Context, table string, key string) error {
+	_, err := db.do(ctx, "DELETE", http.MethodDelete, "docs", db.documentLink(table, key),
+		partitionHeader(db.partitionValue(table, key)), nil, nil)
+	return err
+}
+
+func init() {
+	ycsb.RegisterDBCreator("cosmos", cosmosCreator{})
+
+	prop.Register(prop.String,
+		cosmosEndpoint, cosmosKey, cosmosDatabase, cosmosConsistency, cosmosPartitionKey)
+	prop.Register(prop.Int, cosmosThroughput, cosmosPartitionBuckets, cosmosMaxRetries)
+	prop.Register(prop.Bool, cosmosUpsert, cosmosInsecureSkipVerify)
+	prop.Register(prop.Duration, cosmosTimeout)
+}
@@ -0,0 +1,305 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package couchbase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/couchbase/gocb/v2"
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/util"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+)
+
+// couchbase properties
+const (
+	couchbaseConnStr    = "couchbase.connstr"
+	couchbaseUser       = "couchbase.user"
+	couchbasePassword   = "couchbase.password"
+	couchbaseBucket     = "couchbase.bucket"
+	couchbaseScope      = "couchbase.scope"
+	couchbaseCollection = "couchbase.collection"
+	// "none", "majority", "majority_and_persist_to_active" or "persist_to_majority"
+	couchbaseDurability = "couchbase.durability"
+	// Use the KV service for Read/Insert/Update/Delete, or the query service if false
+	couchbaseKV = "couchbase.kv"
+	// "not_bounded" or "request_plus"
+	couchbaseQueryConsistency = "couchbase.query_consistency"
+	couchbaseTimeout          = "couchbase.timeout"
+)
+
+const defaultName = "_default"
+
+var durabilityLevels = map[string]gocb.DurabilityLevel{
+	// The zero value means no durability requirement.
+	"none":                           0,
+	"majority":                       gocb.DurabilityLevelMajority,
+	"majority_and_persist_to_active": gocb.DurabilityLevelMajorityAndPersistOnMaster,
+	"persist_to_majority":            gocb.DurabilityLevelPersistToMajority,
+}
+
+type couchbaseCreator struct {
+}
+
+type couchbaseDB struct {
+	cluster    *gocb.Cluster
+	collection *gocb.Collection
+	// keyspace is the N1QL keyspace of the collection.
+	keyspace string
+
+	kv           bool
+	durability   gocb.DurabilityLevel
+	queryOptions gocb.QueryOptions
+}
+
+func (c couchbaseCreator) Create(p *properties.Properties) (ycsb.DB, error) {
+	timeout := p.GetParsedDuration(couchbaseTimeout, 10*time.Second)
+	cluster, err := gocb.Connect(p.GetString(couchbaseConnStr, "couchbase://127.0.0.1"), gocb.ClusterOptions{
+		Username: p.GetString(couchbaseUser, "Administrator"),
+		Password: p.GetString(couchbasePassword, ""),
+		TimeoutsConfig: gocb.TimeoutsConfig{
+			KVTimeout:    timeout,
+			QueryTimeout: timeout,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	d := &couchbaseDB{
+		cluster: cluster,
+		kv:      p.GetBool(couchbaseKV, true),
+	}
+
+	durability := p.GetString(couchbaseDurability, "none")
+	level, ok := durabilityLevels[durability]
+	if !ok {
+		return nil, fmt.Errorf("unsupported durability %s", durability)
+	}
+	d.durability = level
+
+	switch consistency := p.GetString(couchbaseQueryConsistency, "not_bounded"); consistency {
+	case "not_bounded":
+		d.queryOptions.ScanConsistency = gocb.QueryScanConsistencyNotBounded
+	case "request_plus":
+		d.queryOptions.ScanConsistency = gocb.QueryScanConsistencyRequestPlus
+	default:
+		return nil, fmt.Errorf("unsupported query consistency %s", consistency)
+	}
+
+	bucketName := p.GetString(couchbaseBucket, "ycsb")
+	bucket := cluster.Bucket(bucketName)
+	if err = bucket.WaitUntilReady(timeout, nil); err != nil {
+		return nil, err
+	}
+
+	scopeName := p.GetString(couchbaseScope, defaultName)
+	collectionName := p.GetString(couchbaseCollection, defaultName)
+	if scopeName == defaultName && collectionName == defaultName {
+		d.collection = bucket.DefaultCollection()
+		d.keyspace = fmt.Sprintf("`%s`", bucketName)
+	} else {
+		d.collection = bucket.Scope(scopeName).Collection(collectionName)
+		d.keyspace = fmt.Sprintf("`%s`.`%s`.`%s`", bucketName, scopeName, collectionName)
+	}
+
+	// Scan and the query service require the primary index.
+	if !p.GetBool(prop.DoTransactions, true) {
+		if err = d.createPrimaryIndex(bucketName); err != nil {
+			return nil, err
+		}
+	}
+
+	return d, nil
+}
+
+func (db *couchbaseDB) createPrimaryIndex(bucketName string) error {
+	var err error
+	if db.keyspace == fmt.Sprintf("`%s`", bucketName) {
+		err = db.cluster.QueryIndexes().CreatePrimaryIndex(bucketName, &gocb.CreatePrimaryQueryIndexOptions{
+			IgnoreIfExists: true,
+		})
+	} else {
+		_, err = db.cluster.Query(fmt.Sprintf("CREATE PRIMARY INDEX ON %s", db.keyspace), nil)
+		if errors.Is(err, gocb.ErrIndexExists) {
+			err = nil
+		}
+	}
+	return err
+}
+
+func (db *couchbaseDB) Close() error {
+	return db.cluster.Close(nil)
+}
+
+func (db *couchbaseDB) InitThread(ctx context.Context, _ int, _ int) context.Context {
+	return ctx
+}
+
+func (db *couchbaseDB) CleanupThread(_ context.Context) {
+}
+
+func getDocID(table string, key string) string {
+	return fmt.Sprintf("%s:%s", table, key)
+}
+
+func getEndDocID(table string) string {
+	// ';' is ':' + 1 in the ASCII
+	return fmt.Sprintf("%s;", table)
+}
+
+// newDoc returns the document of the values, the values are stored as strings to be readable in N1QL.
+func newDoc(values map[string][]byte) map[string]string {
+	doc := make(map[string]string, len(values))
+	for field, value := range values {
+		doc[field] = string(value)
+	}
+	return doc
+}
+
+func decodeDoc(doc map[string]string) map[string][]byte {
+	res := make(map[string][]byte, len(doc))
+	for field, value := range doc {
+		res[field] = util.Slice(value)
+	}
+	return res
+}
+
+func (db *couchbaseDB) query(statement string, args ...interface{}) ([]map[string][]byte, error) {
+	opts := db.queryOptions
+	opts.PositionalParameters = args
+	result, err := db.cluster.Query(statement, &opts)
+	if err != nil {
+		return nil, err
+	}
+	defer result.Close()
+
+	var rows []map[string][]byte
+	for result.Next() {
+		var doc map[string]string
+		if err = result.Row(&doc); err != nil {
+			return nil, err
+		}
+		rows = append(rows, decodeDoc(doc))
+	}
+
+	return rows, result.Err()
+}
+
+func (db *couchbaseDB) selectFields(fields []string) string {
+	if len(fields) == 0 {
+		return "t.*"
+	}
+
+	quoted := make([]string, 0, len(fields))
+	for _, field := range fields {
+		quoted = append(quoted, fmt.Sprintf("t.`%s`", field))
+	}
+	return strings.Join(quoted, ", ")
+}
+
+func (db *couchbaseDB) Read(ctx context.Context, table string, key string, fields []string) (map[string][]byte, error) {
+	if !db.kv {
+		rows, err := db.query(fmt.Sprintf("SELECT %s FROM %s t USE KEYS $1", db.selectFields(fields), db.keyspace), getDocID(table, key))
+		if err != nil || len(rows) == 0 {
+			return nil, err
+		}
+		return rows[0], nil
+	}
+
+	result, err := db.collection.Get(getDocID(table, key), &gocb.GetOptions{Project: fields})
+	if errors.Is(err, gocb.ErrDocumentNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var doc map[string]string
+	if err = result.Content(&doc); err != nil {
+		return nil, err
+	}
+	return decodeDoc(doc), nil
+}
+
+// Scan always uses the query service, the KV service can't read a range.
+func (db *couchbaseDB) Scan(ctx context.Context, table string, startKey string, count int, fields []string) ([]map[string][]byte, error) {
+	statement := fmt.Sprintf("SELECT %s FROM %s t WHERE meta(t).id >= $1 AND meta(t).id < $2 ORDER BY meta(t).id LIMIT $3",
+		db.selectFields(fields), db.keyspace)
+	return db.query(statement, getDocID(table, startKey), getEndDocID(table), count)
+}
+
+func (db *couchbaseDB) Update(ctx context.Context, table string, key string, values map[string][]byte) error {
+	if !db.kv {
+		sets := make([]string, 0, len(values))
+		args := make([]interface{}, 0, 1+len(values))
+		args = append(args, getDocID(table, key))
+		for _, p := range util.NewFieldPairs(values) {
+			args = append(args, string(p.Value))
+			sets = append(sets, fmt.Sprintf("`%s` = $%d", p.Field, len(args)))
+		}
+		_, err := db.query(fmt.Sprintf("UPDATE %s USE KEYS $1 SET %s", db.keyspace, strings.Join(sets, ", ")), args...)
+		return err
+	}
+
+	specs := make([]gocb.MutateInSpec, 0, len(values))
+	for field, value := range values {
+		specs = append(specs, gocb.UpsertSpec(field, string(value), nil))
+	}
+	_, err := db.collection.MutateIn(getDocID(table, key), specs, &gocb.MutateInOptions{
+		DurabilityLevel: db.durability,
+	})
+	return err
+}
+
+func (db *couchbaseDB) Insert(ctx context.Context, table string, key string, values map[string][]byte) error {
+	if !db.kv {
+		_, err := db.query(fmt.Sprintf("UPSERT INTO %s (KEY, VALUE) VALUES ($1, $2)", db.keyspace), getDocID(table, key), newDoc(values))
+		return err
+	}
+
+	_, err := db.collection.Upsert(getDocID(table, key), newDoc(values), &gocb.UpsertOptions{
+		DurabilityLevel: db.durability,
+	})
+	return err
+}
+
+func (db *couchbaseDB) Delete(ctx context.Context, table string, key string) error {
+	if !db.kv {
+		_, err := db.query(fmt.Sprintf("DELETE FROM %s USE KEYS $1", db.keyspace), getDocID(table, key))
+		return err
+	}
+
+	_, err := db.collection.Remove(getDocID(table, key), &gocb.RemoveOptions{
+		DurabilityLevel: db.durability,
+	})
+	if errors.Is(err, gocb.ErrDocumentNotFound) {
+		return nil
+	}
+	return err
+}
+
+func init() {
+	ycsb.RegisterDBCreator("couchbase", couchbaseCreator{})
+
+	prop.Register(prop.String,
+		couchbaseConnStr, couchbaseUser, couchbasePassword, couchbaseBucket, couchbaseScope,
+		couchbaseCollection, couchbaseDurability, couchbaseQueryConsistency)
+	prop.Register(prop.Bool, couchbaseKV)
+	prop.Register(prop.Duration, couchbaseTimeout)
+}
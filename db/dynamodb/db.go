@@ -0,0 +1,418 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dynamodb
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/awserr"
+	"github.com/aws/aws-sdk-go-v2/aws/external"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/measurement"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/util"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+)
+
+// dynamodb properties
+const (
+	dynamodbRegion   = "dynamodb.region"
+	dynamodbEndpoint = "dynamodb.endpoint"
+	// "on_demand" or "provisioned"
+	dynamodbBillingMode    = "dynamodb.billing_mode"
+	dynamodbRCU            = "dynamodb.rcu"
+	dynamodbWCU            = "dynamodb.wcu"
+	dynamodbConsistentRead = "dynamodb.consistent_read"
+)
+
+const (
+	keyAttribute = "YCSB_KEY"
+
+	// The limits of the items in one BatchWriteItem and BatchGetItem request.
+	maxBatchWriteItems = 25
+	maxBatchGetItems   = 100
+)
+
+type dynamodbCreator struct {
+}
+
+type dynamodbDB struct {
+	p              *properties.Properties
+	client         *dynamodb.Client
+	consistentRead bool
+}
+
+func (c dynamodbCreator) Create(p *properties.Properties) (ycsb.DB, error) {
+	cfg, err := external.LoadDefaultAWSConfig()
+	if err != nil {
+		return nil, err
+	}
+	if region, ok := p.Get(dynamodbRegion); ok {
+		cfg.Region = region
+	}
+	if endpoint, ok := p.Get(dynamodbEndpoint); ok {
+		cfg.EndpointResolver = aws.ResolveWithEndpointURL(endpoint)
+	}
+
+	client := dynamodb.New(cfg)
+	// Every throttled attempt is measured, including the ones retried by the SDK,
+	// so throttling is not hidden in the latency of the succeeded operations.
+	client.Handlers.CompleteAttempt.PushBack(func(r *aws.Request) {
+		if isThrottled(r.Error) {
+			measurement.Measure("DYNAMODB_THROTTLED", 0)
+		}
+	})
+
+	db := &dynamodbDB{
+		p:              p,
+		client:         client,
+		consistentRead: p.GetBool(dynamodbConsistentRead, false),
+	}
+
+	if !p.GetBool(prop.DoTransactions, true) {
+		for _, tableName := range util.TableNames(p) {
+			if err := db.createTable(tableName); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return db, nil
+}
+
+func isThrottled(err error) bool {
+	if aerr, ok := err.(awserr.Error); ok {
+		switch aerr.Code() {
+		case dynamodb.ErrCodeProvisionedThroughputExceededException, dynamodb.ErrCodeRequestLimitExceeded, "ThrottlingException":
+			return true
+		}
+	}
+	return false
+}
+
+func isCode(err error, code string) bool {
+	aerr, ok := err.(awserr.Error)
+	return ok && aerr.Code() == code
+}
+
+func (db *dynamodbDB) createTable(tableName string) error {
+	ctx := context.Background()
+
+	if db.p.GetBool(prop.DropData, prop.DropDataDefault) {
+		_, err := db.client.DeleteTableRequest(&dynamodb.DeleteTableInput{TableName: aws.String(tableName)}).Send(ctx)
+		if err != nil && !isCode(err, dynamodb.ErrCodeResourceNotFoundException) {
+			return err
+		}
+		if err == nil {
+			if err = db.client.WaitUntilTableNotExists(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(tableName)}); err != nil {
+				return err
+			}
+		}
+	}
+
+	input := &dynamodb.CreateTableInput{
+		TableName: aws.String(tableName),
+		AttributeDefinitions: []dynamodb.AttributeDefinition{
+			{AttributeName: aws.String(keyAttribute), AttributeType: dynamodb.ScalarAttributeTypeS},
+		},
+		KeySchema: []dynamodb.KeySchemaElement{
+			{AttributeName: aws.String(keyAttribute), KeyType: dynamodb.KeyTypeHash},
+		},
+	}
+
+	switch mode := db.p.GetString(dynamodbBillingMode, "on_demand"); mode {
+	case "on_demand":
+		input.BillingMode = dynamodb.BillingModePayPerRequest
+	case "provisioned":
+		input.BillingMode = dynamodb.BillingModeProvisioned
+		input.ProvisionedThroughput = &dynamodb.ProvisionedThroughput{
+			ReadCapacityUnits:  aws.Int64(db.p.GetInt64(dynamodbRCU, 10)),
+			WriteCapacityUnits: aws.Int64(db.p.GetInt64(dynamodbWCU, 10)),
+		}
+	default:
+		return fmt.Errorf("unsupported billing mode %s", mode)
+	}
+
+	_, err := db.client.CreateTableRequest(input).Send(ctx)
+	if isCode(err, dynamodb.ErrCodeResourceInUseException) {
+		// The table exists already.
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	return db.client.WaitUntilTableExists(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(tableName)})
+}
+
+func (db *dynamodbDB) Close() error {
+	return nil
+}
+
+func (db *dynamodbDB) InitThread(ctx context.Context, _ int, _ int) context.Context {
+	return ctx
+}
+
+func (db *dynamodbDB) CleanupThread(_ context.Context) {
+}
+
+func itemKey(key string) map[string]dynamodb.AttributeValue {
+	return map[string]dynamodb.AttributeValue{
+		keyAttribute: {S: aws.String(key)},
+	}
+}
+
+func newItem(key string, values map[string][]byte) map[string]dynamodb.AttributeValue {
+	item := itemKey(key)
+	for field, value := range values {
+		item[field] = dynamodb.AttributeValue{B: value}
+	}
+	return item
+}
+
+func decodeItem(item map[string]dynamodb.AttributeValue) map[string][]byte {
+	res := make(map[string][]byte, len(item))
+	for field, value := range item {
+		if field == keyAttribute {
+			continue
+		}
+		res[field] = value.B
+	}
+	return res
+}
+
+// projection returns the projection expression and the attribute names of the fields,
+// the names are used to avoid conflicts with the DynamoDB reserved words.
+func projection(fields []string) (*string, map[string]string) {
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	names := make(map[string]string, len(fields))
+	placeholders := make([]string, 0, len(fields))
+	for i, field := range fields {
+		placeholder := fmt.Sprintf("#f%d", i)
+		names[placeholder] = field
+		placeholders = append(placeholders, placeholder)
+	}
+	return aws.String(strings.Join(placeholders, ",")), names
+}
+
+func (db *dynamodbDB) Read(ctx context.Context, table string, key string, fields []string) (map[string][]byte, error) {
+	expr, names := projection(fields)
+	resp, err := db.client.GetItemRequest(&dynamodb.GetItemInput{
+		TableName:                aws.String(table),
+		Key:                      itemKey(key),
+		ConsistentRead:           aws.Bool(db.consistentRead),
+		ProjectionExpression:     expr,
+		ExpressionAttributeNames: names,
+	}).Send(ctx)
+	if err != nil {
+		return nil, err
+	} else if resp.Item == nil {
+		return nil, nil
+	}
+
+	return decodeItem(resp.Item), nil
+}
+
+// Scan reads count items after the start key. DynamoDB only orders the items inside a partition,
+// so like other YCSB bindings the scan follows the table order, not the key order.
+func (db *dynamodbDB) Scan(ctx context.Context, table string, startKey string, count int, fields []string) ([]map[string][]byte, error) {
+	expr, names := projection(fields)
+	input := &dynamodb.ScanInput{
+		TableName:                aws.String(table),
+		ExclusiveStartKey:        itemKey(startKey),
+		ConsistentRead:           aws.Bool(db.consistentRead),
+		ProjectionExpression:     expr,
+		ExpressionAttributeNames: names,
+	}
+
+	res := make([]map[string][]byte, 0, count)
+	for len(res) < count {
+		input.Limit = aws.Int64(int64(count - len(res)))
+		resp, err := db.client.ScanRequest(input).Send(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range resp.Items {
+			res = append(res, decodeItem(item))
+		}
+
+		if len(resp.LastEvaluatedKey) == 0 {
+			break
+		}
+		input.ExclusiveStartKey = resp.LastEvaluatedKey
+	}
+
+	return res, nil
+}
+
+func (db *dynamodbDB) Update(ctx context.Context, table string, key string, values map[string][]byte) error {
+	names := make(map[string]string, len(values))
+	attrValues := make(map[string]dynamodb.AttributeValue, len(values))
+	sets := make([]string, 0, len(values))
+	for i, p := range util.NewFieldPairs(values) {
+		names[fmt.Sprintf("#f%d", i)] = p.Field
+		attrValues[fmt.Sprintf(":v%d", i)] = dynamodb.AttributeValue{B: p.Value}
+		sets = append(sets, fmt.Sprintf("#f%d = :v%d", i, i))
+	}
+
+	_, err := db.client.UpdateItemRequest(&dynamodb.UpdateItemInput{
+		TableName:                 aws.String(table),
+		Key:                       itemKey(key),
+		UpdateExpression:          aws.String("SET " + strings.Join(sets, ", ")),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: attrValues,
+	}).Send(ctx)
+	return err
+}
+
+func (db *dynamodbDB) Insert(ctx context.Context, table string, key string, values map[string][]byte) error {
+	_, err := db.client.PutItemRequest(&dynamodb.PutItemInput{
+		TableName: aws.String(table),
+		Item:      newItem(key, values),
+	}).Send(ctx)
+	return err
+}
+
+func (db *dynamodbDB) Delete(ctx context.Context, table string, key string) error {
+	_, err := db.client.DeleteItemRequest(&dynamodb.DeleteItemInput{
+		TableName: aws.String(table),
+		Key:       itemKey(key),
+	}).Send(ctx)
+	return err
+}
+
+// batchWrite sends the write requests with BatchWriteItem, the unprocessed items are resent
+// until all of them are written.
+func (db *dynamodbDB) batchWrite(ctx context.Context, table string, reqs []dynamodb.WriteRequest) error {
+	for start := 0; start < len(reqs); start += maxBatchWriteItems {
+		end := start + maxBatchWriteItems
+		if end > len(reqs) {
+			end = len(reqs)
+		}
+
+		items := map[string][]dynamodb.WriteRequest{table: reqs[start:end]}
+		for len(items) > 0 {
+			resp, err := db.client.BatchWriteItemRequest(&dynamodb.BatchWriteItemInput{
+				RequestItems: items,
+			}).Send(ctx)
+			if err != nil {
+				return err
+			}
+
+			// The unprocessed items are usually caused by throttling.
+			if len(resp.UnprocessedItems) > 0 {
+				measurement.Measure("DYNAMODB_UNPROCESSED", 0)
+			}
+			items = resp.UnprocessedItems
+		}
+	}
+	return nil
+}
+
+func (db *dynamodbDB) BatchInsert(ctx context.Context, table string, keys []string, values []map[string][]byte) error {
+	reqs := make([]dynamodb.WriteRequest, 0, len(keys))
+	for i, key := range keys {
+		reqs = append(reqs, dynamodb.WriteRequest{
+			PutRequest: &dynamodb.PutRequest{Item: newItem(key, values[i])},
+		})
+	}
+	return db.batchWrite(ctx, table, reqs)
+}
+
+func (db *dynamodbDB) BatchRead(ctx context.Context, table string, keys []string, fields []string) ([]map[string][]byte, error) {
+	expr, names := projection(fields)
+	if expr != nil {
+		// The key is needed to match the items to the keys.
+		names["#k"] = keyAttribute
+		expr = aws.String(*expr + ",#k")
+	}
+
+	rows := make(map[string]map[string][]byte, len(keys))
+	for start := 0; start < len(keys); start += maxBatchGetItems {
+		end := start + maxBatchGetItems
+		if end > len(keys) {
+			end = len(keys)
+		}
+
+		itemKeys := make([]map[string]dynamodb.AttributeValue, 0, end-start)
+		for _, key := range keys[start:end] {
+			itemKeys = append(itemKeys, itemKey(key))
+		}
+
+		items := map[string]dynamodb.KeysAndAttributes{table: {
+			Keys:                     itemKeys,
+			ConsistentRead:           aws.Bool(db.consistentRead),
+			ProjectionExpression:     expr,
+			ExpressionAttributeNames: names,
+		}}
+		for len(items) > 0 {
+			resp, err := db.client.BatchGetItemRequest(&dynamodb.BatchGetItemInput{
+				RequestItems: items,
+			}).Send(ctx)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, item := range resp.Responses[table] {
+				rows[aws.StringValue(item[keyAttribute].S)] = decodeItem(item)
+			}
+
+			if len(resp.UnprocessedKeys) > 0 {
+				measurement.Measure("DYNAMODB_UNPROCESSED", 0)
+			}
+			items = resp.UnprocessedKeys
+		}
+	}
+
+	res := make([]map[string][]byte, len(keys))
+	for i, key := range keys {
+		res[i] = rows[key]
+	}
+	return res, nil
+}
+
+func (db *dynamodbDB) BatchUpdate(ctx context.Context, table string, keys []string, values []map[string][]byte) error {
+	// BatchWriteItem can only replace the whole item, so the items are updated one by one.
+	for i, key := range keys {
+		if err := db.Update(ctx, table, key, values[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (db *dynamodbDB) BatchDelete(ctx context.Context, table string, keys []string) error {
+	reqs := make([]dynamodb.WriteRequest, 0, len(keys))
+	for _, key := range keys {
+		reqs = append(reqs, dynamodb.WriteRequest{
+			DeleteRequest: &dynamodb.DeleteRequest{Key: itemKey(key)},
+		})
+	}
+	return db.batchWrite(ctx, table, reqs)
+}
+
+func init() {
+	ycsb.RegisterDBCreator("dynamodb", dynamodbCreator{})
+
+	prop.Register(prop.String, dynamodbRegion, dynamodbEndpoint, dynamodbBillingMode)
+	prop.Register(prop.Int, dynamodbRCU, dynamodbWCU)
+	prop.Register(prop.Bool, dynamodbConsistentRead)
+}
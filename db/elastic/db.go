@@ -0,0 +1,396 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elastic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/magiconair/properties"
+	"github.com/olivere/elastic/v7"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/util"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+	"go.uber.org/zap"
+)
+
+// elastic properties
+const (
+	elasticURLs     = "elastic.urls"
+	elasticUsername = "elastic.username"
+	elasticPassword = "elastic.password"
+	// Sniffing must be disabled if the nodes are not reachable by their published addresses, like in docker.
+	elasticSniff           = "elastic.sniff"
+	elasticShards          = "elastic.shards"
+	elasticReplicas        = "elastic.replicas"
+	elasticRefreshInterval = "elastic.refresh_interval"
+	// Inserts in the load phase are buffered per thread and indexed in one bulk request.
+	elasticBatchSize = "elastic.batchsize"
+	// Comma separated fields to index when the index is created, so the records can be queried by them.
+	elasticIndexedFields = "elastic.indexed_fields"
+)
+
+const keyField = "YCSB_KEY"
+
+type elasticCreator struct {
+}
+
+type elasticDB struct {
+	client    *elastic.Client
+	batchSize int
+}
+
+type contextKey string
+
+const stateKey = contextKey("elasticDB")
+
+type elasticState struct {
+	pending []elastic.BulkableRequest
+}
+
+func (c elasticCreator) Create(p *properties.Properties) (ycsb.DB, error) {
+	urls := strings.Split(p.GetString(elasticURLs, "http://127.0.0.1:9200"), ",")
+	for i := range urls {
+		urls[i] = strings.TrimSpace(urls[i])
+	}
+
+	opts := []elastic.ClientOptionFunc{
+		elastic.SetURL(urls...),
+		elastic.SetSniff(p.GetBool(elasticSniff, false)),
+	}
+	if username, ok := p.Get(elasticUsername); ok {
+		opts = append(opts, elastic.SetBasicAuth(username, p.GetString(elasticPassword, "")))
+	}
+
+	client, err := elastic.NewClient(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &elasticDB{
+		client:    client,
+		batchSize: 1,
+	}
+
+	if !p.GetBool(prop.DoTransactions, true) {
+		for _, tableName := range util.TableNames(p) {
+			if err = d.createIndex(p, tableName); err != nil {
+				return nil, err
+			}
+		}
+		d.batchSize = p.GetInt(elasticBatchSize, 1000)
+	}
+
+	return d, nil
+}
+
+// indexName returns the index of the table, the index name must be lowercase.
+func indexName(table string) string {
+	return strings.ToLower(table)
+}
+
+func (db *elasticDB) createIndex(p *properties.Properties, table string) error {
+	ctx := context.Background()
+	index := indexName(table)
+
+	existed, err := db.client.IndexExists(index).Do(ctx)
+	if err != nil {
+		return err
+	}
+
+	if existed {
+		if !p.GetBool(prop.DropData, prop.DropDataDefault) {
+			return nil
+		}
+		if _, err = db.client.DeleteIndex(index).Do(ctx); err != nil {
+			return err
+		}
+	}
+
+	// Only the key and the indexed fields are indexed, the other fields are only stored in the source.
+	properties := map[string]interface{}{
+		keyField: map[string]interface{}{"type": "keyword"},
+	}
+	for _, field := range strings.Split(p.GetString(elasticIndexedFields, ""), ",") {
+		if field = strings.TrimSpace(field); len(field) > 0 {
+			properties[field] = map[string]interface{}{"type": "keyword"}
+		}
+	}
+
+	body := map[string]interface{}{
+		"settings": map[string]interface{}{
+			"number_of_shards":   p.GetInt(elasticShards, 1),
+			"number_of_replicas": p.GetInt(elasticReplicas, 0),
+			"refresh_interval":   p.GetString(elasticRefreshInterval, "1s"),
+		},
+		"mappings": map[string]interface{}{
+			"dynamic_templates": []interface{}{
+				map[string]interface{}{
+					"fields": map[string]interface{}{
+						"match_mapping_type": "string",
+						"mapping": map[string]interface{}{
+							"type":       "keyword",
+							"index":      false,
+							"doc_values": false,
+						},
+					},
+				},
+			},
+			"properties": properties,
+		},
+	}
+
+	_, err = db.client.CreateIndex(index).BodyJson(body).Do(ctx)
+	return err
+}
+
+// BufferedInserts implements the BufferedDB BufferedInserts interface.
+func (db *elasticDB) BufferedInserts() bool {
+	return db.batchSize > 1
+}
+
+func (db *elasticDB) Close() error {
+	db.client.Stop()
+	return nil
+}
+
+func (db *elasticDB) InitThread(ctx context.Context, _ int, _ int) context.Context {
+	return context.WithValue(ctx, stateKey, new(elasticState))
+}
+
+func (db *elasticDB) CleanupThread(ctx context.Context) {
+	state := ctx.Value(stateKey).(*elasticState)
+
+	if err := db.flushPending(ctx, state); err != nil {
+		util.Logger().Error("flush pending documents failed", zap.Error(err))
+	}
+}
+
+// newDoc returns the document of the values, the values are stored as strings to be readable.
+func newDoc(key string, values map[string][]byte) map[string]string {
+	doc := make(map[string]string, 1+len(values))
+	doc[keyField] = key
+	for field, value := range values {
+		doc[field] = string(value)
+	}
+	return doc
+}
+
+func decodeDoc(source json.RawMessage) (map[string][]byte, error) {
+	var doc map[string]string
+	if err := json.Unmarshal(source, &doc); err != nil {
+		return nil, err
+	}
+
+	res := make(map[string][]byte, len(doc))
+	for field, value := range doc {
+		if field == keyField {
+			continue
+		}
+		res[field] = util.Slice(value)
+	}
+	return res, nil
+}
+
+func fetchSource(fields []string) *elastic.FetchSourceContext {
+	ctx := elastic.NewFetchSourceContext(true)
+	if len(fields) > 0 {
+		ctx.Include(fields...)
+	}
+	return ctx
+}
+
+func (db *elasticDB) Read(ctx context.Context, table string, key string, fields []string) (map[string][]byte, error) {
+	res, err := db.client.Get().Index(indexName(table)).Id(key).FetchSourceContext(fetchSource(fields)).Do(ctx)
+	if elastic.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	} else if !res.Found {
+		return nil, nil
+	}
+
+	return decodeDoc(res.Source)
+}
+
+func (db *elasticDB) Scan(ctx context.Context, table string, startKey string, count int, fields []string) ([]map[string][]byte, error) {
+	res, err := db.client.Search(indexName(table)).
+		Query(elastic.NewRangeQuery(keyField).Gte(startKey)).
+		Sort(keyField, true).
+		Size(count).
+		FetchSourceContext(fetchSource(fields)).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]map[string][]byte, 0, len(res.Hits.Hits))
+	for _, hit := range res.Hits.Hits {
+		row, err := decodeDoc(hit.Source)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// Query searches the records by the field, the field must be indexed by elastic.indexed_fields.
+func (db *elasticDB) Query(ctx context.Context, table string, field string, value []byte, count int, fields []string) ([]map[string][]byte, error) {
+	res, err := db.client.Search(indexName(table)).
+		Query(elastic.NewTermQuery(field, string(value))).
+		Size(count).
+		FetchSourceContext(fetchSource(fields)).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]map[string][]byte, 0, len(res.Hits.Hits))
+	for _, hit := range res.Hits.Hits {
+		row, err := decodeDoc(hit.Source)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func (db *elasticDB) Update(ctx context.Context, table string, key string, values map[string][]byte) error {
+	doc := make(map[string]string, len(values))
+	for field, value := range values {
+		doc[field] = string(value)
+	}
+
+	_, err := db.client.Update().Index(indexName(table)).Id(key).Doc(doc).Do(ctx)
+	return err
+}
+
+func (db *elasticDB) Insert(ctx context.Context, table string, key string, values map[string][]byte) error {
+	// The document copies the values, so the values can be reused by the workload after Insert returns.
+	doc := newDoc(key, values)
+
+	if db.batchSize <= 1 {
+		_, err := db.client.Index().Index(indexName(table)).Id(key).BodyJson(doc).Do(ctx)
+		return err
+	}
+
+	state := ctx.Value(stateKey).(*elasticState)
+	state.pending = append(state.pending, elastic.NewBulkIndexRequest().Index(indexName(table)).Id(key).Doc(doc))
+
+	if len(state.pending) < db.batchSize {
+		return nil
+	}
+
+	return db.flushPending(ctx, state)
+}
+
+func (db *elasticDB) flushPending(ctx context.Context, state *elasticState) error {
+	if len(state.pending) == 0 {
+		return nil
+	}
+
+	err := db.bulk(ctx, state.pending)
+	state.pending = state.pending[:0]
+	return err
+}
+
+func (db *elasticDB) bulk(ctx context.Context, reqs []elastic.BulkableRequest) error {
+	res, err := db.client.Bulk().Add(reqs...).Do(ctx)
+	if err != nil {
+		return err
+	}
+
+	if failed := res.Failed(); len(failed) > 0 {
+		reason := fmt.Sprintf("status %d", failed[0].Status)
+		if failed[0].Error != nil {
+			reason = failed[0].Error.Reason
+		}
+		return fmt.Errorf("%d of %d bulk requests failed, the first error: %s", len(failed), len(reqs), reason)
+	}
+	return nil
+}
+
+func (db *elasticDB) Delete(ctx context.Context, table string, key string) error {
+	_, err := db.client.Delete().Index(indexName(table)).Id(key).Do(ctx)
+	if elastic.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+func (db *elasticDB) BatchInsert(ctx context.Context, table string, keys []string, values []map[string][]byte) error {
+	reqs := make([]elastic.BulkableRequest, 0, len(keys))
+	for i, key := range keys {
+		reqs = append(reqs, elastic.NewBulkIndexRequest().Index(indexName(table)).Id(key).Doc(newDoc(key, values[i])))
+	}
+	return db.bulk(ctx, reqs)
+}
+
+func (db *elasticDB) BatchRead(ctx context.Context, table string, keys []string, fields []string) ([]map[string][]byte, error) {
+	mget := db.client.Mget()
+	for _, key := range keys {
+		mget.Add(elastic.NewMultiGetItem().Index(indexName(table)).Id(key).FetchSource(fetchSource(fields)))
+	}
+
+	res, err := mget.Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]map[string][]byte, len(keys))
+	for i, doc := range res.Docs {
+		if !doc.Found {
+			continue
+		}
+		if rows[i], err = decodeDoc(doc.Source); err != nil {
+			return nil, err
+		}
+	}
+	return rows, nil
+}
+
+func (db *elasticDB) BatchUpdate(ctx context.Context, table string, keys []string, values []map[string][]byte) error {
+	reqs := make([]elastic.BulkableRequest, 0, len(keys))
+	for i, key := range keys {
+		doc := make(map[string]string, len(values[i]))
+		for field, value := range values[i] {
+			doc[field] = string(value)
+		}
+		reqs = append(reqs, elastic.NewBulkUpdateRequest().Index(indexName(table)).Id(key).Doc(doc))
+	}
+	return db.bulk(ctx, reqs)
+}
+
+func (db *elasticDB) BatchDelete(ctx context.Context, table string, keys []string) error {
+	reqs := make([]elastic.BulkableRequest, 0, len(keys))
+	for _, key := range keys {
+		reqs = append(reqs, elastic.NewBulkDeleteRequest().Index(indexName(table)).Id(key))
+	}
+	return db.bulk(ctx, reqs)
+}
+
+func init() {
+	ycsb.RegisterDBCreator("elastic", elasticCreator{})
+	ycsb.RegisterDBCreator("elasticsearch", elasticCreator{})
+	ycsb.RegisterDBCreator("opensearch", elasticCreator{})
+
+	prop.Register(prop.String,
+		elasticURLs, elasticUsername, elasticPassword, elasticRefreshInterval, elasticIndexedFields)
+	prop.Register(prop.Int, elasticShards, elasticReplicas, elasticBatchSize)
+	prop.Register(prop.Bool, elasticSniff)
+}
@@ -0,0 +1,198 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/util"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+)
+
+// etcd properties
+const (
+	etcdEndpoints   = "etcd.endpoints"
+	etcdDialTimeout = "etcd.dial_timeout"
+	// "linearizable" or "serializable"
+	etcdReadConsistency = "etcd.read_consistency"
+)
+
+type etcdCreator struct {
+}
+
+type etcdDB struct {
+	client  *clientv3.Client
+	r       *util.RowCodec
+	bufPool *util.BufPool
+
+	readOpts []clientv3.OpOption
+}
+
+func (c etcdCreator) Create(p *properties.Properties) (ycsb.DB, error) {
+	endpoints := strings.Split(p.GetString(etcdEndpoints, "127.0.0.1:2379"), ",")
+	for i := range endpoints {
+		endpoints[i] = strings.TrimSpace(endpoints[i])
+	}
+
+	var readOpts []clientv3.OpOption
+	switch consistency := p.GetString(etcdReadConsistency, "linearizable"); consistency {
+	case "linearizable":
+	case "serializable":
+		readOpts = append(readOpts, clientv3.WithSerializable())
+	default:
+		return nil, fmt.Errorf("unsupported read consistency %s", consistency)
+	}
+
+	client, err := clientv3.New(clientv3.Config{
+		Endpoints:   endpoints,
+		DialTimeout: p.GetParsedDuration(etcdDialTimeout, 2*time.Second),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &etcdDB{
+		client:   client,
+		r:        util.NewRowCodec(p),
+		bufPool:  util.NewBufPool(),
+		readOpts: readOpts,
+	}, nil
+}
+
+func (db *etcdDB) Close() error {
+	return db.client.Close()
+}
+
+func (db *etcdDB) InitThread(ctx context.Context, _ int, _ int) context.Context {
+	return ctx
+}
+
+func (db *etcdDB) CleanupThread(_ context.Context) {
+}
+
+func getRowKey(table string, key string) string {
+	return fmt.Sprintf("%s/%s", table, key)
+}
+
+func getEndRowKey(table string) string {
+	// '0' is '/' + 1 in the ASCII
+	return fmt.Sprintf("%s0", table)
+}
+
+func (db *etcdDB) Read(ctx context.Context, table string, key string, fields []string) (map[string][]byte, error) {
+	resp, err := db.client.Get(ctx, getRowKey(table, key), db.readOpts...)
+	if err != nil {
+		return nil, err
+	} else if len(resp.Kvs) == 0 {
+		return nil, nil
+	}
+
+	return db.r.Decode(resp.Kvs[0].Value, fields)
+}
+
+func (db *etcdDB) Scan(ctx context.Context, table string, startKey string, count int, fields []string) ([]map[string][]byte, error) {
+	opts := append([]clientv3.OpOption{
+		clientv3.WithRange(getEndRowKey(table)),
+		clientv3.WithLimit(int64(count)),
+	}, db.readOpts...)
+
+	resp, err := db.client.Get(ctx, getRowKey(table, startKey), opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]map[string][]byte, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		v, err := db.r.Decode(kv.Value, fields)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, v)
+	}
+
+	return res, nil
+}
+
+// Update merges the values into the stored row, the put only succeeds if the row is not
+// changed since it was read.
+func (db *etcdDB) Update(ctx context.Context, table string, key string, values map[string][]byte) error {
+	rowKey := getRowKey(table, key)
+	resp, err := db.client.Get(ctx, rowKey)
+	if err != nil {
+		return err
+	} else if len(resp.Kvs) == 0 {
+		return nil
+	}
+
+	kv := resp.Kvs[0]
+	data, err := db.r.Decode(kv.Value, nil)
+	if err != nil {
+		return err
+	}
+
+	for field, value := range values {
+		data[field] = value
+	}
+
+	buf := db.bufPool.Get()
+	defer db.bufPool.Put(buf)
+
+	rowData, err := db.r.Encode(buf.Bytes(), data)
+	if err != nil {
+		return err
+	}
+
+	txnResp, err := db.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.ModRevision(rowKey), "=", kv.ModRevision)).
+		Then(clientv3.OpPut(rowKey, string(rowData))).
+		Commit()
+	if err != nil {
+		return err
+	} else if !txnResp.Succeeded {
+		return fmt.Errorf("key %s is modified concurrently", rowKey)
+	}
+
+	return nil
+}
+
+func (db *etcdDB) Insert(ctx context.Context, table string, key string, values map[string][]byte) error {
+	buf := db.bufPool.Get()
+	defer db.bufPool.Put(buf)
+
+	rowData, err := db.r.Encode(buf.Bytes(), values)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.client.Put(ctx, getRowKey(table, key), string(rowData))
+	return err
+}
+
+func (db *etcdDB) Delete(ctx context.Context, table string, key string) error {
+	_, err := db.client.Delete(ctx, getRowKey(table, key))
+	return err
+}
+
+func init() {
+	ycsb.RegisterDBCreator("etcd", etcdCreator{})
+
+	prop.Register(prop.String, etcdEndpoints, etcdReadConsistency)
+	prop.Register(prop.Duration, etcdDialTimeout)
+}
@@ -0,0 +1,346 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package external
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"plugin"
+	"sync"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+)
+
+// external properties
+const (
+	// The command line of the driver process, which is run by "sh -c".
+	externalCommand = "external.command"
+	// Number of the driver processes, the threads are assigned to the processes in turn.
+	externalProcesses = "external.processes"
+	// The path of the Go plugin which exports "DBCreator" of the type ycsb.DBCreator,
+	// the command is ignored if it is set.
+	externalPlugin  = "external.plugin"
+	externalTimeout = "external.timeout"
+)
+
+type contextKey string
+
+const stateKey = contextKey("externalDB")
+
+type externalCreator struct {
+}
+
+// request is a JSON line written to the stdin of the driver process, the driver may handle the
+// requests concurrently and respond in any order, the response is matched by the id.
+type request struct {
+	ID         uint64            `json:"id"`
+	Op         string            `json:"op"`
+	Properties map[string]string `json:"properties,omitempty"`
+	Table      string            `json:"table,omitempty"`
+	Key        string            `json:"key,omitempty"`
+	Fields     []string          `json:"fields,omitempty"`
+	Count      int               `json:"count,omitempty"`
+	Values     map[string][]byte `json:"values,omitempty"`
+}
+
+// response is a JSON line read from the stdout of the driver process, the values are base64 encoded.
+type response struct {
+	ID      uint64              `json:"id"`
+	Error   string              `json:"error,omitempty"`
+	Record  map[string][]byte   `json:"record,omitempty"`
+	Records []map[string][]byte `json:"records,omitempty"`
+}
+
+// process is a driver process, the requests of multiple threads are multiplexed on its stdin and stdout.
+type process struct {
+	cmd *exec.Cmd
+
+	writeMu sync.Mutex
+	stdin   io.WriteCloser
+	w       *bufio.Writer
+	enc     *json.Encoder
+
+	mu     sync.Mutex
+	nextID uint64
+	calls  map[uint64]chan *response
+	// err is set when the stdout is closed, all the following calls fail with it.
+	err error
+
+	done chan struct{}
+}
+
+type externalDB struct {
+	processes []*process
+	timeout   time.Duration
+}
+
+type externalState struct {
+	proc *process
+}
+
+func (c externalCreator) Create(p *properties.Properties) (ycsb.DB, error) {
+	if path, ok := p.Get(externalPlugin); ok {
+		return createPluginDB(path, p)
+	}
+
+	command, ok := p.Get(externalCommand)
+	if !ok {
+		return nil, fmt.Errorf("%s or %s must be set", externalCommand, externalPlugin)
+	}
+
+	d := &externalDB{
+		processes: make([]*process, p.GetInt(externalProcesses, 1)),
+		timeout:   p.GetParsedDuration(externalTimeout, 0),
+	}
+	if len(d.processes) == 0 {
+		return nil, fmt.Errorf("%s must be positive", externalProcesses)
+	}
+
+	for i := range d.processes {
+		proc, err := startProcess(command)
+		if err != nil {
+			d.Close()
+			return nil, err
+		}
+		d.processes[i] = proc
+
+		// The driver creates its connections by the properties.
+		if _, err = proc.call(context.Background(), &request{Op: "init", Properties: p.Map()}); err != nil {
+			d.Close()
+			return nil, fmt.Errorf("init driver process failed %v", err)
+		}
+	}
+
+	return d, nil
+}
+
+// createPluginDB creates the DB by the creator exported by the Go plugin, which must be built by
+// the same Go version and dependencies as go-ycsb.
+func createPluginDB(path string, p *properties.Properties) (ycsb.DB, error) {
+	plug, err := plugin.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	sym, err := plug.Lookup("DBCreator")
+	if err != nil {
+		return nil, err
+	}
+
+	creator, ok := sym.(*ycsb.DBCreator)
+	if !ok || *creator == nil {
+		return nil, fmt.Errorf("DBCreator of %s must be a non-nil ycsb.DBCreator variable", path)
+	}
+	return (*creator).Create(p)
+}
+
+func startProcess(command string) (*process, error) {
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Stderr = os.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err = cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	proc := &process{
+		cmd:   cmd,
+		stdin: stdin,
+		w:     bufio.NewWriter(stdin),
+		calls: make(map[uint64]chan *response),
+		done:  make(chan struct{}),
+	}
+	proc.enc = json.NewEncoder(proc.w)
+
+	go proc.readResponses(stdout)
+	return proc, nil
+}
+
+func (proc *process) readResponses(stdout io.Reader) {
+	defer close(proc.done)
+
+	dec := json.NewDecoder(bufio.NewReader(stdout))
+	for {
+		resp := new(response)
+		if err := dec.Decode(resp); err != nil {
+			if err == io.EOF {
+				err = errors.New("driver process exited")
+			}
+			proc.fail(err)
+			return
+		}
+
+		proc.mu.Lock()
+		ch, ok := proc.calls[resp.ID]
+		delete(proc.calls, resp.ID)
+		proc.mu.Unlock()
+
+		if ok {
+			ch <- resp
+		}
+	}
+}
+
+// fail fails all the pending and the following calls with err.
+func (proc *process) fail(err error) {
+	proc.mu.Lock()
+	defer proc.mu.Unlock()
+
+	proc.err = err
+	for id, ch := range proc.calls {
+		ch <- &response{ID: id, Error: err.Error()}
+		delete(proc.calls, id)
+	}
+}
+
+func (proc *process) call(ctx context.Context, req *request) (*response, error) {
+	ch := make(chan *response, 1)
+
+	proc.mu.Lock()
+	if proc.err != nil {
+		proc.mu.Unlock()
+		return nil, proc.err
+	}
+	proc.nextID++
+	req.ID = proc.nextID
+	proc.calls[req.ID] = ch
+	proc.mu.Unlock()
+
+	proc.writeMu.Lock()
+	err := proc.enc.Encode(req)
+	if err == nil {
+		err = proc.w.Flush()
+	}
+	proc.writeMu.Unlock()
+
+	if err != nil {
+		proc.mu.Lock()
+		delete(proc.calls, req.ID)
+		proc.mu.Unlock()
+		return nil, err
+	}
+
+	select {
+	case resp := <-ch:
+		if len(resp.Error) > 0 {
+			return nil, errors.New(resp.Error)
+		}
+		return resp, nil
+	case <-ctx.Done():
+		proc.mu.Lock()
+		delete(proc.calls, req.ID)
+		proc.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+// close asks the driver process to close and waits for it to exit.
+func (proc *process) close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	proc.call(ctx, &request{Op: "close"})
+	cancel()
+	proc.stdin.Close()
+	<-proc.done
+	return proc.cmd.Wait()
+}
+
+func (db *externalDB) Close() error {
+	var err error
+	for _, proc := range db.processes {
+		if proc == nil {
+			continue
+		}
+		if e := proc.close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+func (db *externalDB) InitThread(ctx context.Context, threadID int, _ int) context.Context {
+	state := &externalState{
+		proc: db.processes[threadID%len(db.processes)],
+	}
+
+	return context.WithValue(ctx, stateKey, state)
+}
+
+func (db *externalDB) CleanupThread(_ context.Context) {
+}
+
+func (db *externalDB) call(ctx context.Context, req *request) (*response, error) {
+	if db.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, db.timeout)
+		defer cancel()
+	}
+
+	state := ctx.Value(stateKey).(*externalState)
+	return state.proc.call(ctx, req)
+}
+
+func (db *externalDB) Read(ctx context.Context, table string, key string, fields []string) (map[string][]byte, error) {
+	resp, err := db.call(ctx, &request{Op: "read", Table: table, Key: key, Fields: fields})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Record, nil
+}
+
+func (db *externalDB) Scan(ctx context.Context, table string, startKey string, count int, fields []string) ([]map[string][]byte, error) {
+	resp, err := db.call(ctx, &request{Op: "scan", Table: table, Key: startKey, Count: count, Fields: fields})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Records, nil
+}
+
+func (db *externalDB) Update(ctx context.Context, table string, key string, values map[string][]byte) error {
+	_, err := db.call(ctx, &request{Op: "update", Table: table, Key: key, Values: values})
+	return err
+}
+
+func (db *externalDB) Insert(ctx context.Context, table string, key string, values map[string][]byte) error {
+	_, err := db.call(ctx, &request{Op: "insert", Table: table, Key: key, Values: values})
+	return err
+}
+
+func (db *externalDB) Delete(ctx context.Context, table string, key string) error {
+	_, err := db.call(ctx, &request{Op: "delete", Table: table, Key: key})
+	return err
+}
+
+func init() {
+	ycsb.RegisterDBCreator("external", externalCreator{})
+
+	prop.Register(prop.String, externalCommand, externalPlugin)
+	prop.Register(prop.Int, externalProcesses)
+	prop.Register(prop.Duration, externalTimeout)
+}
@@ -20,21 +20,34 @@ import (
 	"fmt"
 
 	"github.com/apple/foundationdb/bindings/go/src/fdb"
+	"github.com/apple/foundationdb/bindings/go/src/fdb/tuple"
 	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
 	"github.com/pingcap/go-ycsb/pkg/util"
 	"github.com/pingcap/go-ycsb/pkg/ycsb"
+	"go.uber.org/zap"
 )
 
 const (
 	fdbClusterFile = "fdb.cluster"
 	fdbDatabase    = "fdb.dbname"
 	fdbAPIVersion  = "fdb.apiversion"
+	// The max number of writes grouped in one transaction.
+	fdbBatchSize = "fdb.batchsize"
 )
 
+type contextKey string
+
+const stateKey = contextKey("fDB")
+
+type fdbState struct {
+	// pending is the packed inserts not committed yet.
+	pending []fdb.KeyValue
+}
+
 type fDB struct {
-	db      fdb.Database
-	r       *util.RowCodec
-	bufPool *util.BufPool
+	db        fdb.Database
+	batchSize int
 }
 
 func createDB(p *properties.Properties) (ycsb.DB, error) {
@@ -49,57 +62,150 @@ func createDB(p *properties.Properties) (ycsb.DB, error) {
 		return nil, err
 	}
 
-	bufPool := util.NewBufPool()
+	batchSize := p.GetInt(fdbBatchSize, 1)
+	if batchSize <= 0 {
+		return nil, fmt.Errorf("%s must be positive, but got %d", fdbBatchSize, batchSize)
+	}
 
 	return &fDB{
-		db:      db,
-		r:       util.NewRowCodec(p),
-		bufPool: bufPool,
+		db:        db,
+		batchSize: batchSize,
 	}, nil
 }
 
+// BufferedInserts implements the BufferedDB BufferedInserts interface.
+func (db *fDB) BufferedInserts() bool {
+	return db.batchSize > 1
+}
+
 func (db *fDB) Close() error {
 	return nil
 }
 
 func (db *fDB) InitThread(ctx context.Context, _ int, _ int) context.Context {
-	return ctx
+	return context.WithValue(ctx, stateKey, new(fdbState))
 }
 
 func (db *fDB) CleanupThread(ctx context.Context) {
+	state := ctx.Value(stateKey).(*fdbState)
+
+	if err := db.flushPending(state); err != nil {
+		util.Logger().Error("flush pending rows failed", zap.Error(err))
+	}
+}
+
+func (db *fDB) getRowKey(table string, key string) fdb.Key {
+	return tuple.Tuple{table, key}.Pack()
+}
+
+// encodeRow packs the fields as a tuple of the field and value pairs.
+func encodeRow(values map[string][]byte) []byte {
+	t := make(tuple.Tuple, 0, 2*len(values))
+	for field, value := range values {
+		t = append(t, field, value)
+	}
+	return t.Pack()
+}
+
+// decodeRow unpacks the row, only the given fields are returned if fields is not empty.
+func decodeRow(row []byte, fields []string) (map[string][]byte, error) {
+	t, err := tuple.Unpack(row)
+	if err != nil {
+		return nil, err
+	}
+	if len(t)%2 != 0 {
+		return nil, fmt.Errorf("invalid row with %d tuple elements", len(t))
+	}
+
+	var want map[string]struct{}
+	if len(fields) > 0 {
+		want = make(map[string]struct{}, len(fields))
+		for _, field := range fields {
+			want[field] = struct{}{}
+		}
+	}
+
+	res := make(map[string][]byte, len(t)/2)
+	for i := 0; i < len(t); i += 2 {
+		field, ok := t[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid field name %v", t[i])
+		}
+		value, ok := t[i+1].([]byte)
+		if !ok {
+			return nil, fmt.Errorf("invalid value of field %s", field)
+		}
+		if want != nil {
+			if _, ok := want[field]; !ok {
+				continue
+			}
+		}
+		res[field] = value
+	}
+	return res, nil
 }
 
-func (db *fDB) getRowKey(table string, key string) []byte {
-	return util.Slice(fmt.Sprintf("%s:%s", table, key))
+// flushPending commits the pending inserts of the thread.
+func (db *fDB) flushPending(state *fdbState) error {
+	if len(state.pending) == 0 {
+		return nil
+	}
+
+	_, err := db.db.Transact(func(tr fdb.Transaction) (ret interface{}, e error) {
+		for _, kv := range state.pending {
+			tr.Set(kv.Key, kv.Value)
+		}
+		return
+	})
+	state.pending = state.pending[:0]
+	return err
 }
 
-func (db *fDB) getEndRowKey(table string) []byte {
-	// ';' is ':' + 1 in the ASCII
-	return util.Slice(fmt.Sprintf("%s;", table))
+// transactBatches calls f for every index in [0, n) and commits every fdb.batchsize calls in one transaction.
+func (db *fDB) transactBatches(n int, f func(tr fdb.Transaction, i int) error) error {
+	for start := 0; start < n; start += db.batchSize {
+		end := start + db.batchSize
+		if end > n {
+			end = n
+		}
+
+		_, err := db.db.Transact(func(tr fdb.Transaction) (interface{}, error) {
+			for i := start; i < end; i++ {
+				if err := f(tr, i); err != nil {
+					return nil, err
+				}
+			}
+			return nil, nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (db *fDB) Read(ctx context.Context, table string, key string, fields []string) (map[string][]byte, error) {
 	rowKey := db.getRowKey(table, key)
 	row, err := db.db.Transact(func(tr fdb.Transaction) (interface{}, error) {
-		f := tr.Get(fdb.Key(rowKey))
+		f := tr.Get(rowKey)
 		return f.Get()
 	})
 
 	if err != nil {
 		return nil, err
-	} else if row == nil {
+	} else if row.([]byte) == nil {
 		return nil, nil
 	}
 
-	return db.r.Decode(row.([]byte), fields)
+	return decodeRow(row.([]byte), fields)
 }
 
 func (db *fDB) Scan(ctx context.Context, table string, startKey string, count int, fields []string) ([]map[string][]byte, error) {
-	rowKey := db.getRowKey(table, startKey)
+	_, end := tuple.Tuple{table}.FDBRangeKeys()
 	res, err := db.db.Transact(func(tr fdb.Transaction) (interface{}, error) {
 		r := fdb.KeyRange{
-			Begin: fdb.Key(rowKey),
-			End:   fdb.Key(db.getEndRowKey(table)),
+			Begin: db.getRowKey(table, startKey),
+			End:   end,
 		}
 		ri := tr.GetRange(r, fdb.RangeOptions{Limit: count}).Iterator()
 		res := make([]map[string][]byte, 0, count)
@@ -112,7 +218,7 @@ func (db *fDB) Scan(ctx context.Context, table string, startKey string, count in
 			if kv.Value == nil {
 				res = append(res, nil)
 			} else {
-				v, err := db.r.Decode(kv.Value, fields)
+				v, err := decodeRow(kv.Value, fields)
 				if err != nil {
 					return nil, err
 				}
@@ -129,68 +235,110 @@ func (db *fDB) Scan(ctx context.Context, table string, startKey string, count in
 	return res.([]map[string][]byte), nil
 }
 
-func (db *fDB) Update(ctx context.Context, table string, key string, values map[string][]byte) error {
-	rowKey := db.getRowKey(table, key)
-	_, err := db.db.Transact(func(tr fdb.Transaction) (ret interface{}, e error) {
-		f := tr.Get(fdb.Key(rowKey))
-		row, err := f.Get()
-		if err != nil {
-			return nil, err
-		} else if row == nil {
-			return nil, nil
-		}
-
-		data, err := db.r.Decode(row, nil)
-		if err != nil {
-			return nil, err
-		}
+func (db *fDB) updateRow(tr fdb.Transaction, rowKey fdb.Key, values map[string][]byte) error {
+	row, err := tr.Get(rowKey).Get()
+	if err != nil {
+		return err
+	} else if row == nil {
+		return nil
+	}
 
-		for field, value := range values {
-			data[field] = value
-		}
+	data, err := decodeRow(row, nil)
+	if err != nil {
+		return err
+	}
 
-		buf := db.bufPool.Get()
-		defer db.bufPool.Put(buf)
+	for field, value := range values {
+		data[field] = value
+	}
 
-		rowData, err := db.r.Encode(buf.Bytes(), data)
-		if err != nil {
-			return nil, err
-		}
+	tr.Set(rowKey, encodeRow(data))
+	return nil
+}
 
-		tr.Set(fdb.Key(rowKey), rowData)
-		return
+func (db *fDB) Update(ctx context.Context, table string, key string, values map[string][]byte) error {
+	rowKey := db.getRowKey(table, key)
+	_, err := db.db.Transact(func(tr fdb.Transaction) (interface{}, error) {
+		return nil, db.updateRow(tr, rowKey, values)
 	})
 
 	return err
 }
 
 func (db *fDB) Insert(ctx context.Context, table string, key string, values map[string][]byte) error {
-	// Simulate TiDB data
-	buf := db.bufPool.Get()
-	defer db.bufPool.Put(buf)
+	state := ctx.Value(stateKey).(*fdbState)
 
-	rowData, err := db.r.Encode(buf.Bytes(), values)
-	if err != nil {
-		return err
+	// The row is packed here, so the values can be reused by the workload after Insert returns.
+	state.pending = append(state.pending, fdb.KeyValue{
+		Key:   db.getRowKey(table, key),
+		Value: encodeRow(values),
+	})
+
+	if len(state.pending) < db.batchSize {
+		return nil
 	}
 
-	rowKey := db.getRowKey(table, key)
-	_, err = db.db.Transact(func(tr fdb.Transaction) (ret interface{}, e error) {
-		tr.Set(fdb.Key(rowKey), rowData)
-		return
-	})
-	return err
+	return db.flushPending(state)
 }
 
 func (db *fDB) Delete(ctx context.Context, table string, key string) error {
 	rowKey := db.getRowKey(table, key)
 	_, err := db.db.Transact(func(tr fdb.Transaction) (ret interface{}, e error) {
-		tr.Clear(fdb.Key(rowKey))
+		tr.Clear(rowKey)
 		return
 	})
 	return err
 }
 
+func (db *fDB) BatchInsert(ctx context.Context, table string, keys []string, values []map[string][]byte) error {
+	return db.transactBatches(len(keys), func(tr fdb.Transaction, i int) error {
+		tr.Set(db.getRowKey(table, keys[i]), encodeRow(values[i]))
+		return nil
+	})
+}
+
+func (db *fDB) BatchRead(ctx context.Context, table string, keys []string, fields []string) ([]map[string][]byte, error) {
+	res, err := db.db.Transact(func(tr fdb.Transaction) (interface{}, error) {
+		// Issue all the reads first, the futures are resolved concurrently.
+		futures := make([]fdb.FutureByteSlice, len(keys))
+		for i, key := range keys {
+			futures[i] = tr.Get(db.getRowKey(table, key))
+		}
+
+		res := make([]map[string][]byte, len(keys))
+		for i, f := range futures {
+			row, err := f.Get()
+			if err != nil {
+				return nil, err
+			} else if row == nil {
+				continue
+			}
+
+			if res[i], err = decodeRow(row, fields); err != nil {
+				return nil, err
+			}
+		}
+		return res, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.([]map[string][]byte), nil
+}
+
+func (db *fDB) BatchUpdate(ctx context.Context, table string, keys []string, values []map[string][]byte) error {
+	return db.transactBatches(len(keys), func(tr fdb.Transaction, i int) error {
+		return db.updateRow(tr, db.getRowKey(table, keys[i]), values[i])
+	})
+}
+
+func (db *fDB) BatchDelete(ctx context.Context, table string, keys []string) error {
+	return db.transactBatches(len(keys), func(tr fdb.Transaction, i int) error {
+		tr.Clear(db.getRowKey(table, keys[i]))
+		return nil
+	})
+}
+
 type fdbCreator struct {
 }
 
@@ -201,4 +349,7 @@ func (c fdbCreator) Create(p *properties.Properties) (ycsb.DB, error) {
 func init() {
 	ycsb.RegisterDBCreator("fdb", fdbCreator{})
 	ycsb.RegisterDBCreator("foundationdb", fdbCreator{})
+
+	prop.Register(prop.String, fdbClusterFile, fdbDatabase)
+	prop.Register(prop.Int, fdbAPIVersion, fdbBatchSize)
 }
@@ -0,0 +1,245 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/db/grpc/kvpb"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/util"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+	gogrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/encoding/gzip"
+)
+
+// grpc properties
+const (
+	grpcAddress = "grpc.address"
+	grpcTLS     = "grpc.tls"
+	grpcTLSCA   = "grpc.tls_ca"
+	grpcTLSCert = "grpc.tls_cert"
+	grpcTLSKey  = "grpc.tls_key"
+	// Overrides the server name used to verify the certificate.
+	grpcTLSServerName         = "grpc.tls_server_name"
+	grpcTLSInsecureSkipVerify = "grpc.tls_insecure_skip_verify"
+	// "none" or "gzip"
+	grpcCompression = "grpc.compression"
+	// "shared" means all the threads share one channel, "thread" means every thread has its own channel.
+	grpcChannel = "grpc.channel"
+	grpcTimeout = "grpc.timeout"
+)
+
+type contextKey string
+
+const stateKey = contextKey("grpcDB")
+
+type grpcCreator struct {
+}
+
+type grpcDB struct {
+	address  string
+	dialOpts []gogrpc.DialOption
+	timeout  time.Duration
+
+	// conn and client are nil if every thread has its own channel.
+	conn   *gogrpc.ClientConn
+	client kvpb.KVClient
+}
+
+type grpcState struct {
+	conn   *gogrpc.ClientConn
+	client kvpb.KVClient
+}
+
+func (c grpcCreator) Create(p *properties.Properties) (ycsb.DB, error) {
+	d := &grpcDB{
+		address: p.GetString(grpcAddress, "127.0.0.1:50051"),
+		timeout: p.GetParsedDuration(grpcTimeout, 10*time.Second),
+	}
+
+	if p.GetBool(grpcTLS, false) {
+		config, err := util.CreateTLSConfig(
+			p.GetString(grpcTLSCA, ""),
+			p.GetString(grpcTLSCert, ""),
+			p.GetString(grpcTLSKey, ""),
+			p.GetBool(grpcTLSInsecureSkipVerify, false),
+		)
+		if err != nil {
+			return nil, err
+		}
+		config.ServerName = p.GetString(grpcTLSServerName, "")
+		d.dialOpts = append(d.dialOpts, gogrpc.WithTransportCredentials(credentials.NewTLS(config)))
+	} else {
+		d.dialOpts = append(d.dialOpts, gogrpc.WithInsecure())
+	}
+
+	switch compression := p.GetString(grpcCompression, "none"); compression {
+	case "none":
+	case "gzip":
+		d.dialOpts = append(d.dialOpts, gogrpc.WithDefaultCallOptions(gogrpc.UseCompressor(gzip.Name)))
+	default:
+		return nil, fmt.Errorf("unsupported compression %s", compression)
+	}
+
+	switch channel := p.GetString(grpcChannel, "shared"); channel {
+	case "shared":
+		conn, err := gogrpc.Dial(d.address, d.dialOpts...)
+		if err != nil {
+			return nil, err
+		}
+		d.conn = conn
+		d.client = kvpb.NewKVClient(conn)
+	case "thread":
+	default:
+		return nil, fmt.Errorf("unsupported channel %s", channel)
+	}
+
+	return d, nil
+}
+
+func (db *grpcDB) Close() error {
+	if db.conn == nil {
+		return nil
+	}
+	return db.conn.Close()
+}
+
+func (db *grpcDB) InitThread(ctx context.Context, _ int, _ int) context.Context {
+	if db.conn != nil {
+		return ctx
+	}
+
+	conn, err := gogrpc.Dial(db.address, db.dialOpts...)
+	if err != nil {
+		panic(fmt.Sprintf("failed to dial %s %v", db.address, err))
+	}
+
+	state := &grpcState{
+		conn:   conn,
+		client: kvpb.NewKVClient(conn),
+	}
+
+	return context.WithValue(ctx, stateKey, state)
+}
+
+func (db *grpcDB) CleanupThread(ctx context.Context) {
+	if db.conn != nil {
+		return
+	}
+
+	state := ctx.Value(stateKey).(*grpcState)
+	state.conn.Close()
+}
+
+// getClient returns the shared client, or the client of the thread.
+func (db *grpcDB) getClient(ctx context.Context) kvpb.KVClient {
+	if db.client != nil {
+		return db.client
+	}
+	return ctx.Value(stateKey).(*grpcState).client
+}
+
+func (db *grpcDB) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if db.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, db.timeout)
+}
+
+func (db *grpcDB) Read(ctx context.Context, table string, key string, fields []string) (map[string][]byte, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	resp, err := db.getClient(ctx).Get(ctx, &kvpb.GetRequest{
+		Table:  table,
+		Key:    key,
+		Fields: fields,
+	})
+	if err != nil || !resp.GetFound() {
+		return nil, err
+	}
+
+	return resp.GetRecord().GetFields(), nil
+}
+
+func (db *grpcDB) Scan(ctx context.Context, table string, startKey string, count int, fields []string) ([]map[string][]byte, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	resp, err := db.getClient(ctx).Scan(ctx, &kvpb.ScanRequest{
+		Table:    table,
+		StartKey: startKey,
+		Count:    int32(count),
+		Fields:   fields,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]map[string][]byte, 0, len(resp.GetRecords()))
+	for _, record := range resp.GetRecords() {
+		res = append(res, record.GetFields())
+	}
+	return res, nil
+}
+
+func (db *grpcDB) put(ctx context.Context, table string, key string, values map[string][]byte, replace bool) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	_, err := db.getClient(ctx).Put(ctx, &kvpb.PutRequest{
+		Table: table,
+		Record: &kvpb.Record{
+			Key:    key,
+			Fields: values,
+		},
+		Replace: replace,
+	})
+	return err
+}
+
+// Update only sends the updated fields, the server is expected to merge them into the record.
+func (db *grpcDB) Update(ctx context.Context, table string, key string, values map[string][]byte) error {
+	return db.put(ctx, table, key, values, false)
+}
+
+func (db *grpcDB) Insert(ctx context.Context, table string, key string, values map[string][]byte) error {
+	return db.put(ctx, table, key, values, true)
+}
+
+func (db *grpcDB) Delete(ctx context.Context, table string, key string) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	_, err := db.getClient(ctx).Delete(ctx, &kvpb.DeleteRequest{
+		Table: table,
+		Key:   key,
+	})
+	return err
+}
+
+func init() {
+	ycsb.RegisterDBCreator("grpc", grpcCreator{})
+
+	prop.Register(prop.String,
+		grpcAddress, grpcTLSCA, grpcTLSCert, grpcTLSKey, grpcTLSServerName, grpcCompression,
+		grpcChannel)
+	prop.Register(prop.Bool, grpcTLS, grpcTLSInsecureSkipVerify)
+	prop.Register(prop.Duration, grpcTimeout)
+}
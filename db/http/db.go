@@ -0,0 +1,274 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	gohttp "net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/measurement"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/util"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+	"golang.org/x/net/http2"
+)
+
+// http properties
+const (
+	// The URL templates, {table} and {key} are replaced by the escaped table and key,
+	// {count} in the scan URL is replaced by the record count.
+	httpURL       = "http.url"
+	httpReadURL   = "http.read_url"
+	httpInsertURL = "http.insert_url"
+	httpUpdateURL = "http.update_url"
+	httpDeleteURL = "http.delete_url"
+	// Scan is not supported if the scan URL is not set.
+	httpScanURL      = "http.scan_url"
+	httpInsertMethod = "http.insert_method"
+	httpUpdateMethod = "http.update_method"
+	// Every http.header.<name> property is sent as the header <name>.
+	httpHeaderPrefix    = "http.header."
+	httpTimeout         = "http.timeout"
+	httpKeepAlive       = "http.keep_alive"
+	httpMaxIdleConns    = "http.max_idle_conns_per_host"
+	httpHTTP2           = "http.http2"
+	httpInsecureSkipTLS = "http.insecure_skip_verify"
+)
+
+type httpCreator struct {
+}
+
+type httpDB struct {
+	client *gohttp.Client
+	header gohttp.Header
+
+	readURL   string
+	insertURL string
+	updateURL string
+	deleteURL string
+	scanURL   string
+
+	insertMethod string
+	updateMethod string
+}
+
+func (c httpCreator) Create(p *properties.Properties) (ycsb.DB, error) {
+	baseURL := p.GetString(httpURL, "http://127.0.0.1:8080/{table}/{key}")
+
+	d := &httpDB{
+		header:       make(gohttp.Header),
+		readURL:      p.GetString(httpReadURL, baseURL),
+		insertURL:    p.GetString(httpInsertURL, baseURL),
+		updateURL:    p.GetString(httpUpdateURL, baseURL),
+		deleteURL:    p.GetString(httpDeleteURL, baseURL),
+		scanURL:      p.GetString(httpScanURL, ""),
+		insertMethod: p.GetString(httpInsertMethod, gohttp.MethodPut),
+		updateMethod: p.GetString(httpUpdateMethod, gohttp.MethodPut),
+	}
+
+	d.header.Set("Content-Type", "application/json")
+	headers := p.FilterStripPrefix(httpHeaderPrefix)
+	for _, name := range headers.Keys() {
+		d.header.Set(name, headers.GetString(name, ""))
+	}
+
+	threadCount := int(p.GetInt64(prop.ThreadCount, prop.ThreadCountDefault))
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: p.GetBool(httpInsecureSkipTLS, false),
+	}
+
+	var transport gohttp.RoundTripper
+	if p.GetBool(httpHTTP2, false) && strings.HasPrefix(baseURL, "http://") {
+		// HTTP/2 over the cleartext TCP with the prior knowledge.
+		transport = &http2.Transport{
+			AllowHTTP: true,
+			DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+				return net.Dial(network, addr)
+			},
+		}
+	} else {
+		transport = &gohttp.Transport{
+			Proxy:               gohttp.ProxyFromEnvironment,
+			TLSClientConfig:     tlsConfig,
+			DisableKeepAlives:   !p.GetBool(httpKeepAlive, true),
+			MaxIdleConnsPerHost: p.GetInt(httpMaxIdleConns, threadCount),
+			ForceAttemptHTTP2:   p.GetBool(httpHTTP2, false),
+		}
+		if !p.GetBool(httpHTTP2, false) {
+			// A non-nil empty map disables HTTP/2 even if the server supports it.
+			transport.(*gohttp.Transport).TLSNextProto = make(map[string]func(string, *tls.Conn) gohttp.RoundTripper)
+		}
+	}
+
+	d.client = &gohttp.Client{
+		Transport: transport,
+		Timeout:   p.GetParsedDuration(httpTimeout, 10*time.Second),
+	}
+
+	return d, nil
+}
+
+func (db *httpDB) Close() error {
+	db.client.CloseIdleConnections()
+	return nil
+}
+
+func (db *httpDB) InitThread(ctx context.Context, _ int, _ int) context.Context {
+	return ctx
+}
+
+func (db *httpDB) CleanupThread(_ context.Context) {
+}
+
+func buildURL(template string, table string, key string, count int) string {
+	r := strings.NewReplacer(
+		"{table}", url.PathEscape(table),
+		"{key}", url.PathEscape(key),
+		"{count}", strconv.Itoa(count),
+	)
+	return r.Replace(template)
+}
+
+// do sends the request, and decodes the JSON response into res if res is not nil.
+// It returns false if the resource is not found.
+func (db *httpDB) do(ctx context.Context, method string, url string, values map[string][]byte, res interface{}) (bool, error) {
+	var body io.Reader
+	if values != nil {
+		doc := make(map[string]string, len(values))
+		for field, value := range values {
+			doc[field] = string(value)
+		}
+		data, err := json.Marshal(doc)
+		if err != nil {
+			return false, err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := gohttp.NewRequest(method, url, body)
+	if err != nil {
+		return false, err
+	}
+	req = req.WithContext(ctx)
+	// The client may modify the header, so the shared header is cloned.
+	req.Header = db.header.Clone()
+
+	resp, err := db.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		// Drain the body so that the connection can be reused.
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+	}()
+
+	if resp.StatusCode == gohttp.StatusNotFound {
+		return false, nil
+	} else if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return false, fmt.Errorf("%s %s failed with status %d: %s", method, url, resp.StatusCode, msg)
+	}
+
+	if res == nil {
+		return true, nil
+	}
+	return true, json.NewDecoder(resp.Body).Decode(res)
+}
+
+func decodeDoc(doc map[string]string, fields []string) map[string][]byte {
+	if len(fields) == 0 {
+		res := make(map[string][]byte, len(doc))
+		for field, value := range doc {
+			res[field] = util.Slice(value)
+		}
+		return res
+	}
+
+	// The service may return all the fields, so they are filtered here.
+	res := make(map[string][]byte, len(fields))
+	for _, field := range fields {
+		if value, ok := doc[field]; ok {
+			res[field] = util.Slice(value)
+		}
+	}
+	return res
+}
+
+func (db *httpDB) Read(ctx context.Context, table string, key string, fields []string) (map[string][]byte, error) {
+	var doc map[string]string
+	found, err := db.do(ctx, gohttp.MethodGet, buildURL(db.readURL, table, key, 1), nil, &doc)
+	if err != nil || !found {
+		return nil, err
+	}
+
+	return decodeDoc(doc, fields), nil
+}
+
+// Scan expects the response to be the JSON array of the records.
+func (db *httpDB) Scan(ctx context.Context, table string, startKey string, count int, fields []string) ([]map[string][]byte, error) {
+	if len(db.scanURL) == 0 {
+		measurement.Measure("COMMAND_NOT_SUPPORTED", 0)
+		return nil, fmt.Errorf("scan is not supported if %s is not set", httpScanURL)
+	}
+
+	var docs []map[string]string
+	if _, err := db.do(ctx, gohttp.MethodGet, buildURL(db.scanURL, table, startKey, count), nil, &docs); err != nil {
+		return nil, err
+	}
+
+	res := make([]map[string][]byte, 0, len(docs))
+	for _, doc := range docs {
+		res = append(res, decodeDoc(doc, fields))
+	}
+	return res, nil
+}
+
+func (db *httpDB) Update(ctx context.Context, table string, key string, values map[string][]byte) error {
+	_, err := db.do(ctx, db.updateMethod, buildURL(db.updateURL, table, key, 1), values, nil)
+	return err
+}
+
+func (db *httpDB) Insert(ctx context.Context, table string, key string, values map[string][]byte) error {
+	_, err := db.do(ctx, db.insertMethod, buildURL(db.insertURL, table, key, 1), values, nil)
+	return err
+}
+
+func (db *httpDB) Delete(ctx context.Context, table string, key string) error {
+	_, err := db.do(ctx, gohttp.MethodDelete, buildURL(db.deleteURL, table, key, 1), nil, nil)
+	return err
+}
+
+func init() {
+	ycsb.RegisterDBCreator("http", httpCreator{})
+
+	prop.Register(prop.String,
+		httpURL, httpReadURL, httpInsertURL, httpUpdateURL, httpDeleteURL, httpScanURL,
+		httpInsertMethod, httpUpdateMethod, httpHeaderPrefix)
+	prop.Register(prop.Int, httpMaxIdleConns)
+	prop.Register(prop.Bool, httpKeepAlive, httpHTTP2, httpInsecureSkipTLS)
+	prop.Register(prop.Duration, httpTimeout)
+}
@@ -0,0 +1,298 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package influx
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	client "github.com/influxdata/influxdb1-client/v2"
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/util"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+	"go.uber.org/zap"
+)
+
+// influx properties
+const (
+	influxURL             = "influx.url"
+	influxUsername        = "influx.username"
+	influxPassword        = "influx.password"
+	influxDatabase        = "influx.database"
+	influxRetentionPolicy = "influx.retention_policy"
+	// "any", "one", "quorum" or "all", only for InfluxDB Enterprise.
+	influxWriteConsistency = "influx.write_consistency"
+	// Buffer the points of every thread and write them in one request.
+	influxBatchSize = "influx.batchsize"
+	// Scan returns the points of the key in the window before now.
+	influxScanWindow         = "influx.scan_window"
+	influxTimeout            = "influx.timeout"
+	influxInsecureSkipVerify = "influx.insecure_skip_verify"
+)
+
+// The tag of the key, every key is a series of the measurement.
+const keyTag = "YCSB_KEY"
+
+type contextKey string
+
+const stateKey = contextKey("influxDB")
+
+type influxCreator struct {
+}
+
+// influxDB writes every record as a point of the measurement named by the table, Update writes
+// a new point of the updated fields, and Read returns the last value of every field.
+type influxDB struct {
+	p      *properties.Properties
+	client client.Client
+
+	database         string
+	retentionPolicy  string
+	writeConsistency string
+	batchSize        int
+	scanWindow       time.Duration
+}
+
+type influxState struct {
+	// pending points buffered by the writes when batch write is enabled,
+	// they will be flushed when the buffer is full or the thread is cleaned up.
+	pending []*client.Point
+}
+
+func (c influxCreator) Create(p *properties.Properties) (ycsb.DB, error) {
+	cli, err := client.NewHTTPClient(client.HTTPConfig{
+		Addr:               p.GetString(influxURL, "http://127.0.0.1:8086"),
+		Username:           p.GetString(influxUsername, ""),
+		Password:           p.GetString(influxPassword, ""),
+		Timeout:            p.GetParsedDuration(influxTimeout, 10*time.Second),
+		InsecureSkipVerify: p.GetBool(influxInsecureSkipVerify, false),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	d := &influxDB{
+		p:                p,
+		client:           cli,
+		database:         p.GetString(influxDatabase, "ycsb"),
+		retentionPolicy:  p.GetString(influxRetentionPolicy, ""),
+		writeConsistency: p.GetString(influxWriteConsistency, ""),
+		batchSize:        p.GetInt(influxBatchSize, 1),
+		scanWindow:       p.GetParsedDuration(influxScanWindow, time.Hour),
+	}
+
+	if !p.GetBool(prop.DoTransactions, true) {
+		if err = d.createDatabase(); err != nil {
+			cli.Close()
+			return nil, err
+		}
+	}
+
+	return d, nil
+}
+
+func quoteIdent(name string) string {
+	return `"` + strings.Replace(name, `"`, `\"`, -1) + `"`
+}
+
+// query runs the InfluxQL command, the error of any statement is returned as well.
+func (db *influxDB) query(command string, params map[string]interface{}) (*client.Response, error) {
+	q := client.NewQueryWithParameters(command, db.database, "", params)
+	q.RetentionPolicy = db.retentionPolicy
+
+	resp, err := db.client.Query(q)
+	if err != nil {
+		return nil, err
+	}
+	return resp, resp.Error()
+}
+
+func (db *influxDB) createDatabase() error {
+	if _, err := db.query("CREATE DATABASE "+quoteIdent(db.database), nil); err != nil {
+		return err
+	}
+
+	if db.p.GetBool(prop.DropData, prop.DropDataDefault) {
+		for _, tableName := range util.TableNames(db.p) {
+			_, err := db.query("DROP MEASUREMENT "+quoteIdent(tableName), nil)
+			if err != nil && !strings.Contains(err.Error(), "measurement not found") {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (db *influxDB) Close() error {
+	return db.client.Close()
+}
+
+func (db *influxDB) InitThread(ctx context.Context, _ int, _ int) context.Context {
+	state := &influxState{
+		pending: make([]*client.Point, 0, db.batchSize),
+	}
+
+	return context.WithValue(ctx, stateKey, state)
+}
+
+func (db *influxDB) CleanupThread(ctx context.Context) {
+	state := ctx.Value(stateKey).(*influxState)
+	if err := db.flushPending(state); err != nil {
+		util.Logger().Error("flush pending rows failed", zap.Error(err))
+	}
+}
+
+func (db *influxDB) flushPending(state *influxState) error {
+	if len(state.pending) == 0 {
+		return nil
+	}
+
+	bp, err := client.NewBatchPoints(client.BatchPointsConfig{
+		Database:         db.database,
+		RetentionPolicy:  db.retentionPolicy,
+		WriteConsistency: db.writeConsistency,
+	})
+	if err != nil {
+		return err
+	}
+	bp.AddPoints(state.pending)
+	state.pending = state.pending[:0]
+
+	return db.client.Write(bp)
+}
+
+// rowFields returns the non-null fields of every row, the columns are renamed by rename if it's not nil.
+func rowFields(resp *client.Response, rename func(string) string) []map[string][]byte {
+	var res []map[string][]byte
+	for _, result := range resp.Results {
+		for _, series := range result.Series {
+			for _, values := range series.Values {
+				row := make(map[string][]byte, len(series.Columns))
+				for i, column := range series.Columns {
+					s, ok := values[i].(string)
+					if !ok || column == "time" || column == keyTag {
+						continue
+					}
+					if rename != nil {
+						column = rename(column)
+					}
+					row[column] = util.Slice(s)
+				}
+				res = append(res, row)
+			}
+		}
+	}
+	return res
+}
+
+func (db *influxDB) Read(ctx context.Context, table string, key string, fields []string) (map[string][]byte, error) {
+	var selects string
+	var rename func(string) string
+	if len(fields) == 0 {
+		selects = "last(*)"
+		rename = func(column string) string {
+			return strings.TrimPrefix(column, "last_")
+		}
+	} else {
+		exprs := make([]string, 0, len(fields))
+		for _, field := range fields {
+			exprs = append(exprs, fmt.Sprintf("last(%s) AS %s", quoteIdent(field), quoteIdent(field)))
+		}
+		selects = strings.Join(exprs, ", ")
+	}
+
+	command := fmt.Sprintf("SELECT %s FROM %s WHERE %s = $key", selects, quoteIdent(table), quoteIdent(keyTag))
+	resp, err := db.query(command, map[string]interface{}{"key": key})
+	if err != nil {
+		return nil, err
+	}
+
+	rows := rowFields(resp, rename)
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	return rows[0], nil
+}
+
+// Scan returns the latest count points of the startKey series in the scan window.
+func (db *influxDB) Scan(ctx context.Context, table string, startKey string, count int, fields []string) ([]map[string][]byte, error) {
+	selects := "*"
+	if len(fields) > 0 {
+		columns := make([]string, 0, len(fields))
+		for _, field := range fields {
+			columns = append(columns, quoteIdent(field))
+		}
+		selects = strings.Join(columns, ", ")
+	}
+
+	command := fmt.Sprintf("SELECT %s FROM %s WHERE %s = $key AND time > now() - %du ORDER BY time DESC LIMIT %d",
+		selects, quoteIdent(table), quoteIdent(keyTag), db.scanWindow.Nanoseconds()/1000, count)
+	resp, err := db.query(command, map[string]interface{}{"key": startKey})
+	if err != nil {
+		return nil, err
+	}
+
+	return rowFields(resp, nil), nil
+}
+
+func (db *influxDB) write(ctx context.Context, table string, key string, values map[string][]byte) error {
+	fields := make(map[string]interface{}, len(values))
+	for field, value := range values {
+		fields[field] = string(value)
+	}
+
+	point, err := client.NewPoint(table, map[string]string{keyTag: key}, fields, time.Now())
+	if err != nil {
+		return err
+	}
+
+	state := ctx.Value(stateKey).(*influxState)
+	state.pending = append(state.pending, point)
+	if len(state.pending) < db.batchSize {
+		return nil
+	}
+
+	return db.flushPending(state)
+}
+
+// Update writes a point of the updated fields, the other fields keep the last values.
+func (db *influxDB) Update(ctx context.Context, table string, key string, values map[string][]byte) error {
+	return db.write(ctx, table, key, values)
+}
+
+func (db *influxDB) Insert(ctx context.Context, table string, key string, values map[string][]byte) error {
+	return db.write(ctx, table, key, values)
+}
+
+// Delete deletes the series of the key.
+func (db *influxDB) Delete(ctx context.Context, table string, key string) error {
+	command := fmt.Sprintf("DELETE FROM %s WHERE %s = $key", quoteIdent(table), quoteIdent(keyTag))
+	_, err := db.query(command, map[string]interface{}{"key": key})
+	return err
+}
+
+func init() {
+	ycsb.RegisterDBCreator("influx", influxCreator{})
+
+	prop.Register(prop.String,
+		influxURL, influxUsername, influxPassword, influxDatabase, influxRetentionPolicy,
+		influxWriteConsistency)
+	prop.Register(prop.Int, influxBatchSize)
+	prop.Register(prop.Bool, influxInsecureSkipVerify)
+	prop.Register(prop.Duration, influxScanWindow, influxTimeout)
+}
@@ -0,0 +1,124 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/pingcap/go-ycsb/pkg/util"
+	"github.com/segmentio/kafka-go"
+)
+
+const cacheShardCount = 64
+
+type cacheShard struct {
+	sync.RWMutex
+	rows map[string]map[string][]byte
+}
+
+// valueCache is the latest values of the keys, it is sharded to avoid the lock contention.
+type valueCache struct {
+	shards []*cacheShard
+}
+
+func newValueCache() *valueCache {
+	c := &valueCache{
+		shards: make([]*cacheShard, cacheShardCount),
+	}
+	for i := range c.shards {
+		c.shards[i] = &cacheShard{rows: make(map[string]map[string][]byte)}
+	}
+	return c
+}
+
+func cacheKey(table string, key string) string {
+	return table + "/" + key
+}
+
+func (c *valueCache) shard(key string) *cacheShard {
+	return c.shards[uint64(util.StringHash64(key))%cacheShardCount]
+}
+
+func (c *valueCache) get(table string, key string, fields []string) map[string][]byte {
+	k := cacheKey(table, key)
+	s := c.shard(k)
+
+	s.RLock()
+	defer s.RUnlock()
+
+	row, ok := s.rows[k]
+	if !ok {
+		return nil
+	}
+
+	res := make(map[string][]byte, len(row))
+	if len(fields) == 0 {
+		for field, value := range row {
+			res[field] = value
+		}
+		return res
+	}
+
+	for _, field := range fields {
+		if value, ok := row[field]; ok {
+			res[field] = value
+		}
+	}
+	return res
+}
+
+// set merges the values into the cached row, or deletes the row if values is nil.
+func (c *valueCache) set(table string, key string, values map[string][]byte) {
+	k := cacheKey(table, key)
+	s := c.shard(k)
+
+	s.Lock()
+	defer s.Unlock()
+
+	if values == nil {
+		delete(s.rows, k)
+		return
+	}
+
+	row, ok := s.rows[k]
+	if !ok {
+		row = make(map[string][]byte, len(values))
+		s.rows[k] = row
+	}
+	// The values may be reused by the workload, so we must copy them.
+	for field, value := range values {
+		row[field] = append([]byte(nil), value...)
+	}
+}
+
+// apply sets the cache by the consumed message.
+func (c *valueCache) apply(table string, msg kafka.Message) error {
+	if msg.Value == nil {
+		c.set(table, string(msg.Key), nil)
+		return nil
+	}
+
+	var doc map[string]string
+	if err := json.Unmarshal(msg.Value, &doc); err != nil {
+		return err
+	}
+
+	values := make(map[string][]byte, len(doc))
+	for field, value := range doc {
+		values[field] = util.Slice(value)
+	}
+	c.set(table, string(msg.Key), values)
+	return nil
+}
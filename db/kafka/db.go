@@ -0,0 +1,334 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/measurement"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/util"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/gzip"
+	"github.com/segmentio/kafka-go/lz4"
+	"github.com/segmentio/kafka-go/snappy"
+	"github.com/segmentio/kafka-go/zstd"
+)
+
+// kafka properties
+const (
+	kafkaBrokers           = "kafka.brokers"
+	kafkaTopicPrefix       = "kafka.topic_prefix"
+	kafkaPartitions        = "kafka.partitions"
+	kafkaReplicationFactor = "kafka.replication_factor"
+	kafkaBatchSize         = "kafka.batchsize"
+	kafkaBatchTimeout      = "kafka.batch_timeout"
+	// "all", "leader" or "none"
+	kafkaAcks = "kafka.acks"
+	// "none", "gzip", "snappy", "lz4" or "zstd"
+	kafkaCompression = "kafka.compression"
+	// Don't wait for the messages to be written, the write errors are ignored.
+	kafkaAsync = "kafka.async"
+	// Cache the latest values of the keys for Read, Read is not supported if the cache is disabled.
+	kafkaCache = "kafka.cache"
+	// Consume the topics from the beginning to fill the cache before the run phase.
+	kafkaLoadCache = "kafka.load_cache"
+)
+
+var requiredAcks = map[string]int{
+	"all":    -1,
+	"leader": 1,
+	"none":   0,
+}
+
+type kafkaCreator struct {
+}
+
+type kafkaDB struct {
+	brokers     []string
+	topicPrefix string
+
+	// writers is the writer of every table, the writer is shared by the threads to batch the messages.
+	writers map[string]*kafka.Writer
+	// cache is nil if the cache is disabled.
+	cache *valueCache
+}
+
+func (c kafkaCreator) Create(p *properties.Properties) (ycsb.DB, error) {
+	brokers := strings.Split(p.GetString(kafkaBrokers, "127.0.0.1:9092"), ",")
+	for i := range brokers {
+		brokers[i] = strings.TrimSpace(brokers[i])
+	}
+
+	d := &kafkaDB{
+		brokers:     brokers,
+		topicPrefix: p.GetString(kafkaTopicPrefix, ""),
+		writers:     make(map[string]*kafka.Writer),
+	}
+
+	acks := p.GetString(kafkaAcks, "all")
+	requiredAck, ok := requiredAcks[acks]
+	if !ok {
+		return nil, fmt.Errorf("unsupported acks %s", acks)
+	}
+
+	var codec kafka.CompressionCodec
+	switch compression := p.GetString(kafkaCompression, "none"); compression {
+	case "none":
+	case "gzip":
+		codec = gzip.NewCompressionCodec()
+	case "snappy":
+		codec = snappy.NewCompressionCodec()
+	case "lz4":
+		codec = lz4.NewCompressionCodec()
+	case "zstd":
+		codec = zstd.NewCompressionCodec()
+	default:
+		return nil, fmt.Errorf("unsupported compression %s", compression)
+	}
+
+	tableNames := util.TableNames(p)
+	if !p.GetBool(prop.DoTransactions, true) {
+		if err := d.createTopics(p, tableNames); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, tableName := range tableNames {
+		d.writers[tableName] = kafka.NewWriter(kafka.WriterConfig{
+			Brokers: brokers,
+			Topic:   d.topic(tableName),
+			// The messages of the same key must be written to the same partition to be compacted.
+			Balancer:         &kafka.Hash{},
+			BatchSize:        p.GetInt(kafkaBatchSize, 100),
+			BatchTimeout:     p.GetParsedDuration(kafkaBatchTimeout, 10*time.Millisecond),
+			RequiredAcks:     requiredAck,
+			Async:            p.GetBool(kafkaAsync, false),
+			CompressionCodec: codec,
+		})
+	}
+
+	if p.GetBool(kafkaCache, true) {
+		d.cache = newValueCache()
+		if p.GetBool(prop.DoTransactions, true) && p.GetBool(kafkaLoadCache, false) {
+			for _, tableName := range tableNames {
+				if err := d.loadCache(tableName); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	return d, nil
+}
+
+func (db *kafkaDB) topic(table string) string {
+	return db.topicPrefix + table
+}
+
+// createTopics creates the compacted topics of the tables if they don't exist.
+func (db *kafkaDB) createTopics(p *properties.Properties, tableNames []string) error {
+	conn, err := kafka.Dial("tcp", db.brokers[0])
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// The topics must be created by the controller.
+	controller, err := conn.Controller()
+	if err != nil {
+		return err
+	}
+	controllerConn, err := kafka.Dial("tcp", net.JoinHostPort(controller.Host, strconv.Itoa(controller.Port)))
+	if err != nil {
+		return err
+	}
+	defer controllerConn.Close()
+
+	for _, tableName := range tableNames {
+		err = controllerConn.CreateTopics(kafka.TopicConfig{
+			Topic:             db.topic(tableName),
+			NumPartitions:     p.GetInt(kafkaPartitions, 1),
+			ReplicationFactor: p.GetInt(kafkaReplicationFactor, 1),
+			ConfigEntries: []kafka.ConfigEntry{
+				{ConfigName: "cleanup.policy", ConfigValue: "compact"},
+			},
+		})
+		if err != nil && err != kafka.TopicAlreadyExists {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// loadCache consumes every partition of the table topic until the last offset when the DB is created.
+func (db *kafkaDB) loadCache(table string) error {
+	ctx := context.Background()
+
+	conn, err := kafka.Dial("tcp", db.brokers[0])
+	if err != nil {
+		return err
+	}
+	partitions, err := conn.ReadPartitions(db.topic(table))
+	conn.Close()
+	if err != nil {
+		return err
+	}
+
+	for _, partition := range partitions {
+		leader, err := kafka.DialLeader(ctx, "tcp", db.brokers[0], partition.Topic, partition.ID)
+		if err != nil {
+			return err
+		}
+		first, last, err := leader.ReadOffsets()
+		leader.Close()
+		if err != nil {
+			return err
+		} else if first >= last {
+			continue
+		}
+
+		r := kafka.NewReader(kafka.ReaderConfig{
+			Brokers:   db.brokers,
+			Topic:     partition.Topic,
+			Partition: partition.ID,
+			MaxBytes:  10 << 20,
+		})
+		if err = r.SetOffset(first); err != nil {
+			r.Close()
+			return err
+		}
+
+		for {
+			msg, err := r.ReadMessage(ctx)
+			if err != nil {
+				r.Close()
+				return err
+			}
+			if err = db.cache.apply(table, msg); err != nil {
+				r.Close()
+				return err
+			}
+			if msg.Offset+1 >= last {
+				break
+			}
+		}
+		r.Close()
+	}
+
+	return nil
+}
+
+func (db *kafkaDB) Close() error {
+	for _, w := range db.writers {
+		if err := w.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (db *kafkaDB) InitThread(ctx context.Context, _ int, _ int) context.Context {
+	return ctx
+}
+
+func (db *kafkaDB) CleanupThread(_ context.Context) {
+}
+
+// newMessage returns the message keyed by the key, the value is the JSON object of the fields,
+// or nil as the tombstone if values is nil.
+func newMessage(key string, values map[string][]byte) (kafka.Message, error) {
+	msg := kafka.Message{
+		Key: []byte(key),
+	}
+	if values == nil {
+		return msg, nil
+	}
+
+	doc := make(map[string]string, len(values))
+	for field, value := range values {
+		doc[field] = string(value)
+	}
+
+	var err error
+	msg.Value, err = json.Marshal(doc)
+	return msg, err
+}
+
+func (db *kafkaDB) produce(ctx context.Context, table string, key string, values map[string][]byte) error {
+	w, ok := db.writers[table]
+	if !ok {
+		return fmt.Errorf("unknown table %s", table)
+	}
+
+	msg, err := newMessage(key, values)
+	if err != nil {
+		return err
+	}
+
+	if err = w.WriteMessages(ctx, msg); err != nil {
+		return err
+	}
+
+	if db.cache != nil {
+		db.cache.set(table, key, values)
+	}
+	return nil
+}
+
+func (db *kafkaDB) Read(ctx context.Context, table string, key string, fields []string) (map[string][]byte, error) {
+	if db.cache == nil {
+		measurement.Measure("COMMAND_NOT_SUPPORTED", 0)
+		return nil, fmt.Errorf("read is not supported if %s is false", kafkaCache)
+	}
+
+	return db.cache.get(table, key, fields), nil
+}
+
+func (db *kafkaDB) Scan(ctx context.Context, table string, startKey string, count int, fields []string) ([]map[string][]byte, error) {
+	measurement.Measure("COMMAND_NOT_SUPPORTED", 0)
+	return nil, fmt.Errorf("scan is not supported")
+}
+
+// Update produces the message of the updated fields, the consumers are expected to merge the fields.
+func (db *kafkaDB) Update(ctx context.Context, table string, key string, values map[string][]byte) error {
+	return db.produce(ctx, table, key, values)
+}
+
+func (db *kafkaDB) Insert(ctx context.Context, table string, key string, values map[string][]byte) error {
+	return db.produce(ctx, table, key, values)
+}
+
+// Delete produces the tombstone of the key.
+func (db *kafkaDB) Delete(ctx context.Context, table string, key string) error {
+	return db.produce(ctx, table, key, nil)
+}
+
+func init() {
+	ycsb.RegisterDBCreator("kafka", kafkaCreator{})
+
+	prop.Register(prop.String, kafkaBrokers, kafkaTopicPrefix, kafkaAcks, kafkaCompression)
+	prop.Register(prop.Int, kafkaPartitions, kafkaReplicationFactor, kafkaBatchSize)
+	prop.Register(prop.Bool, kafkaAsync, kafkaCache, kafkaLoadCache)
+	prop.Register(prop.Duration, kafkaBatchTimeout)
+}
@@ -0,0 +1,162 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memcache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/measurement"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+)
+
+// memcache properties
+const (
+	memcacheServers      = "memcache.servers"
+	memcacheTimeout      = "memcache.timeout"
+	memcacheMaxIdleConns = "memcache.max_idle_conns"
+)
+
+type memcacheCreator struct {
+}
+
+type memcacheDB struct {
+	client *memcache.Client
+}
+
+func (c memcacheCreator) Create(p *properties.Properties) (ycsb.DB, error) {
+	servers := strings.Split(p.GetString(memcacheServers, "127.0.0.1:11211"), ",")
+	for i := range servers {
+		servers[i] = strings.TrimSpace(servers[i])
+	}
+
+	ring, err := newHashRing(servers)
+	if err != nil {
+		return nil, err
+	}
+
+	client := memcache.NewFromSelector(ring)
+	client.Timeout = p.GetParsedDuration(memcacheTimeout, time.Second)
+	client.MaxIdleConns = p.GetInt(memcacheMaxIdleConns, int(p.GetInt64(prop.ThreadCount, prop.ThreadCountDefault)))
+
+	if p.GetBool(prop.DropData, prop.DropDataDefault) && !p.GetBool(prop.DoTransactions, true) {
+		if err := client.DeleteAll(); err != nil {
+			return nil, err
+		}
+	}
+
+	return &memcacheDB{client: client}, nil
+}
+
+func (db *memcacheDB) Close() error {
+	return nil
+}
+
+func (db *memcacheDB) InitThread(ctx context.Context, _ int, _ int) context.Context {
+	return ctx
+}
+
+func (db *memcacheDB) CleanupThread(_ context.Context) {
+}
+
+func getKey(table string, key string) string {
+	return table + "/" + key
+}
+
+func (db *memcacheDB) get(table string, key string) (map[string][]byte, error) {
+	item, err := db.client.Get(getKey(table, key))
+	if err != nil {
+		return nil, err
+	}
+
+	var values map[string][]byte
+	err = json.Unmarshal(item.Value, &values)
+	return values, err
+}
+
+func (db *memcacheDB) set(table string, key string, values map[string][]byte) error {
+	data, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+
+	return db.client.Set(&memcache.Item{Key: getKey(table, key), Value: data})
+}
+
+func (db *memcacheDB) Read(ctx context.Context, table string, key string, fields []string) (map[string][]byte, error) {
+	values, err := db.get(table, key)
+	if err == memcache.ErrCacheMiss {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if len(fields) == 0 {
+		return values, nil
+	}
+
+	res := make(map[string][]byte, len(fields))
+	for _, field := range fields {
+		if value, ok := values[field]; ok {
+			res[field] = value
+		}
+	}
+	return res, nil
+}
+
+// Scan is not supported by memcached, every call is measured as COMMAND_NOT_SUPPORTED.
+func (db *memcacheDB) Scan(ctx context.Context, table string, startKey string, count int, fields []string) ([]map[string][]byte, error) {
+	measurement.Measure("COMMAND_NOT_SUPPORTED", 0)
+	return nil, fmt.Errorf("scan is not supported")
+}
+
+func (db *memcacheDB) Update(ctx context.Context, table string, key string, values map[string][]byte) error {
+	curValues, err := db.get(table, key)
+	if err != nil {
+		return err
+	}
+
+	for field, value := range values {
+		curValues[field] = value
+	}
+
+	return db.set(table, key, curValues)
+}
+
+func (db *memcacheDB) Insert(ctx context.Context, table string, key string, values map[string][]byte) error {
+	return db.set(table, key, values)
+}
+
+func (db *memcacheDB) Delete(ctx context.Context, table string, key string) error {
+	err := db.client.Delete(getKey(table, key))
+	if err == memcache.ErrCacheMiss {
+		return nil
+	}
+	return err
+}
+
+func init() {
+	ycsb.RegisterDBCreator("memcache", memcacheCreator{})
+	ycsb.RegisterDBCreator("memcached", memcacheCreator{})
+
+	prop.Register(prop.String, memcacheServers)
+	prop.Register(prop.Int, memcacheMaxIdleConns)
+	prop.Register(prop.Duration, memcacheTimeout)
+}
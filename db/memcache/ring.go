@@ -0,0 +1,85 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memcache
+
+import (
+	"fmt"
+	"hash/crc32"
+	"net"
+	"sort"
+)
+
+// virtualNodes is the number of points every server has on the ring.
+const virtualNodes = 160
+
+type ringPoint struct {
+	hash uint32
+	addr net.Addr
+}
+
+// hashRing picks the server of a key by consistent hashing, so only a part of
+// the keys are moved when a server is added or removed.
+type hashRing struct {
+	points []ringPoint
+	addrs  []net.Addr
+}
+
+func newHashRing(servers []string) (*hashRing, error) {
+	r := &hashRing{}
+	for _, server := range servers {
+		addr, err := net.ResolveTCPAddr("tcp", server)
+		if err != nil {
+			return nil, err
+		}
+		r.addrs = append(r.addrs, addr)
+
+		for i := 0; i < virtualNodes; i++ {
+			r.points = append(r.points, ringPoint{
+				hash: crc32.ChecksumIEEE([]byte(fmt.Sprintf("%s-%d", server, i))),
+				addr: addr,
+			})
+		}
+	}
+
+	if len(r.addrs) == 0 {
+		return nil, fmt.Errorf("no memcached server")
+	}
+
+	sort.Slice(r.points, func(i, j int) bool {
+		return r.points[i].hash < r.points[j].hash
+	})
+	return r, nil
+}
+
+// PickServer implements the memcache.ServerSelector PickServer interface.
+func (r *hashRing) PickServer(key string) (net.Addr, error) {
+	h := crc32.ChecksumIEEE([]byte(key))
+	i := sort.Search(len(r.points), func(i int) bool {
+		return r.points[i].hash >= h
+	})
+	if i == len(r.points) {
+		i = 0
+	}
+	return r.points[i].addr, nil
+}
+
+// Each implements the memcache.ServerSelector Each interface.
+func (r *hashRing) Each(f func(net.Addr) error) error {
+	for _, addr := range r.addrs {
+		if err := f(addr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,250 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/util"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+)
+
+// memory properties
+const (
+	// Use 1 shard to make all the operations contend for one lock.
+	memoryShards = "memory.shards"
+	// How long the lock is held by busy waiting in every operation, to simulate the contention.
+	memoryLockContention = "memory.lock_contention"
+)
+
+type memoryCreator struct {
+}
+
+type memoryShard struct {
+	sync.RWMutex
+	rows map[string]map[string][]byte
+}
+
+// memoryDB keeps the rows in a sharded map, it is used to validate the workloads and the measurement
+// without any external database.
+type memoryDB struct {
+	shards   []*memoryShard
+	holdTime time.Duration
+}
+
+func (c memoryCreator) Create(p *properties.Properties) (ycsb.DB, error) {
+	shardCount := p.GetInt(memoryShards, 64)
+	if shardCount <= 0 {
+		return nil, fmt.Errorf("%s must be positive, but got %d", memoryShards, shardCount)
+	}
+
+	db := &memoryDB{
+		shards:   make([]*memoryShard, shardCount),
+		holdTime: p.GetParsedDuration(memoryLockContention, 0),
+	}
+	for i := range db.shards {
+		db.shards[i] = &memoryShard{rows: make(map[string]map[string][]byte)}
+	}
+
+	return db, nil
+}
+
+func rowKey(table string, key string) string {
+	return table + "/" + key
+}
+
+func (db *memoryDB) shard(key string) *memoryShard {
+	return db.shards[uint64(util.StringHash64(key))%uint64(len(db.shards))]
+}
+
+// hold busy waits for the hold time, the caller must hold the lock.
+func (db *memoryDB) hold() {
+	if db.holdTime <= 0 {
+		return
+	}
+
+	deadline := time.Now().Add(db.holdTime)
+	for time.Now().Before(deadline) {
+	}
+}
+
+func (db *memoryDB) Close() error {
+	return nil
+}
+
+func (db *memoryDB) InitThread(ctx context.Context, _ int, _ int) context.Context {
+	return ctx
+}
+
+func (db *memoryDB) CleanupThread(_ context.Context) {
+}
+
+func copyRow(row map[string][]byte, fields []string) map[string][]byte {
+	if len(fields) == 0 {
+		res := make(map[string][]byte, len(row))
+		for field, value := range row {
+			res[field] = value
+		}
+		return res
+	}
+
+	res := make(map[string][]byte, len(fields))
+	for _, field := range fields {
+		if value, ok := row[field]; ok {
+			res[field] = value
+		}
+	}
+	return res
+}
+
+func (db *memoryDB) Read(ctx context.Context, table string, key string, fields []string) (map[string][]byte, error) {
+	k := rowKey(table, key)
+	s := db.shard(k)
+
+	s.RLock()
+	defer s.RUnlock()
+	db.hold()
+
+	row, ok := s.rows[k]
+	if !ok {
+		return nil, nil
+	}
+	return copyRow(row, fields), nil
+}
+
+// Scan walks all the shards since the map is not ordered, it is slow for a large table.
+func (db *memoryDB) Scan(ctx context.Context, table string, startKey string, count int, fields []string) ([]map[string][]byte, error) {
+	prefix := rowKey(table, "")
+	start := rowKey(table, startKey)
+
+	var keys []string
+	for _, s := range db.shards {
+		s.RLock()
+		for k := range s.rows {
+			if k >= start && strings.HasPrefix(k, prefix) {
+				keys = append(keys, k)
+			}
+		}
+		s.RUnlock()
+	}
+
+	sort.Strings(keys)
+	if len(keys) > count {
+		keys = keys[:count]
+	}
+
+	res := make([]map[string][]byte, 0, len(keys))
+	for _, k := range keys {
+		s := db.shard(k)
+		s.RLock()
+		db.hold()
+		// The row may be deleted after the keys are collected.
+		if row, ok := s.rows[k]; ok {
+			res = append(res, copyRow(row, fields))
+		}
+		s.RUnlock()
+	}
+	return res, nil
+}
+
+// ReverseScan walks all the shards like Scan, and returns the records before the start key in the descending order.
+func (db *memoryDB) ReverseScan(ctx context.Context, table string, startKey string, count int, fields []string) ([]map[string][]byte, error) {
+	prefix := rowKey(table, "")
+	start := rowKey(table, startKey)
+
+	var keys []string
+	for _, s := range db.shards {
+		s.RLock()
+		for k := range s.rows {
+			if k <= start && strings.HasPrefix(k, prefix) {
+				keys = append(keys, k)
+			}
+		}
+		s.RUnlock()
+	}
+
+	sort.Sort(sort.Reverse(sort.StringSlice(keys)))
+	if len(keys) > count {
+		keys = keys[:count]
+	}
+
+	res := make([]map[string][]byte, 0, len(keys))
+	for _, k := range keys {
+		s := db.shard(k)
+		s.RLock()
+		db.hold()
+		// The row may be deleted after the keys are collected.
+		if row, ok := s.rows[k]; ok {
+			res = append(res, copyRow(row, fields))
+		}
+		s.RUnlock()
+	}
+	return res, nil
+}
+
+// set merges the values into the row, or replaces the row if replace is true.
+func (db *memoryDB) set(table string, key string, values map[string][]byte, replace bool) {
+	k := rowKey(table, key)
+	s := db.shard(k)
+
+	s.Lock()
+	defer s.Unlock()
+	db.hold()
+
+	row, ok := s.rows[k]
+	if !ok || replace {
+		row = make(map[string][]byte, len(values))
+		s.rows[k] = row
+	}
+	// The values may be reused by the workload, so we must copy them.
+	for field, value := range values {
+		row[field] = append([]byte(nil), value...)
+	}
+}
+
+func (db *memoryDB) Update(ctx context.Context, table string, key string, values map[string][]byte) error {
+	db.set(table, key, values, false)
+	return nil
+}
+
+func (db *memoryDB) Insert(ctx context.Context, table string, key string, values map[string][]byte) error {
+	db.set(table, key, values, true)
+	return nil
+}
+
+func (db *memoryDB) Delete(ctx context.Context, table string, key string) error {
+	k := rowKey(table, key)
+	s := db.shard(k)
+
+	s.Lock()
+	defer s.Unlock()
+	db.hold()
+
+	delete(s.rows, k)
+	return nil
+}
+
+func init() {
+	ycsb.RegisterDBCreator("memory", memoryCreator{})
+
+	prop.Register(prop.Int, memoryShards)
+	prop.Register(prop.Duration, memoryLockContention)
+}
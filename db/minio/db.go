@@ -7,6 +7,7 @@ import (
 
 	"github.com/magiconair/properties"
 	"github.com/minio/minio-go"
+	"github.com/pingcap/go-ycsb/pkg/prop"
 	"github.com/pingcap/go-ycsb/pkg/ycsb"
 )
 
@@ -125,4 +126,7 @@ func (db *minioDB) Delete(ctx context.Context, table string, key string) error {
 
 func init() {
 	ycsb.RegisterDBCreator("minio", minioCreator{})
+
+	prop.Register(prop.String, minioAccessKey, minioSecretKey, minioEndpoint)
+	prop.Register(prop.Bool, minioSecure)
 }
@@ -4,14 +4,21 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/util"
 	"github.com/pingcap/go-ycsb/pkg/ycsb"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
 	"go.mongodb.org/mongo-driver/x/network/command"
 	"go.mongodb.org/mongo-driver/x/network/connstring"
+	"go.uber.org/zap"
 )
 
 const (
@@ -20,6 +27,12 @@ const (
 	mongodbAuthdb    = "mongodb.authdb"
 	mongodbUsername  = "mongodb.username"
 	mongodbPassword  = "mongodb.password"
+	// "majority", the number of nodes or a tag set
+	mongodbW = "mongodb.w"
+	// "primary", "primaryPreferred", "secondary", "secondaryPreferred" or "nearest"
+	mongodbReadPref = "mongodb.readpref"
+	// Comma separated fields to create indexes on during load
+	mongodbIndexes = "mongodb.indexes"
 
 	mongodbUriDefault       = "mongodb://127.0.0.1:27017"
 	mongodbNamespaceDefault = "ycsb.ycsb"
@@ -82,6 +95,30 @@ func (m *mongoDB) Scan(ctx context.Context, table string, startKey string, count
 	return docs, nil
 }
 
+// Query documents by the field, the field should be indexed by mongodb.indexes.
+func (m *mongoDB) Query(ctx context.Context, table string, field string, value []byte, count int, fields []string) ([]map[string][]byte, error) {
+	projection := map[string]bool{"_id": false}
+	for _, field := range fields {
+		projection[field] = true
+	}
+	limit := int64(count)
+	opt := &options.FindOptions{Projection: projection, Limit: &limit}
+	cursor, err := m.coll.Find(ctx, bson.M{field: value}, opt)
+	if err != nil {
+		return nil, fmt.Errorf("Query error: %s", err.Error())
+	}
+	defer cursor.Close(ctx)
+	var docs []map[string][]byte
+	for cursor.Next(ctx) {
+		var doc map[string][]byte
+		if err := cursor.Decode(&doc); err != nil {
+			return docs, fmt.Errorf("Query error: %s", err.Error())
+		}
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
+
 // Insert a document.
 func (m *mongoDB) Insert(ctx context.Context, table string, key string, values map[string][]byte) error {
 	doc := bson.M{"_id": key}
@@ -89,7 +126,7 @@ func (m *mongoDB) Insert(ctx context.Context, table string, key string, values m
 		doc[k] = v
 	}
 	if _, err := m.coll.InsertOne(ctx, doc); err != nil {
-		fmt.Println(err)
+		util.Logger().Debug("insert failed", zap.Error(err))
 		return fmt.Errorf("Insert error: %s", err.Error())
 	}
 	return nil
@@ -162,17 +199,76 @@ func (c mongodbCreator) Create(p *properties.Properties) (ycsb.DB, error) {
 		return nil, errors.New("auth failed")
 	}
 
-	fmt.Println("Connected to MongoDB!")
+	util.Logger().Info("connected to MongoDB")
+
+	collOpts := options.Collection()
+	if w, ok := p.Get(mongodbW); ok {
+		collOpts.SetWriteConcern(parseWriteConcern(w))
+	}
+	if mode, ok := p.Get(mongodbReadPref); ok {
+		rpMode, err := readpref.ModeFromString(mode)
+		if err != nil {
+			return nil, err
+		}
+		rp, err := readpref.New(rpMode)
+		if err != nil {
+			return nil, err
+		}
+		collOpts.SetReadPreference(rp)
+	}
 
 	m := &mongoDB{
 		cli:      cli,
 		dbname:   ns.DB,
 		collname: ns.Collection,
-		coll:     cli.Database(ns.DB).Collection(ns.Collection),
+		coll:     cli.Database(ns.DB).Collection(ns.Collection, collOpts),
+	}
+
+	if !p.GetBool(prop.DoTransactions, true) {
+		if err := m.prepareCollection(ctx, p); err != nil {
+			return nil, err
+		}
 	}
 	return m, nil
 }
 
+func parseWriteConcern(w string) *writeconcern.WriteConcern {
+	if w == "majority" {
+		return writeconcern.New(writeconcern.WMajority())
+	}
+	if n, err := strconv.Atoi(w); err == nil {
+		return writeconcern.New(writeconcern.W(n))
+	}
+	return writeconcern.New(writeconcern.WTagSet(w))
+}
+
+// prepareCollection drops the collection if dropdata is set and creates the indexes before load.
+func (m *mongoDB) prepareCollection(ctx context.Context, p *properties.Properties) error {
+	if p.GetBool(prop.DropData, prop.DropDataDefault) {
+		if err := m.coll.Drop(ctx); err != nil {
+			return err
+		}
+	}
+
+	var models []mongo.IndexModel
+	for _, field := range strings.Split(p.GetString(mongodbIndexes, ""), ",") {
+		field = strings.TrimSpace(field)
+		if len(field) > 0 {
+			models = append(models, mongo.IndexModel{Keys: bson.M{field: 1}})
+		}
+	}
+	if len(models) == 0 {
+		return nil
+	}
+
+	_, err := m.coll.Indexes().CreateMany(ctx, models)
+	return err
+}
+
 func init() {
 	ycsb.RegisterDBCreator("mongodb", mongodbCreator{})
+
+	prop.Register(prop.String,
+		mongodbUri, mongodbNamespace, mongodbAuthdb, mongodbUsername, mongodbPassword, mongodbW,
+		mongodbReadPref, mongodbIndexes)
 }
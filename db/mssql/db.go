@@ -0,0 +1,428 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mssql
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	// mssql package
+	_ "github.com/denisenkom/go-mssqldb"
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/util"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+	"go.uber.org/zap"
+)
+
+// mssql properties
+const (
+	mssqlHost     = "mssql.host"
+	mssqlPort     = "mssql.port"
+	mssqlUser     = "mssql.user"
+	mssqlPassword = "mssql.password"
+	mssqlDBName   = "mssql.db"
+	// "disable", "false" or "true"
+	mssqlEncrypt = "mssql.encrypt"
+	// Use MERGE in Insert to overwrite the existing row instead of failing.
+	mssqlUpsert = "mssql.upsert"
+)
+
+type mssqlCreator struct {
+}
+
+type mssqlDB struct {
+	p       *properties.Properties
+	db      *sql.DB
+	verbose bool
+	upsert  bool
+
+	bufPool *util.BufPool
+
+	queryLogger *util.QueryLogger
+}
+
+type contextKey string
+
+const stateKey = contextKey("mssqlDB")
+
+type mssqlState struct {
+	// Do we need a LRU cache here?
+	stmtCache map[string]*sql.Stmt
+
+	conn *sql.Conn
+}
+
+func (c mssqlCreator) Create(p *properties.Properties) (ycsb.DB, error) {
+	d := new(mssqlDB)
+	d.p = p
+
+	host := p.GetString(mssqlHost, "127.0.0.1")
+	port := p.GetInt(mssqlPort, 1433)
+	user := p.GetString(mssqlUser, "sa")
+	password := p.GetString(mssqlPassword, "")
+	dbName := p.GetString(mssqlDBName, "test")
+
+	query := url.Values{}
+	query.Set("database", dbName)
+	query.Set("encrypt", p.GetString(mssqlEncrypt, "disable"))
+	dsn := url.URL{
+		Scheme:   "sqlserver",
+		User:     url.UserPassword(user, password),
+		Host:     fmt.Sprintf("%s:%d", host, port),
+		RawQuery: query.Encode(),
+	}
+
+	db, err := sql.Open("sqlserver", dsn.String())
+	if err != nil {
+		util.Logger().Error("open mssql failed", zap.Error(err))
+		return nil, err
+	}
+
+	threadCount := int(p.GetInt64(prop.ThreadCount, prop.ThreadCountDefault))
+	db.SetMaxIdleConns(threadCount + 1)
+	db.SetMaxOpenConns(threadCount * 2)
+
+	d.verbose = p.GetBool(prop.Verbose, prop.VerboseDefault)
+	d.upsert = p.GetBool(mssqlUpsert, false)
+	d.db = db
+
+	d.bufPool = util.NewBufPool()
+
+	if d.queryLogger, err = util.NewQueryLogger(p); err != nil {
+		return nil, err
+	}
+
+	for _, tableName := range util.TableNames(p) {
+		if err := d.createTable(tableName); err != nil {
+			return nil, err
+		}
+	}
+
+	return d, nil
+}
+
+func (db *mssqlDB) createTable(tableName string) error {
+	if db.p.GetBool(prop.DropData, prop.DropDataDefault) {
+		if _, err := db.db.Exec(fmt.Sprintf("DROP TABLE IF EXISTS %s", tableName)); err != nil {
+			return err
+		}
+	}
+
+	fieldLength := db.p.GetInt64(prop.FieldLength, prop.FieldLengthDefault)
+
+	// SQL Server doesn't support CREATE TABLE IF NOT EXISTS.
+	buf := new(bytes.Buffer)
+	s := fmt.Sprintf("IF OBJECT_ID(N'%s', N'U') IS NULL CREATE TABLE %s (YCSB_KEY NVARCHAR(64) PRIMARY KEY", tableName, tableName)
+	buf.WriteString(s)
+
+	for _, field := range util.FieldNames(db.p) {
+		buf.WriteString(fmt.Sprintf(", %s NVARCHAR(%d)", strings.ToUpper(field), fieldLength))
+	}
+
+	buf.WriteString(");")
+
+	if db.verbose {
+		util.Logger().Info("query", zap.String("query", buf.String()))
+	}
+
+	_, err := db.db.Exec(buf.String())
+	return err
+}
+
+func (db *mssqlDB) Close() error {
+	db.queryLogger.Close()
+
+	if db.db == nil {
+		return nil
+	}
+
+	return db.db.Close()
+}
+
+func (db *mssqlDB) InitThread(ctx context.Context, _ int, _ int) context.Context {
+	conn, err := db.db.Conn(ctx)
+	if err != nil {
+		panic(fmt.Sprintf("failed to create db conn %v", err))
+	}
+
+	state := &mssqlState{
+		stmtCache: make(map[string]*sql.Stmt),
+		conn:      conn,
+	}
+
+	return context.WithValue(ctx, stateKey, state)
+}
+
+func (db *mssqlDB) CleanupThread(ctx context.Context) {
+	state := ctx.Value(stateKey).(*mssqlState)
+
+	for _, stmt := range state.stmtCache {
+		stmt.Close()
+	}
+	state.conn.Close()
+}
+
+func (db *mssqlDB) getAndCacheStmt(ctx context.Context, query string) (*sql.Stmt, error) {
+	state := ctx.Value(stateKey).(*mssqlState)
+
+	if stmt, ok := state.stmtCache[query]; ok {
+		return stmt, nil
+	}
+
+	stmt, err := state.conn.PrepareContext(ctx, query)
+	if err == sql.ErrConnDone {
+		// Try build the connection and prepare again
+		if state.conn, err = db.db.Conn(ctx); err == nil {
+			stmt, err = state.conn.PrepareContext(ctx, query)
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	state.stmtCache[query] = stmt
+	return stmt, nil
+}
+
+func (db *mssqlDB) clearCacheIfFailed(ctx context.Context, query string, err error) {
+	if err == nil {
+		return
+	}
+
+	state := ctx.Value(stateKey).(*mssqlState)
+	if stmt, ok := state.stmtCache[query]; ok {
+		stmt.Close()
+	}
+	delete(state.stmtCache, query)
+}
+
+func (db *mssqlDB) queryRows(ctx context.Context, query string, count int, args ...interface{}) ([]map[string][]byte, error) {
+	if db.verbose {
+		util.Logger().Info("query", zap.String("query", query), zap.Reflect("args", args))
+	}
+
+	stmt, err := db.getAndCacheStmt(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		db.queryLogger.Log(start, query, args, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	vs := make([]map[string][]byte, 0, count)
+	for rows.Next() {
+		m := make(map[string][]byte, len(cols))
+		dest := make([]interface{}, len(cols))
+		for i := 0; i < len(cols); i++ {
+			v := new([]byte)
+			dest[i] = v
+		}
+		if err = rows.Scan(dest...); err != nil {
+			break
+		}
+
+		for i, v := range dest {
+			m[cols[i]] = *v.(*[]byte)
+		}
+
+		vs = append(vs, m)
+	}
+	if err == nil {
+		err = rows.Err()
+	}
+
+	db.queryLogger.Log(start, query, args, err)
+	return vs, err
+}
+
+func (db *mssqlDB) Read(ctx context.Context, table string, key string, fields []string) (map[string][]byte, error) {
+	var query string
+	if len(fields) == 0 {
+		query = fmt.Sprintf(`SELECT * FROM %s WHERE YCSB_KEY = @p1`, table)
+	} else {
+		query = fmt.Sprintf(`SELECT %s FROM %s WHERE YCSB_KEY = @p1`, strings.Join(fields, ","), table)
+	}
+
+	rows, err := db.queryRows(ctx, query, 1, key)
+	db.clearCacheIfFailed(ctx, query, err)
+
+	if err != nil {
+		return nil, err
+	} else if len(rows) == 0 {
+		return nil, nil
+	}
+
+	return rows[0], nil
+}
+
+func (db *mssqlDB) Scan(ctx context.Context, table string, startKey string, count int, fields []string) ([]map[string][]byte, error) {
+	// SQL Server doesn't support LIMIT, the rows must be ordered to make TOP return the first rows.
+	var query string
+	if len(fields) == 0 {
+		query = fmt.Sprintf(`SELECT TOP (@p2) * FROM %s WHERE YCSB_KEY >= @p1 ORDER BY YCSB_KEY`, table)
+	} else {
+		query = fmt.Sprintf(`SELECT TOP (@p2) %s FROM %s WHERE YCSB_KEY >= @p1 ORDER BY YCSB_KEY`, strings.Join(fields, ","), table)
+	}
+
+	rows, err := db.queryRows(ctx, query, count, startKey, count)
+	db.clearCacheIfFailed(ctx, query, err)
+
+	return rows, err
+}
+
+func (db *mssqlDB) execQuery(ctx context.Context, query string, args ...interface{}) error {
+	if db.verbose {
+		util.Logger().Info("query", zap.String("query", query), zap.Reflect("args", args))
+	}
+
+	stmt, err := db.getAndCacheStmt(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	_, err = stmt.ExecContext(ctx, args...)
+	db.queryLogger.Log(start, query, args, err)
+	db.clearCacheIfFailed(ctx, query, err)
+	return err
+}
+
+func (db *mssqlDB) Update(ctx context.Context, table string, key string, values map[string][]byte) error {
+	buf := db.bufPool.Get()
+	defer db.bufPool.Put(buf)
+
+	buf.WriteString("UPDATE ")
+	buf.WriteString(table)
+	buf.WriteString(" SET ")
+	args := make([]interface{}, 0, len(values)+1)
+	pairs := util.NewFieldPairs(values)
+	for i, p := range pairs {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+
+		// The values are passed as strings, []byte is sent as VARBINARY and can't be converted to NVARCHAR.
+		buf.WriteString(fmt.Sprintf("%s = @p%d", p.Field, i+1))
+		args = append(args, string(p.Value))
+	}
+	buf.WriteString(fmt.Sprintf(" WHERE YCSB_KEY = @p%d", len(pairs)+1))
+
+	args = append(args, key)
+
+	return db.execQuery(ctx, buf.String(), args...)
+}
+
+func (db *mssqlDB) Insert(ctx context.Context, table string, key string, values map[string][]byte) error {
+	args := make([]interface{}, 0, 1+len(values))
+	args = append(args, key)
+
+	buf := db.bufPool.Get()
+	defer db.bufPool.Put(buf)
+
+	pairs := util.NewFieldPairs(values)
+	for _, p := range pairs {
+		args = append(args, string(p.Value))
+	}
+
+	if db.upsert {
+		db.writeMerge(buf, table, pairs)
+	} else {
+		buf.WriteString("INSERT INTO ")
+		buf.WriteString(table)
+		buf.WriteString(" (YCSB_KEY")
+		for _, p := range pairs {
+			buf.WriteString(", ")
+			buf.WriteString(p.Field)
+		}
+		buf.WriteString(") VALUES (@p1")
+		for i := range pairs {
+			buf.WriteString(fmt.Sprintf(", @p%d", i+2))
+		}
+		buf.WriteString(")")
+	}
+
+	return db.execQuery(ctx, buf.String(), args...)
+}
+
+// writeMerge writes the MERGE statement which inserts the row or updates the fields if the key exists.
+// HOLDLOCK is required, otherwise the concurrent MERGE of the same key may fail with the duplicate key error.
+func (db *mssqlDB) writeMerge(buf *bytes.Buffer, table string, pairs util.FieldPairs) {
+	buf.WriteString("MERGE INTO ")
+	buf.WriteString(table)
+	buf.WriteString(" WITH (HOLDLOCK) AS t USING (VALUES (@p1")
+	for i := range pairs {
+		buf.WriteString(fmt.Sprintf(", @p%d", i+2))
+	}
+	buf.WriteString(")) AS s (YCSB_KEY")
+	for _, p := range pairs {
+		buf.WriteString(", ")
+		buf.WriteString(p.Field)
+	}
+	buf.WriteString(") ON t.YCSB_KEY = s.YCSB_KEY")
+
+	if len(pairs) > 0 {
+		buf.WriteString(" WHEN MATCHED THEN UPDATE SET ")
+		for i, p := range pairs {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			buf.WriteString(fmt.Sprintf("%s = s.%s", p.Field, p.Field))
+		}
+	}
+
+	buf.WriteString(" WHEN NOT MATCHED THEN INSERT (YCSB_KEY")
+	for _, p := range pairs {
+		buf.WriteString(", ")
+		buf.WriteString(p.Field)
+	}
+	buf.WriteString(") VALUES (s.YCSB_KEY")
+	for _, p := range pairs {
+		buf.WriteString(", s.")
+		buf.WriteString(p.Field)
+	}
+	// MERGE must be terminated by a semicolon.
+	buf.WriteString(");")
+}
+
+func (db *mssqlDB) Delete(ctx context.Context, table string, key string) error {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE YCSB_KEY = @p1`, table)
+
+	return db.execQuery(ctx, query, key)
+}
+
+func init() {
+	ycsb.RegisterDBCreator("mssql", mssqlCreator{})
+	ycsb.RegisterDBCreator("sqlserver", mssqlCreator{})
+
+	prop.Register(prop.String, mssqlHost, mssqlUser, mssqlPassword, mssqlDBName, mssqlEncrypt)
+	prop.Register(prop.Int, mssqlPort)
+	prop.Register(prop.Bool, mssqlUpsert)
+}
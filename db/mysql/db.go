@@ -18,36 +18,107 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"net"
+	"sort"
+	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
 
+	"github.com/pingcap/go-ycsb/pkg/measurement"
 	"github.com/pingcap/go-ycsb/pkg/prop"
 	"github.com/pingcap/go-ycsb/pkg/util"
 
-	// mysql package
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
 	"github.com/magiconair/properties"
 	"github.com/pingcap/go-ycsb/pkg/ycsb"
+	"go.uber.org/zap"
 )
 
 // mysql properties
 const (
+	// Comma separated hosts like "h1,h2:3307", threads are spread over them.
 	mysqlHost       = "mysql.host"
 	mysqlPort       = "mysql.port"
 	mysqlUser       = "mysql.user"
 	mysqlPassword   = "mysql.password"
 	mysqlDBName     = "mysql.db"
 	mysqlForceIndex = "mysql.force_index"
-	// TODO: support batch and auto commit
+	mysqlBatchSize  = "mysql.batchsize"
+	// "false", "true" or "skip-verify"
+	mysqlTLS      = "mysql.tls"
+	mysqlCAFile   = "mysql.ca_file"
+	mysqlCertFile = "mysql.cert_file"
+	mysqlKeyFile  = "mysql.key_file"
+	// If autocommit is false, operations are executed in explicit transactions
+	// and every transaction contains txnsize operations.
+	mysqlAutoCommit = "mysql.autocommit"
+	mysqlTxnSize    = "mysql.txnsize"
+	// If transactional_rmw is true, read-modify-write is executed with
+	// SELECT ... FOR UPDATE and UPDATE in one transaction.
+	mysqlTransactionalRMW = "mysql.transactional_rmw"
+	mysqlRMWMaxRetries    = "mysql.rmw_max_retries"
+	// Column types of the fields, like "FIELD0:BIGINT,FIELD1:JSON,FIELD2:TEXT",
+	// the fields not specified are VARCHAR(fieldlength).
+	mysqlFieldTypes = "mysql.field_types"
+	// "insert", "insert_ignore", "replace" or "upsert"
+	mysqlInsertMode = "mysql.insert_mode"
+	// Session variables set on every thread connection, like "tidb_txn_mode=pessimistic,tidb_enable_async_commit=1"
+	mysqlSessionVars = "mysql.session_vars"
+	// Comma separated read replicas, reads out of explicit transactions are routed to them round-robin.
+	mysqlReadReplicas = "mysql.read_replicas"
+	// Max prepared statements cached by every connection, 0 means unlimited.
+	mysqlStmtCacheSize = "mysql.stmt_cache_size"
 )
 
 type mysqlCreator struct {
+	// sessionVars are assignments like "a = 1" set on every thread connection before mysql.session_vars.
+	sessionVars []string
+	// presplitRegions is the number of regions to split the TiDB table into before loading.
+	presplitRegions int
 }
 
 type mysqlDB struct {
-	p                 *properties.Properties
+	p *properties.Properties
+	// db is the first primary, used to create the table.
 	db                *sql.DB
+	primaries         []*sql.DB
+	replicas          []*sql.DB
 	verbose           bool
 	forceIndexKeyword string
+	batchSize         int
+	autoCommit        bool
+	txnSize           int
+	rmwMaxRetries     int
+
+	secondaryIndexes []string
+	// queryColumn is the column used to look up records in Read and Scan, YCSB_KEY or a secondary index.
+	queryColumn       string
+	queryIndexKeyword string
+	queryOrderBy      string
+
+	// keySchema is set if the keys are composite, the components are stored in the key columns.
+	keySchema  *util.KeySchema
+	keyColumns []string
+
+	fieldTypes map[string]columnType
+
+	presplitRegions int
+
+	// insertKeyword is the statement prefix of the insert, like "INSERT IGNORE INTO ",
+	// upsert appends ON DUPLICATE KEY UPDATE to the insert statement.
+	insertKeyword string
+	upsert        bool
+
+	// setSessionVars is the SET statement executed on every thread connection, empty if no session variable.
+	setSessionVars string
+
+	retry *retryPolicy
+
+	queryLogger *util.QueryLogger
+
+	stmtCacheSize  int
+	stmtCacheStats stmtCacheStats
 
 	bufPool *util.BufPool
 }
@@ -57,10 +128,27 @@ type contextKey string
 const stateKey = contextKey("mysqlDB")
 
 type mysqlState struct {
-	// Do we need a LRU cache here?
-	stmtCache map[string]*sql.Stmt
+	stmtCache *stmtCache
 
 	conn *sql.Conn
+	// pool is the primary which the conn belongs to.
+	pool *sql.DB
+
+	replicas   []*replicaConn
+	replicaIdx int
+
+	// pending rows buffered by Insert when batch insert is enabled,
+	// they will be flushed when the buffer is full or the thread is cleaned up.
+	pendingTable  string
+	pendingKeys   []string
+	pendingValues []map[string][]byte
+
+	// inTxn is true if an explicit transaction is started on the conn,
+	// txnOps is the number of operations executed in it.
+	inTxn  bool
+	txnOps int
+	// userTxn is true if the transaction is started by Begin, it is only ended by Commit or Rollback.
+	userTxn bool
 }
 
 func (c mysqlCreator) Create(p *properties.Properties) (ycsb.DB, error) {
@@ -73,104 +161,370 @@ func (c mysqlCreator) Create(p *properties.Properties) (ycsb.DB, error) {
 	password := p.GetString(mysqlPassword, "")
 	dbName := p.GetString(mysqlDBName, "test")
 
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s", user, password, host, port, dbName)
-	var err error
-	db, err := sql.Open("mysql", dsn)
+	tlsName, err := registerTLSConfig(p)
 	if err != nil {
 		return nil, err
 	}
 
 	threadCount := int(p.GetInt64(prop.ThreadCount, prop.ThreadCountDefault))
-	db.SetMaxIdleConns(threadCount + 1)
-	db.SetMaxOpenConns(threadCount * 2)
+	openDBs := func(hosts string) ([]*sql.DB, error) {
+		var dbs []*sql.DB
+		for _, addr := range splitHosts(hosts, port) {
+			dsn := fmt.Sprintf("%s:%s@tcp(%s)/%s", user, password, addr, dbName)
+			if len(tlsName) > 0 {
+				dsn += "?tls=" + tlsName
+			}
+
+			db, err := sql.Open("mysql", dsn)
+			if err != nil {
+				return nil, err
+			}
+			db.SetMaxIdleConns(threadCount + 1)
+			db.SetMaxOpenConns(threadCount * 2)
+			dbs = append(dbs, db)
+		}
+		return dbs, nil
+	}
+
+	if d.primaries, err = openDBs(host); err != nil {
+		return nil, err
+	}
+	if len(d.primaries) == 0 {
+		return nil, fmt.Errorf("empty %s", mysqlHost)
+	}
+	if d.replicas, err = openDBs(p.GetString(mysqlReadReplicas, "")); err != nil {
+		return nil, err
+	}
 
 	d.verbose = p.GetBool(prop.Verbose, prop.VerboseDefault)
 	if p.GetBool(mysqlForceIndex, true) {
 		d.forceIndexKeyword = "FORCE INDEX(`PRIMARY`)"
 	}
-	d.db = db
+	d.batchSize = p.GetInt(mysqlBatchSize, 1)
+	d.autoCommit = p.GetBool(mysqlAutoCommit, true)
+	d.txnSize = p.GetInt(mysqlTxnSize, 1)
+	d.rmwMaxRetries = p.GetInt(mysqlRMWMaxRetries, 3)
+
+	d.secondaryIndexes = util.SecondaryIndexes(p)
+	d.queryColumn = "YCSB_KEY"
+	d.queryIndexKeyword = d.forceIndexKeyword
+	queryField, err := util.SecondaryQueryField(p)
+	if err != nil {
+		return nil, err
+	}
+	if len(queryField) > 0 {
+		d.queryColumn = queryField
+		d.queryOrderBy = fmt.Sprintf(" ORDER BY %s", queryField)
+		if len(d.forceIndexKeyword) > 0 {
+			d.queryIndexKeyword = fmt.Sprintf("FORCE INDEX(%s)", secondaryIndexName(queryField))
+		}
+	}
+
+	d.keySchema = util.NewKeySchema(p)
+	d.keyColumns = d.keySchema.Columns()
+	if d.keySchema != nil && len(queryField) > 0 {
+		return nil, fmt.Errorf("%s can't be used with %s", prop.SecondaryQueryField, prop.KeyComposition)
+	}
+	if d.fieldTypes, err = parseFieldTypes(p.GetString(mysqlFieldTypes, "")); err != nil {
+		return nil, err
+	}
+	if p.GetBool(prop.DataIntegrity, prop.DataIntegrityDefault) {
+		for field, t := range d.fieldTypes {
+			if t.kind != kindString {
+				return nil, fmt.Errorf("%s can't be checked by %s, its type is %s", field, prop.DataIntegrity, t.sqlType)
+			}
+		}
+	}
+	switch mode := p.GetString(mysqlInsertMode, "insert_ignore"); mode {
+	case "insert":
+		d.insertKeyword = "INSERT INTO "
+	case "insert_ignore":
+		d.insertKeyword = "INSERT IGNORE INTO "
+	case "replace":
+		d.insertKeyword = "REPLACE INTO "
+	case "upsert":
+		d.insertKeyword = "INSERT INTO "
+		d.upsert = true
+	default:
+		return nil, fmt.Errorf("unsupported %s %s", mysqlInsertMode, mode)
+	}
+	if d.setSessionVars, err = buildSetSessionVars(c.sessionVars, p.GetString(mysqlSessionVars, "")); err != nil {
+		return nil, err
+	}
+	d.presplitRegions = c.presplitRegions
+	d.stmtCacheSize = p.GetInt(mysqlStmtCacheSize, 128)
+	if d.queryLogger, err = util.NewQueryLogger(p); err != nil {
+		return nil, err
+	}
+	if d.retry, err = newRetryPolicy(p); err != nil {
+		return nil, err
+	}
+	d.db = d.primaries[0]
 
 	d.bufPool = util.NewBufPool()
 
-	if err := d.createTable(); err != nil {
-		return nil, err
+	for _, tableName := range util.TableNames(p) {
+		if err := d.createTable(tableName); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.GetBool(mysqlTransactionalRMW, false) {
+		return &txnRMWDB{d}, nil
 	}
 
 	return d, nil
 }
 
-func (db *mysqlDB) createTable() error {
-	tableName := db.p.GetString(prop.TableName, prop.TableNameDefault)
+// splitHosts splits the comma separated hosts and adds the default port to the hosts without port.
+func splitHosts(hosts string, port int) []string {
+	var addrs []string
+	for _, host := range strings.Split(hosts, ",") {
+		host = strings.TrimSpace(host)
+		if len(host) == 0 {
+			continue
+		}
+
+		if _, _, err := net.SplitHostPort(host); err != nil {
+			host = net.JoinHostPort(host, strconv.Itoa(port))
+		}
+		addrs = append(addrs, host)
+	}
+	return addrs
+}
+
+// registerTLSConfig registers a custom TLS config to the driver if any certificate file is set,
+// and returns the value of the tls parameter used in the DSN.
+func registerTLSConfig(p *properties.Properties) (string, error) {
+	tlsMode := strings.ToLower(p.GetString(mysqlTLS, "false"))
+	caFile := p.GetString(mysqlCAFile, "")
+	certFile := p.GetString(mysqlCertFile, "")
+	keyFile := p.GetString(mysqlKeyFile, "")
+
+	switch tlsMode {
+	case "false":
+		return "", nil
+	case "true", "skip-verify", "preferred":
+	default:
+		return "", fmt.Errorf("invalid %s %q, must be false, true, skip-verify or preferred", mysqlTLS, tlsMode)
+	}
+
+	if len(caFile) == 0 && len(certFile) == 0 && len(keyFile) == 0 {
+		return tlsMode, nil
+	}
+	if tlsMode == "preferred" {
+		return "", fmt.Errorf("%s preferred can't be used with the certificate files", mysqlTLS)
+	}
+
+	config, err := util.CreateTLSConfig(caFile, certFile, keyFile, tlsMode == "skip-verify")
+	if err != nil {
+		return "", err
+	}
+
+	// The server name isn't set, so the driver verifies every host of mysql.host by its own name.
+	const tlsName = "ycsb"
+	if err := mysql.RegisterTLSConfig(tlsName, config); err != nil {
+		return "", err
+	}
+	return tlsName, nil
+}
 
+func (db *mysqlDB) createTable(tableName string) error {
 	if db.p.GetBool(prop.DropData, prop.DropDataDefault) && !db.p.GetBool(prop.DoTransactions, true) {
 		if _, err := db.db.Exec(fmt.Sprintf("DROP TABLE IF EXISTS %s", tableName)); err != nil {
 			return err
 		}
 	}
 
-	fieldCount := db.p.GetInt64(prop.FieldCount, prop.FieldCountDefault)
 	fieldLength := db.p.GetInt64(prop.FieldLength, prop.FieldLengthDefault)
 
 	buf := new(bytes.Buffer)
-	s := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (YCSB_KEY VARCHAR(64) PRIMARY KEY", tableName)
+	s := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s", tableName, db.keySchema.ColumnDefinitions())
 	buf.WriteString(s)
 
-	for i := int64(0); i < fieldCount; i++ {
-		buf.WriteString(fmt.Sprintf(", FIELD%d VARCHAR(%d)", i, fieldLength))
+	for _, field := range util.FieldNames(db.p) {
+		field = strings.ToUpper(field)
+		if t, ok := db.fieldTypes[field]; ok {
+			buf.WriteString(fmt.Sprintf(", %s %s", field, t.sqlType))
+		} else {
+			buf.WriteString(fmt.Sprintf(", %s VARCHAR(%d)", field, fieldLength))
+		}
+	}
+
+	buf.WriteString(db.keySchema.PrimaryKeyDefinition())
+
+	for _, field := range db.secondaryIndexes {
+		buf.WriteString(fmt.Sprintf(", INDEX %s (%s)", secondaryIndexName(field), field))
+	}
+
+	buf.WriteString(")")
+
+	if db.presplitRegions > 1 {
+		// Scatter the rows by the shard bits of the row ID, and split the regions of
+		// the row ID and the primary key when the table is created.
+		bits := 0
+		for bits < maxShardRowIDBits && 1<<uint(bits) < db.presplitRegions {
+			bits++
+		}
+		buf.WriteString(fmt.Sprintf(" SHARD_ROW_ID_BITS = %d PRE_SPLIT_REGIONS = %d", bits, bits))
 	}
 
-	buf.WriteString(");")
+	buf.WriteByte(';')
 
 	if db.verbose {
-		fmt.Println(buf.String())
+		util.Logger().Info("query", zap.String("query", buf.String()))
 	}
 
-	_, err := db.db.Exec(buf.String())
-	return err
+	if _, err := db.db.Exec(buf.String()); err != nil {
+		return err
+	}
+
+	if db.presplitRegions > 1 && !db.p.GetBool(prop.DoTransactions, true) {
+		prefix := db.p.GetString(prop.KeyPrefix, prop.KeyPrefixDefault)
+		if db.keySchema != nil {
+			// The key prefix is not stored in the key columns.
+			prefix = ""
+		}
+		query := fmt.Sprintf("SPLIT TABLE %s INDEX `PRIMARY` BETWEEN ('%s0') AND ('%s9') REGIONS %d", tableName, prefix, prefix, db.presplitRegions)
+		if db.verbose {
+			util.Logger().Info("query", zap.String("query", query))
+		}
+
+		if _, err := db.db.Exec(query); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// buildSetSessionVars builds the SET statement from the assignments and the session variables like "a=1,b=2".
+func buildSetSessionVars(assignments []string, vars string) (string, error) {
+	for _, item := range strings.Split(vars, ",") {
+		item = strings.TrimSpace(item)
+		if len(item) == 0 {
+			continue
+		}
+
+		kv := strings.SplitN(item, "=", 2)
+		if len(kv) != 2 || len(strings.TrimSpace(kv[0])) == 0 {
+			return "", fmt.Errorf("invalid session variable %q, must be like name=value", item)
+		}
+		assignments = append(assignments, fmt.Sprintf("%s = %s", strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])))
+	}
+
+	if len(assignments) == 0 {
+		return "", nil
+	}
+	return "SET SESSION " + strings.Join(assignments, ", "), nil
+}
+
+func secondaryIndexName(field string) string {
+	return fmt.Sprintf("IDX_%s", field)
+}
+
+// BufferedInserts implements the BufferedDB BufferedInserts interface.
+func (db *mysqlDB) BufferedInserts() bool {
+	return db.batchSize > 1
 }
 
 func (db *mysqlDB) Close() error {
-	if db.db == nil {
-		return nil
+	hits := atomic.LoadInt64(&db.stmtCacheStats.hits)
+	misses := atomic.LoadInt64(&db.stmtCacheStats.misses)
+	if hits+misses > 0 {
+		util.Logger().Info("prepared statement cache", zap.Int64("hits", hits), zap.Int64("misses", misses))
+	}
+
+	firstErr := db.queryLogger.Close()
+	for _, pool := range append(db.primaries, db.replicas...) {
+		if err := pool.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
 	}
+	return firstErr
+}
 
-	return db.db.Close()
+// newConn gets a connection from the pool and sets the session variables on it.
+func (db *mysqlDB) newConn(ctx context.Context, pool *sql.DB) (*sql.Conn, error) {
+	conn, err := pool.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(db.setSessionVars) > 0 {
+		if db.verbose {
+			util.Logger().Info("query", zap.String("query", db.setSessionVars))
+		}
+		if _, err = conn.ExecContext(ctx, db.setSessionVars); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to set session variables %v", err)
+		}
+	}
+	return conn, nil
 }
 
-func (db *mysqlDB) InitThread(ctx context.Context, _ int, _ int) context.Context {
-	conn, err := db.db.Conn(ctx)
+func (db *mysqlDB) InitThread(ctx context.Context, threadID int, _ int) context.Context {
+	pool := db.primaries[threadID%len(db.primaries)]
+	conn, err := db.newConn(ctx, pool)
 	if err != nil {
 		panic(fmt.Sprintf("failed to create db conn %v", err))
 	}
 
 	state := &mysqlState{
-		stmtCache: make(map[string]*sql.Stmt),
+		stmtCache: newStmtCache(db.stmtCacheSize, &db.stmtCacheStats),
 		conn:      conn,
+		pool:      pool,
 	}
 
+	for _, replica := range db.replicas {
+		conn, err := db.newConn(ctx, replica)
+		if err != nil {
+			panic(fmt.Sprintf("failed to create replica conn %v", err))
+		}
+		state.replicas = append(state.replicas, &replicaConn{
+			pool:      replica,
+			conn:      conn,
+			stmtCache: newStmtCache(db.stmtCacheSize, &db.stmtCacheStats),
+		})
+	}
+	// Start from different replicas in different threads.
+	state.replicaIdx = threadID
+
 	return context.WithValue(ctx, stateKey, state)
 }
 
 func (db *mysqlDB) CleanupThread(ctx context.Context) {
 	state := ctx.Value(stateKey).(*mysqlState)
 
-	for _, stmt := range state.stmtCache {
-		stmt.Close()
+	if err := db.flushPending(ctx, state); err != nil {
+		util.Logger().Error("flush pending rows failed", zap.Error(err))
 	}
+
+	if state.inTxn {
+		if err := db.commitTxn(ctx, state); err != nil {
+			util.Logger().Error("commit transaction failed", zap.Error(err))
+		}
+	}
+
+	state.stmtCache.close()
 	state.conn.Close()
+
+	for _, replica := range state.replicas {
+		replica.close()
+	}
 }
 
 func (db *mysqlDB) getAndCacheStmt(ctx context.Context, query string) (*sql.Stmt, error) {
 	state := ctx.Value(stateKey).(*mysqlState)
 
-	if stmt, ok := state.stmtCache[query]; ok {
+	if stmt, ok := state.stmtCache.get(query); ok {
 		return stmt, nil
 	}
 
 	stmt, err := state.conn.PrepareContext(ctx, query)
 	if err == sql.ErrConnDone {
 		// Try build the connection and prepare again
-		if state.conn, err = db.db.Conn(ctx); err == nil {
+		if state.conn, err = db.newConn(ctx, state.pool); err == nil {
 			stmt, err = state.conn.PrepareContext(ctx, query)
 		}
 	}
@@ -179,7 +533,7 @@ func (db *mysqlDB) getAndCacheStmt(ctx context.Context, query string) (*sql.Stmt
 		return nil, err
 	}
 
-	state.stmtCache[query] = stmt
+	state.stmtCache.put(query, stmt)
 	return stmt, nil
 }
 
@@ -189,25 +543,121 @@ func (db *mysqlDB) clearCacheIfFailed(ctx context.Context, query string, err err
 	}
 
 	state := ctx.Value(stateKey).(*mysqlState)
-	if stmt, ok := state.stmtCache[query]; ok {
-		stmt.Close()
+	state.stmtCache.remove(query)
+}
+
+// beginTxn starts an explicit transaction on the thread connection if autocommit is disabled
+// and there is no running one.
+func (db *mysqlDB) beginTxn(ctx context.Context, state *mysqlState) error {
+	if db.autoCommit || state.inTxn {
+		return nil
+	}
+
+	if db.verbose {
+		util.Logger().Info("query", zap.String("query", "BEGIN"))
+	}
+
+	if _, err := state.conn.ExecContext(ctx, "BEGIN"); err != nil {
+		return err
 	}
-	delete(state.stmtCache, query)
+	state.inTxn = true
+	state.txnOps = 0
+	return nil
+}
+
+// endTxn commits the transaction when it has executed txnSize operations, or
+// rolls it back if the operation failed.
+func (db *mysqlDB) endTxn(ctx context.Context, state *mysqlState, opErr error) error {
+	if !state.inTxn {
+		return opErr
+	}
+
+	if opErr != nil {
+		if db.verbose {
+			util.Logger().Info("query", zap.String("query", "ROLLBACK"))
+		}
+		state.conn.ExecContext(ctx, "ROLLBACK")
+		state.inTxn = false
+		return opErr
+	}
+
+	state.txnOps++
+	if state.txnOps < db.txnSize {
+		return nil
+	}
+
+	return db.commitTxn(ctx, state)
+}
+
+func (db *mysqlDB) commitTxn(ctx context.Context, state *mysqlState) error {
+	if db.verbose {
+		util.Logger().Info("query", zap.String("query", "COMMIT"))
+	}
+
+	state.inTxn = false
+	_, err := state.conn.ExecContext(ctx, "COMMIT")
+	return err
 }
 
 func (db *mysqlDB) queryRows(ctx context.Context, query string, count int, args ...interface{}) ([]map[string][]byte, error) {
+	state := ctx.Value(stateKey).(*mysqlState)
+	if state.userTxn {
+		// The statement can't be retried alone in the transaction started by Begin.
+		return db.doQueryRows(ctx, query, count, args...)
+	}
+
+	if len(state.replicas) > 0 && db.autoCommit {
+		var rows []map[string][]byte
+		err := db.retry.run(ctx, func() (err error) {
+			rows, err = db.queryReplica(ctx, state, query, count, args...)
+			return
+		})
+		return rows, err
+	}
+
+	if db.autoCommit {
+		var rows []map[string][]byte
+		err := db.retry.run(ctx, func() (err error) {
+			rows, err = db.doQueryRows(ctx, query, count, args...)
+			return
+		})
+		return rows, err
+	}
+
+	if err := db.beginTxn(ctx, state); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.doQueryRows(ctx, query, count, args...)
+	if err = db.endTxn(ctx, state, err); err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+func (db *mysqlDB) doQueryRows(ctx context.Context, query string, count int, args ...interface{}) ([]map[string][]byte, error) {
 	if db.verbose {
-		fmt.Printf("%s %v\n", query, args)
+		util.Logger().Info("query", zap.String("query", query), zap.Reflect("args", args))
 	}
 
 	stmt, err := db.getAndCacheStmt(ctx, query)
 	if err != nil {
 		return nil, err
 	}
+	start := time.Now()
 	rows, err := stmt.QueryContext(ctx, args...)
 	if err != nil {
+		db.queryLogger.Log(start, query, args, err)
 		return nil, err
 	}
+
+	vs, err := scanRows(rows, count)
+	db.queryLogger.Log(start, query, args, err)
+	return vs, err
+}
+
+func scanRows(rows *sql.Rows, count int) ([]map[string][]byte, error) {
 	defer rows.Close()
 
 	cols, err := rows.Columns()
@@ -237,15 +687,38 @@ func (db *mysqlDB) queryRows(ctx context.Context, query string, count int, args
 	return vs, rows.Err()
 }
 
+// queryCondition returns the condition to look up the records by the key in Read and Scan.
+func (db *mysqlDB) queryCondition(op string) string {
+	if db.keySchema != nil {
+		return util.KeyCondition(db.keyColumns, op, util.QuestionMark)
+	}
+	return fmt.Sprintf("%s %s ?", db.queryColumn, op)
+}
+
+// keysCondition returns the IN condition of n keys, like "YCSB_KEY IN (?,?)" or "(TENANT_ID, USER_ID) IN ((?,?),(?,?))".
+func (db *mysqlDB) keysCondition(n int) string {
+	if len(db.keyColumns) == 1 {
+		return fmt.Sprintf("%s IN (%s)", db.keyColumns[0], strings.TrimSuffix(strings.Repeat("?,", n), ","))
+	}
+
+	tuple := "(" + strings.TrimSuffix(strings.Repeat("?,", len(db.keyColumns)), ",") + ")"
+	tuples := strings.TrimSuffix(strings.Repeat(tuple+",", n), ",")
+	return fmt.Sprintf("(%s) IN (%s)", strings.Join(db.keyColumns, ", "), tuples)
+}
+
 func (db *mysqlDB) Read(ctx context.Context, table string, key string, fields []string) (map[string][]byte, error) {
 	var query string
 	if len(fields) == 0 {
-		query = fmt.Sprintf(`SELECT * FROM %s %s WHERE YCSB_KEY = ?`, table, db.forceIndexKeyword)
+		query = fmt.Sprintf(`SELECT * FROM %s %s WHERE %s`, table, db.queryIndexKeyword, db.queryCondition("="))
 	} else {
-		query = fmt.Sprintf(`SELECT %s FROM %s %s WHERE YCSB_KEY = ?`, strings.Join(fields, ","), table, db.forceIndexKeyword)
+		query = fmt.Sprintf(`SELECT %s FROM %s %s WHERE %s`, strings.Join(fields, ","), table, db.queryIndexKeyword, db.queryCondition("="))
+	}
+	if len(db.queryOrderBy) > 0 {
+		// The secondary field may be not unique.
+		query += " LIMIT 1"
 	}
 
-	rows, err := db.queryRows(ctx, query, 1, key)
+	rows, err := db.queryRows(ctx, query, 1, db.keySchema.Values(key)...)
 	db.clearCacheIfFailed(ctx, query, err)
 
 	if err != nil {
@@ -260,20 +733,79 @@ func (db *mysqlDB) Read(ctx context.Context, table string, key string, fields []
 func (db *mysqlDB) Scan(ctx context.Context, table string, startKey string, count int, fields []string) ([]map[string][]byte, error) {
 	var query string
 	if len(fields) == 0 {
-		query = fmt.Sprintf(`SELECT * FROM %s %s WHERE YCSB_KEY >= ? LIMIT ?`, table, db.forceIndexKeyword)
+		query = fmt.Sprintf(`SELECT * FROM %s %s WHERE %s%s LIMIT ?`, table, db.queryIndexKeyword, db.queryCondition(">="), db.queryOrderBy)
+	} else {
+		query = fmt.Sprintf(`SELECT %s FROM %s %s WHERE %s%s LIMIT ?`, strings.Join(fields, ","), table, db.queryIndexKeyword, db.queryCondition(">="), db.queryOrderBy)
+	}
+
+	rows, err := db.queryRows(ctx, query, count, append(db.keySchema.Values(startKey), count)...)
+	db.clearCacheIfFailed(ctx, query, err)
+
+	return rows, err
+}
+
+// ReverseScan scans the records in the descending order of the key, or the secondary query field if it is set.
+func (db *mysqlDB) ReverseScan(ctx context.Context, table string, startKey string, count int, fields []string) ([]map[string][]byte, error) {
+	var query string
+	if len(fields) == 0 {
+		query = fmt.Sprintf(`SELECT * FROM %s %s WHERE %s%s LIMIT ?`, table, db.queryIndexKeyword, db.queryCondition("<="), db.reverseOrderBy())
+	} else {
+		query = fmt.Sprintf(`SELECT %s FROM %s %s WHERE %s%s LIMIT ?`, strings.Join(fields, ","), table, db.queryIndexKeyword, db.queryCondition("<="), db.reverseOrderBy())
+	}
+
+	rows, err := db.queryRows(ctx, query, count, append(db.keySchema.Values(startKey), count)...)
+	db.clearCacheIfFailed(ctx, query, err)
+
+	return rows, err
+}
+
+// reverseOrderBy returns the ORDER BY clause of the descending order of the query columns.
+func (db *mysqlDB) reverseOrderBy() string {
+	columns := []string{db.queryColumn}
+	if db.keySchema != nil {
+		columns = db.keyColumns
+	}
+	return fmt.Sprintf(" ORDER BY %s DESC", strings.Join(columns, " DESC, "))
+}
+
+// Query looks up the records by the field, the field should be indexed by sql.secondary_indexes.
+func (db *mysqlDB) Query(ctx context.Context, table string, field string, value []byte, count int, fields []string) ([]map[string][]byte, error) {
+	var query string
+	if len(fields) == 0 {
+		query = fmt.Sprintf(`SELECT * FROM %s WHERE %s = ? LIMIT ?`, table, field)
 	} else {
-		query = fmt.Sprintf(`SELECT %s FROM %s %s WHERE YCSB_KEY >= ? LIMIT ?`, strings.Join(fields, ","), table, db.forceIndexKeyword)
+		query = fmt.Sprintf(`SELECT %s FROM %s WHERE %s = ? LIMIT ?`, strings.Join(fields, ","), table, field)
 	}
 
-	rows, err := db.queryRows(ctx, query, count, startKey, count)
+	rows, err := db.queryRows(ctx, query, count, db.fieldValue(field, value), count)
 	db.clearCacheIfFailed(ctx, query, err)
 
 	return rows, err
 }
 
 func (db *mysqlDB) execQuery(ctx context.Context, query string, args ...interface{}) error {
+	state := ctx.Value(stateKey).(*mysqlState)
+	if state.userTxn {
+		return db.doExecQuery(ctx, query, args...)
+	}
+
+	if db.autoCommit {
+		return db.retry.run(ctx, func() error {
+			return db.doExecQuery(ctx, query, args...)
+		})
+	}
+
+	if err := db.beginTxn(ctx, state); err != nil {
+		return err
+	}
+
+	err := db.doExecQuery(ctx, query, args...)
+	return db.endTxn(ctx, state, err)
+}
+
+func (db *mysqlDB) doExecQuery(ctx context.Context, query string, args ...interface{}) error {
 	if db.verbose {
-		fmt.Printf("%s %v\n", query, args)
+		util.Logger().Info("query", zap.String("query", query), zap.Reflect("args", args))
 	}
 
 	stmt, err := db.getAndCacheStmt(ctx, query)
@@ -281,12 +813,19 @@ func (db *mysqlDB) execQuery(ctx context.Context, query string, args ...interfac
 		return err
 	}
 
+	start := time.Now()
 	_, err = stmt.ExecContext(ctx, args...)
+	db.queryLogger.Log(start, query, args, err)
 	db.clearCacheIfFailed(ctx, query, err)
 	return err
 }
 
 func (db *mysqlDB) Update(ctx context.Context, table string, key string, values map[string][]byte) error {
+	query, args := db.buildUpdate(table, key, values)
+	return db.execQuery(ctx, query, args...)
+}
+
+func (db *mysqlDB) buildUpdate(table string, key string, values map[string][]byte) (string, []interface{}) {
 	buf := db.bufPool.Get()
 	defer db.bufPool.Put(buf)
 
@@ -305,33 +844,40 @@ func (db *mysqlDB) Update(ctx context.Context, table string, key string, values
 
 		buf.WriteString(p.Field)
 		buf.WriteString(`= ?`)
-		args = append(args, p.Value)
+		args = append(args, db.fieldValue(p.Field, p.Value))
 	}
-	buf.WriteString(" WHERE YCSB_KEY = ?")
+	buf.WriteString(" WHERE ")
+	buf.WriteString(util.KeyCondition(db.keyColumns, "=", util.QuestionMark))
 
-	args = append(args, key)
+	args = append(args, db.keySchema.Values(key)...)
 
-	return db.execQuery(ctx, buf.String(), args...)
+	return buf.String(), args
 }
 
 func (db *mysqlDB) Insert(ctx context.Context, table string, key string, values map[string][]byte) error {
-	args := make([]interface{}, 0, 1+len(values))
-	args = append(args, key)
+	if db.batchSize > 1 {
+		return db.bufferInsert(ctx, table, key, values)
+	}
+
+	args := make([]interface{}, 0, len(db.keyColumns)+len(values))
+	args = append(args, db.keySchema.Values(key)...)
 
 	buf := db.bufPool.Get()
 	defer db.bufPool.Put(buf)
 
-	buf.WriteString("INSERT IGNORE INTO ")
+	buf.WriteString(db.insertKeyword)
 	buf.WriteString(table)
-	buf.WriteString(" (YCSB_KEY")
+	buf.WriteString(" (")
+	buf.WriteString(strings.Join(db.keyColumns, ", "))
 
 	pairs := util.NewFieldPairs(values)
 	for _, p := range pairs {
-		args = append(args, p.Value)
+		args = append(args, db.fieldValue(p.Field, p.Value))
 		buf.WriteString(" ,")
 		buf.WriteString(p.Field)
 	}
 	buf.WriteString(") VALUES (?")
+	buf.WriteString(strings.Repeat(" ,?", len(db.keyColumns)-1))
 
 	for i := 0; i < len(pairs); i++ {
 		buf.WriteString(" ,?")
@@ -339,13 +885,197 @@ func (db *mysqlDB) Insert(ctx context.Context, table string, key string, values
 
 	buf.WriteByte(')')
 
+	if db.upsert {
+		fields := make([]string, 0, len(pairs))
+		for _, p := range pairs {
+			fields = append(fields, p.Field)
+		}
+		writeUpsertClause(buf, db.keyColumns[0], fields)
+	}
+
 	return db.execQuery(ctx, buf.String(), args...)
 }
 
+func (db *mysqlDB) bufferInsert(ctx context.Context, table string, key string, values map[string][]byte) error {
+	state := ctx.Value(stateKey).(*mysqlState)
+
+	if state.pendingTable != table {
+		if err := db.flushPending(ctx, state); err != nil {
+			return err
+		}
+		state.pendingTable = table
+	}
+
+	// The values may be reused by the workload after Insert returns, so we must copy them.
+	row := make(map[string][]byte, len(values))
+	for field, value := range values {
+		row[field] = append([]byte(nil), value...)
+	}
+
+	state.pendingKeys = append(state.pendingKeys, key)
+	state.pendingValues = append(state.pendingValues, row)
+
+	if len(state.pendingKeys) < db.batchSize {
+		return nil
+	}
+
+	return db.flushPending(ctx, state)
+}
+
+// flushPending inserts the buffered rows, the statement is measured as MYSQL_BATCH_INSERT, or
+// MYSQL_BATCH_INSERT_ERROR if it fails, since the latencies of the buffered INSERTs don't show
+// the cost of the statements.
+func (db *mysqlDB) flushPending(ctx context.Context, state *mysqlState) error {
+	if len(state.pendingKeys) == 0 {
+		return nil
+	}
+
+	start := time.Now()
+	err := db.BatchInsert(ctx, state.pendingTable, state.pendingKeys, state.pendingValues)
+	if err != nil {
+		measurement.Measure("MYSQL_BATCH_INSERT_ERROR", time.Now().Sub(start))
+	} else {
+		measurement.Measure("MYSQL_BATCH_INSERT", time.Now().Sub(start))
+	}
+	state.pendingKeys = state.pendingKeys[:0]
+	state.pendingValues = state.pendingValues[:0]
+	return err
+}
+
+// batchFields returns the sorted union of the fields in the batch values.
+func batchFields(values []map[string][]byte) []string {
+	set := make(map[string]struct{})
+	for _, value := range values {
+		for field := range value {
+			set[field] = struct{}{}
+		}
+	}
+
+	fields := make([]string, 0, len(set))
+	for field := range set {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+func (db *mysqlDB) BatchInsert(ctx context.Context, table string, keys []string, values []map[string][]byte) error {
+	fields := batchFields(values)
+	args := make([]interface{}, 0, len(keys)*(len(db.keyColumns)+len(fields)))
+
+	buf := db.bufPool.Get()
+	defer db.bufPool.Put(buf)
+
+	buf.WriteString(db.insertKeyword)
+	buf.WriteString(table)
+	buf.WriteString(" (")
+	buf.WriteString(strings.Join(db.keyColumns, ", "))
+	for _, field := range fields {
+		buf.WriteString(" ,")
+		buf.WriteString(field)
+	}
+	buf.WriteString(") VALUES ")
+
+	for i, key := range keys {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+
+		buf.WriteString("(?")
+		buf.WriteString(strings.Repeat(" ,?", len(db.keyColumns)-1))
+		args = append(args, db.keySchema.Values(key)...)
+		for _, field := range fields {
+			// The missing field will be inserted as NULL.
+			buf.WriteString(" ,?")
+			args = append(args, db.fieldValue(field, values[i][field]))
+		}
+		buf.WriteByte(')')
+	}
+
+	if db.upsert {
+		writeUpsertClause(buf, db.keyColumns[0], fields)
+	}
+
+	return db.execQuery(ctx, buf.String(), args...)
+}
+
+// writeUpsertClause writes the ON DUPLICATE KEY UPDATE clause which overwrites the fields with the inserted values.
+func writeUpsertClause(buf *bytes.Buffer, keyColumn string, fields []string) {
+	if len(fields) == 0 {
+		// Nothing to update, keep the existing row.
+		fmt.Fprintf(buf, " ON DUPLICATE KEY UPDATE %s = %s", keyColumn, keyColumn)
+		return
+	}
+
+	buf.WriteString(" ON DUPLICATE KEY UPDATE ")
+	for i, field := range fields {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(field)
+		buf.WriteString(" = VALUES(")
+		buf.WriteString(field)
+		buf.WriteByte(')')
+	}
+}
+
+func (db *mysqlDB) BatchRead(ctx context.Context, table string, keys []string, fields []string) ([]map[string][]byte, error) {
+	var query string
+	condition := db.keysCondition(len(keys))
+	if len(fields) == 0 {
+		query = fmt.Sprintf(`SELECT * FROM %s %s WHERE %s`, table, db.forceIndexKeyword, condition)
+	} else {
+		query = fmt.Sprintf(`SELECT %s, %s FROM %s %s WHERE %s`, strings.Join(db.keyColumns, ", "), strings.Join(fields, ","), table, db.forceIndexKeyword, condition)
+	}
+
+	args := make([]interface{}, 0, len(keys)*len(db.keyColumns))
+	for _, key := range keys {
+		args = append(args, db.keySchema.Values(key)...)
+	}
+
+	rows, err := db.queryRows(ctx, query, len(keys), args...)
+	db.clearCacheIfFailed(ctx, query, err)
+	if err != nil {
+		return nil, err
+	}
+
+	rowsByKey := make(map[string]map[string][]byte, len(rows))
+	for _, row := range rows {
+		rowsByKey[db.keySchema.KeyOfRow(row)] = row
+	}
+
+	// Keep the same order as the keys, nil for the missing records.
+	res := make([]map[string][]byte, len(keys))
+	for i, key := range keys {
+		res[i] = rowsByKey[key]
+	}
+	return res, nil
+}
+
+func (db *mysqlDB) BatchUpdate(ctx context.Context, table string, keys []string, values []map[string][]byte) error {
+	for i, key := range keys {
+		if err := db.Update(ctx, table, key, values[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (db *mysqlDB) Delete(ctx context.Context, table string, key string) error {
-	query := fmt.Sprintf(`DELETE FROM %s WHERE YCSB_KEY = ?`, table)
+	query := fmt.Sprintf(`DELETE FROM %s WHERE %s`, table, util.KeyCondition(db.keyColumns, "=", util.QuestionMark))
+
+	return db.execQuery(ctx, query, db.keySchema.Values(key)...)
+}
 
-	return db.execQuery(ctx, query, key)
+func (db *mysqlDB) BatchDelete(ctx context.Context, table string, keys []string) error {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE %s`, table, db.keysCondition(len(keys)))
+
+	args := make([]interface{}, 0, len(keys)*len(db.keyColumns))
+	for _, key := range keys {
+		args = append(args, db.keySchema.Values(key)...)
+	}
+
+	return db.execQuery(ctx, query, args...)
 }
 
 func (db *mysqlDB) Analyze(ctx context.Context, table string) error {
@@ -355,6 +1085,12 @@ func (db *mysqlDB) Analyze(ctx context.Context, table string) error {
 
 func init() {
 	ycsb.RegisterDBCreator("mysql", mysqlCreator{})
-	ycsb.RegisterDBCreator("tidb", mysqlCreator{})
 	ycsb.RegisterDBCreator("mariadb", mysqlCreator{})
+
+	prop.Register(prop.String,
+		mysqlHost, mysqlUser, mysqlPassword, mysqlDBName, mysqlTLS, mysqlCAFile, mysqlCertFile,
+		mysqlKeyFile, mysqlFieldTypes, mysqlInsertMode, mysqlSessionVars, mysqlReadReplicas)
+	prop.Register(prop.Int,
+		mysqlPort, mysqlBatchSize, mysqlTxnSize, mysqlRMWMaxRetries, mysqlStmtCacheSize)
+	prop.Register(prop.Bool, mysqlForceIndex, mysqlAutoCommit, mysqlTransactionalRMW)
 }
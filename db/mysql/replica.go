@@ -0,0 +1,83 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/pingcap/go-ycsb/pkg/util"
+	"go.uber.org/zap"
+)
+
+// replicaConn is the connection of a thread to a read replica.
+type replicaConn struct {
+	pool      *sql.DB
+	conn      *sql.Conn
+	stmtCache *stmtCache
+}
+
+func (c *replicaConn) close() {
+	c.stmtCache.close()
+	c.conn.Close()
+}
+
+// queryReplica executes the query on the read replicas in round-robin.
+func (db *mysqlDB) queryReplica(ctx context.Context, state *mysqlState, query string, count int, args ...interface{}) ([]map[string][]byte, error) {
+	replica := state.replicas[state.replicaIdx%len(state.replicas)]
+	state.replicaIdx++
+
+	if db.verbose {
+		util.Logger().Info("query", zap.String("query", query), zap.Reflect("args", args))
+	}
+
+	stmt, err := db.getAndCacheReplicaStmt(ctx, replica, query)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		db.queryLogger.Log(start, query, args, err)
+		replica.stmtCache.remove(query)
+		return nil, err
+	}
+
+	vs, err := scanRows(rows, count)
+	db.queryLogger.Log(start, query, args, err)
+	return vs, err
+}
+
+func (db *mysqlDB) getAndCacheReplicaStmt(ctx context.Context, replica *replicaConn, query string) (*sql.Stmt, error) {
+	if stmt, ok := replica.stmtCache.get(query); ok {
+		return stmt, nil
+	}
+
+	stmt, err := replica.conn.PrepareContext(ctx, query)
+	if err == sql.ErrConnDone {
+		// Try build the connection and prepare again
+		if replica.conn, err = db.newConn(ctx, replica.pool); err == nil {
+			stmt, err = replica.conn.PrepareContext(ctx, query)
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	replica.stmtCache.put(query, stmt)
+	return stmt, nil
+}
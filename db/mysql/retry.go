@@ -0,0 +1,105 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/measurement"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+)
+
+// retryPolicy retries the statements which fail with the retryable errors.
+type retryPolicy struct {
+	maxRetries      int
+	backoff         time.Duration
+	retryableErrors map[uint16]struct{}
+}
+
+func newRetryPolicy(p *properties.Properties) (*retryPolicy, error) {
+	r := &retryPolicy{
+		maxRetries:      p.GetInt(prop.SQLMaxRetries, prop.SQLMaxRetriesDefault),
+		retryableErrors: make(map[uint16]struct{}),
+	}
+
+	var err error
+	if r.backoff, err = time.ParseDuration(p.GetString(prop.SQLRetryBackoff, prop.SQLRetryBackoffDefault)); err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", prop.SQLRetryBackoff, err)
+	}
+
+	for _, code := range strings.Split(p.GetString(prop.SQLRetryableErrors, prop.SQLRetryableErrorsDefault), ",") {
+		code = strings.TrimSpace(code)
+		if len(code) == 0 {
+			continue
+		}
+
+		n, err := strconv.ParseUint(code, 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", prop.SQLRetryableErrors, err)
+		}
+		r.retryableErrors[uint16(n)] = struct{}{}
+	}
+
+	return r, nil
+}
+
+func (r *retryPolicy) isRetryable(err error) bool {
+	if e, ok := err.(*mysql.MySQLError); ok {
+		_, ok = r.retryableErrors[e.Number]
+		return ok
+	}
+	return false
+}
+
+// ClassifyError implements the ycsb ErrorClassifier interface, the retryable errors like the
+// deadlocks and the write conflicts of TiDB are classified as conflicts.
+func (db *mysqlDB) ClassifyError(err error) ycsb.ErrorClass {
+	switch {
+	case db.retry.isRetryable(err):
+		return ycsb.ErrorConflict
+	case err == mysql.ErrInvalidConn:
+		return ycsb.ErrorConnection
+	default:
+		return ""
+	}
+}
+
+// run runs the statement until it succeeds, fails with a non-retryable error or
+// runs out of the retries. Every retry is measured as SQL_RETRY.
+func (r *retryPolicy) run(ctx context.Context, f func() error) error {
+	backoff := r.backoff
+	for retry := 0; ; retry++ {
+		start := time.Now()
+		err := f()
+		if err == nil || retry >= r.maxRetries || !r.isRetryable(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+
+		measurement.Measure("SQL_RETRY", time.Now().Sub(start))
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/pingcap/go-ycsb/pkg/measurement"
+	"github.com/pingcap/go-ycsb/pkg/util"
+	"go.uber.org/zap"
+)
+
+// txnRMWDB executes the read-modify-write operation in one transaction.
+type txnRMWDB struct {
+	*mysqlDB
+}
+
+// MySQL and TiDB error codes which mean the transaction conflicts with others and can be retried.
+var conflictErrorCodes = map[uint16]struct{}{
+	1205: {}, // ER_LOCK_WAIT_TIMEOUT
+	1213: {}, // ER_LOCK_DEADLOCK
+	8002: {}, // TiDB: SELECT FOR UPDATE write conflict
+	8022: {}, // TiDB: transaction retry error
+	9007: {}, // TiDB: write conflict
+}
+
+func isConflictError(err error) bool {
+	if e, ok := err.(*mysql.MySQLError); ok {
+		_, ok = conflictErrorCodes[e.Number]
+		return ok
+	}
+	return false
+}
+
+func (db *txnRMWDB) ReadModifyWrite(ctx context.Context, table string, key string, fields []string, values map[string][]byte) (map[string][]byte, error) {
+	state := ctx.Value(stateKey).(*mysqlState)
+
+	// The read-modify-write joins the transaction started by Begin, which is ended by its Commit or
+	// Rollback, and the conflicts can't be retried alone.
+	if state.userTxn {
+		return db.lockAndUpdate(ctx, table, key, fields, values)
+	}
+
+	// Commit the explicit transaction if autocommit is disabled, otherwise the BEGIN below
+	// will commit it implicitly.
+	if state.inTxn {
+		if err := db.commitTxn(ctx, state); err != nil {
+			return nil, err
+		}
+	}
+
+	for retry := 0; ; retry++ {
+		start := time.Now()
+		row, err := db.readModifyWrite(ctx, state, table, key, fields, values)
+		if err == nil {
+			return row, nil
+		}
+
+		if !isConflictError(err) {
+			return nil, err
+		}
+
+		measurement.Measure("READ_MODIFY_WRITE_CONFLICT", time.Now().Sub(start))
+		if retry >= db.rmwMaxRetries {
+			return nil, err
+		}
+		measurement.Measure("READ_MODIFY_WRITE_RETRY", time.Now().Sub(start))
+	}
+}
+
+func (db *txnRMWDB) readModifyWrite(ctx context.Context, state *mysqlState, table string, key string, fields []string, values map[string][]byte) (_ map[string][]byte, err error) {
+	if db.verbose {
+		util.Logger().Info("query", zap.String("query", "BEGIN"))
+	}
+	if _, err = state.conn.ExecContext(ctx, "BEGIN"); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			if db.verbose {
+				util.Logger().Info("query", zap.String("query", "ROLLBACK"))
+			}
+			state.conn.ExecContext(ctx, "ROLLBACK")
+		}
+	}()
+
+	row, err := db.lockAndUpdate(ctx, table, key, fields, values)
+	if err != nil {
+		return nil, err
+	}
+
+	if db.verbose {
+		util.Logger().Info("query", zap.String("query", "COMMIT"))
+	}
+	if _, err = state.conn.ExecContext(ctx, "COMMIT"); err != nil {
+		return nil, err
+	}
+	return row, nil
+}
+
+// lockAndUpdate reads the row by SELECT FOR UPDATE and updates it in the running transaction.
+func (db *txnRMWDB) lockAndUpdate(ctx context.Context, table string, key string, fields []string, values map[string][]byte) (map[string][]byte, error) {
+	var query string
+	if len(fields) == 0 {
+		query = fmt.Sprintf(`SELECT * FROM %s %s WHERE %s FOR UPDATE`, table, db.forceIndexKeyword, util.KeyCondition(db.keyColumns, "=", util.QuestionMark))
+	} else {
+		query = fmt.Sprintf(`SELECT %s FROM %s %s WHERE %s FOR UPDATE`, strings.Join(fields, ","), table, db.forceIndexKeyword, util.KeyCondition(db.keyColumns, "=", util.QuestionMark))
+	}
+
+	rows, err := db.doQueryRows(ctx, query, 1, db.keySchema.Values(key)...)
+	db.clearCacheIfFailed(ctx, query, err)
+	if err != nil {
+		return nil, err
+	}
+
+	updateQuery, args := db.buildUpdate(table, key, values)
+	if err = db.doExecQuery(ctx, updateQuery, args...); err != nil {
+		return nil, err
+	}
+
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	return rows[0], nil
+}
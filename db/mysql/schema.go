@@ -0,0 +1,146 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strings"
+)
+
+type columnKind int
+
+const (
+	kindString columnKind = iota
+	kindInt
+	kindFloat
+	kindJSON
+)
+
+// intRanges is the max value generated for the integer types.
+var intRanges = map[string]uint64{
+	"TINYINT":   math.MaxInt8,
+	"SMALLINT":  math.MaxInt16,
+	"MEDIUMINT": 1<<23 - 1,
+	"INT":       math.MaxInt32,
+	"INTEGER":   math.MaxInt32,
+	"BIGINT":    math.MaxInt64,
+}
+
+type columnType struct {
+	sqlType string
+	kind    columnKind
+	maxInt  uint64
+}
+
+func newColumnType(sqlType string) columnType {
+	sqlType = strings.ToUpper(strings.TrimSpace(sqlType))
+	base := strings.Fields(sqlType)[0]
+	if pos := strings.IndexByte(base, '('); pos >= 0 {
+		base = base[:pos]
+	}
+
+	t := columnType{sqlType: sqlType, kind: kindString}
+	if maxInt, ok := intRanges[base]; ok {
+		t.kind = kindInt
+		t.maxInt = maxInt
+	}
+	switch base {
+	case "FLOAT", "DOUBLE", "REAL", "DECIMAL", "NUMERIC":
+		t.kind = kindFloat
+	case "JSON":
+		t.kind = kindJSON
+	}
+	return t
+}
+
+// parseFieldTypes parses the field type spec like "FIELD0:BIGINT,FIELD1:JSON,FIELD2:TEXT".
+func parseFieldTypes(spec string) (map[string]columnType, error) {
+	types := make(map[string]columnType)
+	for _, item := range splitFieldTypes(spec) {
+		item = strings.TrimSpace(item)
+		if len(item) == 0 {
+			continue
+		}
+
+		pos := strings.IndexByte(item, ':')
+		if pos <= 0 || len(strings.TrimSpace(item[pos+1:])) == 0 {
+			return nil, fmt.Errorf("invalid field type %q, must be like FIELD0:BIGINT", item)
+		}
+
+		field := strings.ToUpper(strings.TrimSpace(item[:pos]))
+		types[field] = newColumnType(item[pos+1:])
+	}
+	return types, nil
+}
+
+// splitFieldTypes splits the spec by the commas which are not in parentheses,
+// so types like DECIMAL(10,2) are kept.
+func splitFieldTypes(spec string) []string {
+	var items []string
+	depth, start := 0, 0
+	for i, c := range spec {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				items = append(items, spec[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(items, spec[start:])
+}
+
+// convert converts the generated value to the data suitable for the column type.
+// The value is derived from the generated bytes, so the same bytes always get the same data.
+func (t columnType) convert(value []byte) interface{} {
+	if value == nil {
+		return nil
+	}
+
+	switch t.kind {
+	case kindInt:
+		return int64(hashValue(value) % t.maxInt)
+	case kindFloat:
+		return float64(hashValue(value)%1000000) / 100
+	case kindJSON:
+		data, _ := json.Marshal(map[string]interface{}{
+			"id":   hashValue(value) % math.MaxInt32,
+			"data": string(value),
+		})
+		return string(data)
+	default:
+		return value
+	}
+}
+
+func hashValue(value []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(value)
+	return h.Sum64()
+}
+
+// fieldValue returns the value to be written to the field.
+func (db *mysqlDB) fieldValue(field string, value []byte) interface{} {
+	if t, ok := db.fieldTypes[field]; ok {
+		return t.convert(value)
+	}
+	return value
+}
@@ -0,0 +1,98 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"container/list"
+	"database/sql"
+	"sync/atomic"
+)
+
+// stmtCacheStats counts the hits and misses of all the statement caches.
+type stmtCacheStats struct {
+	hits   int64
+	misses int64
+}
+
+type stmtCacheEntry struct {
+	query string
+	stmt  *sql.Stmt
+}
+
+// stmtCache is a LRU cache of the prepared statements, the evicted statements are closed.
+// It is not thread safe and used by one thread.
+type stmtCache struct {
+	capacity int
+	ll       *list.List
+	items    map[string]*list.Element
+	stats    *stmtCacheStats
+}
+
+func newStmtCache(capacity int, stats *stmtCacheStats) *stmtCache {
+	return &stmtCache{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+		stats:    stats,
+	}
+}
+
+func (c *stmtCache) get(query string) (*sql.Stmt, bool) {
+	e, ok := c.items[query]
+	if !ok {
+		atomic.AddInt64(&c.stats.misses, 1)
+		return nil, false
+	}
+
+	atomic.AddInt64(&c.stats.hits, 1)
+	c.ll.MoveToFront(e)
+	return e.Value.(*stmtCacheEntry).stmt, true
+}
+
+func (c *stmtCache) put(query string, stmt *sql.Stmt) {
+	if e, ok := c.items[query]; ok {
+		c.ll.MoveToFront(e)
+		entry := e.Value.(*stmtCacheEntry)
+		if entry.stmt != stmt {
+			entry.stmt.Close()
+			entry.stmt = stmt
+		}
+		return
+	}
+
+	c.items[query] = c.ll.PushFront(&stmtCacheEntry{query: query, stmt: stmt})
+	for c.capacity > 0 && c.ll.Len() > c.capacity {
+		c.removeElement(c.ll.Back())
+	}
+}
+
+// remove closes and removes the statement of the query.
+func (c *stmtCache) remove(query string) {
+	if e, ok := c.items[query]; ok {
+		c.removeElement(e)
+	}
+}
+
+func (c *stmtCache) removeElement(e *list.Element) {
+	entry := c.ll.Remove(e).(*stmtCacheEntry)
+	delete(c.items, entry.query)
+	entry.stmt.Close()
+}
+
+// close closes all the statements.
+func (c *stmtCache) close() {
+	for c.ll.Len() > 0 {
+		c.removeElement(c.ll.Back())
+	}
+}
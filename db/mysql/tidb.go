@@ -0,0 +1,79 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"fmt"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+)
+
+// tidb properties
+const (
+	// The transaction settings are set as session variables and left unchanged if not set.
+	// txn_mode is "optimistic" or "pessimistic".
+	tidbTxnMode     = "tidb.txn_mode"
+	tidbAsyncCommit = "tidb.async_commit"
+	tidbOnePC       = "tidb.one_pc"
+	// Split the table into N regions before loading.
+	tidbPresplitRegions = "tidb.presplit_regions"
+)
+
+// maxShardRowIDBits is the max SHARD_ROW_ID_BITS supported by TiDB.
+const maxShardRowIDBits = 15
+
+// tidbCreator creates the mysql driver with the TiDB transaction settings.
+type tidbCreator struct {
+}
+
+func (c tidbCreator) Create(p *properties.Properties) (ycsb.DB, error) {
+	var sessionVars []string
+
+	if mode, ok := p.Get(tidbTxnMode); ok {
+		if mode != "optimistic" && mode != "pessimistic" {
+			return nil, fmt.Errorf("unsupported %s %s", tidbTxnMode, mode)
+		}
+		sessionVars = append(sessionVars, fmt.Sprintf("tidb_txn_mode = '%s'", mode))
+	}
+
+	if _, ok := p.Get(tidbAsyncCommit); ok {
+		sessionVars = append(sessionVars, fmt.Sprintf("tidb_enable_async_commit = %s", onOff(p.GetBool(tidbAsyncCommit, false))))
+	}
+
+	if _, ok := p.Get(tidbOnePC); ok {
+		sessionVars = append(sessionVars, fmt.Sprintf("tidb_enable_1pc = %s", onOff(p.GetBool(tidbOnePC, false))))
+	}
+
+	return mysqlCreator{
+		sessionVars:     sessionVars,
+		presplitRegions: p.GetInt(tidbPresplitRegions, 0),
+	}.Create(p)
+}
+
+func onOff(b bool) string {
+	if b {
+		return "ON"
+	}
+	return "OFF"
+}
+
+func init() {
+	ycsb.RegisterDBCreator("tidb", tidbCreator{})
+
+	prop.Register(prop.String, tidbTxnMode)
+	prop.Register(prop.Int, tidbPresplitRegions)
+	prop.Register(prop.Bool, tidbAsyncCommit, tidbOnePC)
+}
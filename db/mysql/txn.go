@@ -0,0 +1,76 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"context"
+
+	"github.com/pingcap/go-ycsb/pkg/util"
+	"go.uber.org/zap"
+)
+
+// Begin starts an explicit transaction on the thread connection, the following operations
+// are executed in it until Commit or Rollback.
+func (db *mysqlDB) Begin(ctx context.Context) (context.Context, error) {
+	state := ctx.Value(stateKey).(*mysqlState)
+
+	// The buffered rows and the transaction of autocommit=false are committed first,
+	// otherwise the BEGIN below will commit them implicitly.
+	if err := db.flushPending(ctx, state); err != nil {
+		return ctx, err
+	}
+	if state.inTxn {
+		if err := db.commitTxn(ctx, state); err != nil {
+			return ctx, err
+		}
+	}
+
+	if db.verbose {
+		util.Logger().Info("query", zap.String("query", "BEGIN"))
+	}
+	if _, err := state.conn.ExecContext(ctx, "BEGIN"); err != nil {
+		return ctx, err
+	}
+	state.inTxn = true
+	state.userTxn = true
+	return ctx, nil
+}
+
+func (db *mysqlDB) Commit(ctx context.Context) error {
+	state := ctx.Value(stateKey).(*mysqlState)
+
+	// The transaction is kept if the buffered rows fail, so it can be rolled back.
+	if err := db.flushPending(ctx, state); err != nil {
+		return err
+	}
+	state.userTxn = false
+	return db.commitTxn(ctx, state)
+}
+
+func (db *mysqlDB) Rollback(ctx context.Context) error {
+	state := ctx.Value(stateKey).(*mysqlState)
+	state.userTxn = false
+	state.inTxn = false
+
+	// The buffered rows are inserted in the transaction, so they are discarded with it.
+	state.pendingTable = ""
+	state.pendingKeys = state.pendingKeys[:0]
+	state.pendingValues = state.pendingValues[:0]
+
+	if db.verbose {
+		util.Logger().Info("query", zap.String("query", "ROLLBACK"))
+	}
+	_, err := state.conn.ExecContext(ctx, "ROLLBACK")
+	return err
+}
@@ -0,0 +1,97 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// recordDriver records the statements executed on its connections.
+type recordDriver struct {
+	sync.Mutex
+	queries []string
+}
+
+func (d *recordDriver) Open(string) (driver.Conn, error) {
+	return recordConn{d}, nil
+}
+
+type recordConn struct {
+	d *recordDriver
+}
+
+func (c recordConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("prepare is not supported")
+}
+
+func (c recordConn) Close() error {
+	return nil
+}
+
+func (c recordConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("begin is not supported")
+}
+
+func (c recordConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	c.d.Lock()
+	c.d.queries = append(c.d.queries, query)
+	c.d.Unlock()
+	return driver.RowsAffected(1), nil
+}
+
+func TestRollbackBufferedInsert(t *testing.T) {
+	d := new(recordDriver)
+	sql.Register("mysql-record", d)
+	pool, err := sql.Open("mysql-record", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	ctx := context.Background()
+	conn, err := pool.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	db := &mysqlDB{batchSize: 2}
+	state := &mysqlState{conn: conn, pool: pool}
+	ctx = context.WithValue(ctx, stateKey, state)
+
+	if ctx, err = db.Begin(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err = db.Insert(ctx, "usertable", "user1", map[string][]byte{"field0": []byte("value")}); err != nil {
+		t.Fatal(err)
+	}
+	if err = db.Rollback(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// The rows of the rolled back transaction are never flushed.
+	if err = db.flushPending(ctx, state); err != nil {
+		t.Fatal(err)
+	}
+	queries := []string{"BEGIN", "ROLLBACK"}
+	if !reflect.DeepEqual(d.queries, queries) {
+		t.Errorf("want %v, but got %v", queries, d.queries)
+	}
+}
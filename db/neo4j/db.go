@@ -0,0 +1,299 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package neo4j
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/magiconair/properties"
+	goneo4j "github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	neo4jdb "github.com/neo4j/neo4j-go-driver/v4/neo4j/db"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/util"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+)
+
+// neo4j properties
+const (
+	neo4jURI       = "neo4j.uri"
+	neo4jUser      = "neo4j.user"
+	neo4jPassword  = "neo4j.password"
+	neo4jDatabase  = "neo4j.database"
+	neo4jEncrypted = "neo4j.encrypted"
+	// Use MERGE instead of CREATE for Insert, so that the existing vertex is replaced.
+	neo4jUpsert        = "neo4j.upsert"
+	neo4jMaxPoolSize   = "neo4j.max_connection_pool_size"
+	neo4jDropBatchSize = "neo4j.drop_batch_size"
+)
+
+const keyProperty = "YCSB_KEY"
+
+// The error codes of creating the existing constraint.
+const (
+	codeEquivalentSchemaRuleExists = "Neo.ClientError.Schema.EquivalentSchemaRuleAlreadyExists"
+	codeConstraintExists           = "Neo.ClientError.Schema.ConstraintAlreadyExists"
+)
+
+type contextKey string
+
+const stateKey = contextKey("neo4jDB")
+
+type neo4jCreator struct {
+}
+
+// neo4jDB stores every record as a vertex labeled by the table, the key is the YCSB_KEY property
+// which has an unique constraint, and the fields are the other properties.
+type neo4jDB struct {
+	p        *properties.Properties
+	driver   goneo4j.Driver
+	database string
+	upsert   bool
+}
+
+type neo4jState struct {
+	session goneo4j.Session
+}
+
+func (c neo4jCreator) Create(p *properties.Properties) (ycsb.DB, error) {
+	threadCount := int(p.GetInt64(prop.ThreadCount, prop.ThreadCountDefault))
+
+	driver, err := goneo4j.NewDriver(
+		p.GetString(neo4jURI, "bolt://127.0.0.1:7687"),
+		goneo4j.BasicAuth(p.GetString(neo4jUser, "neo4j"), p.GetString(neo4jPassword, ""), ""),
+		func(config *goneo4j.Config) {
+			config.Encrypted = p.GetBool(neo4jEncrypted, false)
+			config.MaxConnectionPoolSize = p.GetInt(neo4jMaxPoolSize, threadCount)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &neo4jDB{
+		p:        p,
+		driver:   driver,
+		database: p.GetString(neo4jDatabase, ""),
+		upsert:   p.GetBool(neo4jUpsert, false),
+	}
+
+	if err = driver.VerifyConnectivity(); err != nil {
+		driver.Close()
+		return nil, err
+	}
+
+	if !p.GetBool(prop.DoTransactions, true) {
+		if err = d.createSchema(); err != nil {
+			driver.Close()
+			return nil, err
+		}
+	}
+
+	return d, nil
+}
+
+// label returns the quoted label of the table.
+func label(table string) string {
+	return "`" + strings.Replace(table, "`", "``", -1) + "`"
+}
+
+func (db *neo4jDB) newSession() (goneo4j.Session, error) {
+	return db.driver.NewSession(goneo4j.SessionConfig{
+		AccessMode:   goneo4j.AccessModeWrite,
+		DatabaseName: db.database,
+	})
+}
+
+func isCode(err error, codes ...string) bool {
+	e, ok := err.(*neo4jdb.DatabaseError)
+	if !ok {
+		return false
+	}
+	for _, code := range codes {
+		if e.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// run runs the auto-commit query and discards the result.
+func run(session goneo4j.Session, query string, params map[string]interface{}) error {
+	res, err := session.Run(query, params)
+	if err != nil {
+		return err
+	}
+	_, err = res.Consume()
+	return err
+}
+
+func (db *neo4jDB) createSchema() error {
+	session, err := db.newSession()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	for _, tableName := range util.TableNames(db.p) {
+		if db.p.GetBool(prop.DropData, prop.DropDataDefault) {
+			// Delete the vertices in batches to avoid the huge transaction.
+			query := fmt.Sprintf("MATCH (n:%s) WITH n LIMIT $limit DETACH DELETE n RETURN count(*)", label(tableName))
+			params := map[string]interface{}{"limit": db.p.GetInt64(neo4jDropBatchSize, 10000)}
+			for {
+				res, err := session.Run(query, params)
+				if err != nil {
+					return err
+				}
+				record, err := res.Single()
+				if err != nil {
+					return err
+				}
+				if n, _ := record.GetByIndex(0).(int64); n == 0 {
+					break
+				}
+			}
+		}
+
+		// The unique constraint creates the index of the key, which is used by Scan as well.
+		query := fmt.Sprintf("CREATE CONSTRAINT ON (n:%s) ASSERT n.%s IS UNIQUE", label(tableName), keyProperty)
+		if err = run(session, query, nil); err != nil && !isCode(err, codeEquivalentSchemaRuleExists, codeConstraintExists) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (db *neo4jDB) Close() error {
+	return db.driver.Close()
+}
+
+func (db *neo4jDB) InitThread(ctx context.Context, _ int, _ int) context.Context {
+	session, err := db.newSession()
+	if err != nil {
+		panic(fmt.Sprintf("failed to create session %v", err))
+	}
+
+	state := &neo4jState{
+		session: session,
+	}
+
+	return context.WithValue(ctx, stateKey, state)
+}
+
+func (db *neo4jDB) CleanupThread(ctx context.Context) {
+	state := ctx.Value(stateKey).(*neo4jState)
+	state.session.Close()
+}
+
+func getSession(ctx context.Context) goneo4j.Session {
+	return ctx.Value(stateKey).(*neo4jState).session
+}
+
+// nodeFields returns the fields of the vertex, or all the fields if fields is empty.
+func nodeFields(node goneo4j.Node, fields []string) map[string][]byte {
+	if len(fields) == 0 {
+		res := make(map[string][]byte, len(node.Props))
+		for field, value := range node.Props {
+			if field == keyProperty {
+				continue
+			}
+			if s, ok := value.(string); ok {
+				res[field] = util.Slice(s)
+			}
+		}
+		return res
+	}
+
+	res := make(map[string][]byte, len(fields))
+	for _, field := range fields {
+		if s, ok := node.Props[field].(string); ok {
+			res[field] = util.Slice(s)
+		}
+	}
+	return res
+}
+
+func (db *neo4jDB) queryNodes(ctx context.Context, query string, params map[string]interface{}, fields []string) ([]map[string][]byte, error) {
+	res, err := getSession(ctx).Run(query, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []map[string][]byte
+	for res.Next() {
+		node, ok := res.Record.GetByIndex(0).(goneo4j.Node)
+		if !ok {
+			return nil, fmt.Errorf("unexpected value %v", res.Record.GetByIndex(0))
+		}
+		rows = append(rows, nodeFields(node, fields))
+	}
+	return rows, res.Err()
+}
+
+func (db *neo4jDB) Read(ctx context.Context, table string, key string, fields []string) (map[string][]byte, error) {
+	query := fmt.Sprintf("MATCH (n:%s {%s: $key}) RETURN n", label(table), keyProperty)
+	rows, err := db.queryNodes(ctx, query, map[string]interface{}{"key": key}, fields)
+	if err != nil || len(rows) == 0 {
+		return nil, err
+	}
+	return rows[0], nil
+}
+
+// Scan walks the index range of the key from startKey.
+func (db *neo4jDB) Scan(ctx context.Context, table string, startKey string, count int, fields []string) ([]map[string][]byte, error) {
+	query := fmt.Sprintf("MATCH (n:%s) WHERE n.%s >= $key RETURN n ORDER BY n.%s LIMIT $count",
+		label(table), keyProperty, keyProperty)
+	return db.queryNodes(ctx, query, map[string]interface{}{"key": startKey, "count": count}, fields)
+}
+
+func toProps(values map[string][]byte) map[string]interface{} {
+	props := make(map[string]interface{}, len(values))
+	for field, value := range values {
+		props[field] = string(value)
+	}
+	return props
+}
+
+func (db *neo4jDB) Update(ctx context.Context, table string, key string, values map[string][]byte) error {
+	query := fmt.Sprintf("MATCH (n:%s {%s: $key}) SET n += $props", label(table), keyProperty)
+	return run(getSession(ctx), query, map[string]interface{}{"key": key, "props": toProps(values)})
+}
+
+func (db *neo4jDB) Insert(ctx context.Context, table string, key string, values map[string][]byte) error {
+	props := toProps(values)
+	props[keyProperty] = key
+
+	var query string
+	if db.upsert {
+		query = fmt.Sprintf("MERGE (n:%s {%s: $key}) SET n = $props", label(table), keyProperty)
+	} else {
+		query = fmt.Sprintf("CREATE (n:%s) SET n = $props", label(table))
+	}
+	return run(getSession(ctx), query, map[string]interface{}{"key": key, "props": props})
+}
+
+func (db *neo4jDB) Delete(ctx context.Context, table string, key string) error {
+	query := fmt.Sprintf("MATCH (n:%s {%s: $key}) DETACH DELETE n", label(table), keyProperty)
+	return run(getSession(ctx), query, map[string]interface{}{"key": key})
+}
+
+func init() {
+	ycsb.RegisterDBCreator("neo4j", neo4jCreator{})
+
+	prop.Register(prop.String, neo4jURI, neo4jUser, neo4jPassword, neo4jDatabase)
+	prop.Register(prop.Int, neo4jMaxPoolSize, neo4jDropBatchSize)
+	prop.Register(prop.Bool, neo4jEncrypted, neo4jUpsert)
+}
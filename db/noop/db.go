@@ -0,0 +1,104 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package noop
+
+import (
+	"context"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+)
+
+type noopCreator struct {
+}
+
+// noopDB returns immediately for every operation, it is used to measure the overhead of the client itself.
+type noopDB struct {
+}
+
+func (c noopCreator) Create(p *properties.Properties) (ycsb.DB, error) {
+	return noopDB{}, nil
+}
+
+func (db noopDB) Close() error {
+	return nil
+}
+
+func (db noopDB) InitThread(ctx context.Context, _ int, _ int) context.Context {
+	return ctx
+}
+
+func (db noopDB) CleanupThread(_ context.Context) {
+}
+
+func (db noopDB) Read(ctx context.Context, table string, key string, fields []string) (map[string][]byte, error) {
+	return nil, nil
+}
+
+func (db noopDB) BatchRead(ctx context.Context, table string, keys []string, fields []string) ([]map[string][]byte, error) {
+	return nil, nil
+}
+
+func (db noopDB) Scan(ctx context.Context, table string, startKey string, count int, fields []string) ([]map[string][]byte, error) {
+	return nil, nil
+}
+
+func (db noopDB) Update(ctx context.Context, table string, key string, values map[string][]byte) error {
+	return nil
+}
+
+func (db noopDB) BatchUpdate(ctx context.Context, table string, keys []string, values []map[string][]byte) error {
+	return nil
+}
+
+func (db noopDB) Insert(ctx context.Context, table string, key string, values map[string][]byte) error {
+	return nil
+}
+
+func (db noopDB) BatchInsert(ctx context.Context, table string, keys []string, values []map[string][]byte) error {
+	return nil
+}
+
+func (db noopDB) Delete(ctx context.Context, table string, key string) error {
+	return nil
+}
+
+func (db noopDB) BatchDelete(ctx context.Context, table string, keys []string) error {
+	return nil
+}
+
+// The asynchronous operations complete immediately, they are used to measure the overhead of
+// the asynchronous path of the client.
+func (db noopDB) AsyncRead(ctx context.Context, table string, key string, fields []string, cb func(map[string][]byte, error)) {
+	cb(nil, nil)
+}
+
+func (db noopDB) AsyncUpdate(ctx context.Context, table string, key string, values map[string][]byte, cb func(error)) {
+	cb(nil)
+}
+
+func (db noopDB) AsyncInsert(ctx context.Context, table string, key string, values map[string][]byte, cb func(error)) {
+	cb(nil)
+}
+
+func (db noopDB) AsyncDelete(ctx context.Context, table string, key string, cb func(error)) {
+	cb(nil)
+}
+
+func (db noopDB) Flush(ctx context.Context) {
+}
+
+func init() {
+	ycsb.RegisterDBCreator("noop", noopCreator{})
+}
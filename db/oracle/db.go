@@ -0,0 +1,571 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build oracle
+
+package oracle
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	// oracle package
+	_ "github.com/godror/godror"
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/util"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+	"go.uber.org/zap"
+)
+
+// oracle properties
+const (
+	oracleHost     = "oracle.host"
+	oraclePort     = "oracle.port"
+	oracleUser     = "oracle.user"
+	oraclePassword = "oracle.password"
+	oracleService  = "oracle.service"
+	// Use a NUMBER primary key generated by a sequence, YCSB_KEY becomes a unique key.
+	oracleSequencePK = "oracle.sequence_pk"
+	oracleBatchSize  = "oracle.batchsize"
+)
+
+// Oracle error codes ignored when the objects are dropped or created.
+const (
+	errTableNotExist    = -942
+	errSequenceNotExist = -2289
+	errNameUsed         = -955
+)
+
+type oracleCreator struct {
+}
+
+type oracleDB struct {
+	p       *properties.Properties
+	db      *sql.DB
+	verbose bool
+
+	sequencePK bool
+	batchSize  int
+	// columns is the selected columns if no field is specified, the generated primary key is excluded.
+	columns string
+
+	bufPool *util.BufPool
+
+	queryLogger *util.QueryLogger
+}
+
+type contextKey string
+
+const stateKey = contextKey("oracleDB")
+
+type oracleState struct {
+	// Do we need a LRU cache here?
+	stmtCache map[string]*sql.Stmt
+
+	conn *sql.Conn
+
+	// pending rows buffered by Insert when batch insert is enabled,
+	// all the pending rows belong to pendingTable.
+	pendingTable  string
+	pendingKeys   []string
+	pendingValues []map[string][]byte
+}
+
+func (c oracleCreator) Create(p *properties.Properties) (ycsb.DB, error) {
+	d := new(oracleDB)
+	d.p = p
+
+	host := p.GetString(oracleHost, "127.0.0.1")
+	port := p.GetInt(oraclePort, 1521)
+	user := p.GetString(oracleUser, "system")
+	password := p.GetString(oraclePassword, "")
+	service := p.GetString(oracleService, "ORCLPDB1")
+
+	dsn := fmt.Sprintf(`user=%q password=%q connectString="%s:%d/%s"`, user, password, host, port, service)
+	db, err := sql.Open("godror", dsn)
+	if err != nil {
+		util.Logger().Error("open oracle failed", zap.Error(err))
+		return nil, err
+	}
+
+	threadCount := int(p.GetInt64(prop.ThreadCount, prop.ThreadCountDefault))
+	db.SetMaxIdleConns(threadCount + 1)
+	db.SetMaxOpenConns(threadCount * 2)
+
+	d.verbose = p.GetBool(prop.Verbose, prop.VerboseDefault)
+	d.sequencePK = p.GetBool(oracleSequencePK, false)
+	d.batchSize = p.GetInt(oracleBatchSize, 1)
+	d.db = db
+
+	fields := util.FieldNames(p)
+	columns := make([]string, 0, 1+len(fields))
+	columns = append(columns, "YCSB_KEY")
+	for _, field := range fields {
+		columns = append(columns, strings.ToUpper(field))
+	}
+	d.columns = strings.Join(columns, ", ")
+
+	d.bufPool = util.NewBufPool()
+
+	if d.queryLogger, err = util.NewQueryLogger(p); err != nil {
+		return nil, err
+	}
+
+	for _, tableName := range util.TableNames(p) {
+		if err := d.createTable(tableName); err != nil {
+			return nil, err
+		}
+	}
+
+	return d, nil
+}
+
+// execIgnoreError executes the DDL in a PL/SQL block and ignores the error with the code,
+// because Oracle doesn't support IF EXISTS or IF NOT EXISTS.
+func (db *oracleDB) execIgnoreError(ddl string, code int) error {
+	query := fmt.Sprintf("BEGIN EXECUTE IMMEDIATE '%s'; EXCEPTION WHEN OTHERS THEN IF SQLCODE != %d THEN RAISE; END IF; END;", ddl, code)
+	if db.verbose {
+		util.Logger().Info("query", zap.String("query", query))
+	}
+
+	_, err := db.db.Exec(query)
+	return err
+}
+
+func sequenceName(tableName string) string {
+	return fmt.Sprintf("%s_SEQ", tableName)
+}
+
+func (db *oracleDB) createTable(tableName string) error {
+	if db.p.GetBool(prop.DropData, prop.DropDataDefault) {
+		if err := db.execIgnoreError(fmt.Sprintf("DROP TABLE %s PURGE", tableName), errTableNotExist); err != nil {
+			return err
+		}
+		if err := db.execIgnoreError(fmt.Sprintf("DROP SEQUENCE %s", sequenceName(tableName)), errSequenceNotExist); err != nil {
+			return err
+		}
+	}
+
+	fieldLength := db.p.GetInt64(prop.FieldLength, prop.FieldLengthDefault)
+
+	buf := new(bytes.Buffer)
+	if db.sequencePK {
+		if err := db.execIgnoreError(fmt.Sprintf("CREATE SEQUENCE %s CACHE 1000", sequenceName(tableName)), errNameUsed); err != nil {
+			return err
+		}
+		buf.WriteString(fmt.Sprintf("CREATE TABLE %s (ID NUMBER DEFAULT %s.NEXTVAL PRIMARY KEY, YCSB_KEY VARCHAR2(64) NOT NULL UNIQUE",
+			tableName, sequenceName(tableName)))
+	} else {
+		buf.WriteString(fmt.Sprintf("CREATE TABLE %s (YCSB_KEY VARCHAR2(64) PRIMARY KEY", tableName))
+	}
+
+	for _, field := range util.FieldNames(db.p) {
+		buf.WriteString(fmt.Sprintf(", %s VARCHAR2(%d)", strings.ToUpper(field), fieldLength))
+	}
+
+	buf.WriteString(")")
+
+	return db.execIgnoreError(buf.String(), errNameUsed)
+}
+
+// BufferedInserts implements the BufferedDB BufferedInserts interface.
+func (db *oracleDB) BufferedInserts() bool {
+	return db.batchSize > 1
+}
+
+func (db *oracleDB) Close() error {
+	db.queryLogger.Close()
+
+	if db.db == nil {
+		return nil
+	}
+
+	return db.db.Close()
+}
+
+func (db *oracleDB) InitThread(ctx context.Context, _ int, _ int) context.Context {
+	conn, err := db.db.Conn(ctx)
+	if err != nil {
+		panic(fmt.Sprintf("failed to create db conn %v", err))
+	}
+
+	state := &oracleState{
+		stmtCache: make(map[string]*sql.Stmt),
+		conn:      conn,
+	}
+
+	return context.WithValue(ctx, stateKey, state)
+}
+
+func (db *oracleDB) CleanupThread(ctx context.Context) {
+	state := ctx.Value(stateKey).(*oracleState)
+
+	if err := db.flushPending(ctx, state); err != nil {
+		util.Logger().Error("flush pending rows failed", zap.Error(err))
+	}
+
+	for _, stmt := range state.stmtCache {
+		stmt.Close()
+	}
+	state.conn.Close()
+}
+
+func (db *oracleDB) getAndCacheStmt(ctx context.Context, query string) (*sql.Stmt, error) {
+	state := ctx.Value(stateKey).(*oracleState)
+
+	if stmt, ok := state.stmtCache[query]; ok {
+		return stmt, nil
+	}
+
+	stmt, err := state.conn.PrepareContext(ctx, query)
+	if err == sql.ErrConnDone {
+		// Try build the connection and prepare again
+		if state.conn, err = db.db.Conn(ctx); err == nil {
+			stmt, err = state.conn.PrepareContext(ctx, query)
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	state.stmtCache[query] = stmt
+	return stmt, nil
+}
+
+func (db *oracleDB) clearCacheIfFailed(ctx context.Context, query string, err error) {
+	if err == nil {
+		return
+	}
+
+	state := ctx.Value(stateKey).(*oracleState)
+	if stmt, ok := state.stmtCache[query]; ok {
+		stmt.Close()
+	}
+	delete(state.stmtCache, query)
+}
+
+func (db *oracleDB) queryRows(ctx context.Context, query string, count int, args ...interface{}) ([]map[string][]byte, error) {
+	if db.verbose {
+		util.Logger().Info("query", zap.String("query", query), zap.Reflect("args", args))
+	}
+
+	stmt, err := db.getAndCacheStmt(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		db.queryLogger.Log(start, query, args, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	vs := make([]map[string][]byte, 0, count)
+	for rows.Next() {
+		m := make(map[string][]byte, len(cols))
+		dest := make([]interface{}, len(cols))
+		for i := 0; i < len(cols); i++ {
+			v := new([]byte)
+			dest[i] = v
+		}
+		if err = rows.Scan(dest...); err != nil {
+			break
+		}
+
+		for i, v := range dest {
+			m[cols[i]] = *v.(*[]byte)
+		}
+
+		vs = append(vs, m)
+	}
+	if err == nil {
+		err = rows.Err()
+	}
+
+	db.queryLogger.Log(start, query, args, err)
+	return vs, err
+}
+
+func (db *oracleDB) selectColumns(fields []string) string {
+	if len(fields) == 0 {
+		return db.columns
+	}
+	return strings.Join(fields, ", ")
+}
+
+func (db *oracleDB) Read(ctx context.Context, table string, key string, fields []string) (map[string][]byte, error) {
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE YCSB_KEY = :1`, db.selectColumns(fields), table)
+
+	rows, err := db.queryRows(ctx, query, 1, key)
+	db.clearCacheIfFailed(ctx, query, err)
+
+	if err != nil {
+		return nil, err
+	} else if len(rows) == 0 {
+		return nil, nil
+	}
+
+	return rows[0], nil
+}
+
+func (db *oracleDB) Scan(ctx context.Context, table string, startKey string, count int, fields []string) ([]map[string][]byte, error) {
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE YCSB_KEY >= :1 ORDER BY YCSB_KEY FETCH FIRST :2 ROWS ONLY`, db.selectColumns(fields), table)
+
+	rows, err := db.queryRows(ctx, query, count, startKey, count)
+	db.clearCacheIfFailed(ctx, query, err)
+
+	return rows, err
+}
+
+func (db *oracleDB) execQuery(ctx context.Context, query string, args ...interface{}) error {
+	if db.verbose {
+		util.Logger().Info("query", zap.String("query", query), zap.Reflect("args", args))
+	}
+
+	stmt, err := db.getAndCacheStmt(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	_, err = stmt.ExecContext(ctx, args...)
+	db.queryLogger.Log(start, query, args, err)
+	db.clearCacheIfFailed(ctx, query, err)
+	return err
+}
+
+func (db *oracleDB) Update(ctx context.Context, table string, key string, values map[string][]byte) error {
+	buf := db.bufPool.Get()
+	defer db.bufPool.Put(buf)
+
+	buf.WriteString("UPDATE ")
+	buf.WriteString(table)
+	buf.WriteString(" SET ")
+	args := make([]interface{}, 0, len(values)+1)
+	pairs := util.NewFieldPairs(values)
+	for i, p := range pairs {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+
+		// The values are bound as strings, []byte is bound as RAW and converted to the hex string.
+		buf.WriteString(fmt.Sprintf("%s = :%d", p.Field, i+1))
+		args = append(args, string(p.Value))
+	}
+	buf.WriteString(fmt.Sprintf(" WHERE YCSB_KEY = :%d", len(pairs)+1))
+
+	args = append(args, key)
+
+	return db.execQuery(ctx, buf.String(), args...)
+}
+
+// writeInsert writes the INSERT statement with the bind variables of the key and the fields.
+func writeInsert(buf *bytes.Buffer, table string, fields []string) {
+	buf.WriteString("INSERT INTO ")
+	buf.WriteString(table)
+	buf.WriteString(" (YCSB_KEY")
+	for _, field := range fields {
+		buf.WriteString(", ")
+		buf.WriteString(field)
+	}
+	buf.WriteString(") VALUES (:1")
+	for i := range fields {
+		buf.WriteString(fmt.Sprintf(", :%d", i+2))
+	}
+	buf.WriteString(")")
+}
+
+func (db *oracleDB) Insert(ctx context.Context, table string, key string, values map[string][]byte) error {
+	if db.batchSize > 1 {
+		return db.bufferInsert(ctx, table, key, values)
+	}
+
+	args := make([]interface{}, 0, 1+len(values))
+	args = append(args, key)
+
+	pairs := util.NewFieldPairs(values)
+	fields := make([]string, 0, len(pairs))
+	for _, p := range pairs {
+		fields = append(fields, p.Field)
+		args = append(args, string(p.Value))
+	}
+
+	buf := db.bufPool.Get()
+	defer db.bufPool.Put(buf)
+
+	writeInsert(buf, table, fields)
+
+	return db.execQuery(ctx, buf.String(), args...)
+}
+
+func (db *oracleDB) bufferInsert(ctx context.Context, table string, key string, values map[string][]byte) error {
+	state := ctx.Value(stateKey).(*oracleState)
+
+	if state.pendingTable != table {
+		if err := db.flushPending(ctx, state); err != nil {
+			return err
+		}
+		state.pendingTable = table
+	}
+
+	// The values may be reused by the workload after Insert returns, so we must copy them.
+	row := make(map[string][]byte, len(values))
+	for field, value := range values {
+		row[field] = append([]byte(nil), value...)
+	}
+
+	state.pendingKeys = append(state.pendingKeys, key)
+	state.pendingValues = append(state.pendingValues, row)
+
+	if len(state.pendingKeys) < db.batchSize {
+		return nil
+	}
+
+	return db.flushPending(ctx, state)
+}
+
+func (db *oracleDB) flushPending(ctx context.Context, state *oracleState) error {
+	if len(state.pendingKeys) == 0 {
+		return nil
+	}
+
+	err := db.BatchInsert(ctx, state.pendingTable, state.pendingKeys, state.pendingValues)
+	state.pendingKeys = state.pendingKeys[:0]
+	state.pendingValues = state.pendingValues[:0]
+	return err
+}
+
+// BatchInsert inserts the rows with the array DML, every bind variable is bound to the slice of the column values,
+// so the rows are sent in one round trip.
+func (db *oracleDB) BatchInsert(ctx context.Context, table string, keys []string, values []map[string][]byte) error {
+	fields := batchFields(values)
+
+	args := make([]interface{}, 0, 1+len(fields))
+	args = append(args, keys)
+	for _, field := range fields {
+		column := make([]string, len(keys))
+		for i := range keys {
+			// The missing field will be inserted as NULL, Oracle treats the empty string as NULL.
+			column[i] = string(values[i][field])
+		}
+		args = append(args, column)
+	}
+
+	buf := db.bufPool.Get()
+	defer db.bufPool.Put(buf)
+
+	writeInsert(buf, table, fields)
+
+	return db.execQuery(ctx, buf.String(), args...)
+}
+
+// batchFields returns the sorted union of the fields in the batch values.
+func batchFields(values []map[string][]byte) []string {
+	set := make(map[string]struct{})
+	for _, value := range values {
+		for field := range value {
+			set[field] = struct{}{}
+		}
+	}
+
+	fields := make([]string, 0, len(set))
+	for field := range set {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+func (db *oracleDB) BatchRead(ctx context.Context, table string, keys []string, fields []string) ([]map[string][]byte, error) {
+	buf := db.bufPool.Get()
+	defer db.bufPool.Put(buf)
+
+	selected := fields
+	if len(fields) > 0 {
+		// YCSB_KEY is required to match the rows with the keys.
+		selected = append([]string{"YCSB_KEY"}, fields...)
+	}
+
+	buf.WriteString(fmt.Sprintf("SELECT %s FROM %s WHERE YCSB_KEY IN (", db.selectColumns(selected), table))
+	args := make([]interface{}, 0, len(keys))
+	for i, key := range keys {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(fmt.Sprintf(":%d", i+1))
+		args = append(args, key)
+	}
+	buf.WriteString(")")
+
+	query := buf.String()
+	rows, err := db.queryRows(ctx, query, len(keys), args...)
+	db.clearCacheIfFailed(ctx, query, err)
+	if err != nil {
+		return nil, err
+	}
+
+	byKey := make(map[string]map[string][]byte, len(rows))
+	for _, row := range rows {
+		byKey[string(row["YCSB_KEY"])] = row
+	}
+
+	res := make([]map[string][]byte, len(keys))
+	for i, key := range keys {
+		res[i] = byKey[key]
+	}
+	return res, nil
+}
+
+func (db *oracleDB) BatchUpdate(ctx context.Context, table string, keys []string, values []map[string][]byte) error {
+	for i, key := range keys {
+		if err := db.Update(ctx, table, key, values[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (db *oracleDB) Delete(ctx context.Context, table string, key string) error {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE YCSB_KEY = :1`, table)
+
+	return db.execQuery(ctx, query, key)
+}
+
+// BatchDelete deletes the rows with the array DML.
+func (db *oracleDB) BatchDelete(ctx context.Context, table string, keys []string) error {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE YCSB_KEY = :1`, table)
+
+	return db.execQuery(ctx, query, keys)
+}
+
+func init() {
+	ycsb.RegisterDBCreator("oracle", oracleCreator{})
+
+	prop.Register(prop.String, oracleHost, oracleUser, oraclePassword, oracleService)
+	prop.Register(prop.Int, oraclePort, oracleBatchSize)
+	prop.Register(prop.Bool, oracleSequencePK)
+}
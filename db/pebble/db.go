@@ -0,0 +1,236 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pebble
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/cockroachdb/pebble"
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/util"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+)
+
+// pebble properties
+const (
+	pebbleDir    = "pebble.dir"
+	pebbleWALDir = "pebble.wal_dir"
+	// Options
+	pebbleBytesPerSync                = "pebble.bytes_per_sync"
+	pebbleCacheSize                   = "pebble.cache_size"
+	pebbleL0CompactionThreshold       = "pebble.l0_compaction_threshold"
+	pebbleL0StopWritesThreshold       = "pebble.l0_stop_writes_threshold"
+	pebbleLBaseMaxBytes               = "pebble.lbase_max_bytes"
+	pebbleMaxConcurrentCompactions    = "pebble.max_concurrent_compactions"
+	pebbleMaxOpenFiles                = "pebble.max_open_files"
+	pebbleMemTableSize                = "pebble.memtable_size"
+	pebbleMemTableStopWritesThreshold = "pebble.memtable_stop_writes_threshold"
+	pebbleDisableWAL                  = "pebble.disable_wal"
+	// WriteOptions
+	pebbleSyncWrites = "pebble.sync_writes"
+)
+
+type pebbleCreator struct {
+}
+
+type pebbleDB struct {
+	p *properties.Properties
+
+	db *pebble.DB
+
+	r       *util.RowCodec
+	bufPool *util.BufPool
+
+	writeOpts *pebble.WriteOptions
+}
+
+func (c pebbleCreator) Create(p *properties.Properties) (ycsb.DB, error) {
+	dir := p.GetString(pebbleDir, "/tmp/pebble")
+	walDir := p.GetString(pebbleWALDir, "")
+
+	if p.GetBool(prop.DropData, prop.DropDataDefault) {
+		os.RemoveAll(dir)
+		if len(walDir) > 0 {
+			os.RemoveAll(walDir)
+		}
+	}
+
+	opts := getOptions(p)
+	opts.WALDir = walDir
+
+	db, err := pebble.Open(dir, opts)
+	// The DB holds its own reference of the cache.
+	opts.Cache.Unref()
+	if err != nil {
+		return nil, err
+	}
+
+	writeOpts := pebble.NoSync
+	if p.GetBool(pebbleSyncWrites, false) {
+		writeOpts = pebble.Sync
+	}
+
+	return &pebbleDB{
+		p:         p,
+		db:        db,
+		r:         util.NewRowCodec(p),
+		bufPool:   util.NewBufPool(),
+		writeOpts: writeOpts,
+	}, nil
+}
+
+func getOptions(p *properties.Properties) *pebble.Options {
+	opts := &pebble.Options{
+		BytesPerSync:                p.GetInt(pebbleBytesPerSync, 512<<10),
+		Cache:                       pebble.NewCache(p.GetInt64(pebbleCacheSize, 8<<20)),
+		L0CompactionThreshold:       p.GetInt(pebbleL0CompactionThreshold, 4),
+		L0StopWritesThreshold:       p.GetInt(pebbleL0StopWritesThreshold, 12),
+		LBaseMaxBytes:               p.GetInt64(pebbleLBaseMaxBytes, 64<<20),
+		MaxConcurrentCompactions:    p.GetInt(pebbleMaxConcurrentCompactions, 1),
+		MaxOpenFiles:                p.GetInt(pebbleMaxOpenFiles, 1000),
+		MemTableSize:                p.GetInt(pebbleMemTableSize, 4<<20),
+		MemTableStopWritesThreshold: p.GetInt(pebbleMemTableStopWritesThreshold, 2),
+		DisableWAL:                  p.GetBool(pebbleDisableWAL, false),
+	}
+
+	return opts.EnsureDefaults()
+}
+
+func (db *pebbleDB) Close() error {
+	return db.db.Close()
+}
+
+func (db *pebbleDB) InitThread(ctx context.Context, _ int, _ int) context.Context {
+	return ctx
+}
+
+func (db *pebbleDB) CleanupThread(_ context.Context) {
+}
+
+func (db *pebbleDB) getRowKey(table string, key string) []byte {
+	return util.Slice(fmt.Sprintf("%s:%s", table, key))
+}
+
+func (db *pebbleDB) getEndRowKey(table string) []byte {
+	// ';' is ':' + 1 in the ASCII
+	return util.Slice(fmt.Sprintf("%s;", table))
+}
+
+func (db *pebbleDB) Read(ctx context.Context, table string, key string, fields []string) (map[string][]byte, error) {
+	value, closer, err := db.db.Get(db.getRowKey(table, key))
+	if err != nil {
+		return nil, err
+	}
+	defer closer.Close()
+
+	// The value is only valid until the closer is closed.
+	return db.r.Decode(append([]byte(nil), value...), fields)
+}
+
+func (db *pebbleDB) Scan(ctx context.Context, table string, startKey string, count int, fields []string) ([]map[string][]byte, error) {
+	res := make([]map[string][]byte, 0, count)
+	it := db.db.NewIter(&pebble.IterOptions{
+		UpperBound: db.getEndRowKey(table),
+	})
+	defer it.Close()
+
+	for it.SeekGE(db.getRowKey(table, startKey)); it.Valid() && len(res) < count; it.Next() {
+		m, err := db.r.Decode(append([]byte(nil), it.Value()...), fields)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, m)
+	}
+
+	if err := it.Error(); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// ReverseScan scans the records before the start key in the descending order.
+func (db *pebbleDB) ReverseScan(ctx context.Context, table string, startKey string, count int, fields []string) ([]map[string][]byte, error) {
+	res := make([]map[string][]byte, 0, count)
+	it := db.db.NewIter(&pebble.IterOptions{
+		LowerBound: db.getRowKey(table, ""),
+	})
+	defer it.Close()
+
+	// The start key is included, so seek to the smallest key after it.
+	for it.SeekLT(append(db.getRowKey(table, startKey), 0)); it.Valid() && len(res) < count; it.Prev() {
+		m, err := db.r.Decode(append([]byte(nil), it.Value()...), fields)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, m)
+	}
+
+	if err := it.Error(); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (db *pebbleDB) Update(ctx context.Context, table string, key string, values map[string][]byte) error {
+	m, err := db.Read(ctx, table, key, nil)
+	if err != nil {
+		return err
+	}
+
+	for field, value := range values {
+		m[field] = value
+	}
+
+	buf := db.bufPool.Get()
+	defer db.bufPool.Put(buf)
+
+	rowData, err := db.r.Encode(buf.Bytes(), m)
+	if err != nil {
+		return err
+	}
+
+	return db.db.Set(db.getRowKey(table, key), rowData, db.writeOpts)
+}
+
+func (db *pebbleDB) Insert(ctx context.Context, table string, key string, values map[string][]byte) error {
+	buf := db.bufPool.Get()
+	defer db.bufPool.Put(buf)
+
+	rowData, err := db.r.Encode(buf.Bytes(), values)
+	if err != nil {
+		return err
+	}
+
+	return db.db.Set(db.getRowKey(table, key), rowData, db.writeOpts)
+}
+
+func (db *pebbleDB) Delete(ctx context.Context, table string, key string) error {
+	return db.db.Delete(db.getRowKey(table, key), db.writeOpts)
+}
+
+func init() {
+	ycsb.RegisterDBCreator("pebble", pebbleCreator{})
+
+	prop.Register(prop.String, pebbleDir, pebbleWALDir)
+	prop.Register(prop.Int,
+		pebbleBytesPerSync, pebbleCacheSize, pebbleL0CompactionThreshold,
+		pebbleL0StopWritesThreshold, pebbleLBaseMaxBytes, pebbleMaxConcurrentCompactions,
+		pebbleMaxOpenFiles, pebbleMemTableSize, pebbleMemTableStopWritesThreshold)
+	prop.Register(prop.Bool, pebbleDisableWAL, pebbleSyncWrites)
+}
@@ -54,6 +54,7 @@ import (
 	"github.com/XiaoMi/pegasus-go-client/pegasus"
 	"github.com/XiaoMi/pegasus-go-client/pegasus2"
 	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
 	"github.com/pingcap/go-ycsb/pkg/ycsb"
 )
 
@@ -173,4 +174,6 @@ func (pegasusCreator) Create(p *properties.Properties) (ycsb.DB, error) {
 
 func init() {
 	ycsb.RegisterDBCreator("pegasus", pegasusCreator{})
+
+	prop.Register(prop.String, "meta_servers")
 }
@@ -0,0 +1,169 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pg
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/measurement"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/util"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+	"go.uber.org/zap"
+)
+
+// cockroach properties
+const (
+	// The AS OF SYSTEM TIME expression used by Read and Scan, like "follower_read_timestamp()" or "'-10s'",
+	// reads are the latest if not set.
+	cockroachAsOfSystemTime = "cockroach.as_of_system_time"
+	// Max retries of the transaction which fails with a serialization error.
+	cockroachMaxRetries = "cockroach.max_retries"
+)
+
+// serializationFailure is the error code which means the transaction must be retried.
+const serializationFailure = "40001"
+
+func isRetryableError(err error) bool {
+	if e, ok := err.(*pq.Error); ok {
+		return e.Code == serializationFailure
+	}
+	return false
+}
+
+// withRetry runs f until it succeeds, fails with a non-retryable error or runs out of the retries.
+// Every restart is measured as TXN_RESTART.
+func (db *pgDB) withRetry(f func() error) error {
+	for retry := 0; ; retry++ {
+		start := time.Now()
+		err := f()
+		if err == nil || retry >= db.maxRetries || !isRetryableError(err) {
+			return err
+		}
+		measurement.Measure("TXN_RESTART", time.Now().Sub(start))
+	}
+}
+
+type cockroachCreator struct {
+}
+
+// cockroachDB executes the read-modify-write operation in one transaction with
+// the CockroachDB client-side retry protocol.
+type cockroachDB struct {
+	*pgDB
+}
+
+func (c cockroachCreator) Create(p *properties.Properties) (ycsb.DB, error) {
+	db, err := pgCreator{}.Create(p)
+	if err != nil {
+		return nil, err
+	}
+
+	d := db.(*pgDB)
+	d.maxRetries = p.GetInt(cockroachMaxRetries, 10)
+	if asOf := p.GetString(cockroachAsOfSystemTime, ""); len(asOf) > 0 {
+		d.asOfSystemTime = fmt.Sprintf(" AS OF SYSTEM TIME %s", asOf)
+	}
+
+	return &cockroachDB{d}, nil
+}
+
+func (db *cockroachDB) exec(ctx context.Context, state *pgState, query string) error {
+	if db.verbose {
+		util.Logger().Info("query", zap.String("query", query))
+	}
+	_, err := state.conn.ExecContext(ctx, query)
+	return err
+}
+
+func (db *cockroachDB) ReadModifyWrite(ctx context.Context, table string, key string, fields []string, values map[string][]byte) (_ map[string][]byte, err error) {
+	state := ctx.Value(stateKey).(*pgState)
+
+	var query string
+	if len(fields) == 0 {
+		query = fmt.Sprintf(`SELECT * FROM %s WHERE %s FOR UPDATE`, table, util.KeyCondition(db.keyColumns, "=", placeholderFrom(1)))
+	} else {
+		query = fmt.Sprintf(`SELECT %s FROM %s WHERE %s FOR UPDATE`, strings.Join(fields, ","), table, util.KeyCondition(db.keyColumns, "=", placeholderFrom(1)))
+	}
+	updateQuery, updateArgs := db.buildUpdate(table, key, values)
+
+	if err = db.exec(ctx, state, "BEGIN"); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			db.exec(ctx, state, "ROLLBACK")
+		}
+	}()
+
+	if err = db.exec(ctx, state, "SAVEPOINT cockroach_restart"); err != nil {
+		return nil, err
+	}
+
+	var row map[string][]byte
+	for retry := 0; ; retry++ {
+		start := time.Now()
+		row, err = db.readModifyWrite(ctx, query, key, updateQuery, updateArgs)
+		if err == nil {
+			err = db.exec(ctx, state, "RELEASE SAVEPOINT cockroach_restart")
+		}
+		if err == nil || retry >= db.maxRetries || !isRetryableError(err) {
+			break
+		}
+
+		measurement.Measure("TXN_RESTART", time.Now().Sub(start))
+		if err = db.exec(ctx, state, "ROLLBACK TO SAVEPOINT cockroach_restart"); err != nil {
+			return nil, err
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err = db.exec(ctx, state, "COMMIT"); err != nil {
+		return nil, err
+	}
+	return row, nil
+}
+
+func (db *cockroachDB) readModifyWrite(ctx context.Context, query string, key string, updateQuery string, updateArgs []interface{}) (map[string][]byte, error) {
+	rows, err := db.doQueryRows(ctx, query, 1, db.keySchema.Values(key)...)
+	db.clearCacheIfFailed(ctx, query, err)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = db.doExecQuery(ctx, updateQuery, updateArgs...); err != nil {
+		return nil, err
+	}
+
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	return rows[0], nil
+}
+
+func init() {
+	ycsb.RegisterDBCreator("cockroach", cockroachCreator{})
+	ycsb.RegisterDBCreator("cdb", cockroachCreator{})
+	ycsb.RegisterDBCreator("cockroachdb", cockroachCreator{})
+
+	prop.Register(prop.String, cockroachAsOfSystemTime)
+	prop.Register(prop.Int, cockroachMaxRetries)
+}
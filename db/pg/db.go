@@ -19,6 +19,7 @@ import (
 	"database/sql"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/pingcap/go-ycsb/pkg/prop"
 	"github.com/pingcap/go-ycsb/pkg/util"
@@ -27,6 +28,7 @@ import (
 	_ "github.com/lib/pq"
 	"github.com/magiconair/properties"
 	"github.com/pingcap/go-ycsb/pkg/ycsb"
+	"go.uber.org/zap"
 )
 
 // pg properties
@@ -36,7 +38,7 @@ const (
 	pgUser     = "pg.user"
 	pgPassword = "pg.password"
 	pgDBName   = "pg.db"
-	pdSSLMode  = "pg.sslmode"
+	pgSSLMode  = "pg.sslmode"
 	// TODO: support batch and auto commit
 )
 
@@ -50,7 +52,23 @@ type pgDB struct {
 
 	bufPool *util.BufPool
 
+	queryLogger *util.QueryLogger
+
+	secondaryIndexes []string
+	// queryColumn is the column used to look up records in Read and Scan, YCSB_KEY or a secondary index.
+	queryColumn  string
+	queryOrderBy string
+
+	// keySchema is set if the keys are composite, the components are stored in the key columns.
+	keySchema  *util.KeySchema
+	keyColumns []string
+
 	dbName string
+
+	// asOfSystemTime is the AS OF SYSTEM TIME clause used by Read and Scan in CockroachDB.
+	asOfSystemTime string
+	// maxRetries is the max retries of the statement which fails with a serialization error in CockroachDB.
+	maxRetries int
 }
 
 type contextKey string
@@ -73,13 +91,13 @@ func (c pgCreator) Create(p *properties.Properties) (ycsb.DB, error) {
 	user := p.GetString(pgUser, "root")
 	password := p.GetString(pgPassword, "")
 	dbName := p.GetString(pgDBName, "test")
-	sslMode := p.GetString(pdSSLMode, "disable")
+	sslMode := p.GetString(pgSSLMode, "disable")
 
 	dsn := fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=%s", user, password, host, port, dbName, sslMode)
 	var err error
 	db, err := sql.Open("postgres", dsn)
 	if err != nil {
-		fmt.Printf("open pg failed %v", err)
+		util.Logger().Error("open pg failed", zap.Error(err))
 		return nil, err
 	}
 
@@ -93,44 +111,81 @@ func (c pgCreator) Create(p *properties.Properties) (ycsb.DB, error) {
 
 	d.bufPool = util.NewBufPool()
 
-	if err := d.createTable(); err != nil {
+	if d.queryLogger, err = util.NewQueryLogger(p); err != nil {
 		return nil, err
 	}
 
+	d.secondaryIndexes = util.SecondaryIndexes(p)
+	d.queryColumn = "YCSB_KEY"
+	queryField, err := util.SecondaryQueryField(p)
+	if err != nil {
+		return nil, err
+	}
+	if len(queryField) > 0 {
+		d.queryColumn = queryField
+		d.queryOrderBy = fmt.Sprintf(" ORDER BY %s", queryField)
+	}
+
+	d.keySchema = util.NewKeySchema(p)
+	d.keyColumns = d.keySchema.Columns()
+	if d.keySchema != nil && len(queryField) > 0 {
+		return nil, fmt.Errorf("%s can't be used with %s", prop.SecondaryQueryField, prop.KeyComposition)
+	}
+
+	for _, tableName := range util.TableNames(p) {
+		if err := d.createTable(tableName); err != nil {
+			return nil, err
+		}
+	}
+
 	return d, nil
 }
 
-func (db *pgDB) createTable() error {
-	tableName := db.p.GetString(prop.TableName, prop.TableNameDefault)
-
+func (db *pgDB) createTable(tableName string) error {
 	if db.p.GetBool(prop.DropData, prop.DropDataDefault) {
 		if _, err := db.db.Exec(fmt.Sprintf("DROP TABLE IF EXISTS %s", tableName)); err != nil {
 			return err
 		}
 	}
 
-	fieldCount := db.p.GetInt64(prop.FieldCount, prop.FieldCountDefault)
 	fieldLength := db.p.GetInt64(prop.FieldLength, prop.FieldLengthDefault)
 
 	buf := new(bytes.Buffer)
-	s := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (YCSB_KEY VARCHAR(64) PRIMARY KEY", tableName)
+	s := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s", tableName, db.keySchema.ColumnDefinitions())
 	buf.WriteString(s)
 
-	for i := int64(0); i < fieldCount; i++ {
-		buf.WriteString(fmt.Sprintf(", FIELD%d VARCHAR(%d)", i, fieldLength))
+	for _, field := range util.FieldNames(db.p) {
+		buf.WriteString(fmt.Sprintf(", %s VARCHAR(%d)", strings.ToUpper(field), fieldLength))
 	}
 
+	buf.WriteString(db.keySchema.PrimaryKeyDefinition())
 	buf.WriteString(");")
 
 	if db.verbose {
-		fmt.Println(buf.String())
+		util.Logger().Info("query", zap.String("query", buf.String()))
 	}
 
-	_, err := db.db.Exec(buf.String())
-	return err
+	if _, err := db.db.Exec(buf.String()); err != nil {
+		return err
+	}
+
+	for _, field := range db.secondaryIndexes {
+		query := fmt.Sprintf("CREATE INDEX IF NOT EXISTS IDX_%s ON %s (%s)", field, tableName, field)
+		if db.verbose {
+			util.Logger().Info("query", zap.String("query", query))
+		}
+
+		if _, err := db.db.Exec(query); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 func (db *pgDB) Close() error {
+	db.queryLogger.Close()
+
 	if db.db == nil {
 		return nil
 	}
@@ -197,8 +252,19 @@ func (db *pgDB) clearCacheIfFailed(ctx context.Context, query string, err error)
 }
 
 func (db *pgDB) queryRows(ctx context.Context, query string, count int, args ...interface{}) ([]map[string][]byte, error) {
+	var rows []map[string][]byte
+	err := db.withRetry(func() (err error) {
+		start := time.Now()
+		rows, err = db.doQueryRows(ctx, query, count, args...)
+		db.queryLogger.Log(start, query, args, err)
+		return
+	})
+	return rows, err
+}
+
+func (db *pgDB) doQueryRows(ctx context.Context, query string, count int, args ...interface{}) ([]map[string][]byte, error) {
 	if db.verbose {
-		fmt.Printf("%s %v\n", query, args)
+		util.Logger().Info("query", zap.String("query", query), zap.Reflect("args", args))
 	}
 
 	stmt, err := db.getAndCacheStmt(ctx, query)
@@ -238,15 +304,27 @@ func (db *pgDB) queryRows(ctx context.Context, query string, count int, args ...
 	return vs, rows.Err()
 }
 
+// queryCondition returns the condition to look up the records by the key in Read and Scan.
+func (db *pgDB) queryCondition(op string) string {
+	if db.keySchema != nil {
+		return util.KeyCondition(db.keyColumns, op, placeholderFrom(1))
+	}
+	return fmt.Sprintf("%s %s $1", db.queryColumn, op)
+}
+
 func (db *pgDB) Read(ctx context.Context, table string, key string, fields []string) (map[string][]byte, error) {
 	var query string
 	if len(fields) == 0 {
-		query = fmt.Sprintf(`SELECT * FROM %s WHERE YCSB_KEY = $1`, table)
+		query = fmt.Sprintf(`SELECT * FROM %s%s WHERE %s`, table, db.asOfSystemTime, db.queryCondition("="))
 	} else {
-		query = fmt.Sprintf(`SELECT %s FROM %s WHERE YCSB_KEY = $1`, strings.Join(fields, ","), table)
+		query = fmt.Sprintf(`SELECT %s FROM %s%s WHERE %s`, strings.Join(fields, ","), table, db.asOfSystemTime, db.queryCondition("="))
+	}
+	if len(db.queryOrderBy) > 0 {
+		// The secondary field may be not unique.
+		query += " LIMIT 1"
 	}
 
-	rows, err := db.queryRows(ctx, query, 1, key)
+	rows, err := db.queryRows(ctx, query, 1, db.keySchema.Values(key)...)
 	db.clearCacheIfFailed(ctx, query, err)
 
 	if err != nil {
@@ -261,20 +339,68 @@ func (db *pgDB) Read(ctx context.Context, table string, key string, fields []str
 func (db *pgDB) Scan(ctx context.Context, table string, startKey string, count int, fields []string) ([]map[string][]byte, error) {
 	var query string
 	if len(fields) == 0 {
-		query = fmt.Sprintf(`SELECT * FROM %s WHERE YCSB_KEY >= $1 LIMIT $2`, table)
+		query = fmt.Sprintf(`SELECT * FROM %s%s WHERE %s%s LIMIT $%d`, table, db.asOfSystemTime, db.queryCondition(">="), db.queryOrderBy, len(db.keyColumns)+1)
+	} else {
+		query = fmt.Sprintf(`SELECT %s FROM %s%s WHERE %s%s LIMIT $%d`, strings.Join(fields, ","), table, db.asOfSystemTime, db.queryCondition(">="), db.queryOrderBy, len(db.keyColumns)+1)
+	}
+
+	rows, err := db.queryRows(ctx, query, count, append(db.keySchema.Values(startKey), count)...)
+	db.clearCacheIfFailed(ctx, query, err)
+
+	return rows, err
+}
+
+// ReverseScan scans the records in the descending order of the key, or the secondary query field if it is set.
+func (db *pgDB) ReverseScan(ctx context.Context, table string, startKey string, count int, fields []string) ([]map[string][]byte, error) {
+	var query string
+	if len(fields) == 0 {
+		query = fmt.Sprintf(`SELECT * FROM %s%s WHERE %s%s LIMIT $%d`, table, db.asOfSystemTime, db.queryCondition("<="), db.reverseOrderBy(), len(db.keyColumns)+1)
+	} else {
+		query = fmt.Sprintf(`SELECT %s FROM %s%s WHERE %s%s LIMIT $%d`, strings.Join(fields, ","), table, db.asOfSystemTime, db.queryCondition("<="), db.reverseOrderBy(), len(db.keyColumns)+1)
+	}
+
+	rows, err := db.queryRows(ctx, query, count, append(db.keySchema.Values(startKey), count)...)
+	db.clearCacheIfFailed(ctx, query, err)
+
+	return rows, err
+}
+
+// reverseOrderBy returns the ORDER BY clause of the descending order of the query columns.
+func (db *pgDB) reverseOrderBy() string {
+	columns := []string{db.queryColumn}
+	if db.keySchema != nil {
+		columns = db.keyColumns
+	}
+	return fmt.Sprintf(" ORDER BY %s DESC", strings.Join(columns, " DESC, "))
+}
+
+// Query looks up the records by the field, the field should be indexed by sql.secondary_indexes.
+func (db *pgDB) Query(ctx context.Context, table string, field string, value []byte, count int, fields []string) ([]map[string][]byte, error) {
+	var query string
+	if len(fields) == 0 {
+		query = fmt.Sprintf(`SELECT * FROM %s%s WHERE %s = $1 LIMIT $2`, table, db.asOfSystemTime, field)
 	} else {
-		query = fmt.Sprintf(`SELECT %s FROM %s WHERE YCSB_KEY >= $1 LIMIT $2`, strings.Join(fields, ","), table)
+		query = fmt.Sprintf(`SELECT %s FROM %s%s WHERE %s = $1 LIMIT $2`, strings.Join(fields, ","), table, db.asOfSystemTime, field)
 	}
 
-	rows, err := db.queryRows(ctx, query, count, startKey, count)
+	rows, err := db.queryRows(ctx, query, count, value, count)
 	db.clearCacheIfFailed(ctx, query, err)
 
 	return rows, err
 }
 
 func (db *pgDB) execQuery(ctx context.Context, query string, args ...interface{}) error {
+	return db.withRetry(func() error {
+		start := time.Now()
+		err := db.doExecQuery(ctx, query, args...)
+		db.queryLogger.Log(start, query, args, err)
+		return err
+	})
+}
+
+func (db *pgDB) doExecQuery(ctx context.Context, query string, args ...interface{}) error {
 	if db.verbose {
-		fmt.Printf("%s %v\n", query, args)
+		util.Logger().Info("query", zap.String("query", query), zap.Reflect("args", args))
 	}
 
 	stmt, err := db.getAndCacheStmt(ctx, query)
@@ -288,6 +414,11 @@ func (db *pgDB) execQuery(ctx context.Context, query string, args ...interface{}
 }
 
 func (db *pgDB) Update(ctx context.Context, table string, key string, values map[string][]byte) error {
+	query, args := db.buildUpdate(table, key, values)
+	return db.execQuery(ctx, query, args...)
+}
+
+func (db *pgDB) buildUpdate(table string, key string, values map[string][]byte) (string, []interface{}) {
 	buf := db.bufPool.Get()
 	defer db.bufPool.Put(buf)
 
@@ -309,23 +440,25 @@ func (db *pgDB) Update(ctx context.Context, table string, key string, values map
 		args = append(args, p.Value)
 		placeHolderIndex++
 	}
-	buf.WriteString(fmt.Sprintf(" WHERE YCSB_KEY = $%d", placeHolderIndex))
+	buf.WriteString(" WHERE ")
+	buf.WriteString(util.KeyCondition(db.keyColumns, "=", placeholderFrom(placeHolderIndex)))
 
-	args = append(args, key)
+	args = append(args, db.keySchema.Values(key)...)
 
-	return db.execQuery(ctx, buf.String(), args...)
+	return buf.String(), args
 }
 
 func (db *pgDB) Insert(ctx context.Context, table string, key string, values map[string][]byte) error {
-	args := make([]interface{}, 0, 1+len(values))
-	args = append(args, key)
+	args := make([]interface{}, 0, len(db.keyColumns)+len(values))
+	args = append(args, db.keySchema.Values(key)...)
 
 	buf := db.bufPool.Get()
 	defer db.bufPool.Put(buf)
 
 	buf.WriteString("INSERT INTO ")
 	buf.WriteString(table)
-	buf.WriteString(" (YCSB_KEY")
+	buf.WriteString(" (")
+	buf.WriteString(strings.Join(db.keyColumns, ", "))
 	pairs := util.NewFieldPairs(values)
 	for _, p := range pairs {
 		args = append(args, p.Value)
@@ -334,8 +467,8 @@ func (db *pgDB) Insert(ctx context.Context, table string, key string, values map
 	}
 	buf.WriteString(") VALUES ($1")
 
-	for i := 0; i < len(pairs); i++ {
-		buf.WriteString(fmt.Sprintf(" ,$%d", i+2))
+	for i := 1; i < len(args); i++ {
+		buf.WriteString(fmt.Sprintf(" ,$%d", i+1))
 	}
 
 	buf.WriteString(") ON CONFLICT DO NOTHING")
@@ -344,14 +477,22 @@ func (db *pgDB) Insert(ctx context.Context, table string, key string, values map
 }
 
 func (db *pgDB) Delete(ctx context.Context, table string, key string) error {
-	query := fmt.Sprintf(`DELETE FROM %s WHERE YCSB_KEY = $1`, table)
+	query := fmt.Sprintf(`DELETE FROM %s WHERE %s`, table, util.KeyCondition(db.keyColumns, "=", placeholderFrom(1)))
 
-	return db.execQuery(ctx, query, key)
+	return db.execQuery(ctx, query, db.keySchema.Values(key)...)
+}
+
+// placeholderFrom returns the placeholders of KeyCondition starting from $index.
+func placeholderFrom(index int) func(i int) string {
+	return func(i int) string {
+		return fmt.Sprintf("$%d", index+i)
+	}
 }
 
 func init() {
 	ycsb.RegisterDBCreator("pg", pgCreator{})
 	ycsb.RegisterDBCreator("postgresql", pgCreator{})
-	ycsb.RegisterDBCreator("cockroach", pgCreator{})
-	ycsb.RegisterDBCreator("cdb", pgCreator{})
+
+	prop.Register(prop.String, pgHost, pgUser, pgPassword, pgDBName, pgSSLMode)
+	prop.Register(prop.Int, pgPort)
 }
@@ -3,30 +3,56 @@ package redis
 import (
 	"context"
 	"crypto/tls"
-	"encoding/json"
-	"fmt"
 	"strings"
 	"time"
 
 	goredis "github.com/go-redis/redis"
 	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/measurement"
 	"github.com/pingcap/go-ycsb/pkg/prop"
 	"github.com/pingcap/go-ycsb/pkg/util"
 	"github.com/pingcap/go-ycsb/pkg/ycsb"
+	"go.uber.org/zap"
 )
 
 type redisClient interface {
-	Get(key string) *goredis.StringCmd
-	Scan(cursor uint64, match string, count int64) *goredis.ScanCmd
-	Set(key string, value interface{}, expiration time.Duration) *goredis.StatusCmd
-	Del(keys ...string) *goredis.IntCmd
+	HGetAll(key string) *goredis.StringStringMapCmd
+	HMGet(key string, fields ...string) *goredis.SliceCmd
+	ZRangeByLex(key string, opt goredis.ZRangeBy) *goredis.StringSliceCmd
+	Pipeline() goredis.Pipeliner
 	FlushDB() *goredis.StatusCmd
 	Close() error
 }
 
+// redisCommander is implemented by both the client and the pipeline.
+type redisCommander interface {
+	HMSet(key string, fields map[string]interface{}) *goredis.StatusCmd
+	ZAdd(key string, members ...goredis.Z) *goredis.IntCmd
+	Del(keys ...string) *goredis.IntCmd
+	ZRem(key string, members ...interface{}) *goredis.IntCmd
+}
+
 type redis struct {
 	client redisClient
 	mode   string
+	// The writes are sent in one pipeline when pipelineSize > 1.
+	pipelineSize int
+}
+
+type contextKey string
+
+const stateKey = contextKey("redis")
+
+type redisState struct {
+	pipe    goredis.Pipeliner
+	pending int
+	// callbacks of the asynchronous operations in the pipeline, they are called after the pipeline is executed.
+	callbacks []func()
+}
+
+// BufferedInserts implements the BufferedDB BufferedInserts interface.
+func (r *redis) BufferedInserts() bool {
+	return r.pipelineSize > 1
 }
 
 func (r *redis) Close() error {
@@ -34,69 +60,259 @@ func (r *redis) Close() error {
 }
 
 func (r *redis) InitThread(ctx context.Context, _ int, _ int) context.Context {
-	return ctx
+	state := &redisState{pipe: r.client.Pipeline()}
+	return context.WithValue(ctx, stateKey, state)
 }
 
-func (r *redis) CleanupThread(_ context.Context) {
+func (r *redis) CleanupThread(ctx context.Context) {
+	state := ctx.Value(stateKey).(*redisState)
+	if err := r.flush(state); err != nil {
+		util.Logger().Error("flush pipeline failed", zap.Error(err))
+	}
+	state.pipe.Close()
 }
 
-func (r *redis) Read(ctx context.Context, table string, key string, fields []string) (map[string][]byte, error) {
-	data := make(map[string][]byte, len(fields))
+// The record is stored as a hash, and the keys of a table are indexed by a sorted set
+// with the same score so Scan can range over them in lexicographical order.
+func recordKey(table string, key string) string {
+	return table + "/" + key
+}
 
-	res, err := r.client.Get(table + "/" + key).Result()
+func indexKey(table string) string {
+	return table + "/index"
+}
 
-	if err != nil {
-		return nil, err
+// write queues the commands in the thread pipeline, and executes the pipeline
+// when it has pipelineSize commands.
+func (r *redis) write(ctx context.Context, cmds int, f func(c redisCommander)) error {
+	state := ctx.Value(stateKey).(*redisState)
+	f(state.pipe)
+	state.pending += cmds
+	if state.pending < r.pipelineSize {
+		return nil
 	}
+	return r.flush(state)
+}
 
-	err = json.Unmarshal([]byte(res), &data)
+// flush executes the pipeline, which is measured as REDIS_PIPELINE, or REDIS_PIPELINE_ERROR if it fails,
+// since the queued writes don't wait for it.
+func (r *redis) flush(state *redisState) error {
+	if state.pending == 0 {
+		return nil
+	}
+	state.pending = 0
+	start := time.Now()
+	_, err := state.pipe.Exec()
 	if err != nil {
-		return nil, err
+		measurement.Measure("REDIS_PIPELINE_ERROR", time.Now().Sub(start))
+	} else {
+		measurement.Measure("REDIS_PIPELINE", time.Now().Sub(start))
 	}
+	for _, cb := range state.callbacks {
+		cb()
+	}
+	state.callbacks = state.callbacks[:0]
+	return err
+}
 
-	// TODO: filter by fields
-
-	return data, err
+func toValues(m map[string]string) map[string][]byte {
+	data := make(map[string][]byte, len(m))
+	for field, value := range m {
+		data[field] = []byte(value)
+	}
+	return data
 }
 
-func (r *redis) Scan(ctx context.Context, table string, startKey string, count int, fields []string) ([]map[string][]byte, error) {
-	return nil, fmt.Errorf("scan is not supported")
+func toFields(values map[string][]byte) map[string]interface{} {
+	fields := make(map[string]interface{}, len(values))
+	for field, value := range values {
+		// The values may be reused by the workload after the command is queued, so we must copy them.
+		fields[field] = string(value)
+	}
+	return fields
 }
 
-func (r *redis) Update(ctx context.Context, table string, key string, values map[string][]byte) error {
-	d, err := r.client.Get(table + "/" + key).Result()
-	if err != nil {
-		return err
+// Read doesn't send the writes pending in the pipeline, so it may not see them.
+func (r *redis) Read(ctx context.Context, table string, key string, fields []string) (map[string][]byte, error) {
+	if len(fields) == 0 {
+		res, err := r.client.HGetAll(recordKey(table, key)).Result()
+		if err != nil || len(res) == 0 {
+			return nil, err
+		}
+		return toValues(res), nil
 	}
 
-	curVal := map[string][]byte{}
-	err = json.Unmarshal([]byte(d), &curVal)
+	res, err := r.client.HMGet(recordKey(table, key), fields...).Result()
 	if err != nil {
-		return err
+		return nil, err
 	}
-	for k, v := range values {
-		curVal[k] = v
+	return toFieldValues(fields, res), nil
+}
+
+// toFieldValues converts the result of HMGET, it returns nil if none of the fields exists.
+func toFieldValues(fields []string, res []interface{}) map[string][]byte {
+	data := make(map[string][]byte, len(fields))
+	for i, v := range res {
+		if s, ok := v.(string); ok {
+			data[fields[i]] = []byte(s)
+		}
 	}
-	var data []byte
-	data, err = json.Marshal(curVal)
-	if err != nil {
-		return err
+	if len(data) == 0 {
+		return nil
 	}
-
-	return r.client.Set(table+"/"+key, string(data), 0).Err()
+	return data
 }
 
-func (r *redis) Insert(ctx context.Context, table string, key string, values map[string][]byte) error {
-	data, err := json.Marshal(values)
+// Scan ranges over the index of the table, and reads the records in one pipeline.
+func (r *redis) Scan(ctx context.Context, table string, startKey string, count int, fields []string) ([]map[string][]byte, error) {
+	keys, err := r.client.ZRangeByLex(indexKey(table), goredis.ZRangeBy{
+		Min:   "[" + startKey,
+		Max:   "+",
+		Count: int64(count),
+	}).Result()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return r.client.Set(table+"/"+key, string(data), 0).Err()
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	pipe := r.client.Pipeline()
+	defer pipe.Close()
+	results := make([]func() (map[string][]byte, error), 0, len(keys))
+	for _, key := range keys {
+		if len(fields) == 0 {
+			cmd := pipe.HGetAll(recordKey(table, key))
+			results = append(results, func() (map[string][]byte, error) {
+				res, err := cmd.Result()
+				if err != nil || len(res) == 0 {
+					return nil, err
+				}
+				return toValues(res), nil
+			})
+		} else {
+			cmd := pipe.HMGet(recordKey(table, key), fields...)
+			results = append(results, func() (map[string][]byte, error) {
+				res, err := cmd.Result()
+				if err != nil {
+					return nil, err
+				}
+				return toFieldValues(fields, res), nil
+			})
+		}
+	}
+	if _, err = pipe.Exec(); err != nil {
+		return nil, err
+	}
+
+	res := make([]map[string][]byte, 0, len(keys))
+	for _, result := range results {
+		data, err := result()
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, data)
+	}
+	return res, nil
+}
+
+func (r *redis) Update(ctx context.Context, table string, key string, values map[string][]byte) error {
+	return r.write(ctx, 1, func(c redisCommander) {
+		c.HMSet(recordKey(table, key), toFields(values))
+	})
+}
+
+func (r *redis) Insert(ctx context.Context, table string, key string, values map[string][]byte) error {
+	return r.write(ctx, 2, func(c redisCommander) {
+		c.HMSet(recordKey(table, key), toFields(values))
+		c.ZAdd(indexKey(table), goredis.Z{Member: key})
+	})
 }
 
 func (r *redis) Delete(ctx context.Context, table string, key string) error {
-	return r.client.Del(table + "/" + key).Err()
+	return r.write(ctx, 2, func(c redisCommander) {
+		c.Del(recordKey(table, key))
+		c.ZRem(indexKey(table), key)
+	})
+}
+
+// The asynchronous operations are queued in the thread pipeline, and the pipeline is executed
+// when the client reaches request.outstanding or the thread finishes.
+func (r *redis) queue(ctx context.Context, cmds int, f func(pipe goredis.Pipeliner) func()) {
+	state := ctx.Value(stateKey).(*redisState)
+	state.callbacks = append(state.callbacks, f(state.pipe))
+	state.pending += cmds
+}
+
+func (r *redis) AsyncRead(ctx context.Context, table string, key string, fields []string, cb func(map[string][]byte, error)) {
+	r.queue(ctx, 1, func(pipe goredis.Pipeliner) func() {
+		if len(fields) == 0 {
+			cmd := pipe.HGetAll(recordKey(table, key))
+			return func() {
+				res, err := cmd.Result()
+				if err != nil || len(res) == 0 {
+					cb(nil, err)
+					return
+				}
+				cb(toValues(res), nil)
+			}
+		}
+
+		// The fields may be reused by the workload, so we must copy them.
+		fields := append([]string(nil), fields...)
+		cmd := pipe.HMGet(recordKey(table, key), fields...)
+		return func() {
+			res, err := cmd.Result()
+			if err != nil {
+				cb(nil, err)
+				return
+			}
+			cb(toFieldValues(fields, res), nil)
+		}
+	})
+}
+
+func (r *redis) AsyncUpdate(ctx context.Context, table string, key string, values map[string][]byte, cb func(error)) {
+	r.queue(ctx, 1, func(pipe goredis.Pipeliner) func() {
+		cmd := pipe.HMSet(recordKey(table, key), toFields(values))
+		return func() {
+			cb(cmd.Err())
+		}
+	})
+}
+
+func (r *redis) AsyncInsert(ctx context.Context, table string, key string, values map[string][]byte, cb func(error)) {
+	r.queue(ctx, 2, func(pipe goredis.Pipeliner) func() {
+		setCmd := pipe.HMSet(recordKey(table, key), toFields(values))
+		indexCmd := pipe.ZAdd(indexKey(table), goredis.Z{Member: key})
+		return func() {
+			if err := setCmd.Err(); err != nil {
+				cb(err)
+				return
+			}
+			cb(indexCmd.Err())
+		}
+	})
+}
+
+func (r *redis) AsyncDelete(ctx context.Context, table string, key string, cb func(error)) {
+	r.queue(ctx, 2, func(pipe goredis.Pipeliner) func() {
+		delCmd := pipe.Del(recordKey(table, key))
+		indexCmd := pipe.ZRem(indexKey(table), key)
+		return func() {
+			if err := delCmd.Err(); err != nil {
+				cb(err)
+				return
+			}
+			cb(indexCmd.Err())
+		}
+	})
+}
+
+func (r *redis) Flush(ctx context.Context) {
+	// The errors are passed to the callbacks.
+	r.flush(ctx.Value(stateKey).(*redisState))
 }
 
 type redisCreator struct{}
@@ -129,6 +345,7 @@ func (r redisCreator) Create(p *properties.Properties) (ycsb.DB, error) {
 		}
 	}
 	rds.mode = mode
+	rds.pipelineSize = p.GetInt(redisPipelineSize, 1)
 
 	return rds, nil
 }
@@ -159,6 +376,7 @@ const (
 	redisTLSCert               = "redis.tls_cert"
 	redisTLSKey                = "redis.tls_key"
 	redisTLSInsecureSkipVerify = "redis.tls_insecure_skip_verify"
+	redisPipelineSize          = "redis.pipeline_size"
 )
 
 func parseTLS(p *properties.Properties) *tls.Config {
@@ -230,4 +448,13 @@ func getOptionsCluster(p *properties.Properties) *goredis.ClusterOptions {
 
 func init() {
 	ycsb.RegisterDBCreator("redis", redisCreator{})
+
+	prop.Register(prop.String,
+		redisMode, redisNetwork, redisAddr, redisPassword, redisTLSCA, redisTLSCert, redisTLSKey)
+	prop.Register(prop.Int,
+		redisDB, redisMaxRedirects, redisMaxRetries, redisMinRetryBackoff, redisMaxRetryBackoff,
+		redisDialTimeout, redisReadTimeout, redisWriteTimeout, redisPoolSize, redisMinIdleConns,
+		redisMaxConnAge, redisPoolTimeout, redisIdleTimeout, redisIdleCheckFreq, redisPipelineSize)
+	prop.Register(prop.Bool,
+		redisReadOnly, redisRouteByLatency, redisRouteRandomly, redisTLSInsecureSkipVerify)
 }
@@ -49,6 +49,9 @@ const (
 	rocksdbUseFsync                        = "rocksdb.use_fsync"
 	rocksdbWriteBufferSize                 = "rocksdb.write_buffer_size"
 	rocksdbMaxWriteBufferNumber            = "rocksdb.max_write_buffer_number"
+	rocksdbCompression                     = "rocksdb.compression"
+	// WriteOptions
+	rocksdbSyncWrites = "rocksdb.sync_writes"
 	// TableOptions/BlockBasedTable
 	rocksdbBlockSize                        = "rocksdb.block_size"
 	rocksdbBlockSizeDeviation               = "rocksdb.block_size_deviation"
@@ -59,6 +62,7 @@ const (
 	rocksdbBlockRestartInterval             = "rocksdb.block_restart_interval"
 	rocksdbFilterPolicy                     = "rocksdb.filter_policy"
 	rocksdbIndexType                        = "rocksdb.index_type"
+	rocksdbBlockCacheSize                   = "rocksdb.block_cache_size"
 	// TODO: add more configurations
 )
 
@@ -93,13 +97,16 @@ func (c rocksDBCreator) Create(p *properties.Properties) (ycsb.DB, error) {
 		return nil, err
 	}
 
+	writeOpts := gorocksdb.NewDefaultWriteOptions()
+	writeOpts.SetSync(p.GetBool(rocksdbSyncWrites, false))
+
 	return &rocksDB{
 		p:         p,
 		db:        db,
 		r:         util.NewRowCodec(p),
 		bufPool:   util.NewBufPool(),
 		readOpts:  gorocksdb.NewDefaultReadOptions(),
-		writeOpts: gorocksdb.NewDefaultWriteOptions(),
+		writeOpts: writeOpts,
 	}, nil
 }
 
@@ -114,6 +121,10 @@ func getTableOptions(p *properties.Properties) *gorocksdb.BlockBasedTableOptions
 	tblOpts.SetWholeKeyFiltering(p.GetBool(rocksdbWholeKeyFiltering, true))
 	tblOpts.SetBlockRestartInterval(p.GetInt(rocksdbBlockRestartInterval, 16))
 
+	if !p.GetBool(rocksdbNoBlockCache, false) {
+		tblOpts.SetBlockCache(gorocksdb.NewLRUCache(p.GetUint64(rocksdbBlockCacheSize, 8<<20)))
+	}
+
 	if b := p.GetString(rocksdbFilterPolicy, ""); len(b) > 0 {
 		if b == "rocksdb.BuiltinBloomFilter" {
 			const defaultBitsPerKey = 10
@@ -156,6 +167,23 @@ func getOptions(p *properties.Properties) *gorocksdb.Options {
 	opts.SetWriteBufferSize(p.GetInt(rocksdbWriteBufferSize, 64<<20))
 	opts.SetMaxWriteBufferNumber(p.GetInt(rocksdbMaxWriteBufferNumber, 2))
 
+	compression := p.GetString(rocksdbCompression, "snappy")
+	if compression == "no" {
+		opts.SetCompression(gorocksdb.NoCompression)
+	} else if compression == "snappy" {
+		opts.SetCompression(gorocksdb.SnappyCompression)
+	} else if compression == "zlib" {
+		opts.SetCompression(gorocksdb.ZLibCompression)
+	} else if compression == "bz2" {
+		opts.SetCompression(gorocksdb.Bz2Compression)
+	} else if compression == "lz4" {
+		opts.SetCompression(gorocksdb.LZ4Compression)
+	} else if compression == "lz4hc" {
+		opts.SetCompression(gorocksdb.LZ4HCCompression)
+	} else if compression == "zstd" {
+		opts.SetCompression(gorocksdb.ZSTDCompression)
+	}
+
 	opts.SetBlockBasedTableFactory(getTableOptions(p))
 
 	return opts
@@ -261,4 +289,19 @@ func (db *rocksDB) Delete(ctx context.Context, table string, key string) error {
 
 func init() {
 	ycsb.RegisterDBCreator("rocksdb", rocksDBCreator{})
+
+	prop.Register(prop.String,
+		rocksdbDir, rocksdbCompression, rocksdbFilterPolicy, rocksdbIndexType)
+	prop.Register(prop.Int,
+		rocksdbArenaBlockSize, rocksdbDBWriteBufferSize, rocksdbHardPendingCompactionBytesLimit,
+		rocksdbLevel0FileNumCompactionTrigger, rocksdbLevel0SlowdownWritesTrigger,
+		rocksdbLevel0StopWritesTrigger, rocksdbMaxBytesForLevelBase, rocksdbMaxTotalWalSize,
+		rocksdbMemtableHugePageSize, rocksdbNumLevels, rocksdbWriteBufferSize,
+		rocksdbMaxWriteBufferNumber, rocksdbBlockSize, rocksdbBlockSizeDeviation,
+		rocksdbBlockRestartInterval, rocksdbBlockCacheSize)
+	prop.Register(prop.Float, rocksdbMaxBytesForLevelMultiplier)
+	prop.Register(prop.Bool,
+		rocksdbAllowConcurrentMemtableWrites, rocsdbAllowMmapReads, rocksdbAllowMmapWrites,
+		rocksdbUseDirectReads, rocksdbUseFsync, rocksdbSyncWrites, rocksdbCacheIndexAndFilterBlocks,
+		rocksdbNoBlockCache, rocksdbPinL0FilterAndIndexBlocksInCache, rocksdbWholeKeyFiltering)
 }
@@ -0,0 +1,304 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/awserr"
+	"github.com/aws/aws-sdk-go-v2/aws/external"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/s3manager"
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/util"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+)
+
+// s3 properties
+const (
+	s3Region    = "s3.region"
+	s3Endpoint  = "s3.endpoint"
+	s3AccessKey = "s3.access_key"
+	s3SecretKey = "s3.secret_key"
+	// MinIO and most S3 compatible services require the path style.
+	s3PathStyle    = "s3.path_style"
+	s3BucketPrefix = "s3.bucket_prefix"
+	// The object larger than the threshold is uploaded by the multipart upload.
+	s3MultipartThreshold = "s3.multipart_threshold"
+	s3PartSize           = "s3.part_size"
+	// Number of the concurrent requests of a multipart upload or download, or the objects read by Scan.
+	s3Concurrency = "s3.concurrency"
+)
+
+type s3Creator struct {
+}
+
+type s3DB struct {
+	p      *properties.Properties
+	client *s3.Client
+
+	uploader   *s3manager.Uploader
+	downloader *s3manager.Downloader
+
+	bucketPrefix       string
+	multipartThreshold int
+	concurrency        int
+
+	r       *util.RowCodec
+	bufPool *util.BufPool
+}
+
+func (c s3Creator) Create(p *properties.Properties) (ycsb.DB, error) {
+	cfg, err := external.LoadDefaultAWSConfig()
+	if err != nil {
+		return nil, err
+	}
+	cfg.Region = p.GetString(s3Region, "us-east-1")
+	if endpoint, ok := p.Get(s3Endpoint); ok {
+		cfg.EndpointResolver = aws.ResolveWithEndpointURL(endpoint)
+	}
+	if accessKey, ok := p.Get(s3AccessKey); ok {
+		cfg.Credentials = aws.NewStaticCredentialsProvider(accessKey, p.GetString(s3SecretKey, ""), "")
+	}
+
+	client := s3.New(cfg)
+	client.ForcePathStyle = p.GetBool(s3PathStyle, false)
+
+	partSize := p.GetInt64(s3PartSize, 8<<20)
+	concurrency := p.GetInt(s3Concurrency, 5)
+
+	db := &s3DB{
+		p:      p,
+		client: client,
+		uploader: s3manager.NewUploaderWithClient(client, func(u *s3manager.Uploader) {
+			u.PartSize = partSize
+			u.Concurrency = concurrency
+		}),
+		downloader: s3manager.NewDownloaderWithClient(client, func(d *s3manager.Downloader) {
+			d.PartSize = partSize
+			d.Concurrency = concurrency
+		}),
+		bucketPrefix:       p.GetString(s3BucketPrefix, ""),
+		multipartThreshold: p.GetInt(s3MultipartThreshold, 8<<20),
+		concurrency:        concurrency,
+		r:                  util.NewRowCodec(p),
+		bufPool:            util.NewBufPool(),
+	}
+
+	if !p.GetBool(prop.DoTransactions, true) {
+		for _, tableName := range util.TableNames(p) {
+			if err := db.createBucket(tableName); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return db, nil
+}
+
+func isCode(err error, code string) bool {
+	aerr, ok := err.(awserr.Error)
+	return ok && aerr.Code() == code
+}
+
+// bucket returns the bucket of the table, the bucket name must be lowercase.
+func (db *s3DB) bucket(table string) *string {
+	return aws.String(db.bucketPrefix + strings.ToLower(table))
+}
+
+func (db *s3DB) createBucket(table string) error {
+	ctx := context.Background()
+	bucket := db.bucket(table)
+
+	if db.p.GetBool(prop.DropData, prop.DropDataDefault) {
+		// HEAD has no response body, so the error code is "NotFound" instead of NoSuchBucket.
+		_, err := db.client.HeadBucketRequest(&s3.HeadBucketInput{Bucket: bucket}).Send(ctx)
+		if err != nil && !isCode(err, "NotFound") {
+			return err
+		}
+		if err == nil {
+			// The bucket must be empty to be deleted.
+			iter := s3manager.NewDeleteListIterator(db.client, &s3.ListObjectsInput{Bucket: bucket})
+			if err = s3manager.NewBatchDeleteWithClient(db.client).Delete(ctx, iter); err != nil {
+				return err
+			}
+			if _, err = db.client.DeleteBucketRequest(&s3.DeleteBucketInput{Bucket: bucket}).Send(ctx); err != nil {
+				return err
+			}
+		}
+	}
+
+	input := &s3.CreateBucketInput{Bucket: bucket}
+	// The location must not be specified in us-east-1.
+	if region := db.client.Config.Region; region != "us-east-1" {
+		input.CreateBucketConfiguration = &s3.CreateBucketConfiguration{
+			LocationConstraint: s3.BucketLocationConstraint(region),
+		}
+	}
+
+	_, err := db.client.CreateBucketRequest(input).Send(ctx)
+	if isCode(err, s3.ErrCodeBucketAlreadyOwnedByYou) {
+		return nil
+	}
+	return err
+}
+
+func (db *s3DB) Close() error {
+	return nil
+}
+
+func (db *s3DB) InitThread(ctx context.Context, _ int, _ int) context.Context {
+	return ctx
+}
+
+func (db *s3DB) CleanupThread(_ context.Context) {
+}
+
+// get reads the object by the ranged GETs of the part size, it returns nil if the object doesn't exist.
+func (db *s3DB) get(ctx context.Context, table string, key string, fields []string) (map[string][]byte, error) {
+	buf := aws.NewWriteAtBuffer(nil)
+	_, err := db.downloader.DownloadWithContext(ctx, buf, &s3.GetObjectInput{
+		Bucket: db.bucket(table),
+		Key:    aws.String(key),
+	})
+	if isCode(err, s3.ErrCodeNoSuchKey) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return db.r.Decode(buf.Bytes(), fields)
+}
+
+func (db *s3DB) Read(ctx context.Context, table string, key string, fields []string) (map[string][]byte, error) {
+	return db.get(ctx, table, key, fields)
+}
+
+// Scan lists the keys from startKey and reads the objects concurrently.
+func (db *s3DB) Scan(ctx context.Context, table string, startKey string, count int, fields []string) ([]map[string][]byte, error) {
+	// StartAfter is exclusive, so startKey is read anyway.
+	keys := []string{startKey}
+	if count > 1 {
+		resp, err := db.client.ListObjectsV2Request(&s3.ListObjectsV2Input{
+			Bucket:     db.bucket(table),
+			StartAfter: aws.String(startKey),
+			MaxKeys:    aws.Int64(int64(count - 1)),
+		}).Send(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range resp.Contents {
+			keys = append(keys, *obj.Key)
+		}
+	}
+
+	rows := make([]map[string][]byte, len(keys))
+	errs := make([]error, len(keys))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, db.concurrency)
+	for i, key := range keys {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, key string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			rows[i], errs[i] = db.get(ctx, table, key, fields)
+		}(i, key)
+	}
+	wg.Wait()
+
+	res := make([]map[string][]byte, 0, len(keys))
+	for i, row := range rows {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		// The object may be deleted after listed.
+		if row != nil {
+			res = append(res, row)
+		}
+	}
+	return res, nil
+}
+
+// put writes the object by one PUT, or by the multipart upload if the object is larger than the threshold.
+func (db *s3DB) put(ctx context.Context, table string, key string, values map[string][]byte) error {
+	buf := db.bufPool.Get()
+	defer db.bufPool.Put(buf)
+
+	data, err := db.r.Encode(buf.Bytes(), values)
+	if err != nil {
+		return err
+	}
+
+	if len(data) <= db.multipartThreshold {
+		_, err = db.client.PutObjectRequest(&s3.PutObjectInput{
+			Bucket: db.bucket(table),
+			Key:    aws.String(key),
+			Body:   bytes.NewReader(data),
+		}).Send(ctx)
+		return err
+	}
+
+	_, err = db.uploader.UploadWithContext(ctx, &s3manager.UploadInput{
+		Bucket: db.bucket(table),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader(data),
+	})
+	return err
+}
+
+// Update reads the object and writes it back with the updated fields, an object can't be updated partially.
+func (db *s3DB) Update(ctx context.Context, table string, key string, values map[string][]byte) error {
+	row, err := db.get(ctx, table, key, nil)
+	if err != nil {
+		return err
+	}
+	if row == nil {
+		row = make(map[string][]byte, len(values))
+	}
+
+	for field, value := range values {
+		row[field] = value
+	}
+
+	return db.put(ctx, table, key, row)
+}
+
+func (db *s3DB) Insert(ctx context.Context, table string, key string, values map[string][]byte) error {
+	return db.put(ctx, table, key, values)
+}
+
+func (db *s3DB) Delete(ctx context.Context, table string, key string) error {
+	_, err := db.client.DeleteObjectRequest(&s3.DeleteObjectInput{
+		Bucket: db.bucket(table),
+		Key:    aws.String(key),
+	}).Send(ctx)
+	return err
+}
+
+func init() {
+	ycsb.RegisterDBCreator("s3", s3Creator{})
+
+	prop.Register(prop.String, s3Region, s3Endpoint, s3AccessKey, s3SecretKey, s3BucketPrefix)
+	prop.Register(prop.Int, s3MultipartThreshold, s3PartSize, s3Concurrency)
+	prop.Register(prop.Bool, s3PathStyle)
+}
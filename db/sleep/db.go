@@ -0,0 +1,167 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sleep
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/generator"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+)
+
+// sleep properties
+const (
+	// "constant", "uniform", "exponential", "zipfian" or "histogram"
+	sleepDistribution = "sleep.distribution"
+	// The latencies are in microseconds.
+	// The constant latency, the mean of the exponential distribution, or the upper bound of
+	// the uniform and zipfian distributions.
+	sleepLatency = "sleep.latency"
+	// The lower bound of the uniform and zipfian distributions.
+	sleepMinLatency = "sleep.min_latency"
+	// The histogram file in the format of fieldlengthhistogram, the values are in microseconds.
+	sleepHistogramFile = "sleep.histogram_file"
+)
+
+type contextKey string
+
+const stateKey = contextKey("sleepDB")
+
+type sleepCreator struct {
+}
+
+// sleepDB sleeps for a latency of the distribution in every operation, it is used to check
+// the client and the measurement with a known latency.
+type sleepDB struct {
+	latency ycsb.Generator
+}
+
+type sleepState struct {
+	r *rand.Rand
+}
+
+func (c sleepCreator) Create(p *properties.Properties) (ycsb.DB, error) {
+	latency := p.GetInt64(sleepLatency, 1000)
+	minLatency := p.GetInt64(sleepMinLatency, 0)
+
+	var gen ycsb.Generator
+	switch distribution := p.GetString(sleepDistribution, "constant"); strings.ToLower(distribution) {
+	case "constant":
+		gen = generator.NewConstant(latency)
+	case "uniform":
+		gen = generator.NewUniform(minLatency, latency)
+	case "exponential":
+		gen = generator.NewExponentialWithMean(float64(latency))
+	case "zipfian":
+		gen = generator.NewZipfianWithRange(minLatency, latency, generator.ZipfianConstant)
+	case "histogram":
+		gen = generator.NewHistogramFromFile(p.MustGetString(sleepHistogramFile))
+	default:
+		return nil, fmt.Errorf("unsupported distribution %s", distribution)
+	}
+
+	return &sleepDB{
+		latency: gen,
+	}, nil
+}
+
+func (db *sleepDB) sleep(ctx context.Context) {
+	state := ctx.Value(stateKey).(*sleepState)
+
+	d := time.Duration(db.latency.Next(state.r)) * time.Microsecond
+	if d <= 0 {
+		return
+	}
+
+	t := time.NewTimer(d)
+	select {
+	case <-t.C:
+	case <-ctx.Done():
+		t.Stop()
+	}
+}
+
+func (db *sleepDB) Close() error {
+	return nil
+}
+
+func (db *sleepDB) InitThread(ctx context.Context, _ int, _ int) context.Context {
+	state := &sleepState{
+		r: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+
+	return context.WithValue(ctx, stateKey, state)
+}
+
+func (db *sleepDB) CleanupThread(_ context.Context) {
+}
+
+func (db *sleepDB) Read(ctx context.Context, table string, key string, fields []string) (map[string][]byte, error) {
+	db.sleep(ctx)
+	return nil, nil
+}
+
+// The batch operations sleep once for the whole batch.
+func (db *sleepDB) BatchRead(ctx context.Context, table string, keys []string, fields []string) ([]map[string][]byte, error) {
+	db.sleep(ctx)
+	return nil, nil
+}
+
+func (db *sleepDB) Scan(ctx context.Context, table string, startKey string, count int, fields []string) ([]map[string][]byte, error) {
+	db.sleep(ctx)
+	return nil, nil
+}
+
+func (db *sleepDB) Update(ctx context.Context, table string, key string, values map[string][]byte) error {
+	db.sleep(ctx)
+	return nil
+}
+
+func (db *sleepDB) BatchUpdate(ctx context.Context, table string, keys []string, values []map[string][]byte) error {
+	db.sleep(ctx)
+	return nil
+}
+
+func (db *sleepDB) Insert(ctx context.Context, table string, key string, values map[string][]byte) error {
+	db.sleep(ctx)
+	return nil
+}
+
+func (db *sleepDB) BatchInsert(ctx context.Context, table string, keys []string, values []map[string][]byte) error {
+	db.sleep(ctx)
+	return nil
+}
+
+func (db *sleepDB) Delete(ctx context.Context, table string, key string) error {
+	db.sleep(ctx)
+	return nil
+}
+
+func (db *sleepDB) BatchDelete(ctx context.Context, table string, keys []string) error {
+	db.sleep(ctx)
+	return nil
+}
+
+func init() {
+	ycsb.RegisterDBCreator("sleep", sleepCreator{})
+
+	prop.Register(prop.String, sleepDistribution, sleepHistogramFile)
+	prop.Register(prop.Int, sleepLatency, sleepMinLatency)
+}
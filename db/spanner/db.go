@@ -26,6 +26,7 @@ import (
 	"cloud.google.com/go/spanner"
 	database "cloud.google.com/go/spanner/admin/database/apiv1"
 	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
 
 	adminpb "google.golang.org/genproto/googleapis/spanner/admin/database/v1"
 
@@ -34,11 +35,23 @@ import (
 
 	"github.com/magiconair/properties"
 	"github.com/pingcap/go-ycsb/pkg/ycsb"
+	"go.uber.org/zap"
 )
 
 const (
 	spannerDBName      = "spanner.db"
 	spannerCredentials = "spanner.credentials"
+	// Session pool sizing
+	spannerMinSessions = "spanner.min_sessions"
+	spannerMaxSessions = "spanner.max_sessions"
+	// "mutation" or "dml"
+	spannerWriteMode = "spanner.write_mode"
+	// Reads are strong if the staleness is 0
+	spannerReadStaleness = "spanner.read_staleness"
+	// "exact" or "max"
+	spannerReadStalenessBound = "spanner.read_staleness_bound"
+	// If set, the tables are interleaved in this parent table
+	spannerInterleaveParent = "spanner.interleave_parent"
 )
 
 type spannerCreator struct {
@@ -48,6 +61,11 @@ type spannerDB struct {
 	p       *properties.Properties
 	client  *spanner.Client
 	verbose bool
+
+	dmlWrite        bool
+	stale           bool
+	timestampBound  spanner.TimestampBound
+	interleaveTable string
 }
 
 type contextKey string
@@ -87,19 +105,72 @@ func (c spannerCreator) Create(p *properties.Properties) (ycsb.DB, error) {
 
 	d.verbose = p.GetBool(prop.Verbose, prop.VerboseDefault)
 
+	switch mode := p.GetString(spannerWriteMode, "mutation"); mode {
+	case "mutation":
+	case "dml":
+		d.dmlWrite = true
+	default:
+		return nil, fmt.Errorf("unsupported write mode %s", mode)
+	}
+
+	if staleness := p.GetParsedDuration(spannerReadStaleness, 0); staleness > 0 {
+		d.stale = true
+		switch bound := p.GetString(spannerReadStalenessBound, "exact"); bound {
+		case "exact":
+			d.timestampBound = spanner.ExactStaleness(staleness)
+		case "max":
+			d.timestampBound = spanner.MaxStaleness(staleness)
+		default:
+			return nil, fmt.Errorf("unsupported staleness bound %s", bound)
+		}
+	}
+
 	_, err = d.createDatabase(ctx, adminClient, dbName)
 	if err != nil {
 		return nil, err
 	}
 
-	client, err := spanner.NewClient(ctx, dbName)
+	config := spanner.ClientConfig{SessionPoolConfig: spanner.DefaultSessionPoolConfig}
+	config.MinOpened = p.GetUint64(spannerMinSessions, config.MinOpened)
+	config.MaxOpened = p.GetUint64(spannerMaxSessions, config.MaxOpened)
+	if config.MinOpened > config.MaxOpened {
+		return nil, fmt.Errorf("%s %d is larger than %s %d", spannerMinSessions, config.MinOpened, spannerMaxSessions, config.MaxOpened)
+	}
+
+	client, err := spanner.NewClientWithConfig(ctx, dbName, config)
 	if err != nil {
 		return nil, err
 	}
 	d.client = client
 
-	if err = d.createTable(ctx, adminClient, dbName); err != nil {
-		return nil, err
+	d.interleaveTable = p.GetString(spannerInterleaveParent, "")
+
+	tableNames := util.TableNames(p)
+	if p.GetBool(prop.DropData, prop.DropDataDefault) {
+		// The interleaved tables must be dropped before the parent table.
+		dropTables := append([]string(nil), tableNames...)
+		if len(d.interleaveTable) > 0 {
+			dropTables = append(dropTables, d.interleaveTable)
+		}
+		for _, tableName := range dropTables {
+			if err = d.dropTable(ctx, adminClient, dbName, tableName); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if len(d.interleaveTable) > 0 {
+		ddl := fmt.Sprintf("CREATE TABLE %s (YCSB_KEY STRING(%d)) PRIMARY KEY (YCSB_KEY)",
+			d.interleaveTable, p.GetInt64(prop.FieldLength, prop.FieldLengthDefault))
+		if err = d.createTable(ctx, adminClient, dbName, d.interleaveTable, ddl); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, tableName := range tableNames {
+		if err = d.createTable(ctx, adminClient, dbName, tableName, d.tableDDL(tableName)); err != nil {
+			return nil, err
+		}
 	}
 
 	return d, nil
@@ -159,62 +230,58 @@ func (db *spannerDB) tableExisted(ctx context.Context, table string) (bool, erro
 	return found, nil
 }
 
-func (db *spannerDB) createTable(ctx context.Context, adminClient *database.DatabaseAdminClient, dbName string) error {
-	tableName := db.p.GetString(prop.TableName, prop.TableNameDefault)
-	fieldCount := db.p.GetInt64(prop.FieldCount, prop.FieldCountDefault)
-	fieldLength := db.p.GetInt64(prop.FieldLength, prop.FieldLengthDefault)
+func (db *spannerDB) updateDDL(ctx context.Context, adminClient *database.DatabaseAdminClient, dbName string, stmt string) error {
+	if db.verbose {
+		util.Logger().Info("query", zap.String("query", stmt))
+	}
 
-	existed, err := db.tableExisted(ctx, tableName)
+	op, err := adminClient.UpdateDatabaseDdl(ctx, &adminpb.UpdateDatabaseDdlRequest{
+		Database:   dbName,
+		Statements: []string{stmt},
+	})
 	if err != nil {
 		return err
 	}
 
-	if db.p.GetBool(prop.DropData, prop.DropDataDefault) && existed {
-		op, err := adminClient.UpdateDatabaseDdl(ctx, &adminpb.UpdateDatabaseDdlRequest{
-			Database: dbName,
-			Statements: []string{
-				fmt.Sprintf("DROP TABLE %s", tableName),
-			},
-		})
-		if err != nil {
-			return err
-		}
+	return op.Wait(ctx)
+}
 
-		if err = op.Wait(ctx); err != nil {
-			return err
-		}
-		existed = false
+func (db *spannerDB) dropTable(ctx context.Context, adminClient *database.DatabaseAdminClient, dbName string, tableName string) error {
+	existed, err := db.tableExisted(ctx, tableName)
+	if err != nil || !existed {
+		return err
 	}
 
-	if existed {
-		return nil
-	}
+	return db.updateDDL(ctx, adminClient, dbName, fmt.Sprintf("DROP TABLE %s", tableName))
+}
+
+func (db *spannerDB) tableDDL(tableName string) string {
+	fieldLength := db.p.GetInt64(prop.FieldLength, prop.FieldLengthDefault)
 
 	buf := new(bytes.Buffer)
 	s := fmt.Sprintf("CREATE TABLE  %s (YCSB_KEY STRING(%d)", tableName, fieldLength)
 	buf.WriteString(s)
 
-	for i := int64(0); i < fieldCount; i++ {
-		buf.WriteString(fmt.Sprintf(", FIELD%d STRING(%d)", i, fieldLength))
+	for _, field := range util.FieldNames(db.p) {
+		buf.WriteString(fmt.Sprintf(", %s STRING(%d)", strings.ToUpper(field), fieldLength))
 	}
 
 	buf.WriteString(") PRIMARY KEY (YCSB_KEY)")
 
-	op, err := adminClient.UpdateDatabaseDdl(ctx, &adminpb.UpdateDatabaseDdlRequest{
-		Database: dbName,
-		Statements: []string{
-			buf.String(),
-		},
-	})
-	if err != nil {
-		return err
+	if len(db.interleaveTable) > 0 {
+		buf.WriteString(fmt.Sprintf(", INTERLEAVE IN PARENT %s ON DELETE CASCADE", db.interleaveTable))
 	}
 
-	if err := op.Wait(ctx); err != nil {
+	return buf.String()
+}
+
+func (db *spannerDB) createTable(ctx context.Context, adminClient *database.DatabaseAdminClient, dbName string, tableName string, ddl string) error {
+	existed, err := db.tableExisted(ctx, tableName)
+	if err != nil || existed {
 		return err
 	}
 
-	return nil
+	return db.updateDDL(ctx, adminClient, dbName, ddl)
 }
 
 func (db *spannerDB) Close() error {
@@ -236,12 +303,21 @@ func (db *spannerDB) CleanupThread(ctx context.Context) {
 	//	state := ctx.Value(stateKey).(*spanner)
 }
 
+// singleRead returns the single-use transaction for the reads, which is stale if the read staleness is set.
+func (db *spannerDB) singleRead() *spanner.ReadOnlyTransaction {
+	txn := db.client.Single()
+	if db.stale {
+		txn = txn.WithTimestampBound(db.timestampBound)
+	}
+	return txn
+}
+
 func (db *spannerDB) queryRows(ctx context.Context, stmt spanner.Statement, count int) ([]map[string][]byte, error) {
 	if db.verbose {
-		fmt.Printf("%s %v\n", stmt.SQL, stmt.Params)
+		util.Logger().Info("query", zap.String("query", stmt.SQL), zap.Reflect("args", stmt.Params))
 	}
 
-	iter := db.client.Single().Query(ctx, stmt)
+	iter := db.singleRead().Query(ctx, stmt)
 	defer iter.Stop()
 
 	vs := make([]map[string][]byte, 0, count)
@@ -333,7 +409,42 @@ func createMutations(key string, mutations map[string][]byte) ([]string, []inter
 	return keys, values
 }
 
+// execDML runs the statements in one read-write transaction.
+func (db *spannerDB) execDML(ctx context.Context, stmts ...spanner.Statement) error {
+	_, err := db.client.ReadWriteTransaction(ctx, func(ctx context.Context, txn *spanner.ReadWriteTransaction) error {
+		for _, stmt := range stmts {
+			if db.verbose {
+				util.Logger().Info("query", zap.String("query", stmt.SQL), zap.Reflect("args", stmt.Params))
+			}
+
+			if _, err := txn.Update(ctx, stmt); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return err
+}
+
 func (db *spannerDB) Update(ctx context.Context, table string, key string, mutations map[string][]byte) error {
+	if db.dmlWrite {
+		buf := new(bytes.Buffer)
+		buf.WriteString(fmt.Sprintf("UPDATE %s SET ", table))
+		stmt := spanner.NewStatement("")
+		for i, p := range util.NewFieldPairs(mutations) {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			buf.WriteString(fmt.Sprintf("%s = @v%d", p.Field, i))
+			stmt.Params[fmt.Sprintf("v%d", i)] = util.String(p.Value)
+		}
+		buf.WriteString(" WHERE YCSB_KEY = @key")
+		stmt.SQL = buf.String()
+		stmt.Params["key"] = key
+
+		return db.execDML(ctx, stmt)
+	}
+
 	keys, values := createMutations(key, mutations)
 	m := spanner.Update(table, keys, values)
 	_, err := db.client.Apply(ctx, []*spanner.Mutation{m})
@@ -341,13 +452,60 @@ func (db *spannerDB) Update(ctx context.Context, table string, key string, mutat
 }
 
 func (db *spannerDB) Insert(ctx context.Context, table string, key string, mutations map[string][]byte) error {
+	if db.dmlWrite {
+		return db.insertDML(ctx, table, key, mutations)
+	}
+
 	keys, values := createMutations(key, mutations)
-	m := spanner.InsertOrUpdate(table, keys, values)
-	_, err := db.client.Apply(ctx, []*spanner.Mutation{m})
+	ms := []*spanner.Mutation{spanner.InsertOrUpdate(table, keys, values)}
+	if len(db.interleaveTable) > 0 {
+		// The parent row must be written before the interleaved row.
+		parent := spanner.InsertOrUpdate(db.interleaveTable, []string{"YCSB_KEY"}, []interface{}{key})
+		ms = append([]*spanner.Mutation{parent}, ms...)
+	}
+	_, err := db.client.Apply(ctx, ms)
+	return err
+}
+
+func (db *spannerDB) insertDML(ctx context.Context, table string, key string, mutations map[string][]byte) error {
+	keys, values := createMutations(key, mutations)
+	stmt := spanner.NewStatement("")
+	placeholders := make([]string, 0, len(keys))
+	for i, v := range values {
+		placeholders = append(placeholders, fmt.Sprintf("@v%d", i))
+		stmt.Params[fmt.Sprintf("v%d", i)] = v
+	}
+	stmt.SQL = fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table, strings.Join(keys, ", "), strings.Join(placeholders, ", "))
+
+	if len(db.interleaveTable) == 0 {
+		return db.execDML(ctx, stmt)
+	}
+
+	_, err := db.client.ReadWriteTransaction(ctx, func(ctx context.Context, txn *spanner.ReadWriteTransaction) error {
+		// DML has no upsert, so the parent row is only inserted if it doesn't exist.
+		_, err := txn.ReadRow(ctx, db.interleaveTable, spanner.Key{key}, []string{"YCSB_KEY"})
+		if spanner.ErrCode(err) == codes.NotFound {
+			parent := spanner.NewStatement(fmt.Sprintf("INSERT INTO %s (YCSB_KEY) VALUES (@key)", db.interleaveTable))
+			parent.Params["key"] = key
+			_, err = txn.Update(ctx, parent)
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = txn.Update(ctx, stmt)
+		return err
+	})
 	return err
 }
 
 func (db *spannerDB) Delete(ctx context.Context, table string, key string) error {
+	if db.dmlWrite {
+		stmt := spanner.NewStatement(fmt.Sprintf("DELETE FROM %s WHERE YCSB_KEY = @key", table))
+		stmt.Params["key"] = key
+		return db.execDML(ctx, stmt)
+	}
+
 	m := spanner.Delete(table, spanner.Key{key})
 	_, err := db.client.Apply(ctx, []*spanner.Mutation{m})
 	return err
@@ -355,4 +513,10 @@ func (db *spannerDB) Delete(ctx context.Context, table string, key string) error
 
 func init() {
 	ycsb.RegisterDBCreator("spanner", spannerCreator{})
+
+	prop.Register(prop.String,
+		spannerDBName, spannerCredentials, spannerWriteMode, spannerReadStalenessBound,
+		spannerInterleaveParent)
+	prop.Register(prop.Int, spannerMinSessions, spannerMaxSessions)
+	prop.Register(prop.Duration, spannerReadStaleness)
 }
@@ -21,6 +21,7 @@ import (
 	"net/url"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/pingcap/go-ycsb/pkg/prop"
 	"github.com/pingcap/go-ycsb/pkg/util"
@@ -29,6 +30,7 @@ import (
 	// sqlite package
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/pingcap/go-ycsb/pkg/ycsb"
+	"go.uber.org/zap"
 )
 
 // Sqlite properties
@@ -37,6 +39,9 @@ const (
 	sqliteMode        = "sqlite.mode"
 	sqliteJournalMode = "sqlite.journalmode"
 	sqliteCache       = "sqlite.cache"
+	// "OFF", "NORMAL", "FULL" or "EXTRA"
+	sqliteSynchronous = "sqlite.synchronous"
+	sqliteBusyTimeout = "sqlite.busy_timeout"
 )
 
 type sqliteCreator struct {
@@ -48,6 +53,17 @@ type sqliteDB struct {
 	verbose bool
 
 	bufPool *util.BufPool
+
+	queryLogger *util.QueryLogger
+
+	secondaryIndexes []string
+	// queryColumn is the column used to look up records in Read and Scan, YCSB_KEY or a secondary index.
+	queryColumn  string
+	queryOrderBy string
+
+	// keySchema is set if the keys are composite, the components are stored in the key columns.
+	keySchema  *util.KeySchema
+	keyColumns []string
 }
 
 func (c sqliteCreator) Create(p *properties.Properties) (ycsb.DB, error) {
@@ -68,6 +84,8 @@ func (c sqliteCreator) Create(p *properties.Properties) (ycsb.DB, error) {
 	v.Set("cache", cache)
 	v.Set("mode", mode)
 	v.Set("_journal_mode", journalMode)
+	v.Set("_synchronous", p.GetString(sqliteSynchronous, "NORMAL"))
+	v.Set("_busy_timeout", fmt.Sprintf("%d", p.GetInt(sqliteBusyTimeout, 5000)))
 	dsn := fmt.Sprintf("file:%s?%s", dbPath, v.Encode())
 	var err error
 	db, err := sql.Open("sqlite3", dsn)
@@ -82,38 +100,75 @@ func (c sqliteCreator) Create(p *properties.Properties) (ycsb.DB, error) {
 
 	d.bufPool = util.NewBufPool()
 
-	if err := d.createTable(); err != nil {
+	if d.queryLogger, err = util.NewQueryLogger(p); err != nil {
+		return nil, err
+	}
+
+	d.secondaryIndexes = util.SecondaryIndexes(p)
+	d.queryColumn = "YCSB_KEY"
+	queryField, err := util.SecondaryQueryField(p)
+	if err != nil {
 		return nil, err
 	}
+	if len(queryField) > 0 {
+		d.queryColumn = queryField
+		d.queryOrderBy = fmt.Sprintf(" ORDER BY %s", queryField)
+	}
+
+	d.keySchema = util.NewKeySchema(p)
+	d.keyColumns = d.keySchema.Columns()
+	if d.keySchema != nil && len(queryField) > 0 {
+		return nil, fmt.Errorf("%s can't be used with %s", prop.SecondaryQueryField, prop.KeyComposition)
+	}
+
+	for _, tableName := range util.TableNames(p) {
+		if err := d.createTable(tableName); err != nil {
+			return nil, err
+		}
+	}
 
 	return d, nil
 }
 
-func (db *sqliteDB) createTable() error {
-	tableName := db.p.GetString(prop.TableName, prop.TableNameDefault)
-
-	fieldCount := db.p.GetInt64(prop.FieldCount, prop.FieldCountDefault)
+func (db *sqliteDB) createTable(tableName string) error {
 	fieldLength := db.p.GetInt64(prop.FieldLength, prop.FieldLengthDefault)
 
 	buf := new(bytes.Buffer)
-	s := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (YCSB_KEY VARCHAR(64) PRIMARY KEY", tableName)
+	s := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s", tableName, db.keySchema.ColumnDefinitions())
 	buf.WriteString(s)
 
-	for i := int64(0); i < fieldCount; i++ {
-		buf.WriteString(fmt.Sprintf(", FIELD%d VARCHAR(%d)", i, fieldLength))
+	for _, field := range util.FieldNames(db.p) {
+		buf.WriteString(fmt.Sprintf(", %s VARCHAR(%d)", strings.ToUpper(field), fieldLength))
 	}
 
+	buf.WriteString(db.keySchema.PrimaryKeyDefinition())
 	buf.WriteString(");")
 
 	if db.verbose {
-		fmt.Println(buf.String())
+		util.Logger().Info("query", zap.String("query", buf.String()))
 	}
 
-	_, err := db.db.Exec(buf.String())
-	return err
+	if _, err := db.db.Exec(buf.String()); err != nil {
+		return err
+	}
+
+	for _, field := range db.secondaryIndexes {
+		query := fmt.Sprintf("CREATE INDEX IF NOT EXISTS IDX_%s ON %s (%s)", field, tableName, field)
+		if db.verbose {
+			util.Logger().Info("query", zap.String("query", query))
+		}
+
+		if _, err := db.db.Exec(query); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 func (db *sqliteDB) Close() error {
+	db.queryLogger.Close()
+
 	if db.db == nil {
 		return nil
 	}
@@ -130,8 +185,15 @@ func (db *sqliteDB) CleanupThread(ctx context.Context) {
 }
 
 func (db *sqliteDB) queryRows(ctx context.Context, query string, count int, args ...interface{}) ([]map[string][]byte, error) {
+	start := time.Now()
+	rows, err := db.doQueryRows(ctx, query, count, args...)
+	db.queryLogger.Log(start, query, args, err)
+	return rows, err
+}
+
+func (db *sqliteDB) doQueryRows(ctx context.Context, query string, count int, args ...interface{}) ([]map[string][]byte, error) {
 	if db.verbose {
-		fmt.Printf("%s %v\n", query, args)
+		util.Logger().Info("query", zap.String("query", query), zap.Reflect("args", args))
 	}
 
 	rows, err := db.db.QueryContext(ctx, query, args...)
@@ -167,15 +229,32 @@ func (db *sqliteDB) queryRows(ctx context.Context, query string, count int, args
 	return vs, rows.Err()
 }
 
+// queryCondition returns the condition to look up the records by the key in Read and Scan.
+func (db *sqliteDB) queryCondition(op string) string {
+	if db.keySchema != nil {
+		return util.KeyCondition(db.keyColumns, op, util.QuestionMark)
+	}
+	return fmt.Sprintf("%s %s ?", db.queryColumn, op)
+}
+
+// queryValues returns the values of the query columns of the key.
+func (db *sqliteDB) queryValues(key string) []interface{} {
+	return db.keySchema.Values(key)
+}
+
 func (db *sqliteDB) Read(ctx context.Context, table string, key string, fields []string) (map[string][]byte, error) {
 	var query string
 	if len(fields) == 0 {
-		query = fmt.Sprintf(`SELECT * FROM %s WHERE YCSB_KEY = ?`, table)
+		query = fmt.Sprintf(`SELECT * FROM %s WHERE %s`, table, db.queryCondition("="))
 	} else {
-		query = fmt.Sprintf(`SELECT %s FROM %s WHERE YCSB_KEY = ?`, strings.Join(fields, ","), table)
+		query = fmt.Sprintf(`SELECT %s FROM %s WHERE %s`, strings.Join(fields, ","), table, db.queryCondition("="))
+	}
+	if len(db.queryOrderBy) > 0 {
+		// The secondary field may be not unique.
+		query += " LIMIT 1"
 	}
 
-	rows, err := db.queryRows(ctx, query, 1, key)
+	rows, err := db.queryRows(ctx, query, 1, db.queryValues(key)...)
 
 	if err != nil {
 		return nil, err
@@ -189,22 +268,66 @@ func (db *sqliteDB) Read(ctx context.Context, table string, key string, fields [
 func (db *sqliteDB) Scan(ctx context.Context, table string, startKey string, count int, fields []string) ([]map[string][]byte, error) {
 	var query string
 	if len(fields) == 0 {
-		query = fmt.Sprintf(`SELECT * FROM %s WHERE YCSB_KEY >= ? LIMIT ?`, table)
+		query = fmt.Sprintf(`SELECT * FROM %s WHERE %s%s LIMIT ?`, table, db.queryCondition(">="), db.queryOrderBy)
 	} else {
-		query = fmt.Sprintf(`SELECT %s FROM %s WHERE YCSB_KEY >= ? LIMIT ?`, strings.Join(fields, ","), table)
+		query = fmt.Sprintf(`SELECT %s FROM %s WHERE %s%s LIMIT ?`, strings.Join(fields, ","), table, db.queryCondition(">="), db.queryOrderBy)
 	}
 
-	rows, err := db.queryRows(ctx, query, count, startKey, count)
+	rows, err := db.queryRows(ctx, query, count, append(db.queryValues(startKey), count)...)
+
+	return rows, err
+}
+
+// ReverseScan scans the records in the descending order of the key, or the secondary query field if it is set.
+func (db *sqliteDB) ReverseScan(ctx context.Context, table string, startKey string, count int, fields []string) ([]map[string][]byte, error) {
+	var query string
+	if len(fields) == 0 {
+		query = fmt.Sprintf(`SELECT * FROM %s WHERE %s%s LIMIT ?`, table, db.queryCondition("<="), db.reverseOrderBy())
+	} else {
+		query = fmt.Sprintf(`SELECT %s FROM %s WHERE %s%s LIMIT ?`, strings.Join(fields, ","), table, db.queryCondition("<="), db.reverseOrderBy())
+	}
+
+	rows, err := db.queryRows(ctx, query, count, append(db.queryValues(startKey), count)...)
+
+	return rows, err
+}
+
+// reverseOrderBy returns the ORDER BY clause of the descending order of the query columns.
+func (db *sqliteDB) reverseOrderBy() string {
+	columns := []string{db.queryColumn}
+	if db.keySchema != nil {
+		columns = db.keyColumns
+	}
+	return fmt.Sprintf(" ORDER BY %s DESC", strings.Join(columns, " DESC, "))
+}
+
+// Query looks up the records by the field, the field should be indexed by sql.secondary_indexes.
+func (db *sqliteDB) Query(ctx context.Context, table string, field string, value []byte, count int, fields []string) ([]map[string][]byte, error) {
+	var query string
+	if len(fields) == 0 {
+		query = fmt.Sprintf(`SELECT * FROM %s WHERE %s = ? LIMIT ?`, table, field)
+	} else {
+		query = fmt.Sprintf(`SELECT %s FROM %s WHERE %s = ? LIMIT ?`, strings.Join(fields, ","), table, field)
+	}
+
+	rows, err := db.queryRows(ctx, query, count, value, count)
 
 	return rows, err
 }
 
 func (db *sqliteDB) execQuery(ctx context.Context, query string, args ...interface{}) error {
+	start := time.Now()
+	err := db.doExecQuery(ctx, query, args...)
+	db.queryLogger.Log(start, query, args, err)
+	return err
+}
+
+func (db *sqliteDB) doExecQuery(ctx context.Context, query string, args ...interface{}) error {
 	if db.verbose {
-		fmt.Printf("%s %v\n", query, args)
+		util.Logger().Info("query", zap.String("query", query), zap.Reflect("args", args))
 	}
 
-	_, err := db.db.ExecContext(ctx, query, args)
+	_, err := db.db.ExecContext(ctx, query, args...)
 	if err != nil {
 		return err
 	}
@@ -233,23 +356,25 @@ func (db *sqliteDB) Update(ctx context.Context, table string, key string, values
 		buf.WriteString(`= ?`)
 		args = append(args, p.Value)
 	}
-	buf.WriteString(" WHERE YCSB_KEY = ?")
+	buf.WriteString(" WHERE ")
+	buf.WriteString(util.KeyCondition(db.keyColumns, "=", util.QuestionMark))
 
-	args = append(args, key)
+	args = append(args, db.keySchema.Values(key)...)
 
 	return db.execQuery(ctx, buf.String(), args...)
 }
 
 func (db *sqliteDB) Insert(ctx context.Context, table string, key string, values map[string][]byte) error {
-	args := make([]interface{}, 0, 1+len(values))
-	args = append(args, key)
+	args := make([]interface{}, 0, len(db.keyColumns)+len(values))
+	args = append(args, db.keySchema.Values(key)...)
 
 	buf := db.bufPool.Get()
 	defer db.bufPool.Put(buf)
 
 	buf.WriteString("INSERT OR IGNORE INTO ")
 	buf.WriteString(table)
-	buf.WriteString(" (YCSB_KEY")
+	buf.WriteString(" (")
+	buf.WriteString(strings.Join(db.keyColumns, ", "))
 
 	pairs := util.NewFieldPairs(values)
 	for _, p := range pairs {
@@ -258,6 +383,7 @@ func (db *sqliteDB) Insert(ctx context.Context, table string, key string, values
 		buf.WriteString(p.Field)
 	}
 	buf.WriteString(") VALUES (?")
+	buf.WriteString(strings.Repeat(" ,?", len(db.keyColumns)-1))
 
 	for i := 0; i < len(pairs); i++ {
 		buf.WriteString(" ,?")
@@ -269,11 +395,15 @@ func (db *sqliteDB) Insert(ctx context.Context, table string, key string, values
 }
 
 func (db *sqliteDB) Delete(ctx context.Context, table string, key string) error {
-	query := fmt.Sprintf(`DELETE FROM %s WHERE YCSB_KEY = ?`, table)
+	query := fmt.Sprintf(`DELETE FROM %s WHERE %s`, table, util.KeyCondition(db.keyColumns, "=", util.QuestionMark))
 
-	return db.execQuery(ctx, query, key)
+	return db.execQuery(ctx, query, db.keySchema.Values(key)...)
 }
 
 func init() {
 	ycsb.RegisterDBCreator("sqlite", sqliteCreator{})
+
+	prop.Register(prop.String,
+		sqliteDBPath, sqliteMode, sqliteJournalMode, sqliteCache, sqliteSynchronous)
+	prop.Register(prop.Int, sqliteBusyTimeout)
 }
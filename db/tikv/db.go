@@ -17,6 +17,7 @@ import (
 	"fmt"
 
 	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
 	"github.com/pingcap/go-ycsb/pkg/ycsb"
 	"github.com/tikv/client-go/config"
 )
@@ -27,6 +28,9 @@ const (
 	tikvType      = "tikv.type"
 	tikvConnCount = "tikv.conncount"
 	tikvBatchSize = "tikv.batchsize"
+	// Only for txn mode, both require TiKV 5.0+
+	tikvAsyncCommit = "tikv.async_commit"
+	tikvOnePC       = "tikv.one_pc"
 )
 
 type tikvCreator struct {
@@ -50,4 +54,8 @@ func (c tikvCreator) Create(p *properties.Properties) (ycsb.DB, error) {
 
 func init() {
 	ycsb.RegisterDBCreator("tikv", tikvCreator{})
+
+	prop.Register(prop.String, tikvPD, tikvType)
+	prop.Register(prop.Int, tikvConnCount, tikvBatchSize)
+	prop.Register(prop.Bool, tikvAsyncCommit, tikvOnePC)
 }
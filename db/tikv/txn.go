@@ -33,6 +33,14 @@ type txnDB struct {
 }
 
 func createTxnDB(p *properties.Properties, conf config.Config) (ycsb.DB, error) {
+	// The bundled client-go only supports the classic two-phase commit, so fail fast
+	// instead of silently benchmarking a different commit protocol.
+	for _, name := range []string{tikvAsyncCommit, tikvOnePC} {
+		if p.GetBool(name, false) {
+			return nil, fmt.Errorf("%s is not supported by the current TiKV client", name)
+		}
+	}
+
 	pdAddr := p.GetString(tikvPD, "127.0.0.1:2379")
 	db, err := txnkv.NewClient(strings.Split(pdAddr, ","), conf)
 	if err != nil {
@@ -58,16 +66,51 @@ func (db *txnDB) InitThread(ctx context.Context, _ int, _ int) context.Context {
 func (db *txnDB) CleanupThread(ctx context.Context) {
 }
 
+type contextKey string
+
+const txnKey = contextKey("tikvTxn")
+
+// Begin starts a transaction, the operations called with the returned context are executed in it.
+func (db *txnDB) Begin(ctx context.Context) (context.Context, error) {
+	tx, err := db.db.Begin()
+	if err != nil {
+		return ctx, err
+	}
+	return context.WithValue(ctx, txnKey, tx), nil
+}
+
+func (db *txnDB) Commit(ctx context.Context) error {
+	return ctx.Value(txnKey).(*txnkv.Transaction).Commit(ctx)
+}
+
+func (db *txnDB) Rollback(ctx context.Context) error {
+	return ctx.Value(txnKey).(*txnkv.Transaction).Rollback()
+}
+
+// begin returns the transaction started by Begin, or begins a new one for the operation,
+// the returned functions only commit and roll back the transaction of the operation.
+func (db *txnDB) begin(ctx context.Context) (*txnkv.Transaction, func(context.Context) error, func() error, error) {
+	if tx, ok := ctx.Value(txnKey).(*txnkv.Transaction); ok {
+		return tx, func(context.Context) error { return nil }, func() error { return nil }, nil
+	}
+
+	tx, err := db.db.Begin()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return tx, tx.Commit, tx.Rollback, nil
+}
+
 func (db *txnDB) getRowKey(table string, key string) []byte {
 	return util.Slice(fmt.Sprintf("%s:%s", table, key))
 }
 
 func (db *txnDB) Read(ctx context.Context, table string, key string, fields []string) (map[string][]byte, error) {
-	tx, err := db.db.Begin()
+	tx, commit, rollback, err := db.begin(ctx)
 	if err != nil {
 		return nil, err
 	}
-	defer tx.Rollback()
+	defer rollback()
 
 	row, err := tx.Get(db.getRowKey(table, key))
 	if kv.IsErrNotFound(err) {
@@ -76,7 +119,7 @@ func (db *txnDB) Read(ctx context.Context, table string, key string, fields []st
 		return nil, err
 	}
 
-	if err = tx.Commit(ctx); err != nil {
+	if err = commit(ctx); err != nil {
 		return nil, err
 	}
 
@@ -84,11 +127,11 @@ func (db *txnDB) Read(ctx context.Context, table string, key string, fields []st
 }
 
 func (db *txnDB) BatchRead(ctx context.Context, table string, keys []string, fields []string) ([]map[string][]byte, error) {
-	tx, err := db.db.Begin()
+	tx, _, rollback, err := db.begin(ctx)
 	if err != nil {
 		return nil, err
 	}
-	defer tx.Rollback()
+	defer rollback()
 
 	rowValues := make([]map[string][]byte, len(keys))
 	for i, key := range keys {
@@ -107,11 +150,11 @@ func (db *txnDB) BatchRead(ctx context.Context, table string, keys []string, fie
 }
 
 func (db *txnDB) Scan(ctx context.Context, table string, startKey string, count int, fields []string) ([]map[string][]byte, error) {
-	tx, err := db.db.Begin()
+	tx, commit, rollback, err := db.begin(ctx)
 	if err != nil {
 		return nil, err
 	}
-	defer tx.Rollback()
+	defer rollback()
 
 	it, err := tx.Iter(db.getRowKey(table, startKey), nil)
 	if err != nil {
@@ -128,7 +171,7 @@ func (db *txnDB) Scan(ctx context.Context, table string, startKey string, count
 		}
 	}
 
-	if err = tx.Commit(ctx); err != nil {
+	if err = commit(ctx); err != nil {
 		return nil, err
 	}
 
@@ -152,11 +195,11 @@ func (db *txnDB) Scan(ctx context.Context, table string, startKey string, count
 func (db *txnDB) Update(ctx context.Context, table string, key string, values map[string][]byte) error {
 	rowKey := db.getRowKey(table, key)
 
-	tx, err := db.db.Begin()
+	tx, commit, rollback, err := db.begin(ctx)
 	if err != nil {
 		return err
 	}
-	defer tx.Rollback()
+	defer rollback()
 
 	row, err := tx.Get(rowKey)
 	if kv.IsErrNotFound(err) {
@@ -186,15 +229,15 @@ func (db *txnDB) Update(ctx context.Context, table string, key string, values ma
 		return err
 	}
 
-	return tx.Commit(ctx)
+	return commit(ctx)
 }
 
 func (db *txnDB) BatchUpdate(ctx context.Context, table string, keys []string, values []map[string][]byte) error {
-	tx, err := db.db.Begin()
+	tx, commit, rollback, err := db.begin(ctx)
 	if err != nil {
 		return err
 	}
-	defer tx.Rollback()
+	defer rollback()
 
 	for i, key := range keys {
 		// TODO should we check the key exist?
@@ -206,7 +249,7 @@ func (db *txnDB) BatchUpdate(ctx context.Context, table string, keys []string, v
 			return err
 		}
 	}
-	return tx.Commit(ctx)
+	return commit(ctx)
 }
 
 func (db *txnDB) Insert(ctx context.Context, table string, key string, values map[string][]byte) error {
@@ -219,26 +262,25 @@ func (db *txnDB) Insert(ctx context.Context, table string, key string, values ma
 		return err
 	}
 
-	tx, err := db.db.Begin()
+	tx, commit, rollback, err := db.begin(ctx)
 	if err != nil {
 		return err
 	}
-
-	defer tx.Rollback()
+	defer rollback()
 
 	if err = tx.Set(db.getRowKey(table, key), rowData); err != nil {
 		return err
 	}
 
-	return tx.Commit(ctx)
+	return commit(ctx)
 }
 
 func (db *txnDB) BatchInsert(ctx context.Context, table string, keys []string, values []map[string][]byte) error {
-	tx, err := db.db.Begin()
+	tx, commit, rollback, err := db.begin(ctx)
 	if err != nil {
 		return err
 	}
-	defer tx.Rollback()
+	defer rollback()
 
 	for i, key := range keys {
 		rowData, err := db.r.Encode(nil, values[i])
@@ -249,31 +291,30 @@ func (db *txnDB) BatchInsert(ctx context.Context, table string, keys []string, v
 			return err
 		}
 	}
-	return tx.Commit(ctx)
+	return commit(ctx)
 }
 
 func (db *txnDB) Delete(ctx context.Context, table string, key string) error {
-	tx, err := db.db.Begin()
+	tx, commit, rollback, err := db.begin(ctx)
 	if err != nil {
 		return err
 	}
-
-	defer tx.Rollback()
+	defer rollback()
 
 	err = tx.Delete(db.getRowKey(table, key))
 	if err != nil {
 		return err
 	}
 
-	return tx.Commit(ctx)
+	return commit(ctx)
 }
 
 func (db *txnDB) BatchDelete(ctx context.Context, table string, keys []string) error {
-	tx, err := db.db.Begin()
+	tx, commit, rollback, err := db.begin(ctx)
 	if err != nil {
 		return err
 	}
-	defer tx.Rollback()
+	defer rollback()
 
 	for _, key := range keys {
 		if err != nil {
@@ -284,5 +325,5 @@ func (db *txnDB) BatchDelete(ctx context.Context, table string, keys []string) e
 			return err
 		}
 	}
-	return tx.Commit(ctx)
+	return commit(ctx)
 }
@@ -0,0 +1,109 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"sync"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+)
+
+type contextKey string
+
+const asyncKey = contextKey("async")
+
+// asyncState limits the outstanding asynchronous operations of a thread.
+type asyncState struct {
+	db ycsb.AsyncDB
+	// tokens has a slot for every outstanding operation.
+	tokens chan struct{}
+	wg     sync.WaitGroup
+}
+
+// withAsync enables the asynchronous operations of the thread if the DB is an AsyncDB and
+// the request.outstanding > 1.
+func withAsync(ctx context.Context, p *properties.Properties, db ycsb.DB) context.Context {
+	outstanding := p.GetInt(prop.RequestOutstanding, prop.RequestOutstandingDefault)
+	if outstanding <= 1 {
+		return ctx
+	}
+
+	if wrapper, ok := db.(DbWrapper); ok {
+		db = wrapper.DB
+	}
+	asyncDB, ok := db.(ycsb.AsyncDB)
+	if !ok {
+		return ctx
+	}
+
+	state := &asyncState{
+		db:     asyncDB,
+		tokens: make(chan struct{}, outstanding),
+	}
+	return context.WithValue(ctx, asyncKey, state)
+}
+
+// withoutAsync disables the asynchronous operations, it is used by the operations
+// which need the results, like read-modify-write.
+func withoutAsync(ctx context.Context) context.Context {
+	if getAsyncState(ctx) == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, asyncKey, (*asyncState)(nil))
+}
+
+func getAsyncState(ctx context.Context) *asyncState {
+	state, _ := ctx.Value(asyncKey).(*asyncState)
+	return state
+}
+
+// acquire blocks until the thread has less than request.outstanding operations,
+// the buffered operations are flushed first so they can complete.
+func (s *asyncState) acquire(ctx context.Context) error {
+	select {
+	case s.tokens <- struct{}{}:
+	default:
+		s.db.Flush(ctx)
+		select {
+		case s.tokens <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	s.wg.Add(1)
+	return nil
+}
+
+// done returns a callback which measures the operation and releases its slot.
+func (s *asyncState) done(ctx context.Context, op string) func(error) {
+	start := begin()
+	return func(err error) {
+		measureRecord(ctx, start, op, err)
+		<-s.tokens
+		s.wg.Done()
+	}
+}
+
+// waitAsync flushes the buffered operations of the thread and waits for all of them to complete.
+func waitAsync(ctx context.Context) {
+	state := getAsyncState(ctx)
+	if state == nil {
+		return
+	}
+	state.db.Flush(ctx)
+	state.wg.Wait()
+}
@@ -18,30 +18,52 @@ import (
 	"fmt"
 	"math/rand"
 	"os"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/magiconair/properties"
 	"github.com/pingcap/go-ycsb/pkg/measurement"
 	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/util"
 	"github.com/pingcap/go-ycsb/pkg/ycsb"
 )
 
 type worker struct {
-	p               *properties.Properties
-	workDB          ycsb.DB
-	workload        ycsb.Workload
-	doTransactions  bool
-	doBatch         bool
-	batchSize       int
-	opCount         int64
-	targetOpsPerMs  float64
-	threadID        int
-	targetOpsTickNs int64
-	opsDone         int64
-}
-
-func newWorker(p *properties.Properties, threadID int, threadCount int, workload ycsb.Workload, db ycsb.DB) *worker {
+	p              *properties.Properties
+	workDB         ycsb.DB
+	workload       ycsb.Workload
+	doTransactions bool
+	doBatch        bool
+	batchSize      int
+	opCount        int64
+	threadID       int
+	threadCount    int
+	opsDone        int64
+	// target is the target throughput of all the threads, nil means unlimited.
+	target *targetProfile
+	// next is the time the next operation is scheduled to start at if the target is set.
+	next time.Time
+	// limiter is shared by all the threads if ratelimiter is "global".
+	limiter *rateLimiter
+	// arrival is the arrival process of the operations, in the open-loop "poisson" and "deterministic"
+	// modes the operations are scheduled at the target rate regardless of their completion.
+	arrival   string
+	queueSize int
+	// deadline is the end of the run if maxexecutiontime is set.
+	deadline time.Time
+	control  *control
+}
+
+// runState is shared by all the workers of a run.
+type runState struct {
+	target   *targetProfile
+	limiter  *rateLimiter
+	deadline time.Time
+	control  *control
+}
+
+func newWorker(p *properties.Properties, threadID int, threadCount int, workload ycsb.Workload, db ycsb.DB, run *runState) *worker {
 	w := new(worker)
 	w.p = p
 	w.doTransactions = p.GetBool(prop.DoTransactions, true)
@@ -50,8 +72,13 @@ func newWorker(p *properties.Properties, threadID int, threadCount int, workload
 		w.doBatch = true
 	}
 	w.threadID = threadID
+	w.threadCount = threadCount
 	w.workload = workload
 	w.workDB = db
+	w.target = run.target
+	w.limiter = run.limiter
+	w.deadline = run.deadline
+	w.control = run.control
 
 	var totalOpCount int64
 	if w.doTransactions {
@@ -64,40 +91,85 @@ func newWorker(p *properties.Properties, threadID int, threadCount int, workload
 		}
 	}
 
-	if totalOpCount < int64(threadCount) {
-		fmt.Printf("totalOpCount(%s/%s/%s): %d should be bigger than threadCount: %d",
-			prop.OperationCount,
-			prop.InsertCount,
-			prop.RecordCount,
-			totalOpCount,
-			threadCount)
+	// The run is only limited by the time if the operation count is not set.
+	if totalOpCount > 0 || w.deadline.IsZero() {
+		if totalOpCount < int64(threadCount) {
+			fmt.Printf("totalOpCount(%s/%s/%s): %d should be bigger than threadCount: %d",
+				prop.OperationCount,
+				prop.InsertCount,
+				prop.RecordCount,
+				totalOpCount,
+				threadCount)
 
-		os.Exit(-1)
-	}
-
-	w.opCount = totalOpCount / int64(threadCount)
+			os.Exit(-1)
+		}
 
-	targetPerThreadPerms := float64(-1)
-	if v := p.GetInt64(prop.Target, 0); v > 0 {
-		targetPerThread := float64(v) / float64(threadCount)
-		targetPerThreadPerms = targetPerThread / 1000.0
+		w.opCount = totalOpCount / int64(threadCount)
 	}
 
-	if targetPerThreadPerms > 0 {
-		w.targetOpsPerMs = targetPerThreadPerms
-		w.targetOpsTickNs = int64(1000000.0 / w.targetOpsPerMs)
+	w.arrival = strings.ToLower(p.GetString(prop.RequestArrival, prop.RequestArrivalDefault))
+	switch w.arrival {
+	case "closed":
+	case "poisson", "deterministic":
+		if w.target == nil {
+			util.Fatalf("%s must be set for the %s arrival", prop.Target, w.arrival)
+		}
+	default:
+		util.Fatalf("unknown arrival %s", w.arrival)
+	}
+	if w.limiter != nil && w.arrival != "closed" {
+		util.Fatalf("the global %s only works with the closed %s", prop.RateLimiter, prop.RequestArrival)
+	}
+	w.queueSize = p.GetInt(prop.RequestQueueSize, prop.RequestQueueSizeDefault)
+	if w.queueSize < 1 {
+		util.Fatalf("%s must be positive", prop.RequestQueueSize)
 	}
 
 	return w
 }
 
-func (w *worker) throttle(ctx context.Context, startTime time.Time) {
-	if w.targetOpsPerMs <= 0 {
+// maxExecutionTime returns the max execution time of the run, 0 means unlimited.
+func maxExecutionTime(p *properties.Properties, target *targetProfile) time.Duration {
+	d := time.Duration(p.GetInt64(prop.MaxExecutiontime, 0)) * time.Second
+	if d == 0 && target != nil && target.duration() > 0 {
+		// The run ends with the last step of the target profile by default.
+		d = target.duration() + time.Duration(p.GetInt64(prop.WarmUpTime, 0))*time.Second
+	}
+	return d
+}
+
+const intendedKey = contextKey("intended")
+
+// withIntendedStart sets the time the operations are scheduled to start at.
+func withIntendedStart(ctx context.Context, t time.Time) context.Context {
+	return context.WithValue(ctx, intendedKey, t)
+}
+
+func getIntendedStart(ctx context.Context) (time.Time, bool) {
+	t, ok := ctx.Value(intendedKey).(time.Time)
+	return t, ok
+}
+
+// tick returns the interval between the operations of a thread at the elapsed time since the start.
+func (w *worker) tick(elapsed time.Duration) time.Duration {
+	return time.Duration(float64(time.Second) * float64(w.threadCount) / w.target.rate(elapsed))
+}
+
+// throttle schedules the next operation after the done operations, and waits until it's time.
+func (w *worker) throttle(ctx context.Context, startTime time.Time, opsCount int) {
+	if w.target == nil {
 		return
 	}
 
-	d := time.Duration(w.opsDone * w.targetOpsTickNs)
-	d = startTime.Add(d).Sub(time.Now())
+	for i := 0; i < opsCount; i++ {
+		w.next = w.next.Add(w.tick(w.next.Sub(startTime)))
+	}
+	sleepUntil(ctx, w.next)
+}
+
+// sleepUntil sleeps until the time or the context is done.
+func sleepUntil(ctx context.Context, t time.Time) {
+	d := time.Until(t)
 	if d < 0 {
 		return
 	}
@@ -107,30 +179,128 @@ func (w *worker) throttle(ctx context.Context, startTime time.Time) {
 	}
 }
 
+// arrive schedules the operations by the open-loop arrival process and sends their scheduled
+// times to the queue, the arrivals are dropped and measured as DROPPED if the queue is full.
+func (w *worker) arrive(ctx context.Context, queue chan<- time.Time) {
+	// The arrivals of the thread are seeded apart from the operations of the workload.
+	clientID := w.p.GetInt64(prop.ClientID, prop.ClientIDDefault)
+	thread := clientID*int64(w.threadCount) + int64(w.threadID)
+	r := rand.New(rand.NewSource(util.ThreadSeed(w.p.GetInt64(prop.RandomSeed, time.Now().UnixNano()), -1-thread)))
+	start := time.Now()
+	next := start
+	for {
+		// The arrivals restart after the pause.
+		if w.control.wait(ctx, time.Time{}) {
+			next = time.Now()
+		}
+
+		// Every loop of the worker does a batch of the operations.
+		interval := float64(w.tick(next.Sub(start))) * float64(w.batchSize)
+		d := interval
+		if w.arrival == "poisson" {
+			d = r.ExpFloat64() * interval
+		}
+		next = next.Add(time.Duration(d))
+
+		if d := time.Until(next); d > 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(d):
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case queue <- next:
+		default:
+			measurement.Measure("DROPPED", 0)
+		}
+	}
+}
+
 func (w *worker) run(ctx context.Context) {
 	// spread the thread operation out so they don't all hit the DB at the same time
-	if w.targetOpsPerMs > 0.0 && w.targetOpsPerMs <= 1.0 {
-		time.Sleep(time.Duration(rand.Int63n(w.targetOpsTickNs)))
+	if w.target != nil && w.limiter == nil {
+		time.Sleep(time.Duration(rand.Int63n(int64(w.tick(0)) + 1)))
+	}
+
+	var queue chan time.Time
+	if w.arrival != "closed" {
+		queue = make(chan time.Time, w.queueSize)
+		arriveCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go w.arrive(arriveCtx, queue)
 	}
 
 	startTime := time.Now()
+	w.next = startTime
+	step := 0
 
 	for w.opCount == 0 || w.opsDone < w.opCount {
+		// The schedule restarts after the pause, and the operations scheduled before the pause are dropped.
+		if w.control.wait(ctx, w.deadline) {
+			w.next = time.Now()
+			for len(queue) > 0 {
+				<-queue
+			}
+		}
+
+		batchSize := w.batchSize
+		if w.opCount > 0 && w.opCount-w.opsDone < int64(batchSize) {
+			// Don't do more operations than the operation count in the last batch.
+			batchSize = int(w.opCount - w.opsDone)
+		}
+
+		opCtx := ctx
+		if queue != nil {
+			select {
+			case <-ctx.Done():
+				return
+			case scheduled := <-queue:
+				measurement.Measure("QUEUE_DELAY", time.Since(scheduled))
+				opCtx = withIntendedStart(ctx, scheduled)
+			}
+		} else if w.limiter != nil {
+			// The operations are throttled from the end of the warm-up.
+			if measurement.IsWarmUpFinished() {
+				scheduled := w.limiter.take(batchSize)
+				sleepUntil(ctx, scheduled)
+				opCtx = withIntendedStart(ctx, scheduled)
+			}
+		} else if w.target != nil {
+			opCtx = withIntendedStart(ctx, w.next)
+		}
+
+		if w.threadID == 0 && w.target != nil && w.target.duration() > 0 && !w.target.overridden() {
+			// Report the steps of the target profile once.
+			if i := w.target.step(time.Since(startTime)); i != step {
+				step = i
+				fmt.Printf("Target %v ops/sec started after %s\n", w.target.steps[i].target, time.Since(startTime).Round(time.Second))
+			}
+		}
+
+		// No new operation is issued after the deadline.
+		if !w.deadline.IsZero() && time.Now().After(w.deadline) {
+			return
+		}
+
 		var err error
 		opsCount := 1
 		if w.doTransactions {
 			if w.doBatch {
-				err = w.workload.DoBatchTransaction(ctx, w.batchSize, w.workDB)
-				opsCount = w.batchSize
+				err = w.workload.DoBatchTransaction(opCtx, batchSize, w.workDB)
+				opsCount = batchSize
 			} else {
-				err = w.workload.DoTransaction(ctx, w.workDB)
+				err = w.workload.DoTransaction(opCtx, w.workDB)
 			}
 		} else {
 			if w.doBatch {
-				err = w.workload.DoBatchInsert(ctx, w.batchSize, w.workDB)
-				opsCount = w.batchSize
+				err = w.workload.DoBatchInsert(opCtx, batchSize, w.workDB)
+				opsCount = batchSize
 			} else {
-				err = w.workload.DoInsert(ctx, w.workDB)
+				err = w.workload.DoInsert(opCtx, w.workDB)
 			}
 		}
 
@@ -140,7 +310,13 @@ func (w *worker) run(ctx context.Context) {
 
 		if measurement.IsWarmUpFinished() {
 			w.opsDone += int64(opsCount)
-			w.throttle(ctx, startTime)
+			if queue == nil && w.limiter == nil {
+				w.throttle(ctx, startTime, opsCount)
+			}
+		} else {
+			// The operations are throttled from the end of the warm-up.
+			startTime = time.Now()
+			w.next = startTime
 		}
 
 		select {
@@ -156,6 +332,7 @@ type Client struct {
 	p        *properties.Properties
 	workload ycsb.Workload
 	db       ycsb.DB
+	usage    *ResourceUsage
 }
 
 // NewClient returns a client with the given workload and DB.
@@ -169,6 +346,48 @@ func (c *Client) Run(ctx context.Context) {
 	var wg sync.WaitGroup
 	threadCount := c.p.GetInt(prop.ThreadCount, 1)
 
+	run := new(runState)
+	var err error
+	if run.target, err = newTargetProfile(c.p); err != nil {
+		util.Fatal(err)
+	}
+
+	switch rateLimiter := strings.ToLower(c.p.GetString(prop.RateLimiter, prop.RateLimiterDefault)); rateLimiter {
+	case "perthread":
+	case "global":
+		if run.target != nil {
+			run.limiter = newRateLimiter(run.target)
+		}
+	default:
+		util.Fatalf("unknown %s %s", prop.RateLimiter, rateLimiter)
+	}
+
+	if d := maxExecutionTime(c.p, run.target); d > 0 {
+		run.deadline = time.Now().Add(d)
+	}
+
+	if t := startTracing(c.p); t != nil {
+		defer t.close()
+	}
+
+	pf, err := startProfiling(c.p)
+	if err != nil {
+		util.Fatal(err)
+	}
+	if pf != nil {
+		defer pf.close()
+	}
+
+	run.control = newControl(c.workload, run.target, run.limiter)
+	if c.p.GetBool(prop.Control, prop.ControlDefault) {
+		serveControl(run.control)
+		defer serveControl(nil)
+	}
+	// The operations are abandoned by canceling their context if they don't complete in the drain time.
+	runCtx, runCancel := context.WithCancel(ctx)
+	defer runCancel()
+
+	monitor := startResourceMonitor()
 	wg.Add(threadCount)
 	measureCtx, measureCancel := context.WithCancel(ctx)
 	measureCh := make(chan struct{}, 1)
@@ -188,14 +407,28 @@ func (c *Client) Run(ctx context.Context) {
 		// finish warming up
 		measurement.EnableWarmUp(false)
 
-		dur := c.p.GetInt64("measurement.interval", 10)
+		dur := c.p.GetInt64(prop.MeasurementInterval, prop.MeasurementIntervalDefault)
+		dash := newDashboard(c.p, monitor)
+		if dash != nil {
+			defer dash.close()
+			if _, ok := c.p.Get(prop.MeasurementInterval); !ok {
+				dur = 1
+			}
+		}
 		t := time.NewTicker(time.Duration(dur) * time.Second)
 		defer t.Stop()
 
 		for {
 			select {
 			case <-t.C:
+				if dash != nil {
+					measurement.Export()
+					dash.update(measurement.LastInterval())
+					dash.render()
+					continue
+				}
 				measurement.Output()
+				fmt.Printf("%-6s - %s\n", "CLIENT", monitor.summary())
 			case <-measureCtx.Done():
 				return
 			}
@@ -206,22 +439,67 @@ func (c *Client) Run(ctx context.Context) {
 		go func(threadId int) {
 			defer wg.Done()
 
-			w := newWorker(c.p, threadId, threadCount, c.workload, c.db)
-			ctx := c.workload.InitThread(ctx, threadId, threadCount)
+			w := newWorker(c.p, threadId, threadCount, c.workload, c.db, run)
+			ctx := c.workload.InitThread(runCtx, threadId, threadCount)
 			ctx = c.db.InitThread(ctx, threadId, threadCount)
+			ctx = withAsync(ctx, c.p, c.db)
 			w.run(ctx)
+			waitAsync(ctx)
 			c.db.CleanupThread(ctx)
 			c.workload.CleanupThread(ctx)
 		}(i)
 	}
 
-	wg.Wait()
+	c.wait(&wg, run.deadline, runCancel)
 	if !c.p.GetBool(prop.DoTransactions, true) {
 		// when loading is finished, try to analyze table if possible.
 		if analyzeDB, ok := c.db.(ycsb.AnalyzeDB); ok {
-			analyzeDB.Analyze(ctx, c.p.GetString(prop.TableName, prop.TableNameDefault))
+			for _, table := range util.TableNames(c.p) {
+				analyzeDB.Analyze(ctx, table)
+			}
 		}
 	}
 	measureCancel()
 	<-measureCh
+	c.usage = monitor.stop()
+}
+
+// ResourceUsage returns the resource usage of the client process in the last run.
+func (c *Client) ResourceUsage() *ResourceUsage {
+	return c.usage
+}
+
+// wait waits for all workers to end. After the deadline no new operation is issued, the operations in
+// flight are drained in the drain time, and abandoned if they don't complete in time, the drain time 0
+// abandons them at the deadline.
+func (c *Client) wait(wg *sync.WaitGroup, deadline time.Time, cancel context.CancelFunc) {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	if deadline.IsZero() {
+		<-done
+		return
+	}
+
+	select {
+	case <-done:
+		return
+	case <-time.After(time.Until(deadline)):
+	}
+
+	completedAtDeadline := completedOps()
+	drainTime := time.Duration(c.p.GetInt64(prop.DrainTime, prop.DrainTimeDefault)) * time.Second
+
+	select {
+	case <-done:
+		fmt.Printf("Drained %d operations in flight at the end of the run\n", completedOps()-completedAtDeadline)
+	case <-time.After(drainTime):
+		abandoned := inFlightOps()
+		cancel()
+		fmt.Printf("Drained %d operations in flight at the end of the run, abandoned %d operations after %s\n",
+			completedOps()-completedAtDeadline, abandoned, drainTime)
+	}
 }
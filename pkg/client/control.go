@@ -0,0 +1,173 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+)
+
+// control pauses, resumes and tunes the running workers through the HTTP endpoints.
+type control struct {
+	workload ycsb.Workload
+	target   *targetProfile
+	limiter  *rateLimiter
+
+	// paused is 1 if the run is paused, the workers wait until resumed is closed.
+	paused  int32
+	mu      sync.Mutex
+	resumed chan struct{}
+}
+
+func newControl(workload ycsb.Workload, target *targetProfile, limiter *rateLimiter) *control {
+	return &control{workload: workload, target: target, limiter: limiter}
+}
+
+// wait blocks while the run is paused until it's resumed, the context is done or the deadline
+// is reached, it returns true if the run was paused.
+func (c *control) wait(ctx context.Context, deadline time.Time) bool {
+	if atomic.LoadInt32(&c.paused) == 0 {
+		return false
+	}
+
+	c.mu.Lock()
+	resumed := c.resumed
+	c.mu.Unlock()
+	if resumed == nil {
+		return false
+	}
+
+	var timeout <-chan time.Time
+	if !deadline.IsZero() {
+		timeout = time.After(time.Until(deadline))
+	}
+	select {
+	case <-resumed:
+	case <-ctx.Done():
+	case <-timeout:
+	}
+	return true
+}
+
+func (c *control) pause() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.resumed == nil {
+		c.resumed = make(chan struct{})
+		atomic.StoreInt32(&c.paused, 1)
+	}
+}
+
+func (c *control) resume() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.resumed != nil {
+		if c.limiter != nil {
+			c.limiter.reset()
+		}
+		atomic.StoreInt32(&c.paused, 0)
+		close(c.resumed)
+		c.resumed = nil
+	}
+}
+
+func (c *control) setTarget(value string) error {
+	if c.target == nil {
+		return fmt.Errorf("the target can only be changed if the run starts with a target")
+	}
+	target, err := strconv.ParseFloat(value, 64)
+	if err != nil || target <= 0 {
+		return fmt.Errorf("invalid target %q, must be positive", value)
+	}
+	c.target.setTarget(target)
+	return nil
+}
+
+func (c *control) setProportions(p *properties.Properties) error {
+	tunable, ok := c.workload.(ycsb.TunableWorkload)
+	if !ok {
+		return fmt.Errorf("the %T doesn't implement the TunableWorkload interface", c.workload)
+	}
+	return tunable.SetProportions(p)
+}
+
+var (
+	controlOnce    sync.Once
+	currentControl atomic.Value
+)
+
+// serveControl serves the control of the run on the debug HTTP server, the endpoints are
+// /control/pause, /control/resume, /control/target?value=<ops> and
+// /control/proportions?readproportion=<proportion>&...
+func serveControl(c *control) {
+	currentControl.Store(c)
+	controlOnce.Do(func() {
+		handle("/control/pause", func(c *control, _ *http.Request) (string, error) {
+			c.pause()
+			return "paused", nil
+		})
+		handle("/control/resume", func(c *control, _ *http.Request) (string, error) {
+			c.resume()
+			return "resumed", nil
+		})
+		handle("/control/target", func(c *control, r *http.Request) (string, error) {
+			value := r.FormValue("value")
+			if err := c.setTarget(value); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("target changed to %s ops/sec", value), nil
+		})
+		handle("/control/proportions", func(c *control, r *http.Request) (string, error) {
+			if err := r.ParseForm(); err != nil {
+				return "", err
+			}
+			p := properties.NewProperties()
+			for key := range r.Form {
+				p.Set(key, r.Form.Get(key))
+			}
+			if err := c.setProportions(p); err != nil {
+				return "", err
+			}
+			return "proportions changed", nil
+		})
+	})
+}
+
+// handle registers the control endpoint, the result is printed and sent back.
+func handle(pattern string, f func(c *control, r *http.Request) (string, error)) {
+	http.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		c, _ := currentControl.Load().(*control)
+		if c == nil {
+			http.Error(w, "no run in progress", http.StatusServiceUnavailable)
+			return
+		}
+		msg, err := f(c, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		fmt.Printf("Control: %s\n", msg)
+		fmt.Fprintln(w, msg)
+	})
+}
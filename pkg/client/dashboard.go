@@ -0,0 +1,194 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/chzyer/readline"
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/measurement"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+)
+
+// dashboardHistory is the max number of the intervals in the sparklines.
+const dashboardHistory = 300
+
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// dashboard shows the results of the last interval, the cumulative results and the sparklines of
+// the throughput of every operation in the alternate screen of the terminal, which is redrawn
+// every measurement interval.
+type dashboard struct {
+	title   string
+	start   time.Time
+	monitor *resourceMonitor
+
+	last    map[string]measurement.TimeSeriesPoint
+	history map[string][]float64
+}
+
+// newDashboard enters the alternate screen if tui is set and stdout is a terminal.
+func newDashboard(p *properties.Properties, monitor *resourceMonitor) *dashboard {
+	if !p.GetBool(prop.TUI, prop.TUIDefault) {
+		return nil
+	}
+	if !readline.IsTerminal(int(os.Stdout.Fd())) {
+		fmt.Println("stdout is not a terminal, the dashboard is disabled")
+		return nil
+	}
+
+	command := "run"
+	if !p.GetBool(prop.DoTransactions, true) {
+		command = "load"
+	}
+	d := &dashboard{
+		title: fmt.Sprintf("go-ycsb %s %s, workload %s, %d threads", command, p.GetString(prop.DB, ""),
+			p.GetString(prop.Workload, "core"), p.GetInt(prop.ThreadCount, 1)),
+		start:   time.Now(),
+		monitor: monitor,
+		last:    make(map[string]measurement.TimeSeriesPoint),
+		history: make(map[string][]float64),
+	}
+	// Enter the alternate screen and hide the cursor.
+	fmt.Print("\x1b[?1049h\x1b[?25l")
+	d.render()
+	return d
+}
+
+// update adds the results of the last interval.
+func (d *dashboard) update(points []measurement.TimeSeriesPoint) {
+	for _, point := range points {
+		d.last[point.Operation] = point
+		h := append(d.history[point.Operation], point.Throughput)
+		if len(h) > dashboardHistory {
+			h = h[len(h)-dashboardHistory:]
+		}
+		d.history[point.Operation] = h
+	}
+}
+
+func (d *dashboard) render() {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s, elapsed %s\n", d.title, time.Since(d.start).Round(time.Second))
+	fmt.Fprintf(&buf, "CLIENT - %s\n\n", d.monitor.summary())
+
+	info := measurement.Info()
+	var ops []string
+	for op := range info {
+		if !strings.HasSuffix(op, "_ERROR") {
+			ops = append(ops, op)
+		}
+	}
+	for op := range d.last {
+		if _, ok := info[op]; !ok && !strings.HasSuffix(op, "_ERROR") {
+			ops = append(ops, op)
+		}
+	}
+	sort.Strings(ops)
+
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', tabwriter.AlignRight)
+	header := "Operation\tOPS\tAvg OPS\tCount\tErrors\tError%\tAvg(us)\tMax(us)"
+	for _, p := range measurement.Percentiles {
+		header += "\t" + p.Name() + "(us)"
+	}
+	fmt.Fprintln(w, header+"\t")
+	for _, op := range ops {
+		point := d.last[op]
+		var count, errors, avgOPS float64
+		if i, ok := info[op]; ok {
+			count = toFloat64(i.Get(measurement.COUNT))
+			avgOPS = toFloat64(i.Get(measurement.QPS))
+		}
+		if i, ok := info[op+"_ERROR"]; ok {
+			errors = toFloat64(i.Get(measurement.COUNT))
+		}
+		errorRate := 0.0
+		if failed := d.last[op+"_ERROR"].Count; failed > 0 {
+			errorRate = float64(failed) / float64(failed+point.Count) * 100
+		}
+
+		line := fmt.Sprintf("%s\t%.1f\t%.1f\t%.0f\t%.0f\t%.2f\t%d\t%d", op, point.Throughput, avgOPS, count, errors,
+			errorRate, point.Mean, point.Max)
+		for _, p := range measurement.Percentiles {
+			line += fmt.Sprintf("\t%d", point.Percentiles[p.Name()])
+		}
+		fmt.Fprintln(w, line+"\t")
+	}
+	w.Flush()
+	buf.WriteString("\nOPS, Error% and the latencies are of the last interval\n\n")
+
+	width := readline.GetScreenWidth() - 32
+	if width < 10 {
+		width = 10
+	}
+	for _, op := range ops {
+		h := d.history[op]
+		if len(h) > width {
+			h = h[len(h)-width:]
+		}
+		fmt.Fprintf(&buf, "%-20s %s %.1f\n", op, sparkline(h), maxOf(h))
+	}
+
+	// Move to the top left and clear the screen.
+	fmt.Print("\x1b[H\x1b[2J" + buf.String())
+}
+
+// close leaves the alternate screen, so the final results are printed in the normal screen.
+func (d *dashboard) close() {
+	fmt.Print("\x1b[?25h\x1b[?1049l")
+}
+
+func maxOf(values []float64) float64 {
+	max := 0.0
+	for _, v := range values {
+		if v > max {
+			max = v
+		}
+	}
+	return max
+}
+
+// sparkline draws the values relative to their max.
+func sparkline(values []float64) string {
+	max := maxOf(values)
+	var b strings.Builder
+	for _, v := range values {
+		i := 0
+		if max > 0 {
+			i = int(v / max * float64(len(sparkBlocks)-1))
+		}
+		b.WriteRune(sparkBlocks[i])
+	}
+	return b.String()
+}
+
+func toFloat64(v interface{}) float64 {
+	switch n := v.(type) {
+	case int:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case float64:
+		return n
+	default:
+		return 0
+	}
+}
@@ -16,10 +16,12 @@ package client
 import (
 	"context"
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	"github.com/pingcap/go-ycsb/pkg/measurement"
 	"github.com/pingcap/go-ycsb/pkg/ycsb"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 // DbWrapper stores the pointer to a implementation of ycsb.DB.
@@ -27,32 +29,108 @@ type DbWrapper struct {
 	DB ycsb.DB
 }
 
-func measure(start time.Time, op string, err error) {
-	lan := time.Now().Sub(start)
+// inFlight is the number of the operations in flight, and completed is the number of the
+// operations completed.
+var inFlight, completed int64
+
+// begin starts an operation, which is in flight until it's measured.
+func begin() time.Time {
+	atomic.AddInt64(&inFlight, 1)
+	return time.Now()
+}
+
+// inFlightOps returns the number of the operations in flight.
+func inFlightOps() int64 {
+	return atomic.LoadInt64(&inFlight)
+}
+
+// completedOps returns the number of the operations completed.
+func completedOps() int64 {
+	return atomic.LoadInt64(&completed)
+}
+
+func init() {
+	prometheus.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "ycsb_operations_in_flight",
+		Help: "The number of the operations in flight.",
+	}, func() float64 {
+		return float64(inFlightOps())
+	}))
+}
+
+const threadKey = contextKey("thread")
+
+func measure(ctx context.Context, start time.Time, op string, err error) {
 	if err != nil {
-		measurement.Measure(fmt.Sprintf("%s_ERROR", op), lan)
+		op = fmt.Sprintf("%s_ERROR", op)
+		measurement.MeasureError(op, string(classifyError(ctx, err)))
+	}
+
+	measureLatency(ctx, start, op, err)
+}
+
+// measureLatency measures the service time of the operation, and the latency from the intended
+// start time as INTENDED_<OP> if the operations are scheduled, which is not affected by the
+// coordinated omission. The operation is also traced if tracing is enabled.
+func measureLatency(ctx context.Context, start time.Time, op string, err error) {
+	atomic.AddInt64(&inFlight, -1)
+	atomic.AddInt64(&completed, 1)
+	now := time.Now()
+	measurement.Measure(op, now.Sub(start))
+	if thread, ok := ctx.Value(threadKey).(int); ok {
+		measurement.MeasureThread(thread, op, now.Sub(start))
+	}
+	if intended, ok := getIntendedStart(ctx); ok {
+		measurement.Measure(fmt.Sprintf("INTENDED_%s", op), now.Sub(intended))
+	}
+	if t := globalTracer; t != nil {
+		t.trace(ctx, op, start, now, err)
+	}
+}
+
+// measureRecord measures the operation on a record, the operation on a deleted record
+// is measured as <OP>_NOT_FOUND whatever the result is.
+func measureRecord(ctx context.Context, start time.Time, op string, err error) {
+	if ycsb.IsExpectedNotFound(ctx) {
+		measureLatency(ctx, start, fmt.Sprintf("%s_NOT_FOUND", op), nil)
 		return
 	}
 
-	measurement.Measure(op, lan)
+	measure(ctx, start, op, err)
 }
 
 func (db DbWrapper) Close() error {
 	return db.DB.Close()
 }
 
+// InitThread also records the thread of the operations, so the latencies of every thread can be
+// measured if measurement.perthread is set.
 func (db DbWrapper) InitThread(ctx context.Context, threadID int, threadCount int) context.Context {
-	return db.DB.InitThread(ctx, threadID, threadCount)
+	ctx = context.WithValue(ctx, threadKey, threadID)
+	return withErrorClassifier(db.DB.InitThread(ctx, threadID, threadCount), db.DB)
 }
 
 func (db DbWrapper) CleanupThread(ctx context.Context) {
 	db.DB.CleanupThread(ctx)
 }
 
+// Read, Update, Insert and Delete are issued asynchronously if the thread enables the asynchronous
+// operations, the latency is measured when the operation completes and the read values are not returned.
 func (db DbWrapper) Read(ctx context.Context, table string, key string, fields []string) (_ map[string][]byte, err error) {
-	start := time.Now()
+	if state := getAsyncState(ctx); state != nil {
+		if err := state.acquire(ctx); err != nil {
+			return nil, err
+		}
+		done := state.done(ctx, "READ")
+		state.db.AsyncRead(ctx, table, key, fields, func(_ map[string][]byte, err error) {
+			done(err)
+		})
+		return nil, nil
+	}
+
+	start := begin()
 	defer func() {
-		measure(start, "READ", err)
+		measureRecord(ctx, start, "READ", err)
 	}()
 
 	return db.DB.Read(ctx, table, key, fields)
@@ -61,14 +139,14 @@ func (db DbWrapper) Read(ctx context.Context, table string, key string, fields [
 func (db DbWrapper) BatchRead(ctx context.Context, table string, keys []string, fields []string) (_ []map[string][]byte, err error) {
 	batchDB, ok := db.DB.(ycsb.BatchDB)
 	if ok {
-		start := time.Now()
+		start := begin()
 		defer func() {
-			measure(start, "BATCH_READ", err)
+			measureRecord(ctx, start, "BATCH_READ", err)
 		}()
 		return batchDB.BatchRead(ctx, table, keys, fields)
 	}
 	for _, key := range keys {
-		_, err := db.DB.Read(ctx, table, key, fields)
+		_, err := db.Read(ctx, table, key, fields)
 		if err != nil {
 			return nil, err
 		}
@@ -77,18 +155,40 @@ func (db DbWrapper) BatchRead(ctx context.Context, table string, keys []string,
 }
 
 func (db DbWrapper) Scan(ctx context.Context, table string, startKey string, count int, fields []string) (_ []map[string][]byte, err error) {
-	start := time.Now()
+	start := begin()
 	defer func() {
-		measure(start, "SCAN", err)
+		measure(ctx, start, "SCAN", err)
 	}()
 
 	return db.DB.Scan(ctx, table, startKey, count, fields)
 }
 
+func (db DbWrapper) ReverseScan(ctx context.Context, table string, startKey string, count int, fields []string) (_ []map[string][]byte, err error) {
+	reverseScanDB, ok := db.DB.(ycsb.ReverseScanDB)
+	if !ok {
+		return nil, fmt.Errorf("the %T doesn't implement the ReverseScanDB interface", db.DB)
+	}
+
+	start := begin()
+	defer func() {
+		measure(ctx, start, "REVERSE_SCAN", err)
+	}()
+
+	return reverseScanDB.ReverseScan(ctx, table, startKey, count, fields)
+}
+
 func (db DbWrapper) Update(ctx context.Context, table string, key string, values map[string][]byte) (err error) {
-	start := time.Now()
+	if state := getAsyncState(ctx); state != nil {
+		if err := state.acquire(ctx); err != nil {
+			return err
+		}
+		state.db.AsyncUpdate(ctx, table, key, values, state.done(ctx, "UPDATE"))
+		return nil
+	}
+
+	start := begin()
 	defer func() {
-		measure(start, "UPDATE", err)
+		measureRecord(ctx, start, "UPDATE", err)
 	}()
 
 	return db.DB.Update(ctx, table, key, values)
@@ -97,14 +197,14 @@ func (db DbWrapper) Update(ctx context.Context, table string, key string, values
 func (db DbWrapper) BatchUpdate(ctx context.Context, table string, keys []string, values []map[string][]byte) (err error) {
 	batchDB, ok := db.DB.(ycsb.BatchDB)
 	if ok {
-		start := time.Now()
+		start := begin()
 		defer func() {
-			measure(start, "BATCH_UPDATE", err)
+			measureRecord(ctx, start, "BATCH_UPDATE", err)
 		}()
 		return batchDB.BatchUpdate(ctx, table, keys, values)
 	}
 	for i := range keys {
-		err := db.DB.Update(ctx, table, keys[i], values[i])
+		err := db.Update(ctx, table, keys[i], values[i])
 		if err != nil {
 			return err
 		}
@@ -113,9 +213,17 @@ func (db DbWrapper) BatchUpdate(ctx context.Context, table string, keys []string
 }
 
 func (db DbWrapper) Insert(ctx context.Context, table string, key string, values map[string][]byte) (err error) {
-	start := time.Now()
+	if state := getAsyncState(ctx); state != nil {
+		if err := state.acquire(ctx); err != nil {
+			return err
+		}
+		state.db.AsyncInsert(ctx, table, key, values, state.done(ctx, "INSERT"))
+		return nil
+	}
+
+	start := begin()
 	defer func() {
-		measure(start, "INSERT", err)
+		measureRecord(ctx, start, "INSERT", err)
 	}()
 
 	return db.DB.Insert(ctx, table, key, values)
@@ -124,14 +232,14 @@ func (db DbWrapper) Insert(ctx context.Context, table string, key string, values
 func (db DbWrapper) BatchInsert(ctx context.Context, table string, keys []string, values []map[string][]byte) (err error) {
 	batchDB, ok := db.DB.(ycsb.BatchDB)
 	if ok {
-		start := time.Now()
+		start := begin()
 		defer func() {
-			measure(start, "BATCH_INSERT", err)
+			measure(ctx, start, "BATCH_INSERT", err)
 		}()
 		return batchDB.BatchInsert(ctx, table, keys, values)
 	}
 	for i := range keys {
-		err := db.DB.Insert(ctx, table, keys[i], values[i])
+		err := db.Insert(ctx, table, keys[i], values[i])
 		if err != nil {
 			return err
 		}
@@ -140,9 +248,17 @@ func (db DbWrapper) BatchInsert(ctx context.Context, table string, keys []string
 }
 
 func (db DbWrapper) Delete(ctx context.Context, table string, key string) (err error) {
-	start := time.Now()
+	if state := getAsyncState(ctx); state != nil {
+		if err := state.acquire(ctx); err != nil {
+			return err
+		}
+		state.db.AsyncDelete(ctx, table, key, state.done(ctx, "DELETE"))
+		return nil
+	}
+
+	start := begin()
 	defer func() {
-		measure(start, "DELETE", err)
+		measureRecord(ctx, start, "DELETE", err)
 	}()
 
 	return db.DB.Delete(ctx, table, key)
@@ -151,14 +267,14 @@ func (db DbWrapper) Delete(ctx context.Context, table string, key string) (err e
 func (db DbWrapper) BatchDelete(ctx context.Context, table string, keys []string) (err error) {
 	batchDB, ok := db.DB.(ycsb.BatchDB)
 	if ok {
-		start := time.Now()
+		start := begin()
 		defer func() {
-			measure(start, "BATCH_DELETE", err)
+			measure(ctx, start, "BATCH_DELETE", err)
 		}()
 		return batchDB.BatchDelete(ctx, table, keys)
 	}
 	for _, key := range keys {
-		err := db.DB.Delete(ctx, table, key)
+		err := db.Delete(ctx, table, key)
 		if err != nil {
 			return err
 		}
@@ -166,6 +282,79 @@ func (db DbWrapper) BatchDelete(ctx context.Context, table string, keys []string
 	return nil
 }
 
+// ReadModifyWrite is measured as one READ_MODIFY_WRITE, the read and the update of the databases
+// without ReadModifyWriteDB aren't measured separately.
+func (db DbWrapper) ReadModifyWrite(ctx context.Context, table string, key string, fields []string, values map[string][]byte) (_ map[string][]byte, err error) {
+	// The read values are needed, so the operations are always synchronous.
+	ctx = withoutAsync(ctx)
+
+	start := begin()
+	defer func() {
+		measureRecord(ctx, start, "READ_MODIFY_WRITE", err)
+	}()
+
+	if rmwDB, ok := db.DB.(ycsb.ReadModifyWriteDB); ok {
+		return rmwDB.ReadModifyWrite(ctx, table, key, fields, values)
+	}
+
+	readValues, err := db.DB.Read(ctx, table, key, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = db.DB.Update(ctx, table, key, values); err != nil {
+		return nil, err
+	}
+	return readValues, nil
+}
+
+func (db DbWrapper) Query(ctx context.Context, table string, field string, value []byte, count int, fields []string) (_ []map[string][]byte, err error) {
+	queryDB, ok := db.DB.(ycsb.QueryDB)
+	if !ok {
+		return nil, fmt.Errorf("the %T doesn't implement the QueryDB interface", db.DB)
+	}
+
+	start := begin()
+	defer func() {
+		measure(ctx, start, "QUERY", err)
+	}()
+
+	return queryDB.Query(ctx, table, field, value, count, fields)
+}
+
+func (db DbWrapper) Begin(ctx context.Context) (_ context.Context, err error) {
+	txnDB, ok := db.DB.(ycsb.TransactionDB)
+	if !ok {
+		return ctx, fmt.Errorf("the %T doesn't implement the TransactionDB interface", db.DB)
+	}
+
+	start := begin()
+	defer func() {
+		measure(ctx, start, "BEGIN", err)
+	}()
+
+	// The operations of the transaction must be executed in order.
+	return txnDB.Begin(withoutAsync(ctx))
+}
+
+func (db DbWrapper) Commit(ctx context.Context) (err error) {
+	start := begin()
+	defer func() {
+		measure(ctx, start, "COMMIT", err)
+	}()
+
+	return db.DB.(ycsb.TransactionDB).Commit(ctx)
+}
+
+func (db DbWrapper) Rollback(ctx context.Context) (err error) {
+	start := begin()
+	defer func() {
+		measure(ctx, start, "ROLLBACK", err)
+	}()
+
+	return db.DB.(ycsb.TransactionDB).Rollback(ctx)
+}
+
 func (db DbWrapper) Analyze(ctx context.Context, table string) error {
 	if analyzeDB, ok := db.DB.(ycsb.AnalyzeDB); ok {
 		return analyzeDB.Analyze(ctx, table)
@@ -0,0 +1,58 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+	"syscall"
+
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+)
+
+const errorClassifierKey = contextKey("errorClassifier")
+
+// withErrorClassifier classifies the errors of the operations of the thread by the DB if it
+// is an ErrorClassifier.
+func withErrorClassifier(ctx context.Context, db ycsb.DB) context.Context {
+	if classifier, ok := db.(ycsb.ErrorClassifier); ok {
+		return context.WithValue(ctx, errorClassifierKey, classifier)
+	}
+	return ctx
+}
+
+// classifyError returns the class of the error by the DB, or by the common errors of the
+// timeouts and the connections.
+func classifyError(ctx context.Context, err error) ycsb.ErrorClass {
+	if classifier, ok := ctx.Value(errorClassifierKey).(ycsb.ErrorClassifier); ok {
+		if class := classifier.ClassifyError(err); class != "" {
+			return class
+		}
+	}
+
+	var netErr net.Error
+	isNetErr := errors.As(err, &netErr)
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), isNetErr && netErr.Timeout():
+		return ycsb.ErrorTimeout
+	case isNetErr, errors.Is(err, driver.ErrBadConn), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF),
+		errors.Is(err, syscall.ECONNREFUSED), errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.EPIPE):
+		return ycsb.ErrorConnection
+	default:
+		return ycsb.ErrorOther
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/util"
+)
+
+// PartitionProperties sets the share of the client clientid of clientcount in the properties, in the
+// load phase insertstart and insertcount are set to its share of the records, and in the run phase
+// operationcount is set to its share of the operations, the last client takes the remainder.
+func PartitionProperties(p *properties.Properties) error {
+	clientCount := p.GetInt64(prop.ClientCount, prop.ClientCountDefault)
+	clientID := p.GetInt64(prop.ClientID, prop.ClientIDDefault)
+	if clientCount < 1 {
+		return fmt.Errorf("%s must be positive", prop.ClientCount)
+	}
+	if clientID < 0 || clientID >= clientCount {
+		return fmt.Errorf("%s %d must be in [0, %s %d)", prop.ClientID, clientID, prop.ClientCount, clientCount)
+	}
+	if clientCount == 1 {
+		return nil
+	}
+
+	share := func(total int64) (int64, int64) {
+		count := total / clientCount
+		start := clientID * count
+		if clientID == clientCount-1 {
+			count += total % clientCount
+		}
+		return start, count
+	}
+
+	if p.GetBool(prop.DoTransactions, true) {
+		if opCount := p.GetInt64(prop.OperationCount, 0); opCount > 0 {
+			_, count := share(opCount)
+			p.Set(prop.OperationCount, strconv.FormatInt(count, 10))
+			fmt.Printf("Client %d of %d runs %d of %d operations\n", clientID, clientCount, count, opCount)
+		}
+		return nil
+	}
+
+	recordCount := p.GetInt64(prop.RecordCount, prop.RecordCountDefault)
+	if recordCount == 0 {
+		return fmt.Errorf("%s must be set to partition the records", prop.RecordCount)
+	}
+	insertStart := p.GetInt64(prop.InsertStart, prop.InsertStartDefault)
+	insertCount := p.GetInt64(prop.InsertCount, recordCount-insertStart)
+	start, count := share(insertCount)
+	p.Set(prop.InsertStart, strconv.FormatInt(insertStart+start, 10))
+	p.Set(prop.InsertCount, strconv.FormatInt(count, 10))
+	fmt.Printf("Client %d of %d loads the records [%d, %d)\n", clientID, clientCount, insertStart+start, insertStart+start+count)
+	return nil
+}
+
+// ResumeProperties sets insertstart and insertcount of the load to the keys after the checkpoint of
+// checkpoint.file if checkpoint.resume is set, it returns false if the load is already completed.
+// The load starts from insertstart if the checkpoint doesn't exist.
+func ResumeProperties(p *properties.Properties) (bool, error) {
+	if p.GetBool(prop.DoTransactions, true) || !p.GetBool(prop.CheckpointResume, prop.CheckpointResumeDefault) {
+		return true, nil
+	}
+	path := p.GetString(prop.CheckpointFile, "")
+	if path == "" {
+		return false, fmt.Errorf("%s must be set to resume the load", prop.CheckpointFile)
+	}
+
+	c, err := util.ReadLoadCheckpoint(path)
+	if os.IsNotExist(err) {
+		fmt.Printf("Checkpoint %s doesn't exist, load from the start\n", path)
+		return true, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	clientID := p.GetInt64(prop.ClientID, prop.ClientIDDefault)
+	clientCount := p.GetInt64(prop.ClientCount, prop.ClientCountDefault)
+	insertStart := p.GetInt64(prop.InsertStart, prop.InsertStartDefault)
+	insertCount := p.GetInt64(prop.InsertCount, p.GetInt64(prop.RecordCount, prop.RecordCountDefault)-insertStart)
+	end := insertStart + insertCount
+	if c.ClientID != clientID || c.ClientCount != clientCount || c.End != end {
+		return false, fmt.Errorf("checkpoint %s of client %d of %d loading the records up to %d doesn't match client %d of %d loading the records up to %d",
+			path, c.ClientID, c.ClientCount, c.End, clientID, clientCount, end)
+	}
+	if c.Next < insertStart || c.Next > end {
+		return false, fmt.Errorf("checkpoint %s at %d is out of the records [%d, %d)", path, c.Next, insertStart, end)
+	}
+
+	if c.Next == end {
+		return false, nil
+	}
+	p.Set(prop.InsertStart, strconv.FormatInt(c.Next, 10))
+	p.Set(prop.InsertCount, strconv.FormatInt(end-c.Next, 10))
+	// Every thread inserts one key at least.
+	if threadCount := p.GetInt64(prop.ThreadCount, prop.ThreadCountDefault); threadCount > end-c.Next {
+		p.Set(prop.ThreadCount, strconv.FormatInt(end-c.Next, 10))
+	}
+	fmt.Printf("Resume the load from checkpoint %s, the records [%d, %d) are loaded\n", path, insertStart, c.Next)
+	return true, nil
+}
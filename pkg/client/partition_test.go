@@ -0,0 +1,165 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/util"
+)
+
+func newTestProperties(kvs map[string]string) *properties.Properties {
+	p := properties.NewProperties()
+	for k, v := range kvs {
+		p.Set(k, v)
+	}
+	return p
+}
+
+func TestPartitionProperties(t *testing.T) {
+	tests := []struct {
+		props map[string]string
+		want  map[string]string
+		err   bool
+	}{
+		// The load shares the records, the last client takes the remainder.
+		{
+			map[string]string{prop.DoTransactions: "false", prop.RecordCount: "10", prop.ClientCount: "3", prop.ClientID: "0"},
+			map[string]string{prop.InsertStart: "0", prop.InsertCount: "3"},
+			false,
+		},
+		{
+			map[string]string{prop.DoTransactions: "false", prop.RecordCount: "10", prop.ClientCount: "3", prop.ClientID: "2"},
+			map[string]string{prop.InsertStart: "6", prop.InsertCount: "4"},
+			false,
+		},
+		{
+			map[string]string{prop.DoTransactions: "false", prop.RecordCount: "100", prop.InsertStart: "10",
+				prop.InsertCount: "20", prop.ClientCount: "2", prop.ClientID: "1"},
+			map[string]string{prop.InsertStart: "20", prop.InsertCount: "10"},
+			false,
+		},
+		// The run shares the operations.
+		{
+			map[string]string{prop.OperationCount: "10", prop.ClientCount: "3", prop.ClientID: "1"},
+			map[string]string{prop.OperationCount: "3"},
+			false,
+		},
+		{
+			map[string]string{prop.OperationCount: "10", prop.ClientCount: "3", prop.ClientID: "2"},
+			map[string]string{prop.OperationCount: "4"},
+			false,
+		},
+		// One client keeps the properties.
+		{
+			map[string]string{prop.DoTransactions: "false", prop.RecordCount: "10"},
+			map[string]string{prop.InsertStart: "", prop.InsertCount: ""},
+			false,
+		},
+		{map[string]string{prop.ClientCount: "0"}, nil, true},
+		{map[string]string{prop.ClientCount: "2", prop.ClientID: "2"}, nil, true},
+		{map[string]string{prop.ClientCount: "2", prop.ClientID: "-1"}, nil, true},
+		{map[string]string{prop.DoTransactions: "false", prop.ClientCount: "2", prop.ClientID: "1"}, nil, true},
+	}
+
+	for _, test := range tests {
+		p := newTestProperties(test.props)
+		err := PartitionProperties(p)
+		if (err != nil) != test.err {
+			t.Errorf("want error %v of %v, but got %v", test.err, test.props, err)
+			continue
+		}
+		for k, v := range test.want {
+			if got := p.GetString(k, ""); got != v {
+				t.Errorf("want %s %q of %v, but got %q", k, v, test.props, got)
+			}
+		}
+	}
+}
+
+func TestResumeProperties(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkpoint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "load.checkpoint")
+	load := map[string]string{prop.DoTransactions: "false", prop.RecordCount: "100", prop.ThreadCount: "10",
+		prop.CheckpointFile: path, prop.CheckpointResume: "true"}
+
+	// The load starts from the beginning without the checkpoint.
+	p := newTestProperties(load)
+	if ok, err := ResumeProperties(p); !ok || err != nil {
+		t.Fatalf("want the load without the checkpoint, but got %v, %v", ok, err)
+	}
+	if _, ok := p.Get(prop.InsertStart); ok {
+		t.Errorf("want %s unset, but got %s", prop.InsertStart, p.GetString(prop.InsertStart, ""))
+	}
+
+	tests := []struct {
+		checkpoint util.LoadCheckpoint
+		ok         bool
+		want       map[string]string
+		err        bool
+	}{
+		{
+			util.LoadCheckpoint{ClientCount: 1, Next: 40, End: 100},
+			true,
+			map[string]string{prop.InsertStart: "40", prop.InsertCount: "60", prop.ThreadCount: "10"},
+			false,
+		},
+		// Every thread inserts one key at least.
+		{
+			util.LoadCheckpoint{ClientCount: 1, Next: 95, End: 100},
+			true,
+			map[string]string{prop.InsertStart: "95", prop.InsertCount: "5", prop.ThreadCount: "5"},
+			false,
+		},
+		// The load is completed.
+		{util.LoadCheckpoint{ClientCount: 1, Next: 100, End: 100}, false, nil, false},
+		// The checkpoint of another client or another load.
+		{util.LoadCheckpoint{ClientID: 1, ClientCount: 2, Next: 40, End: 100}, false, nil, true},
+		{util.LoadCheckpoint{ClientCount: 1, Next: 40, End: 200}, false, nil, true},
+		{util.LoadCheckpoint{ClientCount: 1, Next: 101, End: 100}, false, nil, true},
+	}
+
+	for _, test := range tests {
+		if err := test.checkpoint.Write(path); err != nil {
+			t.Fatal(err)
+		}
+		p := newTestProperties(load)
+		ok, err := ResumeProperties(p)
+		if ok != test.ok || (err != nil) != test.err {
+			t.Errorf("want %v and error %v of %+v, but got %v, %v", test.ok, test.err, test.checkpoint, ok, err)
+			continue
+		}
+		for k, v := range test.want {
+			if got := p.GetString(k, ""); got != v {
+				t.Errorf("want %s %q of %+v, but got %q", k, v, test.checkpoint, got)
+			}
+		}
+	}
+
+	// The run ignores the checkpoint.
+	p = newTestProperties(map[string]string{prop.CheckpointResume: "true"})
+	if ok, err := ResumeProperties(p); !ok || err != nil {
+		t.Errorf("want the run without resuming, but got %v, %v", ok, err)
+	}
+}
@@ -0,0 +1,199 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+)
+
+const (
+	// The fraction of the mutex contention events and the blocking nanoseconds sampled in the
+	// profiling window, they are not sampled out of the window.
+	profileMutexFraction = 5
+	profileBlockRate     = int(time.Microsecond)
+)
+
+// profiler captures the profiles of the client in a window of the run, the CPU profile covers
+// the window, and the other profiles are written at the end of the window.
+type profiler struct {
+	dir      string
+	types    []string
+	delay    time.Duration
+	duration time.Duration
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// startProfiling starts the profiling window if profile.dir is set.
+func startProfiling(p *properties.Properties) (*profiler, error) {
+	dir := p.GetString(prop.ProfileDir, "")
+	if dir == "" {
+		return nil, nil
+	}
+
+	pf := &profiler{dir: dir, done: make(chan struct{})}
+	for _, t := range strings.Split(p.GetString(prop.ProfileTypes, prop.ProfileTypesDefault), ",") {
+		t = strings.ToLower(strings.TrimSpace(t))
+		switch t {
+		case "":
+			continue
+		case "cpu", "heap", "allocs", "goroutine", "mutex", "block":
+		default:
+			return nil, fmt.Errorf("unknown profile type %s in %s", t, prop.ProfileTypes)
+		}
+		pf.types = append(pf.types, t)
+	}
+	if len(pf.types) == 0 {
+		return nil, fmt.Errorf("%s must not be empty", prop.ProfileTypes)
+	}
+
+	var err error
+	if pf.delay, err = time.ParseDuration(p.GetString(prop.ProfileDelay, prop.ProfileDelayDefault)); err != nil || pf.delay < 0 {
+		return nil, fmt.Errorf("%s must be a non-negative duration", prop.ProfileDelay)
+	}
+	if pf.duration, err = time.ParseDuration(p.GetString(prop.ProfileDuration, prop.ProfileDurationDefault)); err != nil || pf.duration <= 0 {
+		return nil, fmt.Errorf("%s must be a positive duration", prop.ProfileDuration)
+	}
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	pf.wg.Add(1)
+	go pf.run()
+	return pf, nil
+}
+
+func (pf *profiler) run() {
+	defer pf.wg.Done()
+
+	if pf.delay > 0 {
+		t := time.NewTimer(pf.delay)
+		select {
+		case <-t.C:
+		case <-pf.done:
+			t.Stop()
+			fmt.Printf("Run finished before the profiling window in %s, no profile is captured\n", pf.delay)
+			return
+		}
+	}
+
+	start := time.Now()
+	cpu, err := pf.startWindow()
+	if err != nil {
+		fmt.Printf("Start profiling failed %v\n", err)
+		return
+	}
+
+	t := time.NewTimer(pf.duration)
+	select {
+	case <-t.C:
+	case <-pf.done:
+		t.Stop()
+	}
+
+	if err = pf.stopWindow(cpu); err != nil {
+		fmt.Printf("Write profiles failed %v\n", err)
+		return
+	}
+	fmt.Printf("Captured the %s profiles in %s, profiled %s\n", strings.Join(pf.types, ", "), pf.dir, time.Now().Sub(start))
+}
+
+func (pf *profiler) path(t string) string {
+	return filepath.Join(pf.dir, t+".pprof")
+}
+
+// startWindow starts the CPU profile and the sampling of the mutex and the block profiles.
+func (pf *profiler) startWindow() (*os.File, error) {
+	var cpu *os.File
+	for _, t := range pf.types {
+		switch t {
+		case "cpu":
+			f, err := os.Create(pf.path(t))
+			if err != nil {
+				return nil, err
+			}
+			if err = pprof.StartCPUProfile(f); err != nil {
+				f.Close()
+				return nil, err
+			}
+			cpu = f
+		case "mutex":
+			runtime.SetMutexProfileFraction(profileMutexFraction)
+		case "block":
+			runtime.SetBlockProfileRate(profileBlockRate)
+		}
+	}
+	return cpu, nil
+}
+
+// stopWindow stops the CPU profile and writes the other profiles.
+func (pf *profiler) stopWindow(cpu *os.File) error {
+	if cpu != nil {
+		pprof.StopCPUProfile()
+		if err := cpu.Close(); err != nil {
+			return err
+		}
+	}
+
+	for _, t := range pf.types {
+		switch t {
+		case "cpu":
+			continue
+		case "heap":
+			// Get the up-to-date statistics of the live objects.
+			runtime.GC()
+		}
+
+		if err := pf.writeProfile(t); err != nil {
+			return err
+		}
+
+		switch t {
+		case "mutex":
+			runtime.SetMutexProfileFraction(0)
+		case "block":
+			runtime.SetBlockProfileRate(0)
+		}
+	}
+	return nil
+}
+
+func (pf *profiler) writeProfile(t string) error {
+	f, err := os.Create(pf.path(t))
+	if err != nil {
+		return err
+	}
+	if err = pprof.Lookup(t).WriteTo(f, 0); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// close ends the profiling window if the run finishes in it, and waits for the profiles written.
+func (pf *profiler) close() {
+	close(pf.done)
+	pf.wg.Wait()
+}
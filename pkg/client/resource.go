@@ -0,0 +1,170 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+)
+
+const (
+	resourceSampleInterval = time.Second
+	// The client is saturated in a second if it uses more than saturatedCPU of GOMAXPROCS, and the run
+	// is flagged if it is saturated in more than saturatedRatio of the seconds.
+	saturatedCPU   = 0.9
+	saturatedRatio = 0.1
+)
+
+// ResourceUsage is the resource usage of the client process in a run.
+type ResourceUsage struct {
+	CPUs             int     `json:"cpus"`
+	AvgCPU           float64 `json:"avg_cpu"`
+	MaxCPU           float64 `json:"max_cpu"`
+	MaxHeapBytes     uint64  `json:"max_heap_bytes"`
+	MaxGoroutines    int     `json:"max_goroutines"`
+	GCCount          uint32  `json:"gc_count"`
+	GCPauseTotal     float64 `json:"gc_pause_total_ms"`
+	GCPauseMax       float64 `json:"gc_pause_max_ms"`
+	Samples          int     `json:"samples"`
+	SaturatedSamples int     `json:"saturated_samples"`
+	Saturated        bool    `json:"saturated"`
+}
+
+// resourceMonitor samples the CPU, the heap, the goroutines and the GC of the client process every
+// second, so the runs limited by the client itself can be told from the regressions of the database.
+type resourceMonitor struct {
+	mu    sync.Mutex
+	usage ResourceUsage
+
+	start      time.Time
+	startCPU   time.Duration
+	startNumGC uint32
+	startPause uint64
+
+	last      time.Time
+	lastCPU   time.Duration
+	lastNumGC uint32
+	// The latest sample.
+	cpu        float64
+	heap       uint64
+	goroutines int
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+func startResourceMonitor() *resourceMonitor {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+
+	m := &resourceMonitor{
+		start:      time.Now(),
+		startCPU:   processCPUTime(),
+		startNumGC: stats.NumGC,
+		startPause: stats.PauseTotalNs,
+		done:       make(chan struct{}),
+	}
+	m.usage.CPUs = runtime.GOMAXPROCS(0)
+	m.last, m.lastCPU, m.lastNumGC = m.start, m.startCPU, stats.NumGC
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+
+		t := time.NewTicker(resourceSampleInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				m.sample()
+			case <-m.done:
+				return
+			}
+		}
+	}()
+	return m
+}
+
+func (m *resourceMonitor) sample() {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	now := time.Now()
+	cpuTime := processCPUTime()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u := &m.usage
+	m.cpu = (cpuTime - m.lastCPU).Seconds() / now.Sub(m.last).Seconds()
+	m.heap = stats.HeapAlloc
+	m.goroutines = runtime.NumGoroutine()
+
+	u.Samples++
+	if m.cpu > u.MaxCPU {
+		u.MaxCPU = m.cpu
+	}
+	if m.cpu >= saturatedCPU*float64(u.CPUs) {
+		u.SaturatedSamples++
+	}
+	if m.heap > u.MaxHeapBytes {
+		u.MaxHeapBytes = m.heap
+	}
+	if m.goroutines > u.MaxGoroutines {
+		u.MaxGoroutines = m.goroutines
+	}
+	// The recent pauses are kept in a circular buffer of 256 GCs.
+	for n := stats.NumGC; n > m.lastNumGC && stats.NumGC-n < uint32(len(stats.PauseNs)); n-- {
+		if pause := float64(stats.PauseNs[(n+255)%256]) / 1e6; pause > u.GCPauseMax {
+			u.GCPauseMax = pause
+		}
+	}
+	u.GCCount = stats.NumGC - m.startNumGC
+	u.GCPauseTotal = float64(stats.PauseTotalNs-m.startPause) / 1e6
+	if elapsed := now.Sub(m.start).Seconds(); elapsed > 0 {
+		u.AvgCPU = (cpuTime - m.startCPU).Seconds() / elapsed
+	}
+
+	m.last, m.lastCPU, m.lastNumGC = now, cpuTime, stats.NumGC
+}
+
+// summary returns the latest sample like the summary of the measurements.
+func (m *resourceMonitor) summary() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return fmt.Sprintf("CPU(cores): %.1f/%d, Heap(MB): %d, Goroutines: %d, GC: %d, GC Pause Max(ms): %.1f",
+		m.cpu, m.usage.CPUs, m.heap>>20, m.goroutines, m.usage.GCCount, m.usage.GCPauseMax)
+}
+
+// stop stops sampling, prints the resource usage of the run, and warns if the client is saturated.
+func (m *resourceMonitor) stop() *ResourceUsage {
+	close(m.done)
+	m.wg.Wait()
+	m.sample()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u := m.usage
+	u.Saturated = u.SaturatedSamples > 0 && float64(u.SaturatedSamples) >= saturatedRatio*float64(u.Samples)
+	fmt.Printf("Client CPU(cores): avg %.1f, max %.1f of %d, Heap Max(MB): %d, Goroutines Max: %d, GC: %d, GC Pause Total(ms): %.1f, GC Pause Max(ms): %.1f\n",
+		u.AvgCPU, u.MaxCPU, u.CPUs, u.MaxHeapBytes>>20, u.MaxGoroutines, u.GCCount, u.GCPauseTotal, u.GCPauseMax)
+	if u.Saturated {
+		fmt.Printf("WARNING: the client used more than %.0f%% of %d cores in %d of %d seconds, the results may be limited by the client\n",
+			saturatedCPU*100, u.CPUs, u.SaturatedSamples, u.Samples)
+	}
+	return &u
+}
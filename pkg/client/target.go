@@ -0,0 +1,248 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+)
+
+// targetStep is a step of the target profile.
+type targetStep struct {
+	// target is the operations per second of all the threads.
+	target float64
+	// end is the offset of the end of the step from the start of the run.
+	end time.Duration
+}
+
+// targetProfile is the target throughput changing over time, the last step lasts until the
+// end of the run.
+type targetProfile struct {
+	steps []targetStep
+	// shape multiplies the target at the elapsed time since the start of the run, nil keeps the
+	// target constant.
+	shape func(elapsed time.Duration) float64
+	// override is the bits of the target set in the run, which replaces the steps if it's not 0.
+	override uint64
+}
+
+// newTargetProfile creates the target profile with its shape from the properties, it returns nil
+// if the target is not set.
+func newTargetProfile(p *properties.Properties) (*targetProfile, error) {
+	t, err := parseTargetProfile(p.GetString(prop.Target, ""))
+	if err != nil || t == nil {
+		return nil, err
+	}
+	if t.shape, err = parseTargetShape(p); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// parseTargetProfile parses the target, which is the operations per second like "1000", or
+// the steps like "1000:60s,5000:60s", it returns nil if the target is not set.
+func parseTargetProfile(value string) (*targetProfile, error) {
+	value = strings.TrimSpace(value)
+	if len(value) == 0 {
+		return nil, nil
+	}
+
+	if !strings.Contains(value, ":") {
+		target, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid target %q", value)
+		}
+		if target <= 0 {
+			return nil, nil
+		}
+		return &targetProfile{steps: []targetStep{{target: target}}}, nil
+	}
+
+	t := new(targetProfile)
+	var end time.Duration
+	for _, step := range strings.Split(value, ",") {
+		parts := strings.Split(strings.TrimSpace(step), ":")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid target step %q, must be target:duration", step)
+		}
+		target, err := strconv.ParseFloat(parts[0], 64)
+		if err != nil || target <= 0 {
+			return nil, fmt.Errorf("invalid target %q in step %q, must be positive", parts[0], step)
+		}
+		d, err := time.ParseDuration(parts[1])
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid duration %q in step %q, must be positive", parts[1], step)
+		}
+		end += d
+		t.steps = append(t.steps, targetStep{target: target, end: end})
+	}
+	return t, nil
+}
+
+// step returns the index of the step at the elapsed time since the start of the run.
+func (t *targetProfile) step(elapsed time.Duration) int {
+	i := 0
+	for i < len(t.steps)-1 && elapsed >= t.steps[i].end {
+		i++
+	}
+	return i
+}
+
+// rate returns the target operations per second at the elapsed time since the start of the run.
+func (t *targetProfile) rate(elapsed time.Duration) float64 {
+	rate := t.steps[t.step(elapsed)].target
+	if override := atomic.LoadUint64(&t.override); override != 0 {
+		rate = math.Float64frombits(override)
+	}
+	if t.shape != nil {
+		rate *= t.shape(elapsed)
+	}
+	return rate
+}
+
+// setTarget replaces the steps by the target operations per second.
+func (t *targetProfile) setTarget(target float64) {
+	atomic.StoreUint64(&t.override, math.Float64bits(target))
+}
+
+// overridden returns true if the steps are replaced by setTarget.
+func (t *targetProfile) overridden() bool {
+	return atomic.LoadUint64(&t.override) != 0
+}
+
+// duration returns the total duration of the steps, 0 if the target is constant.
+func (t *targetProfile) duration() time.Duration {
+	return t.steps[len(t.steps)-1].end
+}
+
+// rateLimiter schedules the operations of all the threads by the target profile, so the threads
+// can take over the slots the slow threads don't use.
+type rateLimiter struct {
+	target *targetProfile
+
+	mu    sync.Mutex
+	start time.Time
+	next  time.Time
+}
+
+func newRateLimiter(target *targetProfile) *rateLimiter {
+	return &rateLimiter{target: target}
+}
+
+// take reserves the slots of n operations, and returns the time the first one is scheduled to start at.
+// The schedule starts with the first slot taken.
+func (l *rateLimiter) take(n int) time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.start.IsZero() {
+		l.start = time.Now()
+		l.next = l.start
+	}
+	scheduled := l.next
+	for i := 0; i < n; i++ {
+		l.next = l.next.Add(time.Duration(float64(time.Second) / l.target.rate(l.next.Sub(l.start))))
+	}
+	return scheduled
+}
+
+// reset restarts the schedule from now, so the slots missed in a pause are not taken in a burst.
+func (l *rateLimiter) reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now := time.Now(); l.next.Before(now) {
+		l.next = now
+	}
+}
+
+// shapePoint is a point of the piecewise shape.
+type shapePoint struct {
+	offset     time.Duration
+	multiplier float64
+}
+
+// parseTargetShape parses the shape of the target over time, it returns nil if the shape is constant.
+func parseTargetShape(p *properties.Properties) (func(elapsed time.Duration) float64, error) {
+	switch shape := strings.ToLower(p.GetString(prop.TargetShape, prop.TargetShapeDefault)); shape {
+	case "constant":
+		return nil, nil
+	case "sine":
+		period, err := time.ParseDuration(p.GetString(prop.TargetShapePeriod, prop.TargetShapePeriodDefault))
+		if err != nil || period <= 0 {
+			return nil, fmt.Errorf("%s must be a positive duration", prop.TargetShapePeriod)
+		}
+		amplitude := p.GetFloat64(prop.TargetShapeAmplitude, prop.TargetShapeAmplitudeDefault)
+		if amplitude < 0 || amplitude >= 1 {
+			return nil, fmt.Errorf("%s must be in [0, 1)", prop.TargetShapeAmplitude)
+		}
+		return func(elapsed time.Duration) float64 {
+			return 1 - amplitude*math.Cos(2*math.Pi*float64(elapsed%period)/float64(period))
+		}, nil
+	case "piecewise":
+		points, err := parseShapePoints(p.GetString(prop.TargetShapePoints, ""))
+		if err != nil {
+			return nil, err
+		}
+		period := points[len(points)-1].offset
+		return func(elapsed time.Duration) float64 {
+			elapsed %= period
+			i := 1
+			for points[i].offset <= elapsed {
+				i++
+			}
+			prev, next := points[i-1], points[i]
+			frac := float64(elapsed-prev.offset) / float64(next.offset-prev.offset)
+			return prev.multiplier + (next.multiplier-prev.multiplier)*frac
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown %s %s", prop.TargetShape, shape)
+	}
+}
+
+// parseShapePoints parses the points of the piecewise shape like "0s:0.2,12h:1,24h:0.2", the first
+// point must be at 0s, and the offsets must be increasing.
+func parseShapePoints(value string) ([]shapePoint, error) {
+	var points []shapePoint
+	for _, point := range strings.Split(value, ",") {
+		parts := strings.Split(strings.TrimSpace(point), ":")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid %s point %q, must be offset:multiplier", prop.TargetShapePoints, point)
+		}
+		offset, err := time.ParseDuration(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid offset %q in %s point %q", parts[0], prop.TargetShapePoints, point)
+		}
+		multiplier, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil || multiplier <= 0 {
+			return nil, fmt.Errorf("invalid multiplier %q in %s point %q, must be positive", parts[1], prop.TargetShapePoints, point)
+		}
+		if (len(points) == 0 && offset != 0) || (len(points) > 0 && offset <= points[len(points)-1].offset) {
+			return nil, fmt.Errorf("the %s must start at 0s and the offsets must be increasing", prop.TargetShapePoints)
+		}
+		points = append(points, shapePoint{offset: offset, multiplier: multiplier})
+	}
+	if len(points) < 2 {
+		return nil, fmt.Errorf("%s must have at least 2 points", prop.TargetShapePoints)
+	}
+	return points, nil
+}
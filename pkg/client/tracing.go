@@ -0,0 +1,258 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+)
+
+const (
+	// tracingBatchSize is the max number of the spans exported in a request.
+	tracingBatchSize = 512
+	// tracingQueueSize is the max number of the spans waiting to be exported, the spans are
+	// dropped if the queue is full, so the tracing never blocks the operations.
+	tracingQueueSize = 8192
+	tracingInterval  = time.Second
+	// Span kind client and status code error of OTLP.
+	otlpSpanKindClient  = 3
+	otlpStatusCodeError = 2
+)
+
+// tracer exports the sampled operations as the OpenTelemetry spans by OTLP/HTTP in JSON, every
+// operation is a client span with the attributes of the database system.
+type tracer struct {
+	endpoint   string
+	sampleRate float64
+	resource   otlpResource
+	client     *http.Client
+
+	spans chan otlpSpan
+	done  chan struct{}
+	wg    sync.WaitGroup
+
+	exported int64
+	dropped  int64
+	failed   int64
+	errOnce  sync.Once
+}
+
+type otlpValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	IntValue    *string `json:"intValue,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+func stringAttribute(key string, value string) otlpAttribute {
+	return otlpAttribute{Key: key, Value: otlpValue{StringValue: &value}}
+}
+
+func intAttribute(key string, value int64) otlpAttribute {
+	s := strconv.FormatInt(value, 10)
+	return otlpAttribute{Key: key, Value: otlpValue{IntValue: &s}}
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpTraces struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+// globalTracer is set if tracing.otlp_endpoint is set, it is never reset, since the abandoned
+// operations may complete after the run.
+var globalTracer *tracer
+
+// startTracing starts exporting the sampled operations if tracing.otlp_endpoint is set.
+func startTracing(p *properties.Properties) *tracer {
+	endpoint := p.GetString(prop.TracingEndpoint, "")
+	if endpoint == "" {
+		return nil
+	}
+
+	t := &tracer{
+		endpoint:   endpoint,
+		sampleRate: p.GetFloat64(prop.TracingSampleRate, prop.TracingSampleRateDefault),
+		client:     &http.Client{Timeout: 10 * time.Second},
+		spans:      make(chan otlpSpan, tracingQueueSize),
+		done:       make(chan struct{}),
+	}
+	t.resource.Attributes = append(t.resource.Attributes,
+		stringAttribute("service.name", p.GetString(prop.TracingServiceName, prop.TracingServiceNameDefault)),
+		stringAttribute("ycsb.workload", p.GetString(prop.Workload, "core")))
+	if db := p.GetString(prop.DB, ""); db != "" {
+		t.resource.Attributes = append(t.resource.Attributes, stringAttribute("db.system", db))
+	}
+
+	t.wg.Add(1)
+	go t.export()
+	globalTracer = t
+	return t
+}
+
+// trace samples the operation, and queues it to be exported as a span.
+func (t *tracer) trace(ctx context.Context, op string, start time.Time, end time.Time, err error) {
+	if rand.Float64() >= t.sampleRate {
+		return
+	}
+
+	var traceID [16]byte
+	var spanID [8]byte
+	rand.Read(traceID[:])
+	rand.Read(spanID[:])
+	span := otlpSpan{
+		TraceID:           hex.EncodeToString(traceID[:]),
+		SpanID:            hex.EncodeToString(spanID[:]),
+		Name:              strings.TrimSuffix(op, "_ERROR"),
+		Kind:              otlpSpanKindClient,
+		StartTimeUnixNano: strconv.FormatInt(start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(end.UnixNano(), 10),
+		Attributes:        []otlpAttribute{stringAttribute("db.operation", strings.TrimSuffix(op, "_ERROR"))},
+	}
+	if thread, ok := ctx.Value(threadKey).(int); ok {
+		span.Attributes = append(span.Attributes, intAttribute("ycsb.thread", int64(thread)))
+	}
+	if err != nil {
+		span.Status = otlpStatus{Code: otlpStatusCodeError, Message: err.Error()}
+		span.Attributes = append(span.Attributes, stringAttribute("ycsb.error_class", string(classifyError(ctx, err))))
+	}
+
+	select {
+	case t.spans <- span:
+	default:
+		atomic.AddInt64(&t.dropped, 1)
+	}
+}
+
+// export sends the queued spans in batches until the tracer is closed.
+func (t *tracer) export() {
+	defer t.wg.Done()
+
+	ticker := time.NewTicker(tracingInterval)
+	defer ticker.Stop()
+
+	batch := make([]otlpSpan, 0, tracingBatchSize)
+	for {
+		select {
+		case span := <-t.spans:
+			if batch = append(batch, span); len(batch) >= tracingBatchSize {
+				t.send(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			t.send(batch)
+			batch = batch[:0]
+		case <-t.done:
+			for {
+				select {
+				case span := <-t.spans:
+					if batch = append(batch, span); len(batch) >= tracingBatchSize {
+						t.send(batch)
+						batch = batch[:0]
+					}
+				default:
+					t.send(batch)
+					return
+				}
+			}
+		}
+	}
+}
+
+func (t *tracer) send(spans []otlpSpan) {
+	if len(spans) == 0 {
+		return
+	}
+
+	body, err := json.Marshal(otlpTraces{ResourceSpans: []otlpResourceSpans{{
+		Resource: t.resource,
+		ScopeSpans: []otlpScopeSpans{{
+			Scope: otlpScope{Name: "github.com/pingcap/go-ycsb"},
+			Spans: spans,
+		}},
+	}}})
+	if err == nil {
+		var resp *http.Response
+		if resp, err = t.client.Post(t.endpoint, "application/json", bytes.NewReader(body)); err == nil {
+			resp.Body.Close()
+			if resp.StatusCode/100 != 2 {
+				err = fmt.Errorf("status %s", resp.Status)
+			}
+		}
+	}
+	if err != nil {
+		atomic.AddInt64(&t.failed, int64(len(spans)))
+		t.errOnce.Do(func() {
+			fmt.Printf("Export spans to %s failed %v\n", t.endpoint, err)
+		})
+		return
+	}
+	atomic.AddInt64(&t.exported, int64(len(spans)))
+}
+
+// close exports the queued spans and prints the numbers of the spans.
+func (t *tracer) close() {
+	close(t.done)
+	t.wg.Wait()
+	fmt.Printf("Traced %d operations, dropped %d spans, failed to export %d spans\n",
+		atomic.LoadInt64(&t.exported), atomic.LoadInt64(&t.dropped), atomic.LoadInt64(&t.failed))
+}
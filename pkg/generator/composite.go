@@ -0,0 +1,56 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import (
+	"math/rand"
+
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+)
+
+// Composite generates integers composed of the components in the mixed radix of their cardinalities,
+// every component is generated by its own generator in [0, cardinality).
+type Composite struct {
+	Number
+	components []ycsb.Generator
+	// strides[i] is the product of the cardinalities of the components after i.
+	strides []int64
+}
+
+// NewComposite creates a Composite generator.
+// components: the generators of the components, the first one is the most significant.
+// cardinalities: the cardinalities of the components.
+func NewComposite(components []ycsb.Generator, cardinalities []int64) *Composite {
+	strides := make([]int64, len(cardinalities))
+	stride := int64(1)
+	for i := len(cardinalities) - 1; i >= 0; i-- {
+		strides[i] = stride
+		stride *= cardinalities[i]
+	}
+
+	return &Composite{
+		components: components,
+		strides:    strides,
+	}
+}
+
+// Next implements the Generator Next interface.
+func (c *Composite) Next(r *rand.Rand) int64 {
+	var value int64
+	for i, component := range c.components {
+		value += component.Next(r) * c.strides[i]
+	}
+	c.SetLastValue(value)
+	return value
+}
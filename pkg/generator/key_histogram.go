@@ -0,0 +1,127 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import (
+	"io/ioutil"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pingcap/go-ycsb/pkg/util"
+)
+
+// KeyHistogram generates integers according to the weights of the buckets, the range is split
+// into the buckets evenly, and the integers in a bucket are chosen uniformly.
+type KeyHistogram struct {
+	Number
+	lowerBound int64
+	interval   int64
+	// cumulative is the cumulative weights of the buckets.
+	cumulative []float64
+}
+
+// NewKeyHistogram creates a KeyHistogram generator.
+// lowerBound: the lower bound of the distribution.
+// upperBound: the upper bound of the distribution.
+// weights: the relative weights of the buckets.
+func NewKeyHistogram(lowerBound int64, upperBound int64, weights []float64) *KeyHistogram {
+	if lowerBound > upperBound {
+		lowerBound, upperBound = upperBound, lowerBound
+	}
+
+	cumulative := make([]float64, len(weights))
+	sum := float64(0)
+	for i, w := range weights {
+		if w > 0 {
+			sum += w
+		}
+		cumulative[i] = sum
+	}
+	if sum <= 0 {
+		util.Fatalf("the sum of the bucket weights must be positive")
+	}
+
+	return &KeyHistogram{
+		lowerBound: lowerBound,
+		interval:   upperBound - lowerBound + 1,
+		cumulative: cumulative,
+	}
+}
+
+// NewKeyHistogramFromFile creates a KeyHistogram generator from a CSV file, every line is
+// "bucket,weight", the bucket is the index from 0, and the missing buckets have zero weight.
+// The empty lines, the lines starting with '#' and the header line are ignored.
+func NewKeyHistogramFromFile(name string, lowerBound int64, upperBound int64) *KeyHistogram {
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		util.Fatalf("load key histogram file %s failed %v", name, err)
+	}
+
+	var weights []float64
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		items := strings.Split(line, ",")
+		if len(items) != 2 {
+			util.Fatalf("invalid line %d %q of key histogram file %s, must be bucket,weight", i+1, line, name)
+		}
+
+		bucket, err := strconv.Atoi(strings.TrimSpace(items[0]))
+		if err != nil && len(weights) == 0 {
+			// The header line.
+			continue
+		} else if err != nil || bucket < 0 {
+			util.Fatalf("invalid bucket of line %d %q of key histogram file %s", i+1, line, name)
+		}
+
+		weight, err := strconv.ParseFloat(strings.TrimSpace(items[1]), 64)
+		if err != nil {
+			util.Fatalf("invalid weight of line %d %q of key histogram file %s", i+1, line, name)
+		}
+
+		for len(weights) <= bucket {
+			weights = append(weights, 0)
+		}
+		weights[bucket] += weight
+	}
+
+	return NewKeyHistogram(lowerBound, upperBound, weights)
+}
+
+// Next implements the Generator Next interface.
+func (h *KeyHistogram) Next(r *rand.Rand) int64 {
+	buckets := int64(len(h.cumulative))
+	n := r.Float64() * h.cumulative[buckets-1]
+	i := int64(sort.Search(len(h.cumulative), func(i int) bool {
+		return h.cumulative[i] > n
+	}))
+	if i >= buckets {
+		i = buckets - 1
+	}
+
+	// The bucket may be empty if there are more buckets than the integers.
+	start := i * h.interval / buckets
+	end := (i + 1) * h.interval / buckets
+	value := h.lowerBound + start
+	if end > start {
+		value += r.Int63n(end - start)
+	}
+	h.SetLastValue(value)
+	return value
+}
@@ -0,0 +1,164 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+// LatestWindow generates the integers in the window of the latest inserted integers, the window
+// is the latest count integers or the integers inserted in the latest duration. A proportion
+// of the integers are in the tail before the window, the tail reaches back to the latest count
+// integers or the integers inserted in the latest duration too, or to the lower bound.
+type LatestWindow struct {
+	Number
+	lowerBound     int64
+	latest         *AcknowledgedCounter
+	window         Window
+	tail           Window
+	tailProportion float64
+
+	// checkpoints samples the latest integer over time to find the integers inserted in a duration.
+	mu          sync.Mutex
+	checkpoints []latestCheckpoint
+	granularity time.Duration
+	retention   time.Duration
+}
+
+// Window is the span of a LatestWindow, either Count integers or the integers in Duration,
+// a zero Window is unbounded.
+type Window struct {
+	Count    int64
+	Duration time.Duration
+}
+
+type latestCheckpoint struct {
+	t    time.Time
+	last int64
+}
+
+// NewLatestWindow creates a LatestWindow generator.
+// lowerBound: the lower bound of the distribution.
+// latest: the counter of the inserted integers.
+// window: the window of the latest integers, must not be zero.
+// tail: how far back the tail reaches from the latest integer, zero reaches to the lower bound.
+// tailProportion: percentage of the integers in the tail.
+func NewLatestWindow(lowerBound int64, latest *AcknowledgedCounter, window Window, tail Window, tailProportion float64) *LatestWindow {
+	if tailProportion < 0.0 || tailProportion > 1.0 {
+		tailProportion = 0.0
+	}
+
+	l := &LatestWindow{
+		lowerBound:     lowerBound,
+		latest:         latest,
+		window:         window,
+		tail:           tail,
+		tailProportion: tailProportion,
+	}
+
+	// Sample the latest integer 100 times in the shortest duration.
+	for _, d := range []time.Duration{window.Duration, tail.Duration} {
+		if d > 0 && (l.granularity == 0 || d/100 < l.granularity) {
+			l.granularity = d / 100
+		}
+	}
+	if l.granularity > 0 && l.granularity < time.Millisecond {
+		l.granularity = time.Millisecond
+	}
+	l.retention = window.Duration
+	if tail.Duration > l.retention {
+		l.retention = tail.Duration
+	}
+	if l.granularity > 0 {
+		// The integers before the first checkpoint, like the loaded ones, are treated as inserted at the start.
+		l.checkpoints = append(l.checkpoints, latestCheckpoint{t: time.Now(), last: latest.Last()})
+	}
+	return l
+}
+
+// Next implements the Generator Next interface.
+func (l *LatestWindow) Next(r *rand.Rand) int64 {
+	now := time.Now()
+	last := l.latest.Last()
+	if l.granularity > 0 {
+		l.checkpoint(now, last)
+	}
+
+	windowStart := l.start(now, last, l.window)
+	if windowStart > last {
+		// Keep at least the latest integer in the window.
+		windowStart = last
+	}
+	tailStart := l.lowerBound
+	if l.tail != (Window{}) {
+		tailStart = l.start(now, last, l.tail)
+	}
+
+	var value int64
+	if tailStart < windowStart && r.Float64() < l.tailProportion {
+		value = tailStart + r.Int63n(windowStart-tailStart)
+	} else {
+		value = windowStart + r.Int63n(last-windowStart+1)
+	}
+
+	l.SetLastValue(value)
+	return value
+}
+
+// start returns the first integer of the window which ends at the latest integer last at time now.
+func (l *LatestWindow) start(now time.Time, last int64, w Window) int64 {
+	start := last + 1 - w.Count
+	if w.Duration > 0 {
+		start = l.lastAt(now.Add(-w.Duration)) + 1
+	}
+	if start < l.lowerBound {
+		start = l.lowerBound
+	}
+	return start
+}
+
+// lastAt returns the latest integer at time t.
+func (l *LatestWindow) lastAt(t time.Time) int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	i := sort.Search(len(l.checkpoints), func(i int) bool {
+		return l.checkpoints[i].t.After(t)
+	})
+	if i == 0 {
+		return l.lowerBound - 1
+	}
+	return l.checkpoints[i-1].last
+}
+
+func (l *LatestWindow) checkpoint(now time.Time, last int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.checkpoints[len(l.checkpoints)-1].t) < l.granularity {
+		return
+	}
+	l.checkpoints = append(l.checkpoints, latestCheckpoint{t: now, last: last})
+
+	// Keep the last checkpoint before the retention, which is the latest integer at the start of the retention.
+	expired := sort.Search(len(l.checkpoints), func(i int) bool {
+		return now.Sub(l.checkpoints[i].t) < l.retention
+	}) - 1
+	if expired > 0 {
+		l.checkpoints = append(l.checkpoints[:0], l.checkpoints[expired:]...)
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import (
+	"math/rand"
+	"time"
+)
+
+// ShiftingHotspot generates integers resembling a hotspot distribution where %x of operations
+// access y% of data items, and the hot set moves to the next y% of data items periodically.
+type ShiftingHotspot struct {
+	Number
+	lowerBound     int64
+	interval       int64
+	hotInterval    int64
+	hotOpnFraction float64
+	start          time.Time
+	shiftInterval  time.Duration
+}
+
+// NewShiftingHotspot creates a ShiftingHotspot generator.
+// lowerBound: the lower bound of the distribution.
+// upperBound: the upper bound of the distribution.
+// hotsetFraction: percentage of data itme.
+// hotOpnFraction: percentage of operations accessing the hot set.
+// shiftInterval: the hot set moves every shiftInterval, it never moves if shiftInterval <= 0.
+func NewShiftingHotspot(lowerBound int64, upperBound int64, hotsetFraction float64, hotOpnFraction float64, shiftInterval time.Duration) *ShiftingHotspot {
+	if hotsetFraction < 0.0 || hotsetFraction > 1.0 {
+		hotsetFraction = 0.0
+	}
+
+	if hotOpnFraction < 0.0 || hotOpnFraction > 1.0 {
+		hotOpnFraction = 0.0
+	}
+
+	if lowerBound > upperBound {
+		lowerBound, upperBound = upperBound, lowerBound
+	}
+
+	interval := upperBound - lowerBound + 1
+	hotInterval := int64(float64(interval) * hotsetFraction)
+	if hotInterval < 1 {
+		hotInterval = 1
+	}
+	return &ShiftingHotspot{
+		lowerBound:     lowerBound,
+		interval:       interval,
+		hotInterval:    hotInterval,
+		hotOpnFraction: hotOpnFraction,
+		start:          time.Now(),
+		shiftInterval:  shiftInterval,
+	}
+}
+
+// hotStart returns the offset of the current hot set, the hot set wraps around at the upper bound.
+func (h *ShiftingHotspot) hotStart() int64 {
+	if h.shiftInterval <= 0 {
+		return 0
+	}
+	shifts := int64(time.Now().Sub(h.start) / h.shiftInterval)
+	return (shifts % h.interval) * h.hotInterval % h.interval
+}
+
+// Next implements the Generator Next interface.
+func (h *ShiftingHotspot) Next(r *rand.Rand) int64 {
+	offset := h.hotStart()
+	if coldInterval := h.interval - h.hotInterval; coldInterval > 0 && r.Float64() >= h.hotOpnFraction {
+		offset += h.hotInterval + r.Int63n(coldInterval)
+	} else {
+		offset += r.Int63n(h.hotInterval)
+	}
+
+	value := h.lowerBound + offset%h.interval
+	h.SetLastValue(value)
+	return value
+}
@@ -0,0 +1,355 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package measurement
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"math/bits"
+	"sync/atomic"
+	"time"
+
+	"github.com/magiconair/properties"
+)
+
+// hdr is a lock-free HdrHistogram, the layout of its counts is the same as the
+// HdrHistogram reference implementation, so it can be encoded into the histogram
+// logs read by the HdrHistogram tools.
+type hdr struct {
+	lowestDiscernibleValue      int64
+	highestTrackableValue       int64
+	significantDigits           int64
+	unitMagnitude               int64
+	subBucketHalfCountMagnitude int64
+	subBucketCount              int64
+	subBucketHalfCount          int64
+	subBucketMask               int64
+	leadingZeroCountBase        int64
+	bucketCount                 int64
+
+	totalCount int64
+	counts     []int64
+}
+
+// Cookies of the V2 histogram encoding.
+const (
+	hdrEncodingCookie           = 0x1c849303 | 0x10
+	hdrCompressedEncodingCookie = 0x1c849304 | 0x10
+	hdrEncodingHeaderSize       = 40
+)
+
+func newHdr(lowestDiscernibleValue, highestTrackableValue int64, significantDigits int64) *hdr {
+	if lowestDiscernibleValue < 1 {
+		lowestDiscernibleValue = 1
+	}
+	if highestTrackableValue < 2*lowestDiscernibleValue {
+		highestTrackableValue = 2 * lowestDiscernibleValue
+	}
+	if significantDigits < 1 || significantDigits > 5 {
+		significantDigits = 3
+	}
+
+	h := &hdr{
+		lowestDiscernibleValue: lowestDiscernibleValue,
+		highestTrackableValue:  highestTrackableValue,
+		significantDigits:      significantDigits,
+	}
+
+	largestValueWithSingleUnitResolution := 2 * int64(math.Pow10(int(significantDigits)))
+	subBucketCountMagnitude := int64(math.Ceil(math.Log2(float64(largestValueWithSingleUnitResolution))))
+	h.subBucketHalfCountMagnitude = subBucketCountMagnitude - 1
+	h.unitMagnitude = int64(bits.Len64(uint64(lowestDiscernibleValue))) - 1
+	h.subBucketCount = 1 << uint(h.subBucketHalfCountMagnitude+1)
+	h.subBucketHalfCount = h.subBucketCount / 2
+	h.subBucketMask = (h.subBucketCount - 1) << uint(h.unitMagnitude)
+	h.leadingZeroCountBase = 64 - h.unitMagnitude - h.subBucketHalfCountMagnitude - 1
+
+	smallestUntrackableValue := h.subBucketCount << uint(h.unitMagnitude)
+	h.bucketCount = 1
+	for smallestUntrackableValue <= highestTrackableValue {
+		if smallestUntrackableValue > math.MaxInt64/2 {
+			h.bucketCount++
+			break
+		}
+		smallestUntrackableValue <<= 1
+		h.bucketCount++
+	}
+
+	h.counts = make([]int64, (h.bucketCount+1)*h.subBucketHalfCount)
+	return h
+}
+
+func (h *hdr) bucketIndex(v int64) int64 {
+	return h.leadingZeroCountBase - int64(bits.LeadingZeros64(uint64(v|h.subBucketMask)))
+}
+
+func (h *hdr) countsIndex(v int64) int {
+	bucketIndex := h.bucketIndex(v)
+	subBucketIndex := v >> uint(bucketIndex+h.unitMagnitude)
+	return int(((bucketIndex + 1) << uint(h.subBucketHalfCountMagnitude)) + subBucketIndex - h.subBucketHalfCount)
+}
+
+// valueFromIndex returns the lowest value counted at the index.
+func (h *hdr) valueFromIndex(index int) int64 {
+	bucketIndex := int64(index)>>uint(h.subBucketHalfCountMagnitude) - 1
+	subBucketIndex := int64(index)&(h.subBucketHalfCount-1) + h.subBucketHalfCount
+	if bucketIndex < 0 {
+		subBucketIndex -= h.subBucketHalfCount
+		bucketIndex = 0
+	}
+	return subBucketIndex << uint(bucketIndex+h.unitMagnitude)
+}
+
+// equivalentRange returns the size of the range of the values counted together with v.
+func (h *hdr) equivalentRange(v int64) int64 {
+	bucketIndex := h.bucketIndex(v)
+	if v>>uint(bucketIndex+h.unitMagnitude) >= h.subBucketCount {
+		bucketIndex++
+	}
+	return 1 << uint(h.unitMagnitude+bucketIndex)
+}
+
+func (h *hdr) highestEquivalentValue(v int64) int64 {
+	return h.valueFromIndex(h.countsIndex(v)) + h.equivalentRange(v) - 1
+}
+
+func (h *hdr) medianEquivalentValue(v int64) int64 {
+	return h.valueFromIndex(h.countsIndex(v)) + h.equivalentRange(v)>>1
+}
+
+// record counts the value, the values out of the trackable range are clamped into it.
+func (h *hdr) record(v int64) {
+	if v < 0 {
+		v = 0
+	} else if v > h.highestTrackableValue {
+		v = h.highestTrackableValue
+	}
+	atomic.AddInt64(&h.counts[h.countsIndex(v)], 1)
+	atomic.AddInt64(&h.totalCount, 1)
+}
+
+// snapshot returns a copy of the histogram, the histogram is reset if reset is true.
+func (h *hdr) snapshot(reset bool) *hdr {
+	s := *h
+	s.counts = make([]int64, len(h.counts))
+	s.totalCount = 0
+	for i := range h.counts {
+		if reset {
+			s.counts[i] = atomic.SwapInt64(&h.counts[i], 0)
+		} else {
+			s.counts[i] = atomic.LoadInt64(&h.counts[i])
+		}
+		s.totalCount += s.counts[i]
+	}
+	if reset {
+		atomic.AddInt64(&h.totalCount, -s.totalCount)
+	}
+	return &s
+}
+
+// The following methods are only used on the snapshots.
+
+func (h *hdr) valueAtPercentile(percentile float64) int64 {
+	countAtPercentile := int64(percentile/100*float64(h.totalCount) + 0.5)
+	if countAtPercentile < 1 {
+		countAtPercentile = 1
+	}
+	var total int64
+	for i, count := range h.counts {
+		total += count
+		if total >= countAtPercentile {
+			return h.highestEquivalentValue(h.valueFromIndex(i))
+		}
+	}
+	return 0
+}
+
+func (h *hdr) min() int64 {
+	for i, count := range h.counts {
+		if count > 0 {
+			return h.valueFromIndex(i)
+		}
+	}
+	return 0
+}
+
+func (h *hdr) max() int64 {
+	for i := len(h.counts) - 1; i >= 0; i-- {
+		if h.counts[i] > 0 {
+			return h.highestEquivalentValue(h.valueFromIndex(i))
+		}
+	}
+	return 0
+}
+
+func (h *hdr) meanAndStdDev() (float64, float64) {
+	if h.totalCount == 0 {
+		return 0, 0
+	}
+	var sum float64
+	for i, count := range h.counts {
+		if count > 0 {
+			sum += float64(h.medianEquivalentValue(h.valueFromIndex(i))) * float64(count)
+		}
+	}
+	mean := sum / float64(h.totalCount)
+	var deviations float64
+	for i, count := range h.counts {
+		if count > 0 {
+			d := float64(h.medianEquivalentValue(h.valueFromIndex(i))) - mean
+			deviations += d * d * float64(count)
+		}
+	}
+	return mean, math.Sqrt(deviations / float64(h.totalCount))
+}
+
+// writePercentiles writes the percentile distribution in the .hgrm format of the
+// HdrHistogram plotter, the values are divided by scale.
+func (h *hdr) writePercentiles(w io.Writer, ticksPerHalfDistance int64, scale float64) error {
+	valueFormat := fmt.Sprintf("%%12.%df", h.significantDigits)
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "%12s %14s %10s %14s\n\n", "Value", "Percentile", "TotalCount", "1/(1-Percentile)")
+
+	var (
+		level float64
+		total int64
+	)
+	for index := 0; h.totalCount > 0 && index < len(h.counts); {
+		count := total + h.counts[index]
+		if h.counts[index] == 0 || 100*float64(count)/float64(h.totalCount) < level {
+			total = count
+			index++
+			continue
+		}
+
+		value := float64(h.highestEquivalentValue(h.valueFromIndex(index))) / scale
+		if level == 100 {
+			fmt.Fprintf(buf, valueFormat+" %2.12f %10d\n", value, level/100, count)
+			break
+		}
+		fmt.Fprintf(buf, valueFormat+" %2.12f %10d %14.2f\n", value, level/100, count, 1/(1-level/100))
+
+		if count >= h.totalCount {
+			// Report the last recorded value again at 100% as the reference implementation.
+			level = 100
+			continue
+		}
+		ticks := ticksPerHalfDistance * int64(math.Pow(2, math.Floor(math.Log2(100/(100-level)))+1))
+		level += 100 / float64(ticks)
+	}
+
+	mean, stdDev := h.meanAndStdDev()
+	fmt.Fprintf(buf, "#[Mean    = "+valueFormat+", StdDeviation   = "+valueFormat+"]\n", mean/scale, stdDev/scale)
+	fmt.Fprintf(buf, "#[Max     = "+valueFormat+", Total count    = %12d]\n", float64(h.max())/scale, h.totalCount)
+	fmt.Fprintf(buf, "#[Buckets = %12d, SubBuckets     = %12d]\n", h.bucketCount, h.subBucketCount)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// encode returns the base64 of the V2 compressed encoding of the histogram, which is
+// used by the histogram logs.
+func (h *hdr) encode() (string, error) {
+	payload := new(bytes.Buffer)
+	var zeros int64
+	var b [binary.MaxVarintLen64]byte
+	for _, count := range h.counts[:h.countsLimit()] {
+		if count == 0 {
+			zeros++
+			continue
+		}
+		if zeros > 0 {
+			// Runs of zero counts are encoded as the negative run lengths.
+			payload.Write(b[:putZigZag(b[:], -zeros)])
+			zeros = 0
+		}
+		payload.Write(b[:putZigZag(b[:], count)])
+	}
+
+	encoded := new(bytes.Buffer)
+	header := []interface{}{
+		int32(hdrEncodingCookie),
+		int32(payload.Len()),
+		int32(0), // normalizing index offset
+		int32(h.significantDigits),
+		h.lowestDiscernibleValue,
+		h.highestTrackableValue,
+		float64(1), // integer to double value conversion ratio
+	}
+	for _, field := range header {
+		binary.Write(encoded, binary.BigEndian, field)
+	}
+	encoded.Write(payload.Bytes())
+
+	compressed := new(bytes.Buffer)
+	zw := zlib.NewWriter(compressed)
+	if _, err := zw.Write(encoded.Bytes()); err != nil {
+		return "", err
+	}
+	if err := zw.Close(); err != nil {
+		return "", err
+	}
+
+	out := new(bytes.Buffer)
+	binary.Write(out, binary.BigEndian, int32(hdrCompressedEncodingCookie))
+	binary.Write(out, binary.BigEndian, int32(compressed.Len()))
+	out.Write(compressed.Bytes())
+	return base64.StdEncoding.EncodeToString(out.Bytes()), nil
+}
+
+// countsLimit returns the length of the counts up to the last non-zero count.
+func (h *hdr) countsLimit() int {
+	for i := len(h.counts) - 1; i >= 0; i-- {
+		if h.counts[i] > 0 {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// latencyHistogram measures the latencies in microseconds and their sum.
+type latencyHistogram struct {
+	hist *hdr
+	sum  int64
+}
+
+func newLatencyHistogram(p *properties.Properties, significantDigits int64) *latencyHistogram {
+	return &latencyHistogram{hist: newHdr(1, p.GetInt64(HdrHistogramMax, HdrHistogramMaxDefault), significantDigits)}
+}
+
+func (l *latencyHistogram) measure(latency time.Duration) {
+	n := int64(latency / time.Microsecond)
+	atomic.AddInt64(&l.sum, n)
+	l.hist.record(n)
+}
+
+// putZigZag encodes v as the ZigZag LEB128 used by the HdrHistogram encoding, whose
+// ninth byte holds the last 8 bits.
+func putZigZag(b []byte, v int64) int {
+	u := uint64(v<<1) ^ uint64(v>>63)
+	for i := 0; i < 8; i++ {
+		if u < 0x80 {
+			b[i] = byte(u)
+			return i + 1
+		}
+		b[i] = byte(u) | 0x80
+		u >>= 7
+	}
+	b[8] = byte(u)
+	return 9
+}
@@ -0,0 +1,204 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package measurement
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/util"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+)
+
+// Properties of the HdrHistogram measurement.
+const (
+	MeasurementType               = "measurementtype"
+	MeasurementTypeDefault        = "histogram"
+	HdrHistogramMax               = "hdrhistogram.max"
+	HdrHistogramMaxDefault        = int64(time.Hour / time.Microsecond)
+	HdrHistogramFileOutput        = "hdrhistogram.fileoutput"
+	HdrHistogramFileOutputDefault = false
+	HdrHistogramOutputPath        = "hdrhistogram.output.path"
+)
+
+// hdrHistogramScale converts the latencies in microseconds to the milliseconds in the
+// exported files, which is the unit expected by the HdrHistogram plotter.
+const hdrHistogramScale = 1000
+
+// hdrHistogram measures the latencies in microseconds with 3 significant digits
+// up to hdrhistogram.max, and exports the interval histogram logs and the
+// percentile distribution if hdrhistogram.fileoutput is set.
+type hdrHistogram struct {
+	total     *hdr
+	interval  *hdr
+	sum       int64
+	min       int64
+	max       int64
+	startTime time.Time
+
+	mu         sync.Mutex
+	path       string
+	log        *os.File
+	logWriter  *bufio.Writer
+	intervalAt time.Time
+}
+
+func newHdrHistogram(p *properties.Properties, op string) *hdrHistogram {
+	h := new(hdrHistogram)
+	h.startTime = time.Now()
+	h.total = newHdr(1, p.GetInt64(HdrHistogramMax, HdrHistogramMaxDefault), 3)
+	h.min = math.MaxInt64
+	h.max = math.MinInt64
+	if !p.GetBool(HdrHistogramFileOutput, HdrHistogramFileOutputDefault) {
+		return h
+	}
+
+	h.interval = newHdr(1, h.total.highestTrackableValue, 3)
+	h.intervalAt = h.startTime
+	h.path = p.GetString(HdrHistogramOutputPath, "") + op
+	var err error
+	if h.log, err = os.Create(h.path + ".hlog"); err != nil {
+		util.Fatalf("create histogram log failed %v", err)
+	}
+	h.logWriter = bufio.NewWriter(h.log)
+	start := float64(h.startTime.UnixNano()) / 1e9
+	fmt.Fprintf(h.logWriter, "#[Histogram log format version 1.3]\n")
+	fmt.Fprintf(h.logWriter, "#[StartTime: %.3f (seconds since epoch), %s]\n", start, h.startTime.Format(time.UnixDate))
+	fmt.Fprintf(h.logWriter, "#[BaseTime: %.3f (seconds since epoch)]\n", start)
+	fmt.Fprintf(h.logWriter, "\"StartTimestamp\",\"Interval_Length\",\"Interval_Max\",\"Interval_Compressed_Histogram\"\n")
+	if err = h.logWriter.Flush(); err != nil {
+		util.Fatalf("write histogram log failed %v", err)
+	}
+	return h
+}
+
+func (h *hdrHistogram) Measure(latency time.Duration) {
+	n := int64(latency / time.Microsecond)
+
+	atomic.AddInt64(&h.sum, n)
+	h.total.record(n)
+	if h.interval != nil {
+		h.interval.record(n)
+	}
+
+	for {
+		oldMin := atomic.LoadInt64(&h.min)
+		if n >= oldMin || atomic.CompareAndSwapInt64(&h.min, oldMin, n) {
+			break
+		}
+	}
+
+	for {
+		oldMax := atomic.LoadInt64(&h.max)
+		if n <= oldMax || atomic.CompareAndSwapInt64(&h.max, oldMax, n) {
+			break
+		}
+	}
+}
+
+func (h *hdrHistogram) Summary() string {
+	return summary(h.getInfo())
+}
+
+func (h *hdrHistogram) Info() ycsb.MeasurementInfo {
+	res := h.getInfo()
+	delete(res, ELAPSED)
+	return newHistogramInfo(res)
+}
+
+func (h *hdrHistogram) getInfo() map[string]interface{} {
+	s := h.total.snapshot(false)
+	count := s.totalCount
+
+	elapsed := time.Now().Sub(h.startTime).Seconds()
+	res := make(map[string]interface{})
+	res[ELAPSED] = elapsed
+	res[COUNT] = count
+	res[QPS] = float64(count) / elapsed
+	res[AVG] = int64(float64(atomic.LoadInt64(&h.sum)) / float64(count))
+	res[MIN] = atomic.LoadInt64(&h.min)
+	res[MAX] = atomic.LoadInt64(&h.max)
+	for _, p := range Percentiles {
+		res[p.Metric] = s.valueAtPercentile(p.Percentile)
+	}
+
+	return res
+}
+
+// export appends the histogram of the interval since the last export to the histogram
+// log, and rewrites the percentile distribution of the whole run.
+func (h *hdrHistogram) export() {
+	if h.interval == nil {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	s := h.interval.snapshot(true)
+	encoded, err := s.encode()
+	if err == nil {
+		fmt.Fprintf(h.logWriter, "%.3f,%.3f,%.3f,%s\n", h.intervalAt.Sub(h.startTime).Seconds(),
+			now.Sub(h.intervalAt).Seconds(), float64(s.max())/hdrHistogramScale, encoded)
+		err = h.logWriter.Flush()
+	}
+	if err != nil {
+		fmt.Printf("write histogram log %s.hlog failed %v\n", h.path, err)
+	}
+	h.intervalAt = now
+
+	f, err := os.Create(h.path + ".hgrm")
+	if err == nil {
+		err = h.total.snapshot(false).writePercentiles(f, 5, hdrHistogramScale)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		fmt.Printf("write percentile distribution %s.hgrm failed %v\n", h.path, err)
+	}
+}
+
+// totals returns the number and the sum in microseconds of the latencies.
+func (h *hdrHistogram) totals() (int64, int64) {
+	return atomic.LoadInt64(&h.total.totalCount), atomic.LoadInt64(&h.sum)
+}
+
+// cumulativeCounts returns the number of the latencies not greater than every upper bound in seconds.
+func (h *hdrHistogram) cumulativeCounts(upperBounds []float64) map[float64]uint64 {
+	counts := make(map[float64]uint64, len(upperBounds))
+	for _, upperBound := range upperBounds {
+		counts[upperBound] = 0
+	}
+	s := h.total.snapshot(false)
+	for i, count := range s.counts {
+		if count == 0 {
+			continue
+		}
+		limit := float64(s.highestEquivalentValue(s.valueFromIndex(i))) / 1e6
+		for _, upperBound := range upperBounds {
+			if limit <= upperBound {
+				counts[upperBound] += uint64(count)
+			}
+		}
+	}
+	return counts
+}
@@ -106,18 +106,21 @@ func (h *histogram) Measure(latency time.Duration) {
 }
 
 func (h *histogram) Summary() string {
-	res := h.getInfo()
+	return summary(h.getInfo())
+}
 
+// summary formats the info of a measurement.
+func summary(res map[string]interface{}) string {
 	buf := new(bytes.Buffer)
 	buf.WriteString(fmt.Sprintf("Takes(s): %.1f, ", res[ELAPSED]))
 	buf.WriteString(fmt.Sprintf("Count: %d, ", res[COUNT]))
 	buf.WriteString(fmt.Sprintf("OPS: %.1f, ", res[QPS]))
 	buf.WriteString(fmt.Sprintf("Avg(us): %d, ", res[AVG]))
 	buf.WriteString(fmt.Sprintf("Min(us): %d, ", res[MIN]))
-	buf.WriteString(fmt.Sprintf("Max(us): %d, ", res[MAX]))
-	buf.WriteString(fmt.Sprintf("99th(us): %d, ", res[PER99TH]))
-	buf.WriteString(fmt.Sprintf("99.9th(us): %d, ", res[PER999TH]))
-	buf.WriteString(fmt.Sprintf("99.99th(us): %d", res[PER9999TH]))
+	buf.WriteString(fmt.Sprintf("Max(us): %d", res[MAX]))
+	for _, p := range Percentiles {
+		buf.WriteString(fmt.Sprintf(", %sth(us): %d", formatPercentile(p.Percentile), res[p.Metric]))
+	}
 
 	return buf.String()
 }
@@ -132,25 +135,21 @@ func (h *histogram) getInfo() map[string]interface{} {
 	sort.Ints(bounds)
 
 	avg := int64(float64(sum) / float64(count))
-	per99 := 0
-	per999 := 0
-	per9999 := 0
+	pers := make([]int, len(Percentiles))
 
 	opCount := int64(0)
 	for _, bound := range bounds {
 		boundCount, _ := h.boundCounts.Get(bound)
 		opCount += boundCount
 		per := float64(opCount) / float64(count)
-		if per99 == 0 && per >= 0.99 {
-			per99 = (bound + 1) * 1000
-		}
-
-		if per999 == 0 && per >= 0.999 {
-			per999 = (bound + 1) * 1000
-		}
-
-		if per9999 == 0 && per >= 0.9999 {
-			per9999 = (bound + 1) * 1000
+		for i, p := range Percentiles {
+			if pers[i] == 0 && per >= p.Percentile/100 {
+				// The upper limit of the bound, which is never greater than the max.
+				pers[i] = int((int64(bound) + 1) * h.boundInterval)
+				if int64(pers[i]) > max {
+					pers[i] = int(max)
+				}
+			}
 		}
 	}
 
@@ -163,13 +162,37 @@ func (h *histogram) getInfo() map[string]interface{} {
 	res[AVG] = avg
 	res[MIN] = min
 	res[MAX] = max
-	res[PER99TH] = per99
-	res[PER999TH] = per999
-	res[PER9999TH] = per9999
+	for i, p := range Percentiles {
+		res[p.Metric] = pers[i]
+	}
 
 	return res
 }
 
+// totals returns the number and the sum in microseconds of the latencies.
+func (h *histogram) totals() (int64, int64) {
+	return atomic.LoadInt64(&h.count), atomic.LoadInt64(&h.sum)
+}
+
+// cumulativeCounts returns the number of the latencies not greater than every upper bound in seconds.
+func (h *histogram) cumulativeCounts(upperBounds []float64) map[float64]uint64 {
+	counts := make(map[float64]uint64, len(upperBounds))
+	for _, upperBound := range upperBounds {
+		counts[upperBound] = 0
+	}
+	for _, bound := range h.boundCounts.Keys() {
+		boundCount, _ := h.boundCounts.Get(bound)
+		// The latencies of the bound are less than its upper limit.
+		limit := float64(int64(bound+1)*h.boundInterval) / 1e6
+		for _, upperBound := range upperBounds {
+			if limit <= upperBound {
+				counts[upperBound] += uint64(boundCount)
+			}
+		}
+	}
+	return counts
+}
+
 type histogramInfo struct {
 	info map[string]interface{}
 }
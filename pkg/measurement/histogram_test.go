@@ -0,0 +1,49 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package measurement
+
+import (
+	"testing"
+	"time"
+
+	"github.com/magiconair/properties"
+)
+
+func TestHistogramPercentiles(t *testing.T) {
+	tests := []struct {
+		buckets string
+		max     int64
+		want    map[string]int
+	}{
+		{"10", 1000, map[string]int{"PER50TH": 510, "PER95TH": 960, "PER99TH": 1000, "PER999TH": 1000, "PER9999TH": 1000}},
+		// The percentiles are never greater than the max.
+		{"1000", 97, map[string]int{"PER50TH": 97, "PER95TH": 97, "PER99TH": 97, "PER999TH": 97, "PER9999TH": 97}},
+	}
+
+	for _, test := range tests {
+		p := properties.NewProperties()
+		p.Set(HistogramBuckets, test.buckets)
+		h := newHistogram(p)
+		for n := int64(1); n <= test.max; n++ {
+			h.Measure(time.Duration(n) * time.Microsecond)
+		}
+
+		info := h.getInfo()
+		for metric, want := range test.want {
+			if got := info[metric]; got != want {
+				t.Errorf("want %s %d of the buckets of %s us, but got %v", metric, want, test.buckets, got)
+			}
+		}
+	}
+}
@@ -14,15 +14,21 @@
 package measurement
 
 import (
+	"bytes"
 	"fmt"
+	"io"
+	"os"
 	"sort"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/magiconair/properties"
 	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/util"
 	"github.com/pingcap/go-ycsb/pkg/ycsb"
+	"go.uber.org/zap"
 )
 
 type measurement struct {
@@ -31,6 +37,30 @@ type measurement struct {
 	p *properties.Properties
 
 	opMeasurement map[string]ycsb.Measurement
+
+	series    *timeSeries
+	intervals map[string]*latencyHistogram
+
+	// threads has the latencies of the operations in every thread if measurement.perthread is set.
+	perThread bool
+	threads   map[threadOp]*latencyHistogram
+
+	// errorClasses counts the failures of the operations measured as <OP>_ERROR by the class.
+	errorClasses map[string]map[string]*int64
+}
+
+// exporter is a measurement which exports its data to files every time the measurements are output.
+type exporter interface {
+	export()
+}
+
+func newMeasurement(p *properties.Properties, op string) ycsb.Measurement {
+	switch p.GetString(MeasurementType, MeasurementTypeDefault) {
+	case "hdrhistogram":
+		return newHdrHistogram(p, op)
+	default:
+		return newHistogram(p)
+	}
 }
 
 func (m *measurement) measure(op string, lan time.Duration) {
@@ -39,16 +69,81 @@ func (m *measurement) measure(op string, lan time.Duration) {
 	m.RUnlock()
 
 	if !ok {
-		opM = newHistogram(m.p)
 		m.Lock()
-		m.opMeasurement[op] = opM
+		if opM, ok = m.opMeasurement[op]; !ok {
+			opM = newMeasurement(m.p, op)
+			m.opMeasurement[op] = opM
+			if m.series != nil {
+				m.intervals[op] = m.series.newHistogram()
+			}
+		}
 		m.Unlock()
 	}
 
 	opM.Measure(lan)
+	if m.series != nil {
+		m.RLock()
+		i := m.intervals[op]
+		m.RUnlock()
+		i.measure(lan)
+	}
+}
+
+func (m *measurement) measureError(op string, class string) {
+	m.RLock()
+	count, ok := m.errorClasses[op][class]
+	m.RUnlock()
+
+	if !ok {
+		m.Lock()
+		if count, ok = m.errorClasses[op][class]; !ok {
+			if m.errorClasses[op] == nil {
+				m.errorClasses[op] = make(map[string]*int64)
+			}
+			count = new(int64)
+			m.errorClasses[op][class] = count
+		}
+		m.Unlock()
+	}
+
+	atomic.AddInt64(count, 1)
+}
+
+func (m *measurement) errorClassInfo() map[string]map[string]int64 {
+	m.RLock()
+	defer m.RUnlock()
+
+	res := make(map[string]map[string]int64, len(m.errorClasses))
+	for op, classes := range m.errorClasses {
+		res[op] = make(map[string]int64, len(classes))
+		for class, count := range classes {
+			res[op][class] = atomic.LoadInt64(count)
+		}
+	}
+	return res
+}
+
+// errorClassSummary returns the counts of the error classes of the operation like ", timeout: 3, other: 1".
+func (m *measurement) errorClassSummary(op string) string {
+	classes := make([]string, 0, len(m.errorClasses[op]))
+	for class := range m.errorClasses[op] {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+
+	buf := new(bytes.Buffer)
+	for _, class := range classes {
+		fmt.Fprintf(buf, ", %s: %d", class, atomic.LoadInt64(m.errorClasses[op][class]))
+	}
+	return buf.String()
 }
 
-func (m *measurement) output() {
+func (m *measurement) output(w io.Writer) {
+	var spreads map[string]*ThreadSpread
+	if m.perThread {
+		spreads = m.threadSpreads()
+	}
+
 	m.RLock()
 	defer m.RUnlock()
 	keys := make([]string, len(m.opMeasurement))
@@ -60,7 +155,28 @@ func (m *measurement) output() {
 	sort.Strings(keys)
 
 	for _, op := range keys {
-		fmt.Printf("%-6s - %s\n", op, m.opMeasurement[op].Summary())
+		fmt.Fprintf(w, "%-6s - %s%s\n", op, m.opMeasurement[op].Summary(), m.errorClassSummary(op))
+	}
+	for _, op := range keys {
+		if spread, ok := spreads[op]; ok {
+			fmt.Fprintf(w, "%-6s - %s\n", op+"_THREADS", spread.Summary())
+		}
+	}
+}
+
+func (m *measurement) export() {
+	m.RLock()
+	defer m.RUnlock()
+
+	for _, opM := range m.opMeasurement {
+		if e, ok := opM.(exporter); ok {
+			e.export()
+		}
+	}
+	if m.series != nil {
+		if err := m.series.write(m.intervals); err != nil {
+			util.Logger().Error("write time series failed", zap.Error(err))
+		}
 	}
 }
 
@@ -88,31 +204,75 @@ func (m *measurement) getOpName() []string {
 
 // InitMeasure initializes the global measurement.
 func InitMeasure(p *properties.Properties) {
+	initPercentiles(p)
 	globalMeasure = new(measurement)
 	globalMeasure.p = p
 	globalMeasure.opMeasurement = make(map[string]ycsb.Measurement, 16)
+	globalMeasure.series = newTimeSeries(p)
+	globalMeasure.intervals = make(map[string]*latencyHistogram, 16)
+	globalMeasure.errorClasses = make(map[string]map[string]*int64)
+	globalMeasure.perThread = p.GetBool(MeasurementPerThread, MeasurementPerThreadDefault)
+	globalMeasure.threads = make(map[threadOp]*latencyHistogram)
 	EnableWarmUp(p.GetInt64(prop.WarmUpTime, 0) > 0)
 }
 
-// Output prints the measurement summary.
+// Output prints the measurement summary, and exports the measurements to the files.
 func Output() {
-	globalMeasure.output()
+	globalMeasure.output(os.Stdout)
+	globalMeasure.export()
+}
+
+// OutputTo writes the measurement summary to w.
+func OutputTo(w io.Writer) {
+	globalMeasure.output(w)
+}
+
+// Export exports the measurements to the files without printing the summary, like the
+// histogram logs and the time series.
+func Export() {
+	globalMeasure.export()
 }
 
 // EnableWarmUp sets whether to enable warm-up.
 func EnableWarmUp(b bool) {
 	if b {
 		atomic.StoreInt32(&warmUp, 1)
-	} else {
-		atomic.StoreInt32(&warmUp, 0)
+	} else if atomic.SwapInt32(&warmUp, 0) == 1 {
+		warmUpFinishedAt.Store(time.Now())
+	}
+	if !b && globalMeasure != nil && globalMeasure.series != nil {
+		// The intervals start when the measurement starts.
+		globalMeasure.series.reset()
 	}
 }
 
+// WarmUpFinishedAt returns the time the last warm-up finished at, it's zero if there is no warm-up.
+func WarmUpFinishedAt() time.Time {
+	t, _ := warmUpFinishedAt.Load().(time.Time)
+	return t
+}
+
 // IsWarmUpFinished returns whether warm-up is finished or not.
 func IsWarmUpFinished() bool {
 	return atomic.LoadInt32(&warmUp) == 0
 }
 
+// State is the state of the global measurement.
+type State struct {
+	m *measurement
+}
+
+// Save returns the state of the global measurement, which can be restored by Restore to
+// continue measuring later, e.g. when multiple databases are measured in turn.
+func Save() State {
+	return State{m: globalMeasure}
+}
+
+// Restore sets the global measurement to the saved state.
+func Restore(s State) {
+	globalMeasure = s.m
+}
+
 // Measure measures the operation.
 func Measure(op string, lan time.Duration) {
 	if IsWarmUpFinished() {
@@ -120,6 +280,49 @@ func Measure(op string, lan time.Duration) {
 	}
 }
 
+// MeasureThread measures the operation in the thread if measurement.perthread is set.
+func MeasureThread(thread int, op string, lan time.Duration) {
+	if globalMeasure.perThread && IsWarmUpFinished() {
+		globalMeasure.measureThread(thread, op, lan)
+	}
+}
+
+// ThreadSpreads returns the spread of the latencies over the threads if measurement.perthread
+// is set, the key of the returned map is the operation name.
+func ThreadSpreads() map[string]*ThreadSpread {
+	return globalMeasure.threadSpreads()
+}
+
+// MeasureError counts the failure of the operation measured as <OP>_ERROR by its class.
+func MeasureError(op string, class string) {
+	if IsWarmUpFinished() {
+		globalMeasure.measureError(op, class)
+	}
+}
+
+// ErrorClasses returns the counts of the failures by the class, the key of the returned map
+// is the operation name like READ_ERROR.
+func ErrorClasses() map[string]map[string]int64 {
+	return globalMeasure.errorClassInfo()
+}
+
+// TimeSeries returns the results of the intervals retained if push.remote_write or report.db is set.
+func TimeSeries() []TimeSeriesPoint {
+	if globalMeasure.series == nil {
+		return nil
+	}
+	return globalMeasure.series.retained()
+}
+
+// LastInterval returns the results of the last measurement interval if the time series is enabled,
+// like by timeseries.file or tui.
+func LastInterval() []TimeSeriesPoint {
+	if globalMeasure.series == nil {
+		return nil
+	}
+	return globalMeasure.series.lastInterval()
+}
+
 // Info returns all the operations MeasurementInfo.
 // The key of returned map is the operation name.
 func Info() map[string]ycsb.MeasurementInfo {
@@ -131,5 +334,46 @@ func GetOpNames() []string {
 	return globalMeasure.getOpName()
 }
 
+// primaryOperations are the operations of the workloads on the records, the others like
+// INTENDED_<OP>, QUEUE_DELAY, VERIFY or the retries of the drivers are measured besides them.
+var primaryOperations = map[string]bool{
+	"READ":              true,
+	"BATCH_READ":        true,
+	"SCAN":              true,
+	"REVERSE_SCAN":      true,
+	"UPDATE":            true,
+	"BATCH_UPDATE":      true,
+	"INSERT":            true,
+	"BATCH_INSERT":      true,
+	"DELETE":            true,
+	"BATCH_DELETE":      true,
+	"READ_MODIFY_WRITE": true,
+	"QUERY":             true,
+}
+
+// IsPrimaryOperation returns whether the operation is one of the workload, the operations on the
+// deleted records measured as <OP>_NOT_FOUND are included, so the totals of the operations and the
+// throughput only count them.
+func IsPrimaryOperation(op string) bool {
+	return primaryOperations[strings.TrimSuffix(op, "_NOT_FOUND")]
+}
+
 var globalMeasure *measurement
 var warmUp int32 // use as bool, 1 means in warmup progress, 0 means warmup finished.
+var warmUpFinishedAt atomic.Value
+
+func init() {
+	prop.Register(prop.String,
+		MeasurementType, MeasurementPercentiles, HdrHistogramOutputPath, TimeSeriesFile, TimeSeriesFormat)
+	prop.Register(prop.Int, HdrHistogramMax, HistogramBuckets, ShardCount)
+	prop.Register(prop.Bool, HdrHistogramFileOutput, MeasurementPerThread)
+
+	prop.SetDefault(MeasurementType, MeasurementTypeDefault)
+	prop.SetDefault(MeasurementPercentiles, MeasurementPercentilesDefault)
+	prop.SetDefault(TimeSeriesFormat, TimeSeriesFormatDefault)
+	prop.SetDefault(HdrHistogramMax, HdrHistogramMaxDefault)
+	prop.SetDefault(HistogramBuckets, HistogramBucketsDefault)
+	prop.SetDefault(ShardCount, ShardCountDefault)
+	prop.SetDefault(HdrHistogramFileOutput, HdrHistogramFileOutputDefault)
+	prop.SetDefault(MeasurementPerThread, MeasurementPerThreadDefault)
+}
@@ -0,0 +1,87 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package measurement
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/util"
+)
+
+// Properties of the reported percentiles.
+const (
+	MeasurementPercentiles        = "measurement.percentiles"
+	MeasurementPercentilesDefault = "50,95,99,99.9,99.99"
+)
+
+// Percentile is a reported percentile of the latencies.
+type Percentile struct {
+	// Metric is the name of the percentile in the MeasurementInfo, like PER999TH.
+	Metric     string
+	Percentile float64
+}
+
+// Name returns the name of the percentile in the exported results, like p99.9.
+func (p Percentile) Name() string {
+	return "p" + formatPercentile(p.Percentile)
+}
+
+// Percentiles are the percentiles reported by the measurements, which are set by
+// measurement.percentiles in InitMeasure, the min and the max are always reported.
+var Percentiles = parsePercentiles(MeasurementPercentilesDefault)
+
+func initPercentiles(p *properties.Properties) {
+	Percentiles = parsePercentiles(p.GetString(MeasurementPercentiles, MeasurementPercentilesDefault))
+}
+
+// parsePercentiles parses the comma separated percentiles in (0, 100], like "50,99,99.9".
+func parsePercentiles(s string) []Percentile {
+	var values []float64
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(field, 64)
+		if err != nil || v <= 0 || v > 100 {
+			util.Fatalf("invalid percentile %q in %s, must be in (0, 100]", field, MeasurementPercentiles)
+		}
+		values = append(values, v)
+	}
+	sort.Float64s(values)
+
+	percentiles := make([]Percentile, 0, len(values))
+	metrics := make(map[string]float64, len(values))
+	for i, v := range values {
+		if i > 0 && v == values[i-1] {
+			continue
+		}
+		// The metric drops the dot, like PER999TH of 99.9, which is the same as the one of 9.99.
+		metric := "PER" + strings.Replace(formatPercentile(v), ".", "", -1) + "TH"
+		if other, ok := metrics[metric]; ok {
+			util.Fatalf("percentiles %s and %s in %s can't be reported together", formatPercentile(other),
+				formatPercentile(v), MeasurementPercentiles)
+		}
+		metrics[metric] = v
+		percentiles = append(percentiles, Percentile{Metric: metric, Percentile: v})
+	}
+	return percentiles
+}
+
+func formatPercentile(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,59 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package measurement
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePercentiles(t *testing.T) {
+	tests := []struct {
+		s           string
+		percentiles []Percentile
+	}{
+		{"", []Percentile{}},
+		{"99", []Percentile{{"PER99TH", 99}}},
+		{MeasurementPercentilesDefault, []Percentile{
+			{"PER50TH", 50}, {"PER95TH", 95}, {"PER99TH", 99}, {"PER999TH", 99.9}, {"PER9999TH", 99.99},
+		}},
+		// The percentiles are sorted and deduplicated.
+		{" 99.9, 50,99.9 ,100", []Percentile{{"PER50TH", 50}, {"PER999TH", 99.9}, {"PER100TH", 100}}},
+		{"0.5,5", []Percentile{{"PER05TH", 0.5}, {"PER5TH", 5}}},
+	}
+
+	for _, test := range tests {
+		percentiles := parsePercentiles(test.s)
+		if !reflect.DeepEqual(percentiles, test.percentiles) {
+			t.Errorf("want %v of %q, but got %v", test.percentiles, test.s, percentiles)
+		}
+	}
+}
+
+func TestPercentileName(t *testing.T) {
+	tests := []struct {
+		percentile float64
+		name       string
+	}{
+		{50, "p50"},
+		{99.9, "p99.9"},
+		{0.5, "p0.5"},
+	}
+
+	for _, test := range tests {
+		if name := (Percentile{Percentile: test.percentile}).Name(); name != test.name {
+			t.Errorf("want %s of %v, but got %s", test.name, test.percentile, name)
+		}
+	}
+}
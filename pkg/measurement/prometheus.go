@@ -0,0 +1,95 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package measurement
+
+import (
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// latencyBuckets are the upper bounds in seconds of the latency buckets exported to Prometheus,
+// from 1ms to about 16s, the resolution of the buckets is limited by histogram.buckets
+// unless the latencies are measured by hdrhistogram.
+var latencyBuckets = prometheus.ExponentialBuckets(0.001, 2, 15)
+
+// promHistogram is a measurement which can be exported as a Prometheus histogram.
+type promHistogram interface {
+	totals() (int64, int64)
+	cumulativeCounts(upperBounds []float64) map[float64]uint64
+}
+
+// collector exports the measurements to Prometheus when they are scraped, the operations
+// measured as <OP>_ERROR are exported as the errors of <OP>, with their own latency histogram.
+type collector struct {
+	operations *prometheus.Desc
+	errors     *prometheus.Desc
+	latency    *prometheus.Desc
+	errLatency *prometheus.Desc
+}
+
+func newCollector() *collector {
+	return &collector{
+		operations: prometheus.NewDesc("ycsb_operations_total",
+			"The number of the completed operations.", []string{"operation"}, nil),
+		errors: prometheus.NewDesc("ycsb_operation_errors_total",
+			"The number of the failed operations.", []string{"operation"}, nil),
+		latency: prometheus.NewDesc("ycsb_operation_latency_seconds",
+			"The latency of the completed operations.", []string{"operation"}, nil),
+		errLatency: prometheus.NewDesc("ycsb_operation_error_latency_seconds",
+			"The latency of the failed operations.", []string{"operation"}, nil),
+	}
+}
+
+// Describe implements the prometheus Collector Describe interface.
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.operations
+	ch <- c.errors
+	ch <- c.latency
+	ch <- c.errLatency
+}
+
+// Collect implements the prometheus Collector Collect interface.
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	m := globalMeasure
+	if m == nil {
+		return
+	}
+
+	m.RLock()
+	histograms := make(map[string]promHistogram, len(m.opMeasurement))
+	for op, opM := range m.opMeasurement {
+		if h, ok := opM.(promHistogram); ok {
+			histograms[op] = h
+		}
+	}
+	m.RUnlock()
+
+	for op, h := range histograms {
+		count, sum := h.totals()
+		if strings.HasSuffix(op, "_ERROR") {
+			op = strings.TrimSuffix(op, "_ERROR")
+			ch <- prometheus.MustNewConstMetric(c.errors, prometheus.CounterValue, float64(count), op)
+			ch <- prometheus.MustNewConstHistogram(c.errLatency, uint64(count), float64(sum)/1e6, h.cumulativeCounts(latencyBuckets), op)
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(c.operations, prometheus.CounterValue, float64(count), op)
+		ch <- prometheus.MustNewConstHistogram(c.latency, uint64(count), float64(sum)/1e6, h.cumulativeCounts(latencyBuckets), op)
+	}
+}
+
+func init() {
+	prometheus.MustRegister(newCollector())
+}
@@ -0,0 +1,137 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package measurement
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// Properties of the latencies of the threads.
+const (
+	MeasurementPerThread        = "measurement.perthread"
+	MeasurementPerThreadDefault = false
+)
+
+// threadOp is the key of the latencies of an operation in a thread.
+type threadOp struct {
+	thread int
+	op     string
+}
+
+// ThreadStat is the latency of an operation in a thread.
+type ThreadStat struct {
+	Thread int   `json:"thread"`
+	Count  int64 `json:"count"`
+	Avg    int64 `json:"avg_us"`
+	P99    int64 `json:"p99_us"`
+}
+
+// ThreadSpread is the spread of the latencies of an operation over the threads, which shows
+// the unfair routing of the connections through the proxies and the load balancers.
+type ThreadSpread struct {
+	Threads       int          `json:"threads"`
+	FastestThread int          `json:"fastest_thread"`
+	FastestP99    int64        `json:"fastest_p99_us"`
+	SlowestThread int          `json:"slowest_thread"`
+	SlowestP99    int64        `json:"slowest_p99_us"`
+	MeanP99       int64        `json:"mean_p99_us"`
+	StdDevP99     int64        `json:"stddev_p99_us"`
+	MinCount      int64        `json:"min_count"`
+	MaxCount      int64        `json:"max_count"`
+	PerThread     []ThreadStat `json:"per_thread"`
+}
+
+func (m *measurement) measureThread(thread int, op string, lan time.Duration) {
+	key := threadOp{thread: thread, op: op}
+	m.RLock()
+	h, ok := m.threads[key]
+	m.RUnlock()
+
+	if !ok {
+		m.Lock()
+		if h, ok = m.threads[key]; !ok {
+			// The latencies of the threads have 2 significant digits to save the memory.
+			h = newLatencyHistogram(m.p, 2)
+			m.threads[key] = h
+		}
+		m.Unlock()
+	}
+
+	h.measure(lan)
+}
+
+func (m *measurement) threadSpreads() map[string]*ThreadSpread {
+	m.RLock()
+	defer m.RUnlock()
+
+	stats := make(map[string][]ThreadStat)
+	for key, h := range m.threads {
+		s := h.hist.snapshot(false)
+		if s.totalCount == 0 {
+			continue
+		}
+		stats[key.op] = append(stats[key.op], ThreadStat{
+			Thread: key.thread,
+			Count:  s.totalCount,
+			Avg:    atomic.LoadInt64(&h.sum) / s.totalCount,
+			P99:    s.valueAtPercentile(99),
+		})
+	}
+
+	spreads := make(map[string]*ThreadSpread, len(stats))
+	for op, threadStats := range stats {
+		sort.Slice(threadStats, func(i, j int) bool {
+			return threadStats[i].Thread < threadStats[j].Thread
+		})
+		spread := &ThreadSpread{
+			Threads:   len(threadStats),
+			MinCount:  math.MaxInt64,
+			PerThread: threadStats,
+		}
+		var sum, squareSum float64
+		for i, stat := range threadStats {
+			if i == 0 || stat.P99 < spread.FastestP99 {
+				spread.FastestThread, spread.FastestP99 = stat.Thread, stat.P99
+			}
+			if i == 0 || stat.P99 > spread.SlowestP99 {
+				spread.SlowestThread, spread.SlowestP99 = stat.Thread, stat.P99
+			}
+			if stat.Count < spread.MinCount {
+				spread.MinCount = stat.Count
+			}
+			if stat.Count > spread.MaxCount {
+				spread.MaxCount = stat.Count
+			}
+			sum += float64(stat.P99)
+			squareSum += float64(stat.P99) * float64(stat.P99)
+		}
+		mean := sum / float64(len(threadStats))
+		spread.MeanP99 = int64(mean)
+		spread.StdDevP99 = int64(math.Sqrt(math.Max(squareSum/float64(len(threadStats))-mean*mean, 0)))
+		spreads[op] = spread
+	}
+	return spreads
+}
+
+// Summary returns the summary of the spread like the summary of the measurements.
+func (s *ThreadSpread) Summary() string {
+	return fmt.Sprintf("Threads: %d, Fastest 99th(us): %d (thread %d), Slowest 99th(us): %d (thread %d), "+
+		"Mean 99th(us): %d, StdDev 99th(us): %d, Min Count: %d, Max Count: %d",
+		s.Threads, s.FastestP99, s.FastestThread, s.SlowestP99, s.SlowestThread,
+		s.MeanP99, s.StdDevP99, s.MinCount, s.MaxCount)
+}
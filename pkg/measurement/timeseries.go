@@ -0,0 +1,218 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package measurement
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/util"
+)
+
+// Properties of the time series of the intervals.
+const (
+	TimeSeriesFile          = "timeseries.file"
+	TimeSeriesFormat        = "timeseries.format"
+	TimeSeriesFormatDefault = "csv"
+)
+
+// TimeSeriesPoint is the result of an operation in an interval, the latencies are in microseconds.
+type TimeSeriesPoint struct {
+	Time        time.Time        `json:"time"`
+	Elapsed     float64          `json:"elapsed_seconds"`
+	Operation   string           `json:"operation"`
+	Count       int64            `json:"count"`
+	Throughput  float64          `json:"throughput"`
+	Mean        int64            `json:"mean_us"`
+	Min         int64            `json:"min_us"`
+	Max         int64            `json:"max_us"`
+	Percentiles map[string]int64 `json:"percentiles_us"`
+}
+
+// timeSeries writes the results of every operation in every measurement interval to
+// timeseries.file, as the CSV rows or the JSON lines, and retains them in memory if they
+// are pushed to push.remote_write or inserted into report.db after the run.
+type timeSeries struct {
+	mu         sync.Mutex
+	p          *properties.Properties
+	file       *os.File
+	writer     *bufio.Writer
+	csv        *csv.Writer
+	retain     bool
+	points     []TimeSeriesPoint
+	last       []TimeSeriesPoint
+	start      time.Time
+	intervalAt time.Time
+}
+
+func newTimeSeries(p *properties.Properties) *timeSeries {
+	path := p.GetString(TimeSeriesFile, "")
+	retain := p.GetString(prop.PushRemoteWrite, "") != "" || p.GetString(prop.ReportDB, "") != ""
+	if path == "" && !retain && !p.GetBool(prop.TUI, prop.TUIDefault) {
+		return nil
+	}
+
+	t := &timeSeries{p: p, retain: retain}
+	if path != "" {
+		t.create(path)
+	}
+	t.reset()
+	return t
+}
+
+func (t *timeSeries) create(path string) {
+	format := t.p.GetString(TimeSeriesFormat, TimeSeriesFormatDefault)
+	if format != "csv" && format != "json" {
+		util.Fatalf("unsupported timeseries.format %s, must be csv or json", format)
+	}
+	var err error
+	if t.file, err = os.Create(path); err != nil {
+		util.Fatalf("create time series file failed %v", err)
+	}
+	t.writer = bufio.NewWriter(t.file)
+	if format == "csv" {
+		t.csv = csv.NewWriter(t.writer)
+		header := []string{"time", "elapsed_seconds", "operation", "count", "throughput", "mean_us", "min_us", "max_us"}
+		for _, p := range Percentiles {
+			header = append(header, p.Name()+"_us")
+		}
+		t.csv.Write(header)
+	}
+}
+
+func (t *timeSeries) newHistogram() *latencyHistogram {
+	return newLatencyHistogram(t.p, 3)
+}
+
+// reset starts the time series again, the results before are dropped.
+func (t *timeSeries) reset() {
+	t.mu.Lock()
+	t.start = time.Now()
+	t.intervalAt = t.start
+	t.points = nil
+	t.last = nil
+	t.mu.Unlock()
+}
+
+// write writes the results of the interval since the last write, and starts the next interval.
+func (t *timeSeries) write(intervals map[string]*latencyHistogram) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ops := make([]string, 0, len(intervals))
+	for op := range intervals {
+		ops = append(ops, op)
+	}
+	sort.Strings(ops)
+
+	now := time.Now()
+	secs := now.Sub(t.intervalAt).Seconds()
+	t.last = make([]TimeSeriesPoint, 0, len(ops))
+	for _, op := range ops {
+		i := intervals[op]
+		s := i.hist.snapshot(true)
+		sum := atomic.SwapInt64(&i.sum, 0)
+		point := TimeSeriesPoint{
+			Time:      now,
+			Elapsed:   now.Sub(t.start).Seconds(),
+			Operation: op,
+			Count:     s.totalCount,
+		}
+		// The operations without any result in the interval are written with the zero
+		// throughput, so the stalls can be seen.
+		if s.totalCount > 0 {
+			point.Throughput = float64(s.totalCount) / secs
+			point.Mean = sum / s.totalCount
+			point.Min = s.min()
+			point.Max = s.max()
+		}
+		point.Percentiles = make(map[string]int64, len(Percentiles))
+		for _, p := range Percentiles {
+			point.Percentiles[p.Name()] = s.valueAtPercentile(p.Percentile)
+		}
+		t.last = append(t.last, point)
+		if t.retain {
+			t.points = append(t.points, point)
+		}
+		if t.writer == nil {
+			continue
+		}
+		if err := t.writePoint(point); err != nil {
+			return err
+		}
+	}
+	t.intervalAt = now
+
+	if t.writer == nil {
+		return nil
+	}
+	if t.csv != nil {
+		t.csv.Flush()
+		if err := t.csv.Error(); err != nil {
+			return err
+		}
+	}
+	return t.writer.Flush()
+}
+
+func (t *timeSeries) writePoint(point TimeSeriesPoint) error {
+	if t.csv == nil {
+		b, err := json.Marshal(point)
+		if err != nil {
+			return err
+		}
+		t.writer.Write(b)
+		return t.writer.WriteByte('\n')
+	}
+
+	record := []string{
+		point.Time.Format(time.RFC3339Nano),
+		strconv.FormatFloat(point.Elapsed, 'f', 3, 64),
+		point.Operation,
+		strconv.FormatInt(point.Count, 10),
+		strconv.FormatFloat(point.Throughput, 'f', 1, 64),
+		strconv.FormatInt(point.Mean, 10),
+		strconv.FormatInt(point.Min, 10),
+		strconv.FormatInt(point.Max, 10),
+	}
+	for _, p := range Percentiles {
+		record = append(record, strconv.FormatInt(point.Percentiles[p.Name()], 10))
+	}
+	return t.csv.Write(record)
+}
+
+// lastInterval returns the points of the last interval.
+func (t *timeSeries) lastInterval() []TimeSeriesPoint {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.last
+}
+
+// retained returns the points retained since the time series starts.
+func (t *timeSeries) retained() []TimeSeriesPoint {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return append([]TimeSeriesPoint(nil), t.points...)
+}
@@ -19,6 +19,18 @@ const (
 	InsertCount        = "insertcount"
 	InsertStartDefault = int64(0)
 
+	// Used by the independent clients to partition the keyspace, the client clientid of clientcount loads
+	// its share of [insertstart, insertstart + insertcount) and runs its share of operationcount, and
+	// the keys inserted in the run phase by the clients don't overlap
+	ClientID           = "clientid"
+	ClientIDDefault    = int64(0)
+	ClientCount        = "clientcount"
+	ClientCountDefault = int64(1)
+
+	// The random sources of the threads are seeded by randomseed, which is picked from the clock and
+	// recorded in the output if it is not set, so a run can be repeated with the same operations
+	RandomSeed = "randomseed"
+
 	OperationCount     = "operationcount"
 	RecordCount        = "recordcount"
 	RecordCountDefault = int64(0)
@@ -26,11 +38,16 @@ const (
 	DB                 = "db"
 	Exporter           = "exporter"
 	ExportFile         = "exportfile"
+	OutputStyle        = "outputstyle"
+	OutputStyleDefault = "text"
 	ThreadCount        = "threadcount"
 	ThreadCountDefault = int64(200)
 	Target             = "target"
 	MaxExecutiontime   = "maxexecutiontime"
 	WarmUpTime         = "warmuptime"
+	WarmUpTimeAlias    = "warmup_time"
+	DrainTime          = "draintime"
+	DrainTimeDefault   = int64(10)
 	DoTransactions     = "dotransactions"
 	Status             = "status"
 	Label              = "label"
@@ -38,8 +55,41 @@ const (
 	BatchSize        = "batch.size"
 	DefaultBatchSize = int(1)
 
+	// The max number of the outstanding operations of a thread if the DB supports the asynchronous operations.
+	RequestOutstanding        = "request.outstanding"
+	RequestOutstandingDefault = 1
+
+	// "closed", "poisson", "deterministic", the operations are scheduled at the target rate regardless of their
+	// completion in the open-loop "poisson" and "deterministic" modes
+	RequestArrival        = "request.arrival"
+	RequestArrivalDefault = "closed"
+	// "perthread", "global", every thread is throttled at its share of the target with a jittered start, or all
+	// the threads share the schedule of the target, only in the closed mode
+	RateLimiter        = "ratelimiter"
+	RateLimiterDefault = "perthread"
+	// Serve the control endpoints on debug.pprof to pause, resume and tune the run, see the README
+	Control        = "control"
+	ControlDefault = false
+	// The max number of the scheduled operations waiting in the queue of a thread in the open-loop modes
+	RequestQueueSize        = "request.queue_size"
+	RequestQueueSizeDefault = 1000
+
+	// "constant", "sine", "piecewise", the target is multiplied by the shape over time, the sine shape starts at
+	// 1 - amplitude and peaks at 1 + amplitude in the middle of the period, the piecewise shape interpolates the
+	// multipliers between the points like "0s:0.2,12h:1,24h:0.2" and repeats after the last point
+	TargetShape                 = "target.shape"
+	TargetShapeDefault          = "constant"
+	TargetShapePeriod           = "target.shape.period"
+	TargetShapePeriodDefault    = "1h"
+	TargetShapeAmplitude        = "target.shape.amplitude"
+	TargetShapeAmplitudeDefault = float64(0.5)
+	TargetShapePoints           = "target.shape.points"
+
 	TableName         = "table"
 	TableNameDefault  = "usertable"
+	// If tablecount > 1, keys are spread over the tables usertable0..usertableN-1
+	TableCount        = "tablecount"
+	TableCountDefault = int64(1)
 	FieldCount        = "fieldcount"
 	FieldCountDefault = int64(10)
 	// "uniform", "zipfian", "constant", "histogram"
@@ -47,6 +97,9 @@ const (
 	FieldLengthDistributionDefault = "constant"
 	FieldLength                    = "fieldlength"
 	FieldLengthDefault             = int64(100)
+	// The min field length of the "uniform" and "zipfian" distributions, fieldlength is the max
+	MinFieldLength        = "minfieldlength"
+	MinFieldLengthDefault = int64(1)
 	// Used if fieldlengthdistribution is "histogram"
 	FieldLengthHistogramFile         = "fieldlengthhistogram"
 	FieldLengthHistogramFileDefault  = "hist.txt"
@@ -66,36 +119,212 @@ const (
 	ScanProportionDefault            = float64(0.0)
 	ReadModifyWriteProportion        = "readmodifywriteproportion"
 	ReadModifyWriteProportionDefault = float64(0.0)
-	// "uniform", "zipfian", "latest"
+	DeleteProportion                 = "deleteproportion"
+	DeleteProportionDefault          = float64(0.0)
+	ReverseScanProportion            = "reversescanproportion"
+	ReverseScanProportionDefault     = float64(0.0)
+
+	// The comma separated names of the phases run back-to-back, every phase runs for phase.<name>.duration
+	// with the operation proportions overridden by phase.<name>.<proportion>, like phase.write.updateproportion
+	Phases      = "phases"
+	PhasePrefix = "phase."
+
+	// "uniform", "sequential", "zipfian", "latest", "hotspot", "shifting_hotspot", "exponential", "file"
 	RequestDistribution        = "requestdistribution"
 	RequestDistributionDefault = "uniform"
 	ZeroPadding                = "zeropadding"
 	ZeroPaddingDefault         = int64(1)
 	MaxScanLength              = "maxscanlength"
 	MaxScanLengthDefault       = int64(1000)
-	// "uniform", "zipfian"
+	// "uniform", "zipfian", "constant"
 	ScanLengthDistribution        = "scanlengthdistribution"
 	ScanLengthDistributionDefault = "uniform"
-	// "ordered", "hashed"
-	InsertOrder                   = "insertorder"
-	InsertOrderDefault            = "hashed"
-	HotspotDataFraction           = "hotspotdatafraction"
-	HotspotDataFractionDefault    = float64(0.2)
-	HotspotOpnFraction            = "hotspotopnfraction"
-	HotspotOpnFractionDefault     = float64(0.8)
+	// "ordered", "hashed", and keytype is "sequential", "hashed", "uuid" or "snowflake", which overrides insertorder if set
+	InsertOrder                = "insertorder"
+	InsertOrderDefault         = "hashed"
+	KeyType                    = "keytype"
+	HotspotDataFraction        = "hotspotdatafraction"
+	HotspotDataFractionDefault = float64(0.2)
+	HotspotOpnFraction         = "hotspotopnfraction"
+	HotspotOpnFractionDefault  = float64(0.8)
+	// The CSV file of the "file" request distribution, every line is "bucket,weight", the keys are split
+	// into the buckets evenly
+	RequestDistributionFile = "requestdistributionfile"
+	// The hot set of the shifting_hotspot distribution moves every hotspotshiftinterval seconds
+	HotspotShiftInterval          = "hotspotshiftinterval"
+	HotspotShiftIntervalDefault   = int64(60)
 	InsertionRetryLimit           = "core_workload_insertion_retry_limit"
 	InsertionRetryLimitDefault    = int64(0)
 	InsertionRetryInterval        = "core_workload_insertion_retry_interval"
 	InsertionRetryIntervalDefault = int64(3)
 
+	// The load saves the progress of the client to checkpoint.file every checkpoint.interval seconds,
+	// and continues from the checkpoint if checkpoint.resume is set
+	CheckpointFile            = "checkpoint.file"
+	CheckpointInterval        = "checkpoint.interval"
+	CheckpointIntervalDefault = int64(10)
+	CheckpointResume          = "checkpoint.resume"
+	CheckpointResumeDefault   = false
+
+	// The fields are named fieldnameprefix followed by the field index. Reads and updates access the comma separated
+	// readfields and writefields if set, otherwise all the fields or a random field by readallfields and writeallfields
+	FieldNamePrefix        = "fieldnameprefix"
+	FieldNamePrefixDefault = "field"
+	ReadFields             = "readfields"
+	WriteFields            = "writefields"
+
+	// "random", "words", "json", "compressible", the kind of the field values, "words" are the space separated words
+	// of the dictionary, "json" are the JSON documents with nested fields, and "compressible" can be compressed at
+	// about fieldvaluecompressionratio
+	FieldValueKind                    = "fieldvaluekind"
+	FieldValueKindDefault             = "random"
+	FieldValueDictionary              = "fieldvaluedictionary"
+	FieldValueCompressionRatio        = "fieldvaluecompressionratio"
+	FieldValueCompressionRatioDefault = float64(2)
+
+	// The deterministic values are derived from the key, the field and the generation, the values of the generations
+	// in [min_generation, generation] are accepted by the verification, so bumping the generation of every run
+	// and setting min_generation detects the lost updates
+	DataIntegrityGeneration           = "dataintegrity.generation"
+	DataIntegrityGenerationDefault    = int64(0)
+	DataIntegrityMinGeneration        = "dataintegrity.min_generation"
+	DataIntegrityMinGenerationDefault = int64(0)
+
+	// The components of the composite keys, like "tenant_id:100:zipfian,user_id:1000:uniform", every component is
+	// name:cardinality[:distribution], the distribution is "uniform" or "zipfian" in the run phase, and the product
+	// of the cardinalities must be recordcount. The SQL databases create the composite primary key of the components
+	KeyComposition                 = "keycomposition"
+	KeyCompositionSeparator        = "keycomposition.separator"
+	KeyCompositionSeparatorDefault = ":"
+
 	ExponentialPercentile        = "exponential.percentile"
 	ExponentialPercentileDefault = float64(95)
 	ExponentialFrac              = "exponential.frac"
 	ExponentialFracDefault       = float64(0.8571428571)
 
+	// Used by the transactional workload, every transaction accesses n keys,
+	// n is chosen by the "constant", "uniform" or "zipfian" distribution in [min_keys, max_keys]
+	TransactionKeysDistribution        = "transaction.keys_distribution"
+	TransactionKeysDistributionDefault = "uniform"
+	TransactionMinKeys                 = "transaction.min_keys"
+	TransactionMinKeysDefault          = int64(1)
+	TransactionMaxKeys                 = "transaction.max_keys"
+	TransactionMaxKeysDefault          = int64(4)
+	// The proportion of the keys read in a transaction, the others are updated
+	TransactionReadProportion        = "transaction.read_proportion"
+	TransactionReadProportionDefault = float64(0.5)
+
+	// Used by the lifecycle workload, a record is deleted after it is read or updated lifetime times, the lifetime is
+	// chosen by the "constant", "uniform" or "zipfian" distribution in [min_lifetime, max_lifetime], and the record
+	// is inserted again after it is accessed dead_lifetime times while deleted
+	LifecycleLifetimeDistribution        = "lifecycle.lifetime_distribution"
+	LifecycleLifetimeDistributionDefault = "uniform"
+	LifecycleMinLifetime                 = "lifecycle.min_lifetime"
+	LifecycleMinLifetimeDefault          = int64(5)
+	LifecycleMaxLifetime                 = "lifecycle.max_lifetime"
+	LifecycleMaxLifetimeDefault          = int64(20)
+	LifecycleDeadLifetime                = "lifecycle.dead_lifetime"
+	LifecycleDeadLifetimeDefault         = int64(5)
+	// The proportion of the reads in the accesses of the live records, the others are updates
+	LifecycleReadProportion        = "lifecycle.read_proportion"
+	LifecycleReadProportionDefault = float64(0.5)
+
+	// Used by the query workload, queryproportion of the operations look up the records by the value of query.field,
+	// the field is filled with query.cardinality distinct values, and a query returns at most query.limit records
+	QueryProportion         = "queryproportion"
+	QueryProportionDefault  = float64(0.1)
+	QueryField              = "query.field"
+	QueryFieldDefault       = "field0"
+	QueryCardinality        = "query.cardinality"
+	QueryCardinalityDefault = int64(100)
+	QueryLimit              = "query.limit"
+	QueryLimitDefault       = int64(100)
+
+	// Used by the append workload, the reads, updates and scans access the window of the latest records, which is
+	// a count like "10000" or a duration like "5m", and append.tail_proportion of them access the tail before the
+	// window, which reaches back to the latest append.tail records or to the first record if append.tail is not set
+	AppendWindow                = "append.window"
+	AppendWindowDefault         = "1000"
+	AppendTail                  = "append.tail"
+	AppendTailProportion        = "append.tail_proportion"
+	AppendTailProportionDefault = float64(0.1)
+
+	// Used by the replay workload, every line of the trace file is "op,key,fields,timestamp", the op is one of
+	// read, update, insert, scan and delete, the fields are separated by ';' and the timestamp is in microseconds.
+	// The operations are replayed as fast as possible if replay.speedup is 0, otherwise the original inter-arrival
+	// times are divided by replay.speedup
+	ReplayFile           = "replay.file"
+	ReplaySpeedup        = "replay.speedup"
+	ReplaySpeedupDefault = float64(0)
+
+	// Used by the verify command, the field of the scanned records which has the key, and the number of
+	// the records of a scan
+	VerifyKeyField         = "verify.key_field"
+	VerifyKeyFieldDefault  = "YCSB_KEY"
+	VerifyScanBatch        = "verify.scan_batch"
+	VerifyScanBatchDefault = 1000
+
+	// The interval in seconds to print the results of the run
+	MeasurementInterval        = "measurement.interval"
+	MeasurementIntervalDefault = int64(10)
+
 	DebugPprof        = "debug.pprof"
 	DebugPprofDefault = ":6060"
 
+	// The level of the logs, debug, info, warn or error. The logs are written to log.file, or stderr if
+	// it is not set, in log.format, json or console, which is json for a file and console for stderr by
+	// default
+	LogLevel        = "log.level"
+	LogLevelDefault = "info"
+	LogFile         = "log.file"
+	LogFormat       = "log.format"
+
+	// Show the live dashboard of the run in the terminal instead of printing the results of every
+	// measurement.interval, which is 1 second by default with the dashboard
+	TUI        = "tui"
+	TUIDefault = false
+
+	// The directory to write the profiles of the client captured in a window of the run to, the window
+	// starts profile.delay after the start of the run and lasts profile.duration, the profiles are not
+	// captured if it is not set. profile.types are the comma separated profiles, cpu, heap, allocs,
+	// goroutine, mutex and block
+	ProfileDir             = "profile.dir"
+	ProfileDelay           = "profile.delay"
+	ProfileDelayDefault    = "0s"
+	ProfileDuration        = "profile.duration"
+	ProfileDurationDefault = "30s"
+	ProfileTypes           = "profile.types"
+	ProfileTypesDefault    = "cpu,heap"
+
+	// The OTLP/HTTP endpoint to export the sampled operations to as the OpenTelemetry spans in JSON, like
+	// http://127.0.0.1:4318/v1/traces, the operations are not traced if it is not set
+	TracingEndpoint           = "tracing.otlp_endpoint"
+	TracingSampleRate         = "tracing.sample_rate"
+	TracingSampleRateDefault  = float64(0.01)
+	TracingServiceName        = "tracing.service_name"
+	TracingServiceNameDefault = "go-ycsb"
+
+	// The Prometheus Pushgateway and the remote write endpoint to push the results to after the run, like
+	// http://127.0.0.1:9091 and http://127.0.0.1:9090/api/v1/write, the intervals of measurement.interval are
+	// only pushed to the remote write endpoint. The results are labeled by the workload, the database, the
+	// command, push.run_id (the start time of the run by default) and the comma separated push.labels
+	// like "commit=abc123,env=staging"
+	PushGateway     = "push.gateway"
+	PushRemoteWrite = "push.remote_write"
+	PushJob         = "push.job"
+	PushJobDefault  = "go-ycsb"
+	PushRunID       = "push.run_id"
+	PushLabels      = "push.labels"
+
+	// The results database to insert the results into after the run, "mysql://" followed by the DSN of
+	// the MySQL driver like mysql://root@tcp(127.0.0.1:3306)/ycsb, or a PostgreSQL URL like
+	// postgres://root@127.0.0.1:5432/ycsb?sslmode=disable, the tables are created if they don't exist.
+	// The results are keyed by report.run_id (the start time of the run by default), the command, the
+	// workload, the database and report.revision, like the git revision of the benchmarked database
+	ReportDB       = "report.db"
+	ReportRunID    = "report.run_id"
+	ReportRevision = "report.revision"
+
 	Verbose         = "verbose"
 	VerboseDefault  = false
 	DropData        = "dropdata"
@@ -106,4 +335,28 @@ const (
 
 	KeyPrefix        = "keyprefix"
 	KeyPrefixDefault = "user"
+
+	// Used by the SQL databases, comma separated fields to create secondary indexes on, like "FIELD0,FIELD2"
+	SecondaryIndexes = "sql.secondary_indexes"
+	// Used by the SQL databases, if set, Read and Scan look up records by this indexed field instead of the key
+	SecondaryQueryField = "sql.secondary_query_field"
+
+	// Used by the SQL databases, max retries of a statement which fails with a retryable error
+	SQLMaxRetries        = "sql.max_retries"
+	SQLMaxRetriesDefault = int(0)
+	// The backoff before the first retry, doubled for every following retry
+	SQLRetryBackoff        = "sql.retry_backoff"
+	SQLRetryBackoffDefault = "10ms"
+	// Comma separated error codes which can be retried
+	SQLRetryableErrors        = "sql.retryable_errors"
+	SQLRetryableErrorsDefault = "1205,1213,8002,8022,9002,9005,9007"
+
+	// Used by the SQL databases, the file to log the executed statements with their latencies
+	SQLLogQueries = "sql.log_queries"
+	// Only log the statements slower than the threshold, like "50ms", 0 logs all statements
+	SQLSlowThreshold        = "sql.slow_threshold"
+	SQLSlowThresholdDefault = "0"
+	// The query log is rotated to <file>.1 when its size exceeds the limit in MB
+	SQLLogMaxSize        = "sql.log_max_size"
+	SQLLogMaxSizeDefault = int64(256)
 )
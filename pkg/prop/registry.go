@@ -0,0 +1,265 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prop
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Kind is the kind of the value of a property.
+type Kind int
+
+// The kinds of the values of the properties.
+const (
+	String Kind = iota
+	Int
+	Float
+	Bool
+	Duration
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Int:
+		return "int"
+	case Float:
+		return "float"
+	case Bool:
+		return "bool"
+	case Duration:
+		return "duration"
+	default:
+		return "string"
+	}
+}
+
+// Check returns an error if the value is not a valid value of the kind.
+func (k Kind) Check(value string) error {
+	var err error
+	switch k {
+	case Int:
+		_, err = strconv.ParseInt(value, 10, 64)
+	case Float:
+		_, err = strconv.ParseFloat(value, 64)
+	case Bool:
+		_, err = strconv.ParseBool(value)
+	case Duration:
+		_, err = time.ParseDuration(value)
+	}
+	if err != nil {
+		return fmt.Errorf("%q is not a valid %s", value, k)
+	}
+	return nil
+}
+
+var (
+	registryMu sync.RWMutex
+	kinds      = make(map[string]Kind)
+	prefixes   = make(map[string]Kind)
+	defaults   = make(map[string]interface{})
+)
+
+// Register registers the names of the properties with the kind of their values, so they are known to
+// the validation of the properties. A name ending with "." is the prefix of a family of properties, like
+// phase.<name>.duration. go-ycsb registers the global properties, and the drivers register their
+// properties in init.
+func Register(kind Kind, names ...string) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	for _, name := range names {
+		if strings.HasSuffix(name, ".") {
+			prefixes[name] = kind
+		} else {
+			kinds[name] = kind
+		}
+	}
+}
+
+// SetDefault sets the default value of a registered property, which is shown by the explain command.
+func SetDefault(name string, value interface{}) {
+	registryMu.Lock()
+	defaults[name] = value
+	registryMu.Unlock()
+}
+
+// Lookup returns the kind of a registered property, it's not ok if the property is unknown.
+func Lookup(name string) (Kind, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	if kind, ok := kinds[name]; ok {
+		return kind, true
+	}
+	for prefix, kind := range prefixes {
+		if strings.HasPrefix(name, prefix) {
+			return kind, true
+		}
+	}
+	return String, false
+}
+
+// Default returns the default value of a registered property, it's not ok if the default is unknown.
+func Default(name string) (string, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	v, ok := defaults[name]
+	if !ok {
+		return "", false
+	}
+	return fmt.Sprint(v), true
+}
+
+// Names returns the sorted names of the registered properties, without the prefixes.
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(kinds))
+	for name := range kinds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	Register(String,
+		Workload, DB, Exporter, ExportFile, OutputStyle, Target, WarmUpTimeAlias, Status, Label,
+		RequestArrival, RateLimiter, TargetShape, TargetShapePoints, TableName, FieldLengthDistribution,
+		FieldLengthHistogramFile, Phases, PhasePrefix, RequestDistribution, ScanLengthDistribution,
+		InsertOrder, KeyType, RequestDistributionFile, FieldNamePrefix, ReadFields, WriteFields,
+		FieldValueKind, FieldValueDictionary, KeyComposition, KeyCompositionSeparator,
+		TransactionKeysDistribution, LifecycleLifetimeDistribution, QueryField, AppendWindow, AppendTail,
+		ReplayFile, VerifyKeyField, DebugPprof, LogLevel, LogFile, LogFormat, ProfileDir, ProfileTypes,
+		TracingEndpoint, TracingServiceName, PushGateway, PushRemoteWrite, PushJob, PushRunID, PushLabels,
+		ReportDB, ReportRunID, ReportRevision, KeyPrefix, SecondaryIndexes, SecondaryQueryField,
+		SQLRetryableErrors, SQLLogQueries, CheckpointFile)
+	Register(Int,
+		MeasurementInterval, InsertStart, InsertCount, ClientID, ClientCount, RandomSeed, OperationCount,
+		RecordCount, ThreadCount, MaxExecutiontime, WarmUpTime, DrainTime, BatchSize, RequestOutstanding,
+		RequestQueueSize, TableCount, FieldCount, FieldLength, MinFieldLength, ZeroPadding, MaxScanLength,
+		HotspotShiftInterval, InsertionRetryLimit, InsertionRetryInterval, DataIntegrityGeneration,
+		DataIntegrityMinGeneration, TransactionMinKeys, TransactionMaxKeys, LifecycleMinLifetime,
+		LifecycleMaxLifetime, LifecycleDeadLifetime, QueryCardinality, QueryLimit, VerifyScanBatch,
+		SQLMaxRetries, SQLLogMaxSize, CheckpointInterval)
+	Register(Float,
+		TargetShapeAmplitude, ReadProportion, UpdateProportion, InsertProportion, ScanProportion,
+		ReadModifyWriteProportion, DeleteProportion, ReverseScanProportion, HotspotDataFraction,
+		HotspotOpnFraction, FieldValueCompressionRatio, ExponentialPercentile, ExponentialFrac,
+		TransactionReadProportion, LifecycleReadProportion, QueryProportion, AppendTailProportion,
+		ReplaySpeedup, TracingSampleRate)
+	Register(Bool,
+		DoTransactions, Control, ReadAllFields, WriteAllFields, DataIntegrity, TUI, Verbose, DropData,
+		Silence, CheckpointResume)
+	Register(Duration,
+		TargetShapePeriod, ProfileDelay, ProfileDuration, SQLRetryBackoff, SQLSlowThreshold)
+
+	SetDefault(InsertStart, InsertStartDefault)
+	SetDefault(BatchSize, DefaultBatchSize)
+	SetDefault(ClientID, ClientIDDefault)
+	SetDefault(ClientCount, ClientCountDefault)
+	SetDefault(RecordCount, RecordCountDefault)
+	SetDefault(OutputStyle, OutputStyleDefault)
+	SetDefault(ThreadCount, ThreadCountDefault)
+	SetDefault(DrainTime, DrainTimeDefault)
+	SetDefault(RequestOutstanding, RequestOutstandingDefault)
+	SetDefault(RequestArrival, RequestArrivalDefault)
+	SetDefault(RateLimiter, RateLimiterDefault)
+	SetDefault(Control, ControlDefault)
+	SetDefault(RequestQueueSize, RequestQueueSizeDefault)
+	SetDefault(TargetShape, TargetShapeDefault)
+	SetDefault(TargetShapePeriod, TargetShapePeriodDefault)
+	SetDefault(TargetShapeAmplitude, TargetShapeAmplitudeDefault)
+	SetDefault(TableName, TableNameDefault)
+	SetDefault(TableCount, TableCountDefault)
+	SetDefault(FieldCount, FieldCountDefault)
+	SetDefault(FieldLengthDistribution, FieldLengthDistributionDefault)
+	SetDefault(FieldLength, FieldLengthDefault)
+	SetDefault(MinFieldLength, MinFieldLengthDefault)
+	SetDefault(FieldLengthHistogramFile, FieldLengthHistogramFileDefault)
+	SetDefault(ReadAllFields, ReadALlFieldsDefault)
+	SetDefault(WriteAllFields, WriteAllFieldsDefault)
+	SetDefault(DataIntegrity, DataIntegrityDefault)
+	SetDefault(ReadProportion, ReadProportionDefault)
+	SetDefault(UpdateProportion, UpdateProportionDefault)
+	SetDefault(InsertProportion, InsertProportionDefault)
+	SetDefault(ScanProportion, ScanProportionDefault)
+	SetDefault(ReadModifyWriteProportion, ReadModifyWriteProportionDefault)
+	SetDefault(DeleteProportion, DeleteProportionDefault)
+	SetDefault(ReverseScanProportion, ReverseScanProportionDefault)
+	SetDefault(RequestDistribution, RequestDistributionDefault)
+	SetDefault(ZeroPadding, ZeroPaddingDefault)
+	SetDefault(MaxScanLength, MaxScanLengthDefault)
+	SetDefault(ScanLengthDistribution, ScanLengthDistributionDefault)
+	SetDefault(InsertOrder, InsertOrderDefault)
+	SetDefault(HotspotDataFraction, HotspotDataFractionDefault)
+	SetDefault(HotspotOpnFraction, HotspotOpnFractionDefault)
+	SetDefault(HotspotShiftInterval, HotspotShiftIntervalDefault)
+	SetDefault(InsertionRetryLimit, InsertionRetryLimitDefault)
+	SetDefault(InsertionRetryInterval, InsertionRetryIntervalDefault)
+	SetDefault(CheckpointInterval, CheckpointIntervalDefault)
+	SetDefault(CheckpointResume, CheckpointResumeDefault)
+	SetDefault(FieldNamePrefix, FieldNamePrefixDefault)
+	SetDefault(FieldValueKind, FieldValueKindDefault)
+	SetDefault(FieldValueCompressionRatio, FieldValueCompressionRatioDefault)
+	SetDefault(DataIntegrityGeneration, DataIntegrityGenerationDefault)
+	SetDefault(DataIntegrityMinGeneration, DataIntegrityMinGenerationDefault)
+	SetDefault(KeyCompositionSeparator, KeyCompositionSeparatorDefault)
+	SetDefault(ExponentialPercentile, ExponentialPercentileDefault)
+	SetDefault(ExponentialFrac, ExponentialFracDefault)
+	SetDefault(TransactionKeysDistribution, TransactionKeysDistributionDefault)
+	SetDefault(TransactionMinKeys, TransactionMinKeysDefault)
+	SetDefault(TransactionMaxKeys, TransactionMaxKeysDefault)
+	SetDefault(TransactionReadProportion, TransactionReadProportionDefault)
+	SetDefault(LifecycleLifetimeDistribution, LifecycleLifetimeDistributionDefault)
+	SetDefault(LifecycleMinLifetime, LifecycleMinLifetimeDefault)
+	SetDefault(LifecycleMaxLifetime, LifecycleMaxLifetimeDefault)
+	SetDefault(LifecycleDeadLifetime, LifecycleDeadLifetimeDefault)
+	SetDefault(LifecycleReadProportion, LifecycleReadProportionDefault)
+	SetDefault(QueryProportion, QueryProportionDefault)
+	SetDefault(QueryField, QueryFieldDefault)
+	SetDefault(QueryCardinality, QueryCardinalityDefault)
+	SetDefault(QueryLimit, QueryLimitDefault)
+	SetDefault(AppendWindow, AppendWindowDefault)
+	SetDefault(AppendTailProportion, AppendTailProportionDefault)
+	SetDefault(ReplaySpeedup, ReplaySpeedupDefault)
+	SetDefault(VerifyKeyField, VerifyKeyFieldDefault)
+	SetDefault(VerifyScanBatch, VerifyScanBatchDefault)
+	SetDefault(MeasurementInterval, MeasurementIntervalDefault)
+	SetDefault(DebugPprof, DebugPprofDefault)
+	SetDefault(LogLevel, LogLevelDefault)
+	SetDefault(TUI, TUIDefault)
+	SetDefault(ProfileDelay, ProfileDelayDefault)
+	SetDefault(ProfileDuration, ProfileDurationDefault)
+	SetDefault(ProfileTypes, ProfileTypesDefault)
+	SetDefault(TracingSampleRate, TracingSampleRateDefault)
+	SetDefault(TracingServiceName, TracingServiceNameDefault)
+	SetDefault(PushJob, PushJobDefault)
+	SetDefault(Verbose, VerboseDefault)
+	SetDefault(DropData, DropDataDefault)
+	SetDefault(Silence, SilenceDefault)
+	SetDefault(KeyPrefix, KeyPrefixDefault)
+	SetDefault(SQLMaxRetries, SQLMaxRetriesDefault)
+	SetDefault(SQLRetryBackoff, SQLRetryBackoffDefault)
+	SetDefault(SQLRetryableErrors, SQLRetryableErrorsDefault)
+	SetDefault(SQLSlowThreshold, SQLSlowThresholdDefault)
+	SetDefault(SQLLogMaxSize, SQLLogMaxSizeDefault)
+}
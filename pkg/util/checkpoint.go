@@ -0,0 +1,86 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/magiconair/properties"
+)
+
+// LoadCheckpoint is the progress of the load of a client, the inserts of the keys in [insertstart, Next)
+// are completed, and the client loads the keys up to End.
+type LoadCheckpoint struct {
+	ClientID    int64
+	ClientCount int64
+	Next        int64
+	End         int64
+}
+
+// ReadLoadCheckpoint reads the checkpoint file.
+func ReadLoadCheckpoint(path string) (*LoadCheckpoint, error) {
+	p, err := properties.LoadFile(path, properties.UTF8)
+	if err != nil {
+		return nil, err
+	}
+
+	c := new(LoadCheckpoint)
+	for _, field := range []struct {
+		name  string
+		value *int64
+	}{
+		{"clientid", &c.ClientID},
+		{"clientcount", &c.ClientCount},
+		{"next", &c.Next},
+		{"end", &c.End},
+	} {
+		value, ok := p.Get(field.name)
+		if !ok {
+			return nil, fmt.Errorf("%s is missing in the checkpoint %s", field.name, path)
+		}
+		if *field.value, err = strconv.ParseInt(value, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid %s in the checkpoint %s: %v", field.name, path, err)
+		}
+	}
+	return c, nil
+}
+
+// Write writes the checkpoint file, the file is replaced atomically so a crash never leaves a
+// partial checkpoint.
+func (c *LoadCheckpoint) Write(path string) error {
+	content := fmt.Sprintf("# The inserts of the keys before next are completed\nclientid=%d\nclientcount=%d\nnext=%d\nend=%d\n",
+		c.ClientID, c.ClientCount, c.Next, c.End)
+
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err = f.WriteString(content); err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
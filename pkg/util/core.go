@@ -16,6 +16,7 @@ package util
 import (
 	"fmt"
 	"sort"
+	"strings"
 
 	"github.com/magiconair/properties"
 	"github.com/pingcap/go-ycsb/pkg/prop"
@@ -24,26 +25,71 @@ import (
 // createFieldIndices is a helper function to create a field -> index mapping
 // for the core workload
 func createFieldIndices(p *properties.Properties) map[string]int64 {
-	fieldCount := p.GetInt64(prop.FieldCount, prop.FieldCountDefault)
-	m := make(map[string]int64, fieldCount)
-	for i := int64(0); i < fieldCount; i++ {
-		field := fmt.Sprintf("field%d", i)
-		m[field] = i
+	fields := FieldNames(p)
+	m := make(map[string]int64, len(fields))
+	for i, field := range fields {
+		m[field] = int64(i)
 	}
 	return m
 }
 
-// allFields is a helper function to create all fields
-func allFields(p *properties.Properties) []string {
+// FieldNames returns the names of all the fields, which are fieldnameprefix followed by the field index,
+// the SQL databases use the upper-case names as the columns.
+func FieldNames(p *properties.Properties) []string {
 	fieldCount := p.GetInt64(prop.FieldCount, prop.FieldCountDefault)
+	prefix := p.GetString(prop.FieldNamePrefix, prop.FieldNamePrefixDefault)
 	fields := make([]string, 0, fieldCount)
 	for i := int64(0); i < fieldCount; i++ {
-		field := fmt.Sprintf("field%d", i)
+		field := fmt.Sprintf("%s%d", prefix, i)
 		fields = append(fields, field)
 	}
 	return fields
 }
 
+// TableNames returns the names of all the tables used by the workload,
+// the tables are suffixed with the table index if tablecount > 1.
+func TableNames(p *properties.Properties) []string {
+	table := p.GetString(prop.TableName, prop.TableNameDefault)
+	tableCount := p.GetInt64(prop.TableCount, prop.TableCountDefault)
+	if tableCount <= 1 {
+		return []string{table}
+	}
+
+	tables := make([]string, 0, tableCount)
+	for i := int64(0); i < tableCount; i++ {
+		tables = append(tables, fmt.Sprintf("%s%d", table, i))
+	}
+	return tables
+}
+
+// SecondaryIndexes returns the upper-case fields to create secondary indexes on for the SQL databases.
+func SecondaryIndexes(p *properties.Properties) []string {
+	var fields []string
+	for _, field := range strings.Split(p.GetString(prop.SecondaryIndexes, ""), ",") {
+		field = strings.ToUpper(strings.TrimSpace(field))
+		if len(field) > 0 {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// SecondaryQueryField returns the upper-case field used to look up records instead of the key,
+// it must be one of the secondary indexes.
+func SecondaryQueryField(p *properties.Properties) (string, error) {
+	field := strings.ToUpper(strings.TrimSpace(p.GetString(prop.SecondaryQueryField, "")))
+	if len(field) == 0 {
+		return "", nil
+	}
+
+	for _, index := range SecondaryIndexes(p) {
+		if index == field {
+			return field, nil
+		}
+	}
+	return "", fmt.Errorf("%s %s must be one of %s", prop.SecondaryQueryField, field, prop.SecondaryIndexes)
+}
+
 // RowCodec is a helper struct to encode and decode TiDB format row
 type RowCodec struct {
 	fieldIndices map[string]int64
@@ -54,7 +100,7 @@ type RowCodec struct {
 func NewRowCodec(p *properties.Properties) *RowCodec {
 	return &RowCodec{
 		fieldIndices: createFieldIndices(p),
-		fields:       allFields(p),
+		fields:       FieldNames(p),
 	}
 }
 
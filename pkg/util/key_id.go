@@ -0,0 +1,70 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+)
+
+// UUID returns a random-looking UUIDv4 derived from the integer, the same integer
+// always has the same UUID, so the records can be read back by the key number.
+func UUID(n int64) string {
+	// The fnv hashes of the adjacent integers share most of their bytes, so the
+	// integer is mixed by the splitmix64 finalizer instead.
+	var u [16]byte
+	binary.BigEndian.PutUint64(u[0:8], mix64(uint64(n)+goldenGamma))
+	binary.BigEndian.PutUint64(u[8:16], mix64(uint64(n)+goldenGamma+goldenGamma))
+
+	// Set the version 4 and the RFC 4122 variant.
+	u[6] = u[6]&0x0f | 0x40
+	u[8] = u[8]&0x3f | 0x80
+
+	buf := make([]byte, 36)
+	hex.Encode(buf[0:8], u[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], u[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], u[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], u[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], u[10:16])
+	return string(buf)
+}
+
+const goldenGamma = uint64(0x9e3779b97f4a7c15)
+
+// mix64 is the finalizer of splitmix64.
+func mix64(z uint64) uint64 {
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+const (
+	snowflakeSequenceBits = 12
+	snowflakeWorkerBits   = 10
+	// snowflakeTimeOffset is the milliseconds from the Twitter snowflake epoch to 2020-01-01.
+	snowflakeTimeOffset = int64(289001825343)
+)
+
+// Snowflake returns a time-sorted snowflake ID derived from the integer, which is the
+// 41 bits timestamp in milliseconds, the 10 bits worker ID and the 12 bits sequence,
+// as if the integers were generated at 4096 IDs per millisecond by one worker.
+func Snowflake(n int64) int64 {
+	sequence := n & (1<<snowflakeSequenceBits - 1)
+	timestamp := snowflakeTimeOffset + n>>snowflakeSequenceBits
+	return timestamp<<(snowflakeWorkerBits+snowflakeSequenceBits) | sequence
+}
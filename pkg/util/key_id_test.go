@@ -0,0 +1,71 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestUUID(t *testing.T) {
+	pattern := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	seen := make(map[string]int64)
+	for n := int64(0); n < 100000; n++ {
+		id := UUID(n)
+		if !pattern.MatchString(id) {
+			t.Fatalf("want a UUIDv4 of %d, but got %s", n, id)
+		}
+		if other, ok := seen[id]; ok {
+			t.Fatalf("want the unique UUIDs, but %d and %d have %s", other, n, id)
+		}
+		seen[id] = n
+	}
+
+	if UUID(42) != UUID(42) {
+		t.Errorf("want the same UUID of the same integer, but got %s and %s", UUID(42), UUID(42))
+	}
+}
+
+func TestSnowflake(t *testing.T) {
+	tests := []struct {
+		n        int64
+		sequence int64
+	}{
+		{0, 0},
+		{1, 1},
+		{4095, 4095},
+		{4096, 0},
+		{1 << 30, 0},
+	}
+
+	for _, test := range tests {
+		id := Snowflake(test.n)
+		if sequence := id & (1<<snowflakeSequenceBits - 1); sequence != test.sequence {
+			t.Errorf("want sequence %d of %d, but got %d", test.sequence, test.n, sequence)
+		}
+		if worker := id >> snowflakeSequenceBits & (1<<snowflakeWorkerBits - 1); worker != 0 {
+			t.Errorf("want worker 0 of %d, but got %d", test.n, worker)
+		}
+	}
+
+	// The IDs are unique and ordered as the integers.
+	prev := Snowflake(0)
+	for n := int64(1); n < 100000; n++ {
+		id := Snowflake(n)
+		if id <= prev {
+			t.Fatalf("want the increasing IDs, but %d has %d after %d", n, id, prev)
+		}
+		prev = id
+	}
+}
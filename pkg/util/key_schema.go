@@ -0,0 +1,215 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+)
+
+// KeyComponent is a component of the composite keys.
+type KeyComponent struct {
+	// Name is the name of the component, the SQL databases use the upper-case name as the column.
+	Name        string
+	Cardinality int64
+	// Distribution is "uniform" or "zipfian", the distribution of the component in the run phase.
+	Distribution string
+
+	// width is the number of the digits of the component padded with zeros.
+	width int
+}
+
+// KeySchema is the schema of the composite keys set by keycomposition, like "tenant_id:100:zipfian,user_id:1000:uniform".
+// The key number is split into the components in the mixed radix of the cardinalities, and the key is the key prefix
+// followed by the zero-padded components joined by the separator, like "user0042:0137".
+// The SQL databases store the components in separate columns as the composite primary key.
+type KeySchema struct {
+	Components []KeyComponent
+	prefix     string
+	separator  string
+	// strides[i] is the product of the cardinalities of the components after i.
+	strides []int64
+}
+
+// NewKeySchema returns the schema of the composite keys, or nil if keycomposition is not set.
+func NewKeySchema(p *properties.Properties) *KeySchema {
+	composition := strings.TrimSpace(p.GetString(prop.KeyComposition, ""))
+	if len(composition) == 0 {
+		return nil
+	}
+
+	s := &KeySchema{
+		prefix:    p.GetString(prop.KeyPrefix, prop.KeyPrefixDefault),
+		separator: p.GetString(prop.KeyCompositionSeparator, prop.KeyCompositionSeparatorDefault),
+	}
+	if len(s.separator) == 0 || strings.ContainsAny(s.separator, "0123456789") {
+		Fatalf("invalid %s %q, must be non-empty without digits", prop.KeyCompositionSeparator, s.separator)
+	}
+
+	zeroPadding := int(p.GetInt64(prop.ZeroPadding, prop.ZeroPaddingDefault))
+	for _, item := range strings.Split(composition, ",") {
+		parts := strings.Split(strings.TrimSpace(item), ":")
+		if len(parts) < 2 || len(parts) > 3 {
+			Fatalf("invalid key component %q, must be name:cardinality[:distribution]", item)
+		}
+
+		c := KeyComponent{Name: strings.TrimSpace(parts[0]), Distribution: "uniform"}
+		var err error
+		if c.Cardinality, err = strconv.ParseInt(strings.TrimSpace(parts[1]), 10, 64); err != nil || c.Cardinality < 1 {
+			Fatalf("invalid cardinality of key component %q", item)
+		}
+		if len(parts) == 3 {
+			c.Distribution = strings.ToLower(strings.TrimSpace(parts[2]))
+		}
+		if c.Distribution != "uniform" && c.Distribution != "zipfian" {
+			Fatalf("unknown distribution %s of key component %q", c.Distribution, item)
+		}
+
+		c.width = len(strconv.FormatInt(c.Cardinality-1, 10))
+		if c.width < zeroPadding {
+			c.width = zeroPadding
+		}
+		s.Components = append(s.Components, c)
+	}
+
+	s.strides = make([]int64, len(s.Components))
+	stride := int64(1)
+	for i := len(s.Components) - 1; i >= 0; i-- {
+		s.strides[i] = stride
+		stride *= s.Components[i].Cardinality
+	}
+	return s
+}
+
+// Cardinality returns the number of the keys, which is the product of the cardinalities.
+func (s *KeySchema) Cardinality() int64 {
+	return s.strides[0] * s.Components[0].Cardinality
+}
+
+// Key returns the key of the key number, the first component may exceed its cardinality
+// if the key number exceeds the cardinality of the schema.
+func (s *KeySchema) Key(keyNum int64) string {
+	var b strings.Builder
+	b.WriteString(s.prefix)
+	for i, c := range s.Components {
+		if i > 0 {
+			b.WriteString(s.separator)
+		}
+		v := keyNum / s.strides[i]
+		if i > 0 {
+			v %= c.Cardinality
+		}
+		fmt.Fprintf(&b, "%0[2]*[1]d", v, c.width)
+	}
+	return b.String()
+}
+
+// Columns returns the key columns of the SQL databases, which is YCSB_KEY if the schema is nil.
+func (s *KeySchema) Columns() []string {
+	if s == nil {
+		return []string{"YCSB_KEY"}
+	}
+
+	columns := make([]string, 0, len(s.Components))
+	for _, c := range s.Components {
+		columns = append(columns, strings.ToUpper(c.Name))
+	}
+	return columns
+}
+
+// Values splits the key into the values of the key columns, the missing components are empty,
+// so a partial key like the empty start key of a scan is still ordered before the full keys.
+func (s *KeySchema) Values(key string) []interface{} {
+	if s == nil {
+		return []interface{}{key}
+	}
+
+	parts := strings.Split(strings.TrimPrefix(key, s.prefix), s.separator)
+	values := make([]interface{}, len(s.Components))
+	for i := range values {
+		if i < len(parts) {
+			values[i] = parts[i]
+		} else {
+			values[i] = ""
+		}
+	}
+	return values
+}
+
+// KeyOfRow returns the key of the row read from the SQL databases, the key columns are removed from the row.
+func (s *KeySchema) KeyOfRow(row map[string][]byte) string {
+	columns := s.Columns()
+	if s == nil {
+		key := string(row[columns[0]])
+		delete(row, columns[0])
+		return key
+	}
+
+	var b strings.Builder
+	b.WriteString(s.prefix)
+	for i, column := range columns {
+		if i > 0 {
+			b.WriteString(s.separator)
+		}
+		b.Write(row[column])
+		delete(row, column)
+	}
+	return b.String()
+}
+
+// KeyCondition returns the SQL condition comparing the key columns with the placeholders,
+// like "YCSB_KEY = ?" or "(TENANT_ID, USER_ID) >= (?, ?)", placeholder returns the i-th placeholder.
+func KeyCondition(columns []string, op string, placeholder func(i int) string) string {
+	if len(columns) == 1 {
+		return fmt.Sprintf("%s %s %s", columns[0], op, placeholder(0))
+	}
+
+	placeholders := make([]string, len(columns))
+	for i := range columns {
+		placeholders[i] = placeholder(i)
+	}
+	return fmt.Sprintf("(%s) %s (%s)", strings.Join(columns, ", "), op, strings.Join(placeholders, ", "))
+}
+
+// ColumnDefinitions returns the SQL definitions of the key columns, the primary key is
+// defined by PrimaryKeyDefinition if the keys are composite.
+func (s *KeySchema) ColumnDefinitions() string {
+	if s == nil {
+		return "YCSB_KEY VARCHAR(64) PRIMARY KEY"
+	}
+
+	definitions := make([]string, 0, len(s.Components))
+	for _, column := range s.Columns() {
+		definitions = append(definitions, fmt.Sprintf("%s VARCHAR(64)", column))
+	}
+	return strings.Join(definitions, ", ")
+}
+
+// PrimaryKeyDefinition returns the SQL definition of the composite primary key like ", PRIMARY KEY (TENANT_ID, USER_ID)",
+// or empty if the keys are not composite.
+func (s *KeySchema) PrimaryKeyDefinition() string {
+	if s == nil {
+		return ""
+	}
+	return fmt.Sprintf(", PRIMARY KEY (%s)", strings.Join(s.Columns(), ", "))
+}
+
+// QuestionMark is the placeholder of KeyCondition for the databases using "?".
+func QuestionMark(int) string {
+	return "?"
+}
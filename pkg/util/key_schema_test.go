@@ -0,0 +1,104 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+)
+
+func TestKeySchema(t *testing.T) {
+	p := properties.NewProperties()
+	p.Set(prop.KeyComposition, "tenant_id:100:zipfian,user_id:1000")
+	s := NewKeySchema(p)
+
+	if s.Cardinality() != 100000 {
+		t.Errorf("want cardinality %d, but got %d", 100000, s.Cardinality())
+	}
+	columns := []string{"TENANT_ID", "USER_ID"}
+	if !reflect.DeepEqual(s.Columns(), columns) {
+		t.Errorf("want columns %v, but got %v", columns, s.Columns())
+	}
+
+	tests := []struct {
+		keyNum int64
+		key    string
+		values []interface{}
+	}{
+		{0, "user00:000", []interface{}{"00", "000"}},
+		{42, "user00:042", []interface{}{"00", "042"}},
+		{1000, "user01:000", []interface{}{"01", "000"}},
+		{99999, "user99:999", []interface{}{"99", "999"}},
+		// The first component exceeds its cardinality.
+		{100001, "user100:001", []interface{}{"100", "001"}},
+	}
+
+	for _, test := range tests {
+		key := s.Key(test.keyNum)
+		if key != test.key {
+			t.Errorf("want key %s of %d, but got %s", test.key, test.keyNum, key)
+		}
+		values := s.Values(key)
+		if !reflect.DeepEqual(values, test.values) {
+			t.Errorf("want values %v of %s, but got %v", test.values, key, values)
+		}
+
+		row := map[string][]byte{"TENANT_ID": []byte(values[0].(string)), "USER_ID": []byte(values[1].(string)), "FIELD0": nil}
+		if k := s.KeyOfRow(row); k != key {
+			t.Errorf("want key %s of the row, but got %s", key, k)
+		}
+		if len(row) != 1 {
+			t.Errorf("want the key columns removed from the row, but got %v", row)
+		}
+	}
+
+	// The partial key like the empty start key of a scan has the empty components.
+	if values := s.Values(""); !reflect.DeepEqual(values, []interface{}{"", ""}) {
+		t.Errorf("want the empty values of the empty key, but got %v", values)
+	}
+}
+
+func TestKeyCondition(t *testing.T) {
+	tests := []struct {
+		columns   []string
+		condition string
+	}{
+		{[]string{"YCSB_KEY"}, "YCSB_KEY >= ?"},
+		{[]string{"TENANT_ID", "USER_ID"}, "(TENANT_ID, USER_ID) >= (?, ?)"},
+	}
+
+	for _, test := range tests {
+		if condition := KeyCondition(test.columns, ">=", QuestionMark); condition != test.condition {
+			t.Errorf("want %s, but got %s", test.condition, condition)
+		}
+	}
+}
+
+func TestNilKeySchema(t *testing.T) {
+	s := NewKeySchema(properties.NewProperties())
+	if s != nil {
+		t.Fatalf("want nil schema without %s, but got %v", prop.KeyComposition, s)
+	}
+
+	if values := s.Values("user1"); !reflect.DeepEqual(values, []interface{}{"user1"}) {
+		t.Errorf("want the key as the value, but got %v", values)
+	}
+	row := map[string][]byte{"YCSB_KEY": []byte("user1")}
+	if key := s.KeyOfRow(row); key != "user1" || len(row) != 0 {
+		t.Errorf("want key user1 and the empty row, but got %s and %v", key, row)
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+var logger, _ = newLogger(zapcore.InfoLevel, "stderr", "console")
+
+func newLogger(level zapcore.Level, output string, format string) (*zap.Logger, error) {
+	encoder := zap.NewProductionEncoderConfig()
+	encoder.EncodeTime = zapcore.ISO8601TimeEncoder
+	if format == "console" {
+		encoder.EncodeLevel = zapcore.CapitalLevelEncoder
+	}
+
+	cfg := zap.Config{
+		Level:            zap.NewAtomicLevelAt(level),
+		Encoding:         format,
+		EncoderConfig:    encoder,
+		OutputPaths:      []string{output},
+		ErrorOutputPaths: []string{"stderr"},
+	}
+	return cfg.Build()
+}
+
+// InitLogger replaces the global logger with the one writing the logs of the level or above to the file,
+// or stderr if the file is empty, in the format, json or console. The format is json for a file and
+// console for stderr if it is empty. It must be called before the logger is used concurrently.
+func InitLogger(level string, file string, format string) error {
+	var l zapcore.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("invalid log level %q", level)
+	}
+
+	output := "stderr"
+	if file != "" {
+		output = file
+	}
+	if format == "" {
+		format = "console"
+		if file != "" {
+			format = "json"
+		}
+	}
+	if format != "json" && format != "console" {
+		return fmt.Errorf("invalid log format %q, must be json or console", format)
+	}
+
+	nl, err := newLogger(l, output, format)
+	if err != nil {
+		return fmt.Errorf("open log file %s failed %v", file, err)
+	}
+	logger = nl
+	return nil
+}
+
+// Logger returns the global logger, which writes the logs of the info level or above to stderr until
+// InitLogger is called.
+func Logger() *zap.Logger {
+	return logger
+}
+
+// SyncLogger flushes the buffered logs of the global logger.
+func SyncLogger() {
+	logger.Sync()
+}
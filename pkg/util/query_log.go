@@ -0,0 +1,148 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"go.uber.org/zap"
+)
+
+// QueryLogger logs the executed SQL statements with their latencies to a file,
+// the file is rotated when its size exceeds the limit.
+type QueryLogger struct {
+	mu            sync.Mutex
+	path          string
+	maxSize       int64
+	slowThreshold time.Duration
+
+	f    *os.File
+	w    *bufio.Writer
+	size int64
+}
+
+// NewQueryLogger creates the QueryLogger, it returns nil if sql.log_queries is not set.
+func NewQueryLogger(p *properties.Properties) (*QueryLogger, error) {
+	path := p.GetString(prop.SQLLogQueries, "")
+	if len(path) == 0 {
+		return nil, nil
+	}
+
+	slowThreshold, err := time.ParseDuration(p.GetString(prop.SQLSlowThreshold, prop.SQLSlowThresholdDefault))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", prop.SQLSlowThreshold, err)
+	}
+
+	l := &QueryLogger{
+		path:          path,
+		maxSize:       p.GetInt64(prop.SQLLogMaxSize, prop.SQLLogMaxSizeDefault) * 1024 * 1024,
+		slowThreshold: slowThreshold,
+	}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *QueryLogger) open() error {
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	l.f = f
+	l.w = bufio.NewWriter(f)
+	l.size = info.Size()
+	return nil
+}
+
+func (l *QueryLogger) rotate() error {
+	l.w.Flush()
+	l.f.Close()
+	if err := os.Rename(l.path, l.path+".1"); err != nil {
+		return err
+	}
+	return l.open()
+}
+
+// Log logs the statement which started at start, it is a no-op on a nil QueryLogger.
+func (l *QueryLogger) Log(start time.Time, query string, args []interface{}, err error) {
+	if l == nil {
+		return
+	}
+
+	latency := time.Now().Sub(start)
+	if latency < l.slowThreshold {
+		return
+	}
+
+	line := fmt.Sprintf("[%s] %s %s %s", start.Format("2006-01-02 15:04:05.000"), latency, query, formatArgs(args))
+	if err != nil {
+		line += fmt.Sprintf(" error: %v", err)
+	}
+	line += "\n"
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.maxSize > 0 && l.size+int64(len(line)) > l.maxSize && l.size > 0 {
+		if err := l.rotate(); err != nil {
+			Logger().Error("rotate query log failed", zap.String("path", l.path), zap.Error(err))
+			return
+		}
+	}
+
+	n, _ := l.w.WriteString(line)
+	l.size += int64(n)
+}
+
+func formatArgs(args []interface{}) string {
+	values := make([]interface{}, len(args))
+	for i, arg := range args {
+		if b, ok := arg.([]byte); ok {
+			values[i] = String(b)
+		} else {
+			values[i] = arg
+		}
+	}
+	return fmt.Sprintf("%v", values)
+}
+
+// Close flushes and closes the log file, it is a no-op on a nil QueryLogger.
+func (l *QueryLogger) Close() error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.w.Flush(); err != nil {
+		l.f.Close()
+		return err
+	}
+	return l.f.Close()
+}
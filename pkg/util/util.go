@@ -35,6 +35,12 @@ func Fatal(args ...interface{}) {
 	os.Exit(1)
 }
 
+// ThreadSeed returns the seed of the random source of the thread derived from the random seed, the
+// adjacent threads get the independent sources.
+func ThreadSeed(seed int64, thread int64) int64 {
+	return int64(mix64(uint64(seed) ^ mix64(uint64(thread)+goldenGamma)))
+}
+
 var letters = []byte("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ")
 
 // RandBytes fills the bytes with alphabetic characters randomly
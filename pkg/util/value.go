@@ -0,0 +1,148 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"math/rand"
+	"strconv"
+)
+
+// DefaultWords is the dictionary of the generated words and JSON documents.
+var DefaultWords = []string{
+	"the", "of", "and", "to", "in", "for", "is", "on", "that", "by",
+	"this", "with", "you", "it", "not", "or", "be", "are", "from", "at",
+	"as", "your", "all", "have", "new", "more", "an", "was", "we", "will",
+	"home", "can", "us", "about", "if", "page", "my", "has", "search", "free",
+	"but", "our", "one", "other", "do", "no", "information", "time", "they", "site",
+	"he", "up", "may", "what", "which", "their", "news", "out", "use", "any",
+	"there", "see", "only", "so", "his", "when", "contact", "here", "business", "who",
+	"web", "also", "now", "help", "get", "view", "online", "first", "been", "would",
+	"how", "were", "me", "services", "some", "these", "click", "its", "like", "service",
+	"than", "find", "price", "date", "back", "top", "people", "had", "list", "name",
+}
+
+// RandWords fills the bytes with the space separated words chosen randomly from the dictionary,
+// the last word may be truncated.
+func RandWords(r *rand.Rand, b []byte, words []string) {
+	for i := 0; i < len(b); {
+		if i > 0 {
+			b[i] = ' '
+			i++
+		}
+		i += copy(b[i:], words[r.Intn(len(words))])
+	}
+}
+
+// jsonMinSize is the size of the smallest document with the padding field `{"text":""}`.
+const jsonMinSize = 11
+
+// RandJSON fills the bytes with a JSON document which has the random fields of the numbers, the booleans,
+// the strings, the arrays and the nested objects, the document is padded by a text field to fill the bytes.
+// The words in the dictionary must not have the characters to be escaped.
+func RandJSON(r *rand.Rand, b []byte, words []string) {
+	n := len(b)
+	if n == 0 {
+		return
+	} else if n == 1 {
+		b[0] = '0'
+		return
+	} else if n < jsonMinSize {
+		b[0], b[1] = '{', '}'
+		for i := 2; i < n; i++ {
+			b[i] = ' '
+		}
+		return
+	}
+
+	out := append(b[:0], '{')
+	var field []byte
+	for i := 0; ; i++ {
+		field = appendJSONField(field[:0], r, words, i)
+		if len(out)+len(field)+jsonMinSize-1 > n {
+			break
+		}
+		out = append(out, field...)
+	}
+
+	out = append(out, `"text":"`...)
+	pad := n - len(out) - 2
+	RandWords(r, out[len(out):len(out)+pad], words)
+	out = append(out[:len(out)+pad], `"}`...)
+}
+
+func appendJSONString(dst []byte, r *rand.Rand, words []string, count int) []byte {
+	dst = append(dst, '"')
+	for i := 0; i < count; i++ {
+		if i > 0 {
+			dst = append(dst, ' ')
+		}
+		dst = append(dst, words[r.Intn(len(words))]...)
+	}
+	return append(dst, '"')
+}
+
+// appendJSONField appends the i-th field like `"name3":value,` to dst.
+func appendJSONField(dst []byte, r *rand.Rand, words []string, i int) []byte {
+	dst = append(dst, '"')
+	dst = append(dst, words[r.Intn(len(words))]...)
+	dst = strconv.AppendInt(dst, int64(i), 10)
+	dst = append(dst, `":`...)
+
+	switch r.Intn(5) {
+	case 0:
+		dst = strconv.AppendInt(dst, r.Int63n(1000000), 10)
+	case 1:
+		dst = strconv.AppendBool(dst, r.Intn(2) == 0)
+	case 2:
+		dst = appendJSONString(dst, r, words, 1+r.Intn(3))
+	case 3:
+		dst = append(dst, '[')
+		for j, count := 0, 1+r.Intn(4); j < count; j++ {
+			if j > 0 {
+				dst = append(dst, ',')
+			}
+			dst = strconv.AppendInt(dst, r.Int63n(1000000), 10)
+		}
+		dst = append(dst, ']')
+	default:
+		dst = append(dst, '{')
+		dst = appendJSONString(dst, r, words, 1)
+		dst = append(dst, ':')
+		dst = appendJSONString(dst, r, words, 1)
+		dst = append(dst, ',')
+		dst = appendJSONString(dst, r, words, 1)
+		dst = append(dst, ':')
+		dst = strconv.AppendInt(dst, r.Int63n(1000000), 10)
+		dst = append(dst, '}')
+	}
+
+	return append(dst, ',')
+}
+
+// RandCompressibleBytes fills the bytes with a random fragment of len(b) / ratio alphabetic characters
+// repeated, so the bytes can be compressed at about the ratio.
+func RandCompressibleBytes(r *rand.Rand, b []byte, ratio float64) {
+	raw := int(float64(len(b)) / ratio)
+	if raw < 1 {
+		raw = 1
+	}
+	if raw > len(b) {
+		raw = len(b)
+	}
+
+	RandBytes(r, b[:raw])
+	for i := raw; i < len(b); {
+		i += copy(b[i:], b[:raw])
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"encoding/json"
+	"math/rand"
+	"testing"
+)
+
+func TestRandJSON(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, size := range []int{1, 2, 5, jsonMinSize - 1, jsonMinSize, jsonMinSize + 1, 50, 100, 1000, 4096} {
+		b := make([]byte, size)
+		RandJSON(r, b, DefaultWords)
+		if !json.Valid(b) {
+			t.Errorf("want a valid JSON document of %d bytes, but got %q", size, b)
+		}
+	}
+}
+
+func TestRandCompressibleBytes(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	tests := []struct {
+		size  int
+		ratio float64
+		raw   int
+	}{
+		{100, 1, 100},
+		{100, 2, 50},
+		{100, 3, 33},
+		{100, 0.5, 100},
+		{100, 1000, 1},
+		{1, 2, 1},
+	}
+
+	for _, test := range tests {
+		b := make([]byte, test.size)
+		RandCompressibleBytes(r, b, test.ratio)
+		for i := range b {
+			if b[i] != b[i%test.raw] {
+				t.Errorf("want %d bytes repeating a fragment of %d bytes at ratio %v, but got %q",
+					test.size, test.raw, test.ratio, b)
+				break
+			}
+		}
+	}
+}
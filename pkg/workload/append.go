@@ -0,0 +1,77 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workload
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/generator"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/util"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+)
+
+// appendCreator creates the core workload whose reads, updates and scans follow the window of
+// the latest inserted records, like the feeds and the timelines, the keyspace grows at the
+// insert rate in the run phase.
+type appendCreator struct {
+}
+
+// parseWindow parses the window of the latest records, which is a count like "10000"
+// or a duration like "5m".
+func parseWindow(name string, value string) generator.Window {
+	value = strings.TrimSpace(value)
+	if len(value) == 0 {
+		return generator.Window{}
+	}
+
+	if count, err := strconv.ParseInt(value, 10, 64); err == nil && count > 0 {
+		return generator.Window{Count: count}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d > 0 {
+		return generator.Window{Duration: d}
+	}
+	util.Fatalf("invalid %s %q, must be a positive count or duration", name, value)
+	return generator.Window{}
+}
+
+// Create implements the WorkloadCreator Create interface.
+func (appendCreator) Create(p *properties.Properties) (ycsb.Workload, error) {
+	w, err := coreCreator{}.Create(p)
+	if err != nil {
+		return nil, err
+	}
+	c := w.(*core)
+
+	window := parseWindow(prop.AppendWindow, p.GetString(prop.AppendWindow, prop.AppendWindowDefault))
+	if window == (generator.Window{}) {
+		util.Fatalf("%s must be set for the append workload", prop.AppendWindow)
+	}
+	tail := parseWindow(prop.AppendTail, p.GetString(prop.AppendTail, ""))
+	tailProportion := p.GetFloat64(prop.AppendTailProportion, prop.AppendTailProportionDefault)
+	if tailProportion < 0 || tailProportion > 1 {
+		util.Fatalf("%s must be in [0, 1]", prop.AppendTailProportion)
+	}
+
+	insertStart := p.GetInt64(prop.InsertStart, prop.InsertStartDefault)
+	c.keyChooser = generator.NewLatestWindow(insertStart, c.transactionInsertKeySequence, window, tail, tailProportion)
+	return c, nil
+}
+
+func init() {
+	ycsb.RegisterWorkloadCreator("append", appendCreator{})
+}
@@ -0,0 +1,221 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workload
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+)
+
+// maxAuditReports is the max number of the problematic keys printed of every kind.
+const maxAuditReports = 10
+
+type auditResult struct {
+	sync.Mutex
+
+	records int64
+	missing int64
+	corrupt int64
+	stale   int64
+	extra   int64
+	reports map[string]int
+}
+
+func (a *auditResult) report(kind string, format string, args ...interface{}) {
+	a.Lock()
+	defer a.Unlock()
+
+	if a.reports[kind] < maxAuditReports {
+		fmt.Printf("%s: %s\n", kind, fmt.Sprintf(format, args...))
+	}
+	a.reports[kind]++
+}
+
+// Audit implements the AuditWorkload Audit interface, it reads every record in
+// [insertstart, insertstart + insertcount) to find the missing records and verifies their values
+// if dataintegrity is set, then scans the tables to find the unexpected records if the database
+// returns the key of the scanned records in the field verify.key_field.
+func (c *core) Audit(ctx context.Context, db ycsb.DB, threadCount int) error {
+	insertStart := c.p.GetInt64(prop.InsertStart, prop.InsertStartDefault)
+	insertCount := c.p.GetInt64(prop.InsertCount, c.recordCount-insertStart)
+
+	result := &auditResult{reports: make(map[string]int)}
+	var nextKeyNum int64 = insertStart
+	var wg sync.WaitGroup
+	errs := make(chan error, threadCount)
+	for i := 0; i < threadCount; i++ {
+		wg.Add(1)
+		go func(threadID int) {
+			defer wg.Done()
+
+			ctx := c.InitThread(ctx, threadID, threadCount)
+			ctx = db.InitThread(ctx, threadID, threadCount)
+			defer func() {
+				db.CleanupThread(ctx)
+				c.CleanupThread(ctx)
+			}()
+
+			state := ctx.Value(stateKey).(*coreState)
+			for {
+				keyNum := atomic.AddInt64(&nextKeyNum, 1) - 1
+				if keyNum >= insertStart+insertCount {
+					return
+				}
+				if err := ctx.Err(); err != nil {
+					errs <- err
+					return
+				}
+				c.auditRecord(ctx, db, state, keyNum, result)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	select {
+	case err := <-errs:
+		return err
+	default:
+	}
+
+	// expected maps the expected keys to their tables.
+	expected := make(map[string]string, insertCount)
+	for keyNum := insertStart; keyNum < insertStart+insertCount; keyNum++ {
+		expected[c.buildKeyName(keyNum)] = c.tableName(keyNum)
+	}
+	keyField := c.p.GetString(prop.VerifyKeyField, prop.VerifyKeyFieldDefault)
+	scanned, err := c.auditExtra(ctx, db, keyField, expected, result)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Audit %d records, missing: %d, corrupt: %d, stale: %d", result.records, result.missing,
+		result.corrupt, result.stale)
+	if scanned {
+		fmt.Printf(", extra: %d\n", result.extra)
+	} else {
+		fmt.Printf(", the unexpected records are not checked since the scanned records have no field %s\n", keyField)
+	}
+
+	if result.missing+result.corrupt+result.stale+result.extra > 0 {
+		return fmt.Errorf("audit failed")
+	}
+	return nil
+}
+
+func (c *core) auditRecord(ctx context.Context, db ycsb.DB, state *coreState, keyNum int64, result *auditResult) {
+	atomic.AddInt64(&result.records, 1)
+	keyName := c.buildKeyName(keyNum)
+	values, err := db.Read(ctx, c.tableName(keyNum), keyName, state.fieldNames)
+	if err != nil || len(values) == 0 {
+		atomic.AddInt64(&result.missing, 1)
+		if err != nil {
+			result.report("MISSING", "%s, %v", keyName, err)
+		} else {
+			result.report("MISSING", "%s", keyName)
+		}
+		return
+	}
+
+	if !c.dataIntegrity {
+		return
+	}
+
+	for fieldKey, value := range values {
+		// The SQL databases may return the upper-case fields.
+		if strings.EqualFold(fieldKey, c.queryField) {
+			continue
+		}
+
+		generation, ok := c.matchGeneration(state, keyName, fieldKey, value)
+		if !ok {
+			atomic.AddInt64(&result.corrupt, 1)
+			result.report("CORRUPT", "field %s of %s is %q", fieldKey, keyName, value)
+			return
+		} else if generation < c.minGeneration {
+			atomic.AddInt64(&result.stale, 1)
+			result.report("STALE", "field %s of %s is of generation %d", fieldKey, keyName, generation)
+			return
+		}
+	}
+}
+
+// auditExtra scans the tables to find the records not expected, it returns false if the scanned
+// records have no key field.
+func (c *core) auditExtra(ctx context.Context, db ycsb.DB, keyField string, expected map[string]string,
+	result *auditResult) (bool, error) {
+	batch := c.p.GetInt(prop.VerifyScanBatch, prop.VerifyScanBatchDefault)
+	ctx = c.InitThread(ctx, 0, 1)
+	ctx = db.InitThread(ctx, 0, 1)
+	defer func() {
+		db.CleanupThread(ctx)
+		c.CleanupThread(ctx)
+	}()
+
+	for _, table := range c.tables {
+		startKey := ""
+		for {
+			rows, err := db.Scan(ctx, table, startKey, batch, nil)
+			if err != nil {
+				return false, err
+			}
+
+			lastKey := startKey
+			for _, row := range rows {
+				key, ok := c.rowKey(row, keyField)
+				if !ok {
+					return false, nil
+				}
+				if key == startKey && len(startKey) > 0 {
+					// The start key is scanned again.
+					continue
+				}
+
+				if expectedTable, ok := expected[key]; !ok || expectedTable != table {
+					result.extra++
+					result.report("EXTRA", "%s in %s", key, table)
+				}
+				lastKey = key
+			}
+
+			if len(rows) < batch || lastKey == startKey {
+				break
+			}
+			startKey = lastKey
+		}
+	}
+	return true, nil
+}
+
+func (c *core) rowKey(row map[string][]byte, keyField string) (string, bool) {
+	if c.keySchema != nil {
+		// The composite keys are stored in the key columns.
+		if _, ok := row[c.keySchema.Columns()[0]]; !ok {
+			return "", false
+		}
+		return c.keySchema.KeyOfRow(row), true
+	}
+
+	for field, value := range row {
+		if strings.EqualFold(field, keyField) {
+			return string(value), true
+		}
+	}
+	return "", false
+}
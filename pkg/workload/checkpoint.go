@@ -0,0 +1,128 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workload
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/generator"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/util"
+	"go.uber.org/zap"
+)
+
+// loadCheckpoint saves the progress of the load periodically. The threads share the key sequence,
+// so the inserts complete out of order, and the checkpoint is the key before which all the inserts
+// succeeded. The checkpoint stops at the first failed insert, so the resumed load inserts it again.
+type loadCheckpoint struct {
+	path     string
+	interval time.Duration
+	keys     *generator.AcknowledgedCounter
+	progress util.LoadCheckpoint
+	// failed is the first key whose insert failed, the keys after it are not acknowledged, so the
+	// window of the acknowledged counter doesn't fill up.
+	failed int64
+
+	// The last saved checkpoint, only written by save.
+	saved int64
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// newLoadCheckpoint returns the checkpoint of the keys in [insertStart, insertStart + insertCount)
+// if checkpoint.file is set in the load phase, the keys must be generated by its key sequence.
+func newLoadCheckpoint(p *properties.Properties, insertStart int64, insertCount int64) *loadCheckpoint {
+	path := p.GetString(prop.CheckpointFile, "")
+	if path == "" || p.GetBool(prop.DoTransactions, true) {
+		return nil
+	}
+
+	c := &loadCheckpoint{
+		path:     path,
+		interval: time.Duration(p.GetInt64(prop.CheckpointInterval, prop.CheckpointIntervalDefault)) * time.Second,
+		keys:     generator.NewAcknowledgedCounter(insertStart),
+		progress: util.LoadCheckpoint{
+			ClientID:    p.GetInt64(prop.ClientID, prop.ClientIDDefault),
+			ClientCount: p.GetInt64(prop.ClientCount, prop.ClientCountDefault),
+			Next:        insertStart,
+			End:         insertStart + insertCount,
+		},
+		saved:  -1,
+		failed: math.MaxInt64,
+		done:   make(chan struct{}),
+	}
+	if c.interval <= 0 {
+		util.Fatalf("%s must be positive", prop.CheckpointInterval)
+	}
+
+	c.wg.Add(1)
+	go c.run()
+	return c
+}
+
+func (c *loadCheckpoint) run() {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			c.save()
+		}
+	}
+}
+
+// complete marks the insert of the key as completed if it succeeded.
+func (c *loadCheckpoint) complete(keyNum int64, ok bool) {
+	if !ok {
+		for {
+			failed := atomic.LoadInt64(&c.failed)
+			if keyNum >= failed || atomic.CompareAndSwapInt64(&c.failed, failed, keyNum) {
+				return
+			}
+		}
+	}
+	if keyNum < atomic.LoadInt64(&c.failed) {
+		c.keys.Acknowledge(keyNum)
+	}
+}
+
+// save writes the checkpoint if the load has made progress since the last one.
+func (c *loadCheckpoint) save() {
+	progress := c.progress
+	progress.Next = c.keys.Last() + 1
+	if progress.Next == c.saved {
+		return
+	}
+	if err := progress.Write(c.path); err != nil {
+		util.Logger().Error("save checkpoint failed", zap.String("file", c.path), zap.Error(err))
+		return
+	}
+	c.saved = progress.Next
+}
+
+// close stops saving the checkpoint periodically and saves the last one.
+func (c *loadCheckpoint) close() {
+	close(c.done)
+	c.wg.Wait()
+	c.save()
+}
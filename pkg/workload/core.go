@@ -17,11 +17,13 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io/ioutil"
 	"math"
 	"math/rand"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/magiconair/properties"
@@ -40,6 +42,9 @@ type coreState struct {
 	r *rand.Rand
 	// fieldNames is a copy of core.fieldNames to be goroutine-local
 	fieldNames []string
+	// readFieldSubset and writeFieldSubset are the goroutine-local copies too.
+	readFieldSubset  []string
+	writeFieldSubset []string
 }
 
 type operationType int64
@@ -50,13 +55,20 @@ const (
 	insert
 	scan
 	readModifyWrite
+	del
+	reverseScan
 )
 
+// maxLiveKeyRetries is the max times to choose a key again if the chosen key is deleted.
+const maxLiveKeyRetries = 10
+
 // Core is the core benchmark scenario. Represents a set of clients doing simple CRUD operations.
 type core struct {
 	p *properties.Properties
 
-	table      string
+	// tables has only one table if tablecount <= 1, otherwise the keys are
+	// spread over the tables by the key number.
+	tables     []string
 	fieldCount int64
 	fieldNames []string
 
@@ -65,18 +77,65 @@ type core struct {
 	writeAllFields       bool
 	dataIntegrity        bool
 
+	// readFieldSubset and writeFieldSubset are the fields accessed by every read and update if set.
+	readFieldSubset  []string
+	writeFieldSubset []string
+
+	// valueKind is the kind of the random values, "random", "words", "json" or "compressible".
+	valueKind        string
+	words            []string
+	compressionRatio float64
+
+	// generation is the generation of the deterministic values written if dataIntegrity is set,
+	// the values of the older generations are accepted by the verification down to minGeneration.
+	generation    int64
+	minGeneration int64
+
+	// operationChooser is the *generator.Discrete of the operations, which can be replaced in the run
+	// by SetProportions, proportions are the properties of the current proportions.
+	operationChooser atomic.Value
+	proportionsMu    sync.Mutex
+	proportions      *properties.Properties
+	phases           *phaseSchedule
+
 	keySequence                  ycsb.Generator
-	operationChooser             *generator.Discrete
 	keyChooser                   ycsb.Generator
 	fieldChooser                 ycsb.Generator
 	transactionInsertKeySequence *generator.AcknowledgedCounter
 	scanLength                   ycsb.Generator
-	orderedInserts               bool
+	keyType                      string
+	keyPrefix                    string
 	recordCount                  int64
 	zeroPadding                  int64
 	insertionRetryLimit          int64
 	insertionRetryInterval       int64
 
+	// checkpoint is set if the progress of the load is saved, it acknowledges the keys of keySequence.
+	checkpoint *loadCheckpoint
+
+	// The keys inserted in the run phase by the client clientID of clientCount are interleaved with
+	// the ones of the other clients.
+	clientID    int64
+	clientCount int64
+	randomSeed  int64
+	// threadRuns counts the initializations of every thread.
+	threadRunsMu sync.Mutex
+	threadRuns   map[int64]int64
+
+	// keySchema is set if the keys are composite, the key numbers are split into the components.
+	keySchema *util.KeySchema
+
+	// deletedKeys tracks the numbers of the keys deleted in the run phase if deleteproportion > 0,
+	// so the deletes and the updates target the existing keys, and the reads of the deleted keys
+	// are measured as READ_NOT_FOUND.
+	trackDeletes bool
+	deletedKeys  util.ConcurrentMap
+
+	// queryField is filled with queryCardinality distinct values by the query workload,
+	// so a query by its value matches several records.
+	queryField       string
+	queryCardinality int64
+
 	valuePool sync.Pool
 }
 
@@ -84,15 +143,22 @@ func getFieldLengthGenerator(p *properties.Properties) ycsb.Generator {
 	var fieldLengthGenerator ycsb.Generator
 	fieldLengthDistribution := p.GetString(prop.FieldLengthDistribution, prop.FieldLengthDistributionDefault)
 	fieldLength := p.GetInt64(prop.FieldLength, prop.FieldLengthDefault)
+	minFieldLength := p.GetInt64(prop.MinFieldLength, prop.MinFieldLengthDefault)
 	fieldLengthHistogram := p.GetString(prop.FieldLengthHistogramFile, prop.FieldLengthHistogramFileDefault)
 
-	switch strings.ToLower(fieldLengthDistribution) {
+	fieldLengthDistribution = strings.ToLower(fieldLengthDistribution)
+	if (fieldLengthDistribution == "uniform" || fieldLengthDistribution == "zipfian") &&
+		(minFieldLength < 1 || minFieldLength > fieldLength) {
+		util.Fatalf("invalid field length range [%d, %d]", minFieldLength, fieldLength)
+	}
+
+	switch fieldLengthDistribution {
 	case "constant":
 		fieldLengthGenerator = generator.NewConstant(fieldLength)
 	case "uniform":
-		fieldLengthGenerator = generator.NewUniform(1, fieldLength)
+		fieldLengthGenerator = generator.NewUniform(minFieldLength, fieldLength)
 	case "zipfian":
-		fieldLengthGenerator = generator.NewZipfianWithRange(1, fieldLength, generator.ZipfianConstant)
+		fieldLengthGenerator = generator.NewZipfianWithRange(minFieldLength, fieldLength, generator.ZipfianConstant)
 	case "histogram":
 		fieldLengthGenerator = generator.NewHistogramFromFile(fieldLengthHistogram)
 	default:
@@ -102,12 +168,68 @@ func getFieldLengthGenerator(p *properties.Properties) ycsb.Generator {
 	return fieldLengthGenerator
 }
 
+func getFieldValueKind(p *properties.Properties) (kind string, words []string, compressionRatio float64) {
+	kind = strings.ToLower(p.GetString(prop.FieldValueKind, prop.FieldValueKindDefault))
+	switch kind {
+	case "random":
+	case "words", "json":
+		words = util.DefaultWords
+		if fileName := p.GetString(prop.FieldValueDictionary, ""); len(fileName) > 0 {
+			words = loadWords(fileName)
+		}
+	case "compressible":
+		compressionRatio = p.GetFloat64(prop.FieldValueCompressionRatio, prop.FieldValueCompressionRatioDefault)
+		if compressionRatio < 1 {
+			util.Fatalf("%s must not be less than 1", prop.FieldValueCompressionRatio)
+		}
+	default:
+		util.Fatalf("unknown field value kind %s", kind)
+	}
+
+	return
+}
+
+// loadWords loads the whitespace separated words of the dictionary file, the words
+// which need to be escaped in JSON are skipped.
+func loadWords(fileName string) []string {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		util.Fatalf("load dictionary file %s failed %v", fileName, err)
+	}
+
+	var words []string
+	for _, word := range strings.Fields(string(data)) {
+		if strings.IndexFunc(word, func(c rune) bool {
+			return c == '"' || c == '\\' || c < 0x20 || c > 0x7e
+		}) < 0 {
+			words = append(words, word)
+		}
+	}
+	if len(words) == 0 {
+		util.Fatalf("no word in dictionary file %s", fileName)
+	}
+	return words
+}
+
+// proportionProps are the properties of the operation proportions.
+var proportionProps = []string{
+	prop.ReadProportion,
+	prop.UpdateProportion,
+	prop.InsertProportion,
+	prop.ScanProportion,
+	prop.ReadModifyWriteProportion,
+	prop.DeleteProportion,
+	prop.ReverseScanProportion,
+}
+
 func createOperationGenerator(p *properties.Properties) *generator.Discrete {
 	readProportion := p.GetFloat64(prop.ReadProportion, prop.ReadProportionDefault)
 	updateProportion := p.GetFloat64(prop.UpdateProportion, prop.UpdateProportionDefault)
 	insertProportion := p.GetFloat64(prop.InsertProportion, prop.InsertProportionDefault)
 	scanProportion := p.GetFloat64(prop.ScanProportion, prop.ScanProportionDefault)
 	readModifyWriteProportion := p.GetFloat64(prop.ReadModifyWriteProportion, prop.ReadModifyWriteProportionDefault)
+	deleteProportion := p.GetFloat64(prop.DeleteProportion, prop.DeleteProportionDefault)
+	reverseScanProportion := p.GetFloat64(prop.ReverseScanProportion, prop.ReverseScanProportionDefault)
 
 	operationChooser := generator.NewDiscrete()
 	if readProportion > 0 {
@@ -130,17 +252,37 @@ func createOperationGenerator(p *properties.Properties) *generator.Discrete {
 		operationChooser.Add(readModifyWriteProportion, int64(readModifyWrite))
 	}
 
+	if deleteProportion > 0 {
+		operationChooser.Add(deleteProportion, int64(del))
+	}
+
+	if reverseScanProportion > 0 {
+		operationChooser.Add(reverseScanProportion, int64(reverseScan))
+	}
+
 	return operationChooser
 }
 
 // InitThread implements the Workload InitThread interface.
-func (c *core) InitThread(ctx context.Context, _ int, _ int) context.Context {
-	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+func (c *core) InitThread(ctx context.Context, threadID int, threadCount int) context.Context {
+	// The threads of all the clients get the distinct sources, and so do the threads initialized
+	// again by the rounds of compare.
+	thread := c.clientID*int64(threadCount) + int64(threadID)
+	seed := util.ThreadSeed(c.randomSeed, thread)
+	c.threadRunsMu.Lock()
+	if runs := c.threadRuns[thread]; runs > 0 {
+		seed = util.ThreadSeed(seed, runs)
+	}
+	c.threadRuns[thread]++
+	c.threadRunsMu.Unlock()
+	r := rand.New(rand.NewSource(seed))
 	fieldNames := make([]string, len(c.fieldNames))
 	copy(fieldNames, c.fieldNames)
 	state := &coreState{
-		r:          r,
-		fieldNames: fieldNames,
+		r:                r,
+		fieldNames:       fieldNames,
+		readFieldSubset:  append([]string(nil), c.readFieldSubset...),
+		writeFieldSubset: append([]string(nil), c.writeFieldSubset...),
 	}
 	return context.WithValue(ctx, stateKey, state)
 }
@@ -152,16 +294,111 @@ func (c *core) CleanupThread(_ context.Context) {
 
 // Close implements the Workload Close interface.
 func (c *core) Close() error {
+	if c.checkpoint != nil {
+		c.checkpoint.close()
+	}
+	return nil
+}
+
+// tableName returns the table which the key belongs to.
+func (c *core) tableName(keyNum int64) string {
+	return c.tables[keyNum%int64(len(c.tables))]
+}
+
+// forEachTable splits the batch by the tables of the keys and calls f for every table.
+// The values may be nil.
+func (c *core) forEachTable(ctx context.Context, keyNums []int64, keys []string, values []map[string][]byte,
+	f func(ctx context.Context, table string, keys []string, values []map[string][]byte) error) error {
+	if len(c.tables) == 1 {
+		return f(ctx, c.tables[0], keys, values)
+	}
+
+	tableKeys := make(map[string][]string)
+	tableValues := make(map[string][]map[string][]byte)
+	for i, keyNum := range keyNums {
+		table := c.tableName(keyNum)
+		tableKeys[table] = append(tableKeys[table], keys[i])
+		if values != nil {
+			tableValues[table] = append(tableValues[table], values[i])
+		}
+	}
+
+	for _, table := range c.tables {
+		if keys, ok := tableKeys[table]; ok {
+			if err := f(ctx, table, keys, tableValues[table]); err != nil {
+				return err
+			}
+		}
+	}
 	return nil
 }
 
 func (c *core) buildKeyName(keyNum int64) string {
-	if !c.orderedInserts {
+	if c.clientCount > 1 && keyNum >= c.recordCount {
+		keyNum = c.recordCount + (keyNum-c.recordCount)*c.clientCount + c.clientID
+	}
+
+	if c.keySchema != nil {
+		return c.keySchema.Key(keyNum)
+	}
+
+	switch c.keyType {
+	case "hashed":
 		keyNum = util.Hash64(keyNum)
+	case "uuid":
+		return c.keyPrefix + util.UUID(keyNum)
+	case "snowflake":
+		keyNum = util.Snowflake(keyNum)
+	}
+
+	return fmt.Sprintf("%s%0[3]*[2]d", c.keyPrefix, keyNum, c.zeroPadding)
+}
+
+// readFields returns the fields to read, the subset set by readfields, all the fields, or a random field.
+func (c *core) readFields(state *coreState) []string {
+	if len(state.readFieldSubset) > 0 {
+		return state.readFieldSubset
+	} else if c.readAllFields {
+		return state.fieldNames
+	}
+	return []string{state.fieldNames[c.fieldChooser.Next(state.r)]}
+}
+
+// buildUpdateValues builds the values to update, the subset set by writefields, all the fields, or a random field.
+func (c *core) buildUpdateValues(state *coreState, key string) map[string][]byte {
+	if len(state.writeFieldSubset) == 0 {
+		if c.writeAllFields {
+			return c.buildValues(state, key)
+		}
+		return c.buildSingleValue(state, key)
+	}
+
+	values := make(map[string][]byte, len(state.writeFieldSubset))
+	for _, fieldKey := range state.writeFieldSubset {
+		values[fieldKey] = c.buildFieldValue(state, key, fieldKey)
+	}
+	return values
+}
+
+// parseFieldSubset parses the comma separated fields, every field must be one of the fields.
+func (c *core) parseFieldSubset(name string, value string) []string {
+	if len(strings.TrimSpace(value)) == 0 {
+		return nil
 	}
 
-	prefix := c.p.GetString(prop.KeyPrefix, prop.KeyPrefixDefault)
-	return fmt.Sprintf("%s%0[3]*[2]d", prefix, keyNum, c.zeroPadding)
+	var fields []string
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		found := false
+		for _, fieldName := range c.fieldNames {
+			found = found || fieldName == field
+		}
+		if !found {
+			util.Fatalf("%s %s must be one of the fields %s..%s", name, field, c.fieldNames[0], c.fieldNames[c.fieldCount-1])
+		}
+		fields = append(fields, field)
+	}
+	return fields
 }
 
 func (c *core) buildSingleValue(state *coreState, key string) map[string][]byte {
@@ -170,14 +407,7 @@ func (c *core) buildSingleValue(state *coreState, key string) map[string][]byte
 	r := state.r
 	fieldKey := state.fieldNames[c.fieldChooser.Next(r)]
 
-	var buf []byte
-	if c.dataIntegrity {
-		buf = c.buildDeterministicValue(state, key, fieldKey)
-	} else {
-		buf = c.buildRandomValue(state)
-	}
-
-	values[fieldKey] = buf
+	values[fieldKey] = c.buildFieldValue(state, key, fieldKey)
 
 	return values
 }
@@ -186,18 +416,20 @@ func (c *core) buildValues(state *coreState, key string) map[string][]byte {
 	values := make(map[string][]byte, c.fieldCount)
 
 	for _, fieldKey := range state.fieldNames {
-		var buf []byte
-		if c.dataIntegrity {
-			buf = c.buildDeterministicValue(state, key, fieldKey)
-		} else {
-			buf = c.buildRandomValue(state)
-		}
-
-		values[fieldKey] = buf
+		values[fieldKey] = c.buildFieldValue(state, key, fieldKey)
 	}
 	return values
 }
 
+func (c *core) buildFieldValue(state *coreState, key string, fieldKey string) []byte {
+	if fieldKey == c.queryField {
+		return c.buildQueryValue(state)
+	} else if c.dataIntegrity {
+		return c.buildDeterministicValue(state, key, fieldKey, c.generation)
+	}
+	return c.buildRandomValue(state)
+}
+
 func (c *core) getValueBuffer(size int) []byte {
 	buf := c.valuePool.Get().([]byte)
 	if cap(buf) >= size {
@@ -217,11 +449,22 @@ func (c *core) buildRandomValue(state *coreState) []byte {
 	// TODO: use pool for the buffer
 	r := state.r
 	buf := c.getValueBuffer(int(c.fieldLengthGenerator.Next(r)))
-	util.RandBytes(r, buf)
+	switch c.valueKind {
+	case "words":
+		util.RandWords(r, buf, c.words)
+	case "json":
+		util.RandJSON(r, buf, c.words)
+	case "compressible":
+		util.RandCompressibleBytes(r, buf, c.compressionRatio)
+	default:
+		util.RandBytes(r, buf)
+	}
 	return buf
 }
 
-func (c *core) buildDeterministicValue(state *coreState, key string, fieldKey string) []byte {
+// buildDeterministicValue builds the value derived from the key, the field and the generation,
+// the generation 0 is omitted to be compatible with the values written without the generation.
+func (c *core) buildDeterministicValue(state *coreState, key string, fieldKey string, generation int64) []byte {
 	// TODO: use pool for the buffer
 	r := state.r
 	size := c.fieldLengthGenerator.Next(r)
@@ -230,6 +473,10 @@ func (c *core) buildDeterministicValue(state *coreState, key string, fieldKey st
 	b.WriteString(key)
 	b.WriteByte(':')
 	b.WriteString(strings.ToLower(fieldKey))
+	if generation > 0 {
+		b.WriteByte(':')
+		b.WriteString(strconv.FormatInt(generation, 10))
+	}
 	for int64(b.Len()) < size {
 		b.WriteByte(':')
 		n := util.BytesHash64(b.Bytes())
@@ -239,18 +486,53 @@ func (c *core) buildDeterministicValue(state *coreState, key string, fieldKey st
 	return b.Bytes()
 }
 
-func (c *core) verifyRow(state *coreState, key string, values map[string][]byte) {
+func (c *core) buildQueryValue(state *coreState) []byte {
+	return queryValue(state.r.Int63n(c.queryCardinality))
+}
+
+func queryValue(n int64) []byte {
+	return []byte(fmt.Sprintf("value%d", n))
+}
+
+// verifyRow verifies the deterministic values of the record, the verification is measured
+// as VERIFY, or VERIFY_FAILED if any value is unexpected.
+func (c *core) verifyRow(state *coreState, key string, values map[string][]byte) error {
 	if len(values) == 0 {
 		// null data here, need panic?
-		return
+		return nil
 	}
 
+	start := time.Now()
 	for fieldKey, value := range values {
-		expected := c.buildDeterministicValue(state, key, fieldKey)
-		if !bytes.Equal(expected, value) {
-			util.Fatalf("unexpected deterministic value, expect %q, but got %q", expected, value)
+		// The SQL databases may return the upper-case fields.
+		if strings.EqualFold(fieldKey, c.queryField) {
+			continue
+		}
+		generation, ok := c.matchGeneration(state, key, fieldKey, value)
+		if !ok {
+			measurement.Measure("VERIFY_FAILED", time.Now().Sub(start))
+			expected := c.buildDeterministicValue(state, key, fieldKey, c.generation)
+			return fmt.Errorf("unexpected deterministic value, expect %q, but got %q", expected, value)
+		} else if generation < c.minGeneration {
+			measurement.Measure("VERIFY_FAILED", time.Now().Sub(start))
+			return fmt.Errorf("stale deterministic value %q of generation %d, expect generation %d at least",
+				value, generation, c.minGeneration)
+		}
+	}
+
+	measurement.Measure("VERIFY", time.Now().Sub(start))
+	return nil
+}
+
+// matchGeneration returns the latest generation not greater than the current generation
+// whose deterministic value of the field is the value.
+func (c *core) matchGeneration(state *coreState, key string, fieldKey string, value []byte) (int64, bool) {
+	for generation := c.generation; generation >= 0; generation-- {
+		if bytes.Equal(c.buildDeterministicValue(state, key, fieldKey, generation), value) {
+			return generation, true
 		}
 	}
+	return 0, false
 }
 
 // DoInsert implements the Workload DoInsert interface.
@@ -266,7 +548,7 @@ func (c *core) DoInsert(ctx context.Context, db ycsb.DB) error {
 
 	var err error
 	for {
-		err = db.Insert(ctx, c.table, dbKey, values)
+		err = db.Insert(ctx, c.tableName(keyNum), dbKey, values)
 		if err == nil {
 			break
 		}
@@ -293,6 +575,9 @@ func (c *core) DoInsert(ctx context.Context, db ycsb.DB) error {
 		time.Sleep(time.Duration(sleepTimeMs) * time.Millisecond)
 	}
 
+	if c.checkpoint != nil {
+		c.checkpoint.complete(keyNum, err == nil)
+	}
 	return err
 }
 
@@ -304,11 +589,13 @@ func (c *core) DoBatchInsert(ctx context.Context, batchSize int, db ycsb.DB) err
 	}
 	state := ctx.Value(stateKey).(*coreState)
 	r := state.r
+	var keyNums []int64
 	var keys []string
 	var values []map[string][]byte
 	for i := 0; i < batchSize; i++ {
 		keyNum := c.keySequence.Next(r)
 		dbKey := c.buildKeyName(keyNum)
+		keyNums = append(keyNums, keyNum)
 		keys = append(keys, dbKey)
 		values = append(values, c.buildValues(state, dbKey))
 	}
@@ -321,7 +608,7 @@ func (c *core) DoBatchInsert(ctx context.Context, batchSize int, db ycsb.DB) err
 	numOfRetries := int64(0)
 	var err error
 	for {
-		err = batchDB.BatchInsert(ctx, c.table, keys, values)
+		err = c.forEachTable(ctx, keyNums, keys, values, batchDB.BatchInsert)
 		if err == nil {
 			break
 		}
@@ -347,15 +634,69 @@ func (c *core) DoBatchInsert(ctx context.Context, batchSize int, db ycsb.DB) err
 
 		time.Sleep(time.Duration(sleepTimeMs) * time.Millisecond)
 	}
+	if c.checkpoint != nil {
+		for _, keyNum := range keyNums {
+			c.checkpoint.complete(keyNum, err == nil)
+		}
+	}
 	return err
 }
 
+// nextOperation chooses the next operation, by the operation mix of the current phase if the phases are set.
+func (c *core) nextOperation(r *rand.Rand) operationType {
+	if c.phases != nil {
+		return c.phases.nextOperation(r)
+	}
+	return operationType(c.operationChooser.Load().(*generator.Discrete).Next(r))
+}
+
+// SetProportions implements the TunableWorkload SetProportions interface.
+func (c *core) SetProportions(p *properties.Properties) error {
+	if c.phases != nil {
+		return fmt.Errorf("the proportions can't be changed with %s", prop.Phases)
+	}
+
+	for _, key := range p.Keys() {
+		found := false
+		for _, name := range proportionProps {
+			found = found || key == name
+		}
+		if !found {
+			return fmt.Errorf("unknown proportion %s", key)
+		}
+		if v, err := strconv.ParseFloat(p.MustGetString(key), 64); err != nil || v < 0 {
+			return fmt.Errorf("invalid %s %s", key, p.MustGetString(key))
+		}
+	}
+
+	c.proportionsMu.Lock()
+	defer c.proportionsMu.Unlock()
+
+	proportions := properties.NewProperties()
+	proportions.Merge(c.proportions)
+	proportions.Merge(p)
+	total := float64(0)
+	for _, name := range proportionProps {
+		total += proportions.GetFloat64(name, 0)
+	}
+	if total <= 0 {
+		return fmt.Errorf("at least one proportion must be positive")
+	}
+	if !c.trackDeletes && proportions.GetFloat64(prop.DeleteProportion, 0) > 0 {
+		return fmt.Errorf("the deletes can't be enabled in the run if %s is 0 at the start", prop.DeleteProportion)
+	}
+
+	c.operationChooser.Store(createOperationGenerator(proportions))
+	c.proportions = proportions
+	return nil
+}
+
 // DoTransaction implements the Workload DoTransaction interface.
 func (c *core) DoTransaction(ctx context.Context, db ycsb.DB) error {
 	state := ctx.Value(stateKey).(*coreState)
 	r := state.r
 
-	operation := operationType(c.operationChooser.Next(r))
+	operation := c.nextOperation(r)
 	switch operation {
 	case read:
 		return c.doTransactionRead(ctx, db, state)
@@ -365,6 +706,10 @@ func (c *core) DoTransaction(ctx context.Context, db ycsb.DB) error {
 		return c.doTransactionInsert(ctx, db, state)
 	case scan:
 		return c.doTransactionScan(ctx, db, state)
+	case del:
+		return c.doTransactionDelete(ctx, db, state)
+	case reverseScan:
+		return c.doTransactionReverseScan(ctx, db, state)
 	default:
 		return c.doTransactionReadModifyWrite(ctx, db, state)
 	}
@@ -379,7 +724,7 @@ func (c *core) DoBatchTransaction(ctx context.Context, batchSize int, db ycsb.DB
 	state := ctx.Value(stateKey).(*coreState)
 	r := state.r
 
-	operation := operationType(c.operationChooser.Next(r))
+	operation := c.nextOperation(r)
 	switch operation {
 	case read:
 		return c.doBatchTransactionRead(ctx, batchSize, batchDB, state)
@@ -387,9 +732,29 @@ func (c *core) DoBatchTransaction(ctx context.Context, batchSize int, db ycsb.DB
 		return c.doBatchTransactionInsert(ctx, batchSize, batchDB, state)
 	case update:
 		return c.doBatchTransactionUpdate(ctx, batchSize, batchDB, state)
+	case del:
+		return c.doBatchTransactionDelete(ctx, batchSize, batchDB, state)
 	case scan:
-		panic("The batch mode don't support the scan operation")
+		// Scan and read-modify-write can't be batched, so they are done one by one.
+		for i := 0; i < batchSize; i++ {
+			if err := c.doTransactionScan(ctx, db, state); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reverseScan:
+		for i := 0; i < batchSize; i++ {
+			if err := c.doTransactionReverseScan(ctx, db, state); err != nil {
+				return err
+			}
+		}
+		return nil
 	default:
+		for i := 0; i < batchSize; i++ {
+			if err := c.doTransactionReadModifyWrite(ctx, db, state); err != nil {
+				return err
+			}
+		}
 		return nil
 	}
 }
@@ -411,68 +776,105 @@ func (c *core) nextKeyNum(state *coreState) int64 {
 	return keyNum
 }
 
+// isDeleted returns whether the key is deleted in the run phase.
+func (c *core) isDeleted(keyNum int64) bool {
+	return c.trackDeletes && c.deletedKeys.Has(int(keyNum))
+}
+
+// nextLiveKeyNum chooses a key which is not deleted, it may still return a deleted key
+// if all the retries choose the deleted keys.
+func (c *core) nextLiveKeyNum(state *coreState) int64 {
+	keyNum := c.nextKeyNum(state)
+	for i := 0; i < maxLiveKeyRetries && c.isDeleted(keyNum); i++ {
+		keyNum = c.nextKeyNum(state)
+	}
+	return keyNum
+}
+
+// claimDeleteKeyNum chooses a key and marks it deleted, so the concurrent deletes never target
+// the same key. deleted is true if all the retries choose the deleted keys. The mark is cleared
+// by unclaimDeleteKeyNum if the delete fails.
+func (c *core) claimDeleteKeyNum(state *coreState) (keyNum int64, deleted bool) {
+	for i := 0; i <= maxLiveKeyRetries; i++ {
+		keyNum = c.nextKeyNum(state)
+		if c.deletedKeys.SetIfAbsent(int(keyNum), 0) {
+			return keyNum, false
+		}
+	}
+	return keyNum, true
+}
+
+// unclaimDeleteKeyNum clears the mark of the key claimed by claimDeleteKeyNum whose delete fails.
+func (c *core) unclaimDeleteKeyNum(keyNum int64) {
+	c.deletedKeys.Remove(int(keyNum))
+}
+
+// anyDeleted returns whether any of the keys is deleted in the run phase.
+func (c *core) anyDeleted(keyNums []int64) bool {
+	for _, keyNum := range keyNums {
+		if c.isDeleted(keyNum) {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *core) doTransactionRead(ctx context.Context, db ycsb.DB, state *coreState) error {
-	r := state.r
 	keyNum := c.nextKeyNum(state)
 	keyName := c.buildKeyName(keyNum)
-
-	var fields []string
-	if !c.readAllFields {
-		fieldName := state.fieldNames[c.fieldChooser.Next(r)]
-		fields = append(fields, fieldName)
-	} else {
-		fields = state.fieldNames
+	deleted := c.isDeleted(keyNum)
+	if deleted {
+		ctx = ycsb.WithExpectedNotFound(ctx)
 	}
 
-	values, err := db.Read(ctx, c.table, keyName, fields)
-	if err != nil {
+	fields := c.readFields(state)
+
+	values, err := db.Read(ctx, c.tableName(keyNum), keyName, fields)
+	if deleted {
+		// The DB may report the deleted record as an error.
+		return nil
+	} else if err != nil {
 		return err
 	}
 
 	if c.dataIntegrity {
-		c.verifyRow(state, keyName, values)
+		return c.verifyRow(state, keyName, values)
 	}
 
 	return nil
 }
 
 func (c *core) doTransactionReadModifyWrite(ctx context.Context, db ycsb.DB, state *coreState) error {
-	start := time.Now()
-	defer func() {
-		measurement.Measure("READ_MODIFY_WRITE", time.Now().Sub(start))
-	}()
-
-	r := state.r
-	keyNum := c.nextKeyNum(state)
+	keyNum := c.nextLiveKeyNum(state)
+	if c.isDeleted(keyNum) {
+		// The write would insert the deleted record again on the databases which upsert.
+		measurement.Measure("READ_MODIFY_WRITE_NOT_FOUND", 0)
+		return nil
+	}
 	keyName := c.buildKeyName(keyNum)
 
-	var fields []string
-	if !c.readAllFields {
-		fieldName := state.fieldNames[c.fieldChooser.Next(r)]
-		fields = append(fields, fieldName)
-	} else {
-		fields = state.fieldNames
-	}
+	fields := c.readFields(state)
 
-	var values map[string][]byte
-	if c.writeAllFields {
-		values = c.buildValues(state, keyName)
-	} else {
-		values = c.buildSingleValue(state, keyName)
-	}
+	values := c.buildUpdateValues(state, keyName)
 	defer c.putValues(values)
 
-	readValues, err := db.Read(ctx, c.table, keyName, fields)
-	if err != nil {
-		return err
+	table := c.tableName(keyNum)
+	var readValues map[string][]byte
+	var err error
+	if rmwDB, ok := db.(ycsb.ReadModifyWriteDB); ok {
+		readValues, err = rmwDB.ReadModifyWrite(ctx, table, keyName, fields, values)
+	} else {
+		readValues, err = db.Read(ctx, table, keyName, fields)
+		if err == nil {
+			err = db.Update(ctx, table, keyName, values)
+		}
 	}
-
-	if err := db.Update(ctx, c.table, keyName, values); err != nil {
+	if err != nil {
 		return err
 	}
 
 	if c.dataIntegrity {
-		c.verifyRow(state, keyName, readValues)
+		return c.verifyRow(state, keyName, readValues)
 	}
 
 	return nil
@@ -486,7 +888,7 @@ func (c *core) doTransactionInsert(ctx context.Context, db ycsb.DB, state *coreS
 	values := c.buildValues(state, dbKey)
 	defer c.putValues(values)
 
-	return db.Insert(ctx, c.table, dbKey, values)
+	return db.Insert(ctx, c.tableName(keyNum), dbKey, values)
 }
 
 func (c *core) doTransactionScan(ctx context.Context, db ycsb.DB, state *coreState) error {
@@ -496,53 +898,85 @@ func (c *core) doTransactionScan(ctx context.Context, db ycsb.DB, state *coreSta
 
 	scanLen := c.scanLength.Next(r)
 
-	var fields []string
-	if !c.readAllFields {
-		fieldName := state.fieldNames[c.fieldChooser.Next(r)]
-		fields = append(fields, fieldName)
-	} else {
-		fields = state.fieldNames
+	fields := c.readFields(state)
+
+	_, err := db.Scan(ctx, c.tableName(keyNum), startKeyName, int(scanLen), fields)
+
+	return err
+}
+
+func (c *core) doTransactionReverseScan(ctx context.Context, db ycsb.DB, state *coreState) error {
+	reverseScanDB, ok := db.(ycsb.ReverseScanDB)
+	if !ok {
+		return fmt.Errorf("the %T doesn't implement the ReverseScanDB interface", db)
 	}
 
-	_, err := db.Scan(ctx, c.table, startKeyName, int(scanLen), fields)
+	r := state.r
+	keyNum := c.nextKeyNum(state)
+	startKeyName := c.buildKeyName(keyNum)
+
+	scanLen := c.scanLength.Next(r)
+
+	fields := c.readFields(state)
+
+	_, err := reverseScanDB.ReverseScan(ctx, c.tableName(keyNum), startKeyName, int(scanLen), fields)
 
 	return err
 }
 
 func (c *core) doTransactionUpdate(ctx context.Context, db ycsb.DB, state *coreState) error {
-	keyNum := c.nextKeyNum(state)
+	keyNum := c.nextLiveKeyNum(state)
+	if c.isDeleted(keyNum) {
+		// The update would insert the deleted record again on the databases which upsert.
+		measurement.Measure("UPDATE_NOT_FOUND", 0)
+		return nil
+	}
 	keyName := c.buildKeyName(keyNum)
 
-	var values map[string][]byte
-	if c.writeAllFields {
-		values = c.buildValues(state, keyName)
-	} else {
-		values = c.buildSingleValue(state, keyName)
-	}
+	values := c.buildUpdateValues(state, keyName)
 
 	defer c.putValues(values)
 
-	return db.Update(ctx, c.table, keyName, values)
+	return db.Update(ctx, c.tableName(keyNum), keyName, values)
 }
 
-func (c *core) doBatchTransactionRead(ctx context.Context, batchSize int, db ycsb.BatchDB, state *coreState) error {
-	r := state.r
-	var fields []string
+func (c *core) doTransactionDelete(ctx context.Context, db ycsb.DB, state *coreState) error {
+	keyNum, deleted := c.claimDeleteKeyNum(state)
+	if deleted {
+		ctx = ycsb.WithExpectedNotFound(ctx)
+	}
 
-	if !c.readAllFields {
-		fieldName := state.fieldNames[c.fieldChooser.Next(r)]
-		fields = append(fields, fieldName)
-	} else {
-		fields = state.fieldNames
+	err := db.Delete(ctx, c.tableName(keyNum), c.buildKeyName(keyNum))
+	if deleted {
+		return nil
+	} else if err != nil {
+		c.unclaimDeleteKeyNum(keyNum)
 	}
+	return err
+}
+
+func (c *core) doBatchTransactionRead(ctx context.Context, batchSize int, db ycsb.BatchDB, state *coreState) error {
+	fields := c.readFields(state)
 
+	keyNums := make([]int64, batchSize)
 	keys := make([]string, batchSize)
 	for i := 0; i < batchSize; i++ {
-		keys[i] = c.buildKeyName(c.nextKeyNum(state))
+		keyNums[i] = c.nextLiveKeyNum(state)
+		keys[i] = c.buildKeyName(keyNums[i])
+	}
+	deleted := c.anyDeleted(keyNums)
+	if deleted {
+		ctx = ycsb.WithExpectedNotFound(ctx)
 	}
 
-	_, err := db.BatchRead(ctx, c.table, keys, fields)
-	if err != nil {
+	err := c.forEachTable(ctx, keyNums, keys, nil, func(ctx context.Context, table string, keys []string, _ []map[string][]byte) error {
+		_, err := db.BatchRead(ctx, table, keys, fields)
+		return err
+	})
+	if deleted {
+		// The DB may report the deleted records as an error.
+		return nil
+	} else if err != nil {
 		return err
 	}
 
@@ -552,11 +986,13 @@ func (c *core) doBatchTransactionRead(ctx context.Context, batchSize int, db ycs
 
 func (c *core) doBatchTransactionInsert(ctx context.Context, batchSize int, db ycsb.BatchDB, state *coreState) error {
 	r := state.r
+	keyNums := make([]int64, batchSize)
 	keys := make([]string, batchSize)
 	values := make([]map[string][]byte, batchSize)
 	for i := 0; i < batchSize; i++ {
 		keyNum := c.transactionInsertKeySequence.Next(r)
 		keyName := c.buildKeyName(keyNum)
+		keyNums[i] = keyNum
 		keys[i] = keyName
 		if c.writeAllFields {
 			values[i] = c.buildValues(state, keyName)
@@ -572,21 +1008,27 @@ func (c *core) doBatchTransactionInsert(ctx context.Context, batchSize int, db y
 		}
 	}()
 
-	return db.BatchInsert(ctx, c.table, keys, values)
+	return c.forEachTable(ctx, keyNums, keys, values, db.BatchInsert)
 }
 
 func (c *core) doBatchTransactionUpdate(ctx context.Context, batchSize int, db ycsb.BatchDB, state *coreState) error {
-	keys := make([]string, batchSize)
-	values := make([]map[string][]byte, batchSize)
+	keyNums := make([]int64, 0, batchSize)
+	keys := make([]string, 0, batchSize)
+	values := make([]map[string][]byte, 0, batchSize)
 	for i := 0; i < batchSize; i++ {
-		keyNum := c.nextKeyNum(state)
-		keyName := c.buildKeyName(keyNum)
-		keys[i] = keyName
-		if c.writeAllFields {
-			values[i] = c.buildValues(state, keyName)
-		} else {
-			values[i] = c.buildSingleValue(state, keyName)
+		// The deleted keys are skipped, the update would insert them again on the databases which upsert.
+		keyNum := c.nextLiveKeyNum(state)
+		if c.isDeleted(keyNum) {
+			continue
 		}
+		keyName := c.buildKeyName(keyNum)
+		keyNums = append(keyNums, keyNum)
+		keys = append(keys, keyName)
+		values = append(values, c.buildUpdateValues(state, keyName))
+	}
+	if len(keys) == 0 {
+		measurement.Measure("BATCH_UPDATE_NOT_FOUND", 0)
+		return nil
 	}
 
 	defer func() {
@@ -595,7 +1037,40 @@ func (c *core) doBatchTransactionUpdate(ctx context.Context, batchSize int, db y
 		}
 	}()
 
-	return db.BatchUpdate(ctx, c.table, keys, values)
+	return c.forEachTable(ctx, keyNums, keys, values, db.BatchUpdate)
+}
+
+func (c *core) doBatchTransactionDelete(ctx context.Context, batchSize int, db ycsb.BatchDB, state *coreState) error {
+	keyNums := make([]int64, 0, batchSize)
+	keys := make([]string, 0, batchSize)
+	for i := 0; i < batchSize; i++ {
+		// The keys which are already deleted are skipped.
+		if keyNum, deleted := c.claimDeleteKeyNum(state); !deleted {
+			keyNums = append(keyNums, keyNum)
+			keys = append(keys, c.buildKeyName(keyNum))
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	deletedTables := make(map[string]bool)
+	err := c.forEachTable(ctx, keyNums, keys, nil, func(ctx context.Context, table string, keys []string, _ []map[string][]byte) error {
+		if err := db.BatchDelete(ctx, table, keys); err != nil {
+			return err
+		}
+		deletedTables[table] = true
+		return nil
+	})
+	if err != nil {
+		// The keys of the failed batch and of the tables not reached may still exist.
+		for _, keyNum := range keyNums {
+			if !deletedTables[c.tableName(keyNum)] {
+				c.unclaimDeleteKeyNum(keyNum)
+			}
+		}
+	}
+	return err
 }
 
 // CoreCreator creates the Core workload.
@@ -606,13 +1081,11 @@ type coreCreator struct {
 func (coreCreator) Create(p *properties.Properties) (ycsb.Workload, error) {
 	c := new(core)
 	c.p = p
-	c.table = p.GetString(prop.TableName, prop.TableNameDefault)
+	c.tables = util.TableNames(p)
 	c.fieldCount = p.GetInt64(prop.FieldCount, prop.FieldCountDefault)
-	c.fieldNames = make([]string, c.fieldCount)
-	for i := int64(0); i < c.fieldCount; i++ {
-		c.fieldNames[i] = fmt.Sprintf("field%d", i)
-	}
+	c.fieldNames = util.FieldNames(p)
 	c.fieldLengthGenerator = getFieldLengthGenerator(p)
+	c.valueKind, c.words, c.compressionRatio = getFieldValueKind(p)
 	c.recordCount = p.GetInt64(prop.RecordCount, prop.RecordCountDefault)
 	if c.recordCount == 0 {
 		c.recordCount = int64(math.MaxInt32)
@@ -629,22 +1102,65 @@ func (coreCreator) Create(p *properties.Properties) (ycsb.Workload, error) {
 			c.recordCount, insertStart, insertCount)
 	}
 	c.zeroPadding = p.GetInt64(prop.ZeroPadding, prop.ZeroPaddingDefault)
+	c.clientID = p.GetInt64(prop.ClientID, prop.ClientIDDefault)
+	c.clientCount = p.GetInt64(prop.ClientCount, prop.ClientCountDefault)
+	c.randomSeed = p.GetInt64(prop.RandomSeed, time.Now().UnixNano())
+	c.threadRuns = make(map[int64]int64)
+	c.keyPrefix = p.GetString(prop.KeyPrefix, prop.KeyPrefixDefault)
 	c.readAllFields = p.GetBool(prop.ReadAllFields, prop.ReadALlFieldsDefault)
 	c.writeAllFields = p.GetBool(prop.WriteAllFields, prop.WriteAllFieldsDefault)
+	c.readFieldSubset = c.parseFieldSubset(prop.ReadFields, p.GetString(prop.ReadFields, ""))
+	c.writeFieldSubset = c.parseFieldSubset(prop.WriteFields, p.GetString(prop.WriteFields, ""))
 	c.dataIntegrity = p.GetBool(prop.DataIntegrity, prop.DataIntegrityDefault)
 	fieldLengthDistribution := p.GetString(prop.FieldLengthDistribution, prop.FieldLengthDistributionDefault)
 	if c.dataIntegrity && fieldLengthDistribution != "constant" {
 		util.Fatal("must have constant field size to check data integrity")
 	}
+	c.generation = p.GetInt64(prop.DataIntegrityGeneration, prop.DataIntegrityGenerationDefault)
+	c.minGeneration = p.GetInt64(prop.DataIntegrityMinGeneration, prop.DataIntegrityMinGenerationDefault)
+	if c.generation < 0 || c.minGeneration < 0 || c.minGeneration > c.generation {
+		util.Fatalf("invalid generation range [%d, %d]", c.minGeneration, c.generation)
+	}
 
-	if p.GetString(prop.InsertOrder, prop.InsertOrderDefault) == "hashed" {
-		c.orderedInserts = false
-	} else {
-		c.orderedInserts = true
+	switch insertOrder := p.GetString(prop.InsertOrder, prop.InsertOrderDefault); strings.ToLower(insertOrder) {
+	case "hashed":
+		c.keyType = "hashed"
+	case "ordered":
+		c.keyType = "sequential"
+	default:
+		util.Fatalf("unknown insert order %s", insertOrder)
+	}
+	// keytype overrides insertorder.
+	if keyType, ok := p.Get(prop.KeyType); ok {
+		switch c.keyType = strings.ToLower(keyType); c.keyType {
+		case "sequential", "hashed", "uuid", "snowflake":
+		default:
+			util.Fatalf("unknown key type %s", keyType)
+		}
 	}
 
 	c.keySequence = generator.NewCounter(insertStart)
-	c.operationChooser = createOperationGenerator(p)
+	if c.checkpoint = newLoadCheckpoint(p, insertStart, insertCount); c.checkpoint != nil {
+		c.keySequence = c.checkpoint.keys
+	}
+	c.operationChooser.Store(createOperationGenerator(p))
+	c.proportions = properties.NewProperties()
+	for _, name := range proportionProps {
+		if v, ok := p.Get(name); ok {
+			c.proportions.Set(name, v)
+		}
+	}
+	if c.phases = newPhaseSchedule(p); c.phases != nil {
+		// The run ends with the last phase by default.
+		if _, ok := p.Get(prop.MaxExecutiontime); !ok {
+			seconds := int64((c.phases.duration() + time.Second - 1) / time.Second)
+			p.Set(prop.MaxExecutiontime, strconv.FormatInt(seconds, 10))
+		}
+	}
+	if p.GetFloat64(prop.DeleteProportion, prop.DeleteProportionDefault) > 0 || (c.phases != nil && c.phases.hasDeletes(p)) {
+		c.trackDeletes = true
+		c.deletedKeys = util.New(64)
+	}
 
 	c.transactionInsertKeySequence = generator.NewAcknowledgedCounter(c.recordCount)
 	switch requestDistrib {
@@ -663,6 +1179,17 @@ func (coreCreator) Create(p *properties.Properties) (ycsb.Workload, error) {
 		hotsetFraction := p.GetFloat64(prop.HotspotDataFraction, prop.HotspotDataFractionDefault)
 		hotopnFraction := p.GetFloat64(prop.HotspotOpnFraction, prop.HotspotOpnFractionDefault)
 		c.keyChooser = generator.NewHotspot(insertStart, insertStart+insertCount-1, hotsetFraction, hotopnFraction)
+	case "shifting_hotspot":
+		hotsetFraction := p.GetFloat64(prop.HotspotDataFraction, prop.HotspotDataFractionDefault)
+		hotopnFraction := p.GetFloat64(prop.HotspotOpnFraction, prop.HotspotOpnFractionDefault)
+		shiftInterval := time.Duration(p.GetInt64(prop.HotspotShiftInterval, prop.HotspotShiftIntervalDefault)) * time.Second
+		c.keyChooser = generator.NewShiftingHotspot(insertStart, insertStart+insertCount-1, hotsetFraction, hotopnFraction, shiftInterval)
+	case "file":
+		fileName := p.GetString(prop.RequestDistributionFile, "")
+		if len(fileName) == 0 {
+			util.Fatalf("%s must be set for the file request distribution", prop.RequestDistributionFile)
+		}
+		c.keyChooser = generator.NewKeyHistogramFromFile(fileName, insertStart, insertStart+insertCount-1)
 	case "exponential":
 		percentile := p.GetFloat64(prop.ExponentialPercentile, prop.ExponentialPercentileDefault)
 		frac := p.GetFloat64(prop.ExponentialFrac, prop.ExponentialFracDefault)
@@ -671,12 +1198,34 @@ func (coreCreator) Create(p *properties.Properties) (ycsb.Workload, error) {
 		util.Fatalf("unknown request distribution %s", requestDistrib)
 	}
 
+	if c.keySchema = util.NewKeySchema(p); c.keySchema != nil {
+		// The components of the composite keys are chosen by their own distributions.
+		if c.keySchema.Cardinality() != c.recordCount {
+			util.Fatalf("the number of the composite keys %d must be %s %d", c.keySchema.Cardinality(),
+				prop.RecordCount, c.recordCount)
+		}
+
+		components := make([]ycsb.Generator, 0, len(c.keySchema.Components))
+		cardinalities := make([]int64, 0, len(c.keySchema.Components))
+		for _, component := range c.keySchema.Components {
+			if component.Distribution == "zipfian" {
+				components = append(components, generator.NewZipfianWithRange(0, component.Cardinality-1, generator.ZipfianConstant))
+			} else {
+				components = append(components, generator.NewUniform(0, component.Cardinality-1))
+			}
+			cardinalities = append(cardinalities, component.Cardinality)
+		}
+		c.keyChooser = generator.NewComposite(components, cardinalities)
+	}
+
 	c.fieldChooser = generator.NewUniform(0, c.fieldCount-1)
 	switch scanLengthDistrib {
 	case "uniform":
 		c.scanLength = generator.NewUniform(1, maxScanLength)
 	case "zipfian":
 		c.scanLength = generator.NewZipfianWithRange(1, maxScanLength, generator.ZipfianConstant)
+	case "constant":
+		c.scanLength = generator.NewConstant(maxScanLength)
 	default:
 		util.Fatalf("distribution %s not allowed for scan length", scanLengthDistrib)
 	}
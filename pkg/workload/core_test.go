@@ -0,0 +1,158 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workload
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/measurement"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+)
+
+// upsertDB is a database in memory whose updates insert the missing records, like the key-value stores.
+type upsertDB struct {
+	sync.Mutex
+	rows map[string]map[string][]byte
+	// failDeletes fails all the deletes.
+	failDeletes bool
+}
+
+func newUpsertDB() *upsertDB {
+	return &upsertDB{rows: make(map[string]map[string][]byte)}
+}
+
+func (db *upsertDB) Close() error {
+	return nil
+}
+
+func (db *upsertDB) InitThread(ctx context.Context, _ int, _ int) context.Context {
+	return ctx
+}
+
+func (db *upsertDB) CleanupThread(_ context.Context) {
+}
+
+func (db *upsertDB) Read(_ context.Context, _ string, key string, _ []string) (map[string][]byte, error) {
+	db.Lock()
+	defer db.Unlock()
+	row, ok := db.rows[key]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return row, nil
+}
+
+func (db *upsertDB) Scan(_ context.Context, _ string, _ string, _ int, _ []string) ([]map[string][]byte, error) {
+	return nil, nil
+}
+
+func (db *upsertDB) Update(_ context.Context, _ string, key string, values map[string][]byte) error {
+	db.Lock()
+	defer db.Unlock()
+	db.rows[key] = values
+	return nil
+}
+
+func (db *upsertDB) Insert(_ context.Context, _ string, key string, values map[string][]byte) error {
+	db.Lock()
+	defer db.Unlock()
+	db.rows[key] = values
+	return nil
+}
+
+func (db *upsertDB) Delete(_ context.Context, _ string, key string) error {
+	db.Lock()
+	defer db.Unlock()
+	if db.failDeletes {
+		return errors.New("delete failed")
+	}
+	if _, ok := db.rows[key]; !ok {
+		return errors.New("not found")
+	}
+	delete(db.rows, key)
+	return nil
+}
+
+func newTestCore(t *testing.T, kvs map[string]string) *core {
+	p := properties.NewProperties()
+	for k, v := range kvs {
+		p.Set(k, v)
+	}
+	measurement.InitMeasure(p)
+
+	w, err := coreCreator{}.Create(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w.(*core)
+}
+
+func TestDeleteTracking(t *testing.T) {
+	tests := []map[string]string{
+		{prop.UpdateProportion: "0.5", prop.DeleteProportion: "0.5"},
+		{prop.ReadModifyWriteProportion: "0.5", prop.DeleteProportion: "0.5"},
+		{prop.ReadProportion: "0.3", prop.UpdateProportion: "0.3", prop.DeleteProportion: "0.4"},
+	}
+
+	for _, proportions := range tests {
+		kvs := map[string]string{prop.RecordCount: "100", prop.ReadProportion: "0"}
+		for k, v := range proportions {
+			kvs[k] = v
+		}
+		c := newTestCore(t, kvs)
+		db := newUpsertDB()
+		ctx := c.InitThread(context.Background(), 0, 1)
+		for i := 0; i < 100; i++ {
+			if err := c.DoInsert(ctx, db); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// The deletes target the existing keys, and the updates never insert the deleted keys again.
+		for i := 0; i < 500; i++ {
+			if err := c.DoTransaction(ctx, db); err != nil {
+				t.Fatalf("want no error of %v, but got %v", proportions, err)
+			}
+		}
+		for keyNum := int64(0); keyNum < 100; keyNum++ {
+			_, exists := db.rows[c.buildKeyName(keyNum)]
+			if c.isDeleted(keyNum) == exists {
+				t.Errorf("want key %d deleted %v of %v, but got %v", keyNum, !exists, proportions, c.isDeleted(keyNum))
+			}
+		}
+	}
+}
+
+func TestFailedDelete(t *testing.T) {
+	c := newTestCore(t, map[string]string{prop.RecordCount: "100", prop.ReadProportion: "0", prop.DeleteProportion: "1"})
+	db := newUpsertDB()
+	db.failDeletes = true
+	ctx := c.InitThread(context.Background(), 0, 1)
+
+	// The keys of the failed deletes are still live.
+	for i := 0; i < 10; i++ {
+		if err := c.DoTransaction(ctx, db); err == nil {
+			t.Fatal("want the delete failed, but got no error")
+		}
+	}
+	for keyNum := int64(0); keyNum < 100; keyNum++ {
+		if c.isDeleted(keyNum) {
+			t.Errorf("want key %d not deleted, but got deleted", keyNum)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workload
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync/atomic"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/generator"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/util"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+)
+
+// lifecycle runs the records through the insert, read/update, delete and reinsert cycles,
+// like the sessions in a session store. The records are loaded in the same way as the core workload.
+type lifecycle struct {
+	*core
+
+	lifetimeGenerator ycsb.Generator
+	deadLifetime      int64
+	readProportion    float64
+
+	// lives has the state of every record, a positive value is the remaining accesses before
+	// the record is deleted, a non-positive value is the negative remaining accesses before
+	// the deleted record is inserted again.
+	lives []int64
+}
+
+// SetProportions implements the TunableWorkload SetProportions interface, the lifecycle workload
+// doesn't choose the operations by the proportions.
+func (l *lifecycle) SetProportions(_ *properties.Properties) error {
+	return fmt.Errorf("the lifecycle workload doesn't support changing the proportions")
+}
+
+// DoTransaction implements the Workload DoTransaction interface.
+func (l *lifecycle) DoTransaction(ctx context.Context, db ycsb.DB) error {
+	state := ctx.Value(stateKey).(*coreState)
+	keyNum := l.nextKeyNum(state)
+	life := &l.lives[keyNum]
+
+	for {
+		v := atomic.LoadInt64(life)
+		switch {
+		case v > 1:
+			if atomic.CompareAndSwapInt64(life, v, v-1) {
+				return l.doAccess(ctx, db, state, keyNum)
+			}
+		case v == 1:
+			if atomic.CompareAndSwapInt64(life, v, -l.deadLifetime) {
+				return db.Delete(ctx, l.tableName(keyNum), l.buildKeyName(keyNum))
+			}
+		case v == 0:
+			if atomic.CompareAndSwapInt64(life, v, l.lifetimeGenerator.Next(state.r)) {
+				return l.doInsert(ctx, db, state, keyNum)
+			}
+		default:
+			if atomic.CompareAndSwapInt64(life, v, v+1) {
+				return l.doDeadRead(ctx, db, state, keyNum)
+			}
+		}
+	}
+}
+
+// DoBatchTransaction implements the Workload DoBatchTransaction interface,
+// the operations of the records in different states can't be batched, so they are done one by one.
+func (l *lifecycle) DoBatchTransaction(ctx context.Context, batchSize int, db ycsb.DB) error {
+	for i := 0; i < batchSize; i++ {
+		if err := l.DoTransaction(ctx, db); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// doAccess reads or updates the live record.
+func (l *lifecycle) doAccess(ctx context.Context, db ycsb.DB, state *coreState, keyNum int64) error {
+	keyName := l.buildKeyName(keyNum)
+	table := l.tableName(keyNum)
+
+	if state.r.Float64() < l.readProportion {
+		values, err := db.Read(ctx, table, keyName, l.readFields(state))
+		if err != nil {
+			return err
+		}
+
+		if l.dataIntegrity {
+			return l.verifyRow(state, keyName, values)
+		}
+		return nil
+	}
+
+	values := l.buildUpdateValues(state, keyName)
+	defer l.putValues(values)
+
+	return db.Update(ctx, table, keyName, values)
+}
+
+func (l *lifecycle) doInsert(ctx context.Context, db ycsb.DB, state *coreState, keyNum int64) error {
+	keyName := l.buildKeyName(keyNum)
+	values := l.buildValues(state, keyName)
+	defer l.putValues(values)
+
+	return db.Insert(ctx, l.tableName(keyNum), keyName, values)
+}
+
+// doDeadRead reads the deleted record, it is measured as READ_NOT_FOUND.
+func (l *lifecycle) doDeadRead(ctx context.Context, db ycsb.DB, state *coreState, keyNum int64) error {
+	ctx = ycsb.WithExpectedNotFound(ctx)
+	db.Read(ctx, l.tableName(keyNum), l.buildKeyName(keyNum), l.readFields(state))
+	return nil
+}
+
+type lifecycleCreator struct {
+}
+
+// Create implements the WorkloadCreator Create interface.
+func (lifecycleCreator) Create(p *properties.Properties) (ycsb.Workload, error) {
+	w, err := coreCreator{}.Create(p)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &lifecycle{
+		core:           w.(*core),
+		deadLifetime:   p.GetInt64(prop.LifecycleDeadLifetime, prop.LifecycleDeadLifetimeDefault),
+		readProportion: p.GetFloat64(prop.LifecycleReadProportion, prop.LifecycleReadProportionDefault),
+	}
+	if l.deadLifetime < 0 {
+		util.Fatalf("%s must not be negative", prop.LifecycleDeadLifetime)
+	}
+
+	minLifetime := p.GetInt64(prop.LifecycleMinLifetime, prop.LifecycleMinLifetimeDefault)
+	maxLifetime := p.GetInt64(prop.LifecycleMaxLifetime, prop.LifecycleMaxLifetimeDefault)
+	if minLifetime < 1 || maxLifetime < minLifetime {
+		util.Fatalf("invalid lifetime range [%d, %d]", minLifetime, maxLifetime)
+	}
+
+	lifetimeDistrib := p.GetString(prop.LifecycleLifetimeDistribution, prop.LifecycleLifetimeDistributionDefault)
+	switch strings.ToLower(lifetimeDistrib) {
+	case "constant":
+		l.lifetimeGenerator = generator.NewConstant(maxLifetime)
+	case "uniform":
+		l.lifetimeGenerator = generator.NewUniform(minLifetime, maxLifetime)
+	case "zipfian":
+		l.lifetimeGenerator = generator.NewZipfianWithRange(minLifetime, maxLifetime, generator.ZipfianConstant)
+	default:
+		util.Fatalf("unknown lifetime distribution %s", lifetimeDistrib)
+	}
+
+	if p.GetBool(prop.DoTransactions, true) {
+		if p.GetInt64(prop.RecordCount, prop.RecordCountDefault) == 0 {
+			util.Fatalf("%s must be set for the lifecycle workload", prop.RecordCount)
+		}
+
+		// The loaded records start at a random point of their lifetimes, so they are not deleted at the same time.
+		r := rand.New(rand.NewSource(l.randomSeed))
+		l.lives = make([]int64, l.recordCount)
+		for i := range l.lives {
+			l.lives[i] = 1 + r.Int63n(l.lifetimeGenerator.Next(r))
+		}
+	}
+
+	return l, nil
+}
+
+func init() {
+	ycsb.RegisterWorkloadCreator("lifecycle", lifecycleCreator{})
+}
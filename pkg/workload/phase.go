@@ -0,0 +1,117 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workload
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/generator"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/util"
+)
+
+// phase is a period of the run with its own operation mix.
+type phase struct {
+	name string
+	// end is the offset of the end of the phase from the start of the first phase.
+	end              time.Duration
+	operationChooser *generator.Discrete
+}
+
+// phaseSchedule runs the phases back-to-back, the first phase starts with the first
+// transaction, and the last phase lasts until the end of the run.
+type phaseSchedule struct {
+	phases []phase
+
+	startOnce sync.Once
+	start     time.Time
+	current   int64
+}
+
+// newPhaseSchedule parses the phases, it returns nil if no phase is set.
+func newPhaseSchedule(p *properties.Properties) *phaseSchedule {
+	names := strings.Split(p.GetString(prop.Phases, ""), ",")
+	s := new(phaseSchedule)
+	var end time.Duration
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if len(name) == 0 {
+			continue
+		}
+
+		prefix := prop.PhasePrefix + name + "."
+		durationKey := prefix + "duration"
+		d, err := time.ParseDuration(p.GetString(durationKey, ""))
+		if err != nil || d <= 0 {
+			util.Fatalf("%s must be a positive duration like 60s", durationKey)
+		}
+		end += d
+
+		// The properties of the phase override the global ones.
+		phaseProps := properties.NewProperties()
+		phaseProps.Merge(p)
+		phaseProps.Merge(p.FilterStripPrefix(prefix))
+		s.phases = append(s.phases, phase{
+			name:             name,
+			end:              end,
+			operationChooser: createOperationGenerator(phaseProps),
+		})
+	}
+
+	if len(s.phases) == 0 {
+		return nil
+	}
+	return s
+}
+
+// hasDeletes returns true if any phase deletes records.
+func (s *phaseSchedule) hasDeletes(p *properties.Properties) bool {
+	for _, ph := range s.phases {
+		key := prop.PhasePrefix + ph.name + "." + prop.DeleteProportion
+		if p.GetFloat64(key, p.GetFloat64(prop.DeleteProportion, prop.DeleteProportionDefault)) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// duration returns the total duration of the phases.
+func (s *phaseSchedule) duration() time.Duration {
+	return s.phases[len(s.phases)-1].end
+}
+
+// nextOperation chooses the next operation by the operation mix of the current phase.
+func (s *phaseSchedule) nextOperation(r *rand.Rand) operationType {
+	s.startOnce.Do(func() {
+		s.start = time.Now()
+		fmt.Printf("Phase %s started\n", s.phases[0].name)
+	})
+
+	elapsed := time.Since(s.start)
+	current := atomic.LoadInt64(&s.current)
+	i := current
+	for i < int64(len(s.phases))-1 && elapsed >= s.phases[i].end {
+		i++
+	}
+	if i != current && atomic.CompareAndSwapInt64(&s.current, current, i) {
+		fmt.Printf("Phase %s started after %s\n", s.phases[i].name, elapsed.Round(time.Second))
+	}
+	return operationType(s.phases[i].operationChooser.Next(r))
+}
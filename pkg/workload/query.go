@@ -0,0 +1,107 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workload
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/util"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+)
+
+// queryWorkload looks up the records by the value of a field in addition to the core operations,
+// the field is filled with a few distinct values so every query matches several records.
+type queryWorkload struct {
+	*core
+
+	queryProportion float64
+	queryLimit      int64
+}
+
+// DoTransaction implements the Workload DoTransaction interface.
+func (q *queryWorkload) DoTransaction(ctx context.Context, db ycsb.DB) error {
+	state := ctx.Value(stateKey).(*coreState)
+	if state.r.Float64() >= q.queryProportion {
+		return q.core.DoTransaction(ctx, db)
+	}
+
+	return q.doQuery(ctx, db, state)
+}
+
+// DoBatchTransaction implements the Workload DoBatchTransaction interface,
+// the queries can't be batched, so they are done one by one.
+func (q *queryWorkload) DoBatchTransaction(ctx context.Context, batchSize int, db ycsb.DB) error {
+	state := ctx.Value(stateKey).(*coreState)
+	if state.r.Float64() >= q.queryProportion {
+		return q.core.DoBatchTransaction(ctx, batchSize, db)
+	}
+
+	for i := 0; i < batchSize; i++ {
+		if err := q.doQuery(ctx, db, state); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (q *queryWorkload) doQuery(ctx context.Context, db ycsb.DB, state *coreState) error {
+	queryDB, ok := db.(ycsb.QueryDB)
+	if !ok {
+		return fmt.Errorf("the %T doesn't implement the QueryDB interface", db)
+	}
+
+	table := q.tables[state.r.Intn(len(q.tables))]
+	_, err := queryDB.Query(ctx, table, q.queryField, q.buildQueryValue(state), int(q.queryLimit), q.readFields(state))
+	return err
+}
+
+type queryCreator struct {
+}
+
+// Create implements the WorkloadCreator Create interface.
+func (queryCreator) Create(p *properties.Properties) (ycsb.Workload, error) {
+	w, err := coreCreator{}.Create(p)
+	if err != nil {
+		return nil, err
+	}
+
+	q := &queryWorkload{
+		core:            w.(*core),
+		queryProportion: p.GetFloat64(prop.QueryProportion, prop.QueryProportionDefault),
+		queryLimit:      p.GetInt64(prop.QueryLimit, prop.QueryLimitDefault),
+	}
+
+	q.queryField = p.GetString(prop.QueryField, prop.QueryFieldDefault)
+	found := false
+	for _, field := range q.fieldNames {
+		found = found || field == q.queryField
+	}
+	if !found {
+		util.Fatalf("%s %s must be one of the fields %s..%s", prop.QueryField, q.queryField, q.fieldNames[0], q.fieldNames[q.fieldCount-1])
+	}
+
+	q.queryCardinality = p.GetInt64(prop.QueryCardinality, prop.QueryCardinalityDefault)
+	if q.queryCardinality < 1 {
+		util.Fatalf("%s must be positive", prop.QueryCardinality)
+	}
+
+	return q, nil
+}
+
+func init() {
+	ycsb.RegisterWorkloadCreator("query", queryCreator{})
+}
@@ -0,0 +1,302 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workload
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/util"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+)
+
+// replayOp is an operation of the trace.
+type replayOp struct {
+	op     operationType
+	key    string
+	fields []string
+	// timestamp is in microseconds.
+	timestamp int64
+}
+
+// parseReplayLine parses the trace line "op,key,fields,timestamp", the fields are separated by ';'.
+func parseReplayLine(line string) (replayOp, error) {
+	items := strings.Split(line, ",")
+	if len(items) != 4 {
+		return replayOp{}, fmt.Errorf("must be op,key,fields,timestamp")
+	}
+
+	var o replayOp
+	switch strings.ToLower(strings.TrimSpace(items[0])) {
+	case "read":
+		o.op = read
+	case "update":
+		o.op = update
+	case "insert":
+		o.op = insert
+	case "scan":
+		o.op = scan
+	case "delete":
+		o.op = del
+	default:
+		return replayOp{}, fmt.Errorf("unknown operation %s", items[0])
+	}
+
+	o.key = strings.TrimSpace(items[1])
+	if len(o.key) == 0 {
+		return replayOp{}, fmt.Errorf("empty key")
+	}
+
+	for _, field := range strings.Split(items[2], ";") {
+		if field = strings.TrimSpace(field); len(field) > 0 {
+			o.fields = append(o.fields, field)
+		}
+	}
+
+	var err error
+	if o.timestamp, err = strconv.ParseInt(strings.TrimSpace(items[3]), 10, 64); err != nil {
+		return replayOp{}, fmt.Errorf("invalid timestamp %s", items[3])
+	}
+	return o, nil
+}
+
+// replay replays the operations of a trace file in the run phase, the records are loaded
+// in the same way as the core workload. The trace is replayed again from the beginning
+// if operationcount exceeds the number of the operations in the trace.
+type replay struct {
+	*core
+
+	table    string
+	fileName string
+	// speedup is the factor to speed up the original inter-arrival times,
+	// 0 means replaying the operations as fast as possible.
+	speedup float64
+
+	mu     sync.Mutex
+	file   *os.File
+	reader *bufio.Reader
+	line   int
+	// start and startTimestamp are the wall time and the trace timestamp of the first
+	// operation of the current pass.
+	start          time.Time
+	startTimestamp int64
+	started        bool
+}
+
+// next returns the next operation of the trace and the time to do it.
+func (r *replay) next() (replayOp, time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for {
+		line, err := r.reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			util.Fatalf("read trace file %s failed %v", r.fileName, err)
+		}
+		if err == io.EOF && len(line) == 0 {
+			if _, err = r.file.Seek(0, io.SeekStart); err != nil {
+				util.Fatalf("rewind trace file %s failed %v", r.fileName, err)
+			}
+			r.reader.Reset(r.file)
+			r.line = 0
+			r.started = false
+			continue
+		}
+
+		r.line++
+		line = strings.TrimSpace(line)
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		o, err := parseReplayLine(line)
+		if err != nil {
+			util.Fatalf("invalid line %d %q of trace file %s, %v", r.line, line, r.fileName, err)
+		}
+
+		if !r.started {
+			r.start = time.Now()
+			r.startTimestamp = o.timestamp
+			r.started = true
+		}
+
+		if r.speedup <= 0 {
+			return o, r.start
+		}
+		d := time.Duration(float64(o.timestamp-r.startTimestamp) / r.speedup * float64(time.Microsecond))
+		return o, r.start.Add(d)
+	}
+}
+
+// SetProportions implements the TunableWorkload SetProportions interface, the replay workload
+// doesn't choose the operations by the proportions.
+func (r *replay) SetProportions(_ *properties.Properties) error {
+	return fmt.Errorf("the replay workload doesn't support changing the proportions")
+}
+
+// DoTransaction implements the Workload DoTransaction interface.
+func (r *replay) DoTransaction(ctx context.Context, db ycsb.DB) error {
+	o, at := r.next()
+	if d := time.Until(at); d > 0 {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(d):
+		}
+	}
+
+	state := ctx.Value(stateKey).(*coreState)
+	fields := o.fields
+	if len(fields) == 0 {
+		fields = state.fieldNames
+	}
+
+	switch o.op {
+	case read:
+		values, err := db.Read(ctx, r.table, o.key, fields)
+		if err != nil {
+			return err
+		}
+
+		if r.dataIntegrity {
+			return r.verifyRow(state, o.key, values)
+		}
+		return nil
+	case update, insert:
+		values := make(map[string][]byte, len(fields))
+		for _, fieldKey := range fields {
+			values[fieldKey] = r.buildFieldValue(state, o.key, fieldKey)
+		}
+		defer r.putValues(values)
+
+		if o.op == update {
+			return db.Update(ctx, r.table, o.key, values)
+		}
+		return db.Insert(ctx, r.table, o.key, values)
+	case scan:
+		_, err := db.Scan(ctx, r.table, o.key, int(r.scanLength.Next(state.r)), fields)
+		return err
+	default:
+		return db.Delete(ctx, r.table, o.key)
+	}
+}
+
+// DoBatchTransaction implements the Workload DoBatchTransaction interface,
+// the operations are replayed one by one to keep the order of the trace.
+func (r *replay) DoBatchTransaction(ctx context.Context, batchSize int, db ycsb.DB) error {
+	for i := 0; i < batchSize; i++ {
+		if err := r.DoTransaction(ctx, db); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close implements the Workload Close interface.
+func (r *replay) Close() error {
+	r.core.Close()
+	if r.file != nil {
+		return r.file.Close()
+	}
+	return nil
+}
+
+// countReplayOps validates the trace file and returns the number of the operations.
+func countReplayOps(fileName string) int64 {
+	f, err := os.Open(fileName)
+	if err != nil {
+		util.Fatalf("open trace file %s failed %v", fileName, err)
+	}
+	defer f.Close()
+
+	var count int64
+	scanner := bufio.NewScanner(f)
+	for i := 1; scanner.Scan(); i++ {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if _, err := parseReplayLine(line); err != nil {
+			util.Fatalf("invalid line %d %q of trace file %s, %v", i, line, fileName, err)
+		}
+		count++
+	}
+	if err := scanner.Err(); err != nil {
+		util.Fatalf("read trace file %s failed %v", fileName, err)
+	}
+
+	return count
+}
+
+type replayCreator struct {
+}
+
+// Create implements the WorkloadCreator Create interface.
+func (replayCreator) Create(p *properties.Properties) (ycsb.Workload, error) {
+	w, err := coreCreator{}.Create(p)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &replay{
+		core:     w.(*core),
+		table:    p.GetString(prop.TableName, prop.TableNameDefault),
+		fileName: p.GetString(prop.ReplayFile, ""),
+		speedup:  p.GetFloat64(prop.ReplaySpeedup, prop.ReplaySpeedupDefault),
+	}
+	if r.speedup < 0 {
+		util.Fatalf("%s must not be negative", prop.ReplaySpeedup)
+	}
+
+	if !p.GetBool(prop.DoTransactions, true) {
+		return r, nil
+	}
+
+	if len(r.tables) > 1 {
+		util.Fatalf("the replay workload doesn't support %s > 1", prop.TableCount)
+	}
+	if len(r.fileName) == 0 {
+		util.Fatalf("%s must be set for the replay workload", prop.ReplayFile)
+	}
+
+	count := countReplayOps(r.fileName)
+	if count == 0 {
+		util.Fatalf("no operation in trace file %s", r.fileName)
+	}
+	if _, ok := p.Get(prop.OperationCount); !ok {
+		// Replay the trace once by default.
+		p.Set(prop.OperationCount, strconv.FormatInt(count, 10))
+	}
+
+	if r.file, err = os.Open(r.fileName); err != nil {
+		return nil, err
+	}
+	r.reader = bufio.NewReader(r.file)
+
+	return r, nil
+}
+
+func init() {
+	ycsb.RegisterWorkloadCreator("replay", replayCreator{})
+}
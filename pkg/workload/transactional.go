@@ -0,0 +1,173 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workload
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/generator"
+	"github.com/pingcap/go-ycsb/pkg/measurement"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/util"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+)
+
+// transactional runs multi-statement transactions, every transaction reads or updates
+// several keys. The records are loaded in the same way as the core workload.
+type transactional struct {
+	*core
+
+	keysGenerator  ycsb.Generator
+	readProportion float64
+}
+
+// SetProportions implements the TunableWorkload SetProportions interface, the transactional workload
+// doesn't choose the operations by the proportions.
+func (t *transactional) SetProportions(_ *properties.Properties) error {
+	return fmt.Errorf("the transactional workload doesn't support changing the proportions")
+}
+
+// DoTransaction implements the Workload DoTransaction interface.
+func (t *transactional) DoTransaction(ctx context.Context, db ycsb.DB) error {
+	txnDB, ok := db.(ycsb.TransactionDB)
+	if !ok {
+		return fmt.Errorf("the %T doesn't implement the TransactionDB interface", db)
+	}
+	state := ctx.Value(stateKey).(*coreState)
+
+	start := time.Now()
+	err := t.doTransaction(ctx, db, txnDB, state)
+	lan := time.Now().Sub(start)
+	if err != nil {
+		measurement.Measure("TRANSACTION_ERROR", lan)
+		return err
+	}
+
+	measurement.Measure("TRANSACTION", lan)
+	return nil
+}
+
+// DoBatchTransaction implements the Workload DoBatchTransaction interface,
+// the transactions can't be batched, so they are done one by one.
+func (t *transactional) DoBatchTransaction(ctx context.Context, batchSize int, db ycsb.DB) error {
+	for i := 0; i < batchSize; i++ {
+		if err := t.DoTransaction(ctx, db); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nextKeyNums chooses the keys of a transaction, the duplicated keys are only accessed once.
+func (t *transactional) nextKeyNums(state *coreState) []int64 {
+	n := t.keysGenerator.Next(state.r)
+	keyNums := make([]int64, 0, n)
+	seen := make(map[int64]struct{}, n)
+	for i := int64(0); i < n; i++ {
+		keyNum := t.nextKeyNum(state)
+		if _, ok := seen[keyNum]; ok {
+			continue
+		}
+		seen[keyNum] = struct{}{}
+		keyNums = append(keyNums, keyNum)
+	}
+	return keyNums
+}
+
+func (t *transactional) doTransaction(ctx context.Context, db ycsb.DB, txnDB ycsb.TransactionDB, state *coreState) (err error) {
+	r := state.r
+	keyNums := t.nextKeyNums(state)
+
+	txnCtx, err := txnDB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			txnDB.Rollback(txnCtx)
+		}
+	}()
+
+	for _, keyNum := range keyNums {
+		keyName := t.buildKeyName(keyNum)
+		table := t.tableName(keyNum)
+
+		if r.Float64() < t.readProportion {
+			var values map[string][]byte
+			if values, err = db.Read(txnCtx, table, keyName, t.readFields(state)); err != nil {
+				return err
+			}
+
+			if t.dataIntegrity {
+				if err = t.verifyRow(state, keyName, values); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		values := t.buildUpdateValues(state, keyName)
+		err = db.Update(txnCtx, table, keyName, values)
+		t.putValues(values)
+		if err != nil {
+			return err
+		}
+	}
+
+	return txnDB.Commit(txnCtx)
+}
+
+type transactionalCreator struct {
+}
+
+// Create implements the WorkloadCreator Create interface.
+func (transactionalCreator) Create(p *properties.Properties) (ycsb.Workload, error) {
+	w, err := coreCreator{}.Create(p)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &transactional{
+		core:           w.(*core),
+		readProportion: p.GetFloat64(prop.TransactionReadProportion, prop.TransactionReadProportionDefault),
+	}
+
+	minKeys := p.GetInt64(prop.TransactionMinKeys, prop.TransactionMinKeysDefault)
+	maxKeys := p.GetInt64(prop.TransactionMaxKeys, prop.TransactionMaxKeysDefault)
+	if minKeys < 1 || maxKeys < minKeys {
+		util.Fatalf("invalid transaction keys range [%d, %d]", minKeys, maxKeys)
+	}
+
+	keysDistrib := p.GetString(prop.TransactionKeysDistribution, prop.TransactionKeysDistributionDefault)
+	switch strings.ToLower(keysDistrib) {
+	case "constant":
+		t.keysGenerator = generator.NewConstant(maxKeys)
+	case "uniform":
+		t.keysGenerator = generator.NewUniform(minKeys, maxKeys)
+	case "zipfian":
+		t.keysGenerator = generator.NewZipfianWithRange(minKeys, maxKeys, generator.ZipfianConstant)
+	default:
+		util.Fatalf("unknown transaction keys distribution %s", keysDistrib)
+	}
+
+	return t, nil
+}
+
+func init() {
+	ycsb.RegisterWorkloadCreator("transactional", transactionalCreator{})
+}
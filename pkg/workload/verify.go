@@ -0,0 +1,132 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workload
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/measurement"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/util"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+)
+
+// verify reads every record once in the run phase and verifies its deterministic values, the records
+// are measured as VERIFY if they are intact, VERIFY_LOST if they are missing, VERIFY_CORRUPTED if
+// any value is not derived from the key and the field, or VERIFY_STALE if any value is of a generation
+// older than dataintegrity.min_generation, which is a lost update. The records are loaded in the same
+// way as the core workload.
+type verify struct {
+	*core
+
+	endKeyNum int64
+}
+
+// SetProportions implements the TunableWorkload SetProportions interface, the verify workload
+// doesn't choose the operations by the proportions.
+func (v *verify) SetProportions(_ *properties.Properties) error {
+	return fmt.Errorf("the verify workload doesn't support changing the proportions")
+}
+
+// DoTransaction implements the Workload DoTransaction interface.
+func (v *verify) DoTransaction(ctx context.Context, db ycsb.DB) error {
+	state := ctx.Value(stateKey).(*coreState)
+	keyNum := v.keySequence.Next(state.r)
+	if keyNum >= v.endKeyNum {
+		return nil
+	}
+	keyName := v.buildKeyName(keyNum)
+
+	start := time.Now()
+	values, err := db.Read(ctx, v.tableName(keyNum), keyName, state.fieldNames)
+	if err != nil {
+		return err
+	}
+	lan := time.Now().Sub(start)
+
+	if len(values) == 0 {
+		measurement.Measure("VERIFY_LOST", lan)
+		return fmt.Errorf("record %s is lost", keyName)
+	}
+
+	for fieldKey, value := range values {
+		// The SQL databases may return the upper-case fields.
+		if strings.EqualFold(fieldKey, v.queryField) {
+			continue
+		}
+
+		generation, ok := v.matchGeneration(state, keyName, fieldKey, value)
+		if !ok {
+			measurement.Measure("VERIFY_CORRUPTED", lan)
+			return fmt.Errorf("field %s of record %s is corrupted, got %q", fieldKey, keyName, value)
+		} else if generation < v.minGeneration {
+			measurement.Measure("VERIFY_STALE", lan)
+			return fmt.Errorf("field %s of record %s is of generation %d, expect generation %d at least",
+				fieldKey, keyName, generation, v.minGeneration)
+		}
+	}
+
+	measurement.Measure("VERIFY", lan)
+	return nil
+}
+
+// DoBatchTransaction implements the Workload DoBatchTransaction interface,
+// the records are verified one by one.
+func (v *verify) DoBatchTransaction(ctx context.Context, batchSize int, db ycsb.DB) error {
+	for i := 0; i < batchSize; i++ {
+		if err := v.DoTransaction(ctx, db); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type verifyCreator struct {
+}
+
+// Create implements the WorkloadCreator Create interface.
+func (verifyCreator) Create(p *properties.Properties) (ycsb.Workload, error) {
+	w, err := coreCreator{}.Create(p)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &verify{
+		core: w.(*core),
+	}
+	if !v.dataIntegrity {
+		util.Fatalf("%s must be set for the verify workload", prop.DataIntegrity)
+	}
+
+	insertStart := p.GetInt64(prop.InsertStart, prop.InsertStartDefault)
+	insertCount := p.GetInt64(prop.InsertCount, v.recordCount-insertStart)
+	v.endKeyNum = insertStart + insertCount
+	if _, ok := p.Get(prop.OperationCount); !ok && p.GetBool(prop.DoTransactions, true) {
+		// Verify every record once by default, the operations are split among the threads evenly.
+		threadCount := p.GetInt64(prop.ThreadCount, 1)
+		opCount := (insertCount + threadCount - 1) / threadCount * threadCount
+		p.Set(prop.OperationCount, strconv.FormatInt(opCount, 10))
+	}
+
+	return v, nil
+}
+
+func init() {
+	ycsb.RegisterWorkloadCreator("verify", verifyCreator{})
+}
@@ -95,6 +95,73 @@ type BatchDB interface {
 	BatchDelete(ctx context.Context, table string, keys []string) error
 }
 
+// ReadModifyWriteDB is the interface for the DB that can read and update a record atomically.
+type ReadModifyWriteDB interface {
+	// ReadModifyWrite reads a record and then updates it in one transaction.
+	// table: The name of the table.
+	// key: The record key of the record to read and update.
+	// fields: The list of fields to read, nil|empty for reading all.
+	// values: A map of field/value pairs to update in the record.
+	ReadModifyWrite(ctx context.Context, table string, key string, fields []string, values map[string][]byte) (map[string][]byte, error)
+}
+
+// ReverseScanDB is the interface for the DB that can scan the records in the descending order.
+type ReverseScanDB interface {
+	// ReverseScan scans records from the database in the descending order.
+	// table: The name of the table.
+	// startKey: The last record key to read, the records before it are read.
+	// count: The number of records to read.
+	// fields: The list of fields to read, nil|empty for reading all.
+	ReverseScan(ctx context.Context, table string, startKey string, count int, fields []string) ([]map[string][]byte, error)
+}
+
+// QueryDB is the interface for the DB that can look up the records by the value of a field,
+// like by a secondary index.
+type QueryDB interface {
+	// Query returns at most count records whose field equals the value.
+	// table: The name of the table.
+	// field: The field to filter the records by.
+	// value: The value of the field.
+	// count: The max number of records to return.
+	// fields: The list of fields to read, nil|empty for reading all.
+	Query(ctx context.Context, table string, field string, value []byte, count int, fields []string) ([]map[string][]byte, error)
+}
+
+// TransactionDB is the interface for the DB that supports multi-statement transactions.
+type TransactionDB interface {
+	// Begin starts a transaction, the operations called with the returned context are
+	// executed in the transaction until Commit or Rollback is called with it.
+	Begin(ctx context.Context) (context.Context, error)
+
+	// Commit commits the transaction started by Begin.
+	Commit(ctx context.Context) error
+
+	// Rollback rolls back the transaction started by Begin.
+	Rollback(ctx context.Context) error
+}
+
+// AsyncDB is the interface for the DB that can complete the operations asynchronously, like
+// pipelining the requests. The callback may be called in any goroutine, and it must be called once
+// even if the operation fails. The arguments must not be used after the method returns, since the
+// workload may reuse them.
+type AsyncDB interface {
+	// AsyncRead reads a record and calls cb with the result.
+	AsyncRead(ctx context.Context, table string, key string, fields []string, cb func(map[string][]byte, error))
+
+	// AsyncUpdate updates a record and calls cb with the result.
+	AsyncUpdate(ctx context.Context, table string, key string, values map[string][]byte, cb func(error))
+
+	// AsyncInsert inserts a record and calls cb with the result.
+	AsyncInsert(ctx context.Context, table string, key string, values map[string][]byte, cb func(error))
+
+	// AsyncDelete deletes a record and calls cb with the result.
+	AsyncDelete(ctx context.Context, table string, key string, cb func(error))
+
+	// Flush sends the operations buffered by the thread, it is called when the thread
+	// reaches the outstanding operation limit or finishes.
+	Flush(ctx context.Context)
+}
+
 // AnalyzeDB is the interface for the DB that can perform an analysis on given table.
 type AnalyzeDB interface {
 	// Analyze performs a key distribution analysis for the table.
@@ -102,6 +169,33 @@ type AnalyzeDB interface {
 	Analyze(ctx context.Context, table string) error
 }
 
+// ErrorClass is the category of the failure of an operation.
+type ErrorClass string
+
+// The error classes, the failures are counted by the class for every operation.
+const (
+	ErrorTimeout    ErrorClass = "timeout"
+	ErrorNotFound   ErrorClass = "not_found"
+	ErrorConflict   ErrorClass = "conflict"
+	ErrorConnection ErrorClass = "connection"
+	ErrorOther      ErrorClass = "other"
+)
+
+// ErrorClassifier is the interface for the DB that can classify its errors, like the write
+// conflicts and the retryable errors. The errors not classified by the DB are classified as
+// timeout, connection or other by the client.
+type ErrorClassifier interface {
+	// ClassifyError returns the class of the error, or "" to use the class of the client.
+	ClassifyError(err error) ErrorClass
+}
+
+// BufferedDB is the interface for the DB that buffers the inserts of the thread and writes them
+// later in a batch, so an insert returning nil may not be written yet.
+type BufferedDB interface {
+	// BufferedInserts returns whether the inserts are buffered.
+	BufferedInserts() bool
+}
+
 var dbCreators = map[string]DBCreator{}
 
 // RegisterDBCreator registers a creator for the database
@@ -118,3 +212,19 @@ func RegisterDBCreator(name string, creator DBCreator) {
 func GetDBCreator(name string) DBCreator {
 	return dbCreators[name]
 }
+
+type contextKey string
+
+const notFoundKey = contextKey("notFound")
+
+// WithExpectedNotFound marks the operations called with the returned context as accessing a deleted record,
+// they are measured as <OP>_NOT_FOUND whatever the result is, since some DBs report the missing record as an error.
+func WithExpectedNotFound(ctx context.Context) context.Context {
+	return context.WithValue(ctx, notFoundKey, true)
+}
+
+// IsExpectedNotFound returns whether the operations called with the context access a deleted record.
+func IsExpectedNotFound(ctx context.Context) bool {
+	expected, _ := ctx.Value(notFoundKey).(bool)
+	return expected
+}
@@ -50,6 +50,20 @@ type Workload interface {
 	DoBatchTransaction(ctx context.Context, batchSize int, db DB) error
 }
 
+// AuditWorkload is a Workload which can audit the records in the database after the run.
+type AuditWorkload interface {
+	// Audit checks that every expected record exists with the valid values by threadCount threads,
+	// and reports the missing, the corrupt and the unexpected records, it returns an error if any is found.
+	Audit(ctx context.Context, db DB, threadCount int) error
+}
+
+// TunableWorkload is a Workload whose operation proportions can be changed in the run.
+type TunableWorkload interface {
+	// SetProportions changes the operation proportions set in p like readproportion, the other
+	// proportions are not changed.
+	SetProportions(p *properties.Properties) error
+}
+
 var workloadCreators = map[string]WorkloadCreator{}
 
 // RegisterWorkloadCreator registers a creator for the workload